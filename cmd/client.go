@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/app"
+	"github.com/dinkur/dinkur-desktop/pkg/calendar"
+	"github.com/dinkur/dinkur-desktop/pkg/entryname"
+	"github.com/dinkur/dinkur-desktop/pkg/rules"
+	"github.com/dinkur/dinkur/pkg/dinkur"
+	"github.com/dinkur/dinkur/pkg/dinkurdb"
+)
+
+// connectClient connects to the Dinkur database for subcommands that run
+// without the GUI.
+func connectClient(ctx context.Context) (dinkur.Client, error) {
+	client := dinkurdb.NewClient(cfg.Sqlite.Path, dinkurdb.Options{
+		MkdirAll: cfg.Sqlite.Mkdir,
+	})
+	if err := client.Connect(ctx); err != nil {
+		return nil, fmt.Errorf("connect to Dinkur database: %w", err)
+	}
+	return client, nil
+}
+
+// parseDateFlag parses a date flag value in the format YYYY-MM-DD, and
+// returns when that day starts in the calendar.
+func parseDateFlag(cal *calendar.Calendar, name, value string) (time.Time, error) {
+	date, err := cal.ParseDate(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("--%s: invalid date %q, must be in the format YYYY-MM-DD", name, value)
+	}
+	return cal.StartOfDate(date), nil
+}
+
+// listEntries lists the entries within the time span, together with the
+// metadata parsed from their names and the categorization rules.
+func listEntries(ctx context.Context, start, end time.Time) ([]app.Entry, error) {
+	parser, err := entryname.New(entryname.Options{
+		TagPrefix:      cfg.Tags.TagPrefix,
+		ClientPrefix:   cfg.Tags.ClientPrefix,
+		TicketPatterns: cfg.Tags.TicketPatterns,
+	})
+	if err != nil {
+		return nil, err
+	}
+	engine, err := rules.New(cfg.Categorization)
+	if err != nil {
+		return nil, err
+	}
+	client, err := connectClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	dinkurEntries, err := client.GetEntryList(ctx, dinkur.SearchEntry{
+		Start: &start,
+		End:   &end,
+		Limit: app.EntrySearchLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list entries: %w", err)
+	}
+	entries := make([]app.Entry, len(dinkurEntries))
+	for i, entry := range dinkurEntries {
+		entries[i] = app.ParseEntry(parser, engine, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show the config and manage secrets",
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the config",
+	Long: `Prints the config as read from the config file and the command-line flags,
+including the defaults. Secrets, such as tokens, are never printed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		redacted := cfg.Redacted()
+		fmt.Fprintf(cmd.OutOrStdout(), "# Read from: %s\n", cfg.FileUsed())
+		enc := yaml.NewEncoder(cmd.OutOrStdout())
+		defer enc.Close()
+		return enc.Encode(&redacted)
+	},
+}
+
+var configSecretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "List the secret config keys",
+	Long: `Lists the keys of the config fields that hold secrets, and whether each
+secret is set. Secrets are kept in the freedesktop Secret Service, such as
+GNOME Keyring or KWallet, or in an encrypted file if it's not available.
+The key of the encrypted file is kept in a directory of its own, only
+accessible by the user, apart from the config directory.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := cfg.LoadSecrets(config.Secrets()); err != nil {
+			return err
+		}
+		keys := cfg.SecretKeys()
+		if len(keys) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No secrets in the current config.")
+		}
+		for _, key := range keys {
+			status := "not set"
+			if cfg.HasSecret(key) {
+				status = "set"
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s (%s)\n", key, status)
+		}
+		return nil
+	},
+}
+
+var configSetSecretCmd = &cobra.Command{
+	Use:   "set-secret <key>",
+	Short: "Store a secret, read from stdin",
+	Long: `Stores the secret of a config key, such as "webhooks.items.my-hook.secret",
+in the secret store. The value is read from the first line of stdin, so that
+it doesn't end up in the shell history. An empty value deletes the secret.
+
+Dots in names are escaped with a backslash, such as in
+"issueTrackers.items.jira\.example\.com.token". Run "config secrets" to list
+the keys.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Value of %s: ", args[0])
+		value, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+		if err != nil && value == "" && !errors.Is(err, io.EOF) {
+			return err
+		}
+		value = strings.TrimRight(value, "\r\n")
+		if err := cfg.SetSecret(config.Secrets(), args[0], value); err != nil {
+			return err
+		}
+		if value == "" {
+			fmt.Fprintf(cmd.OutOrStdout(), "Deleted %s.\n", args[0])
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "Stored %s.\n", args[0])
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configSecretsCmd)
+	configCmd.AddCommand(configSetSecretCmd)
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/app"
+	"github.com/dinkur/dinkur-desktop/pkg/billing"
+	"github.com/dinkur/dinkur-desktop/pkg/calendar"
+	"github.com/dinkur/dinkur-desktop/pkg/invoice"
+	"github.com/spf13/cobra"
+)
+
+var invoiceCreateFlags = struct {
+	client string
+	from   string
+	to     string
+	format string
+	output string
+}{
+	format: string(invoice.FormatHTML),
+}
+
+var invoiceCmd = &cobra.Command{
+	Use:   "invoice",
+	Short: "Create invoice drafts from billable entries",
+}
+
+var invoiceCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create an invoice draft for a client and period",
+	Long: `Creates an invoice draft from the billable entries of a client within the
+given dates, using the rates and rounding from the billing config.
+
+The invoice number is taken from a counter stored in the data directory,
+which is only increased when the invoice is written successfully.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cal, err := calendar.New(cfg.Calendar)
+		if err != nil {
+			return err
+		}
+		from, err := parseDateFlag(cal, "from", invoiceCreateFlags.from)
+		if err != nil {
+			return err
+		}
+		to, err := parseDateFlag(cal, "to", invoiceCreateFlags.to)
+		if err != nil {
+			return err
+		}
+		to = to.AddDate(0, 0, 1)
+		calc, err := billing.New(cfg.Billing, cal)
+		if err != nil {
+			return err
+		}
+		report, err := billingReport(cmd, calc, from, to)
+		if err != nil {
+			return err
+		}
+		numbers := invoice.NewNumberStore(filepath.Join(cfg.DataDir, "invoice-number.json"))
+		number, err := numbers.Next()
+		if err != nil {
+			return err
+		}
+		inv, err := invoice.New(calc, report, invoiceCreateFlags.client, invoice.Options{
+			Number:  invoice.FormatNumber(cfg.Invoice.NumberFormat, number),
+			Date:    time.Now(),
+			DueDays: cfg.Invoice.DueDays,
+			From:    cfg.Invoice.From,
+		})
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := invoice.Write(&buf, inv, invoice.Format(invoiceCreateFlags.format), cfg.Invoice.Template); err != nil {
+			return err
+		}
+		if err := numbers.Use(number); err != nil {
+			return err
+		}
+		if err := os.WriteFile(invoiceCreateFlags.output, buf.Bytes(), 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Wrote invoice %s of %s to %s\n", inv.Number, inv.FormattedTotal, invoiceCreateFlags.output)
+		return nil
+	},
+}
+
+var invoiceTemplateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Print the built-in invoice HTML template",
+	Long: `Prints the built-in invoice HTML template, to be used as a starting point
+for your own template. Set the invoice.template config to the path of your
+template to use it instead.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, err := fmt.Fprint(cmd.OutOrStdout(), invoice.DefaultTemplate)
+		return err
+	},
+}
+
+// billingReport lists the entries within the time span and calculates their
+// billable totals.
+func billingReport(cmd *cobra.Command, calc *billing.Calculator, start, end time.Time) (billing.Report, error) {
+	entries, err := listEntries(cmd.Context(), start, end)
+	if err != nil {
+		return billing.Report{}, err
+	}
+	return calc.Report(app.BillingEntries(entries, time.Now()), start, end), nil
+}
+
+func init() {
+	rootCmd.AddCommand(invoiceCmd)
+	invoiceCmd.AddCommand(invoiceCreateCmd)
+	invoiceCmd.AddCommand(invoiceTemplateCmd)
+
+	invoiceCreateCmd.Flags().StringVar(&invoiceCreateFlags.client, "client", "", "client to invoice, as in the @client of entry names or project of categorization rules")
+	invoiceCreateCmd.Flags().StringVar(&invoiceCreateFlags.from, "from", "", "first date of the billing period, as YYYY-MM-DD")
+	invoiceCreateCmd.Flags().StringVar(&invoiceCreateFlags.to, "to", "", "last date of the billing period, as YYYY-MM-DD")
+	invoiceCreateCmd.Flags().StringVar(&invoiceCreateFlags.format, "format", invoiceCreateFlags.format, `invoice file format: "html" or "pdf"`)
+	invoiceCreateCmd.Flags().StringVarP(&invoiceCreateFlags.output, "output", "o", "", "file to write the invoice to")
+	invoiceCreateCmd.MarkFlagRequired("client")
+	invoiceCreateCmd.MarkFlagRequired("from")
+	invoiceCreateCmd.MarkFlagRequired("to")
+	invoiceCreateCmd.MarkFlagRequired("output")
+}
@@ -110,10 +110,14 @@ func readConfig(cmd *cobra.Command) error {
 	} else {
 		newCfg, err = config.ReadAuto(v)
 	}
-	if err != nil {
-		log.Warn().WithError(err).Message("Failed loading config. Continuing with default config.")
-		cfg = config.Default
-	} else {
+	switch {
+	case newCfg == nil:
+		log.Warn().WithError(err).Message("Failed loading config. Continuing with default config, which will not be saved.")
+		cfg = config.Fallback(err)
+	case err != nil:
+		log.Warn().WithError(err).Message("Invalid config. Continuing with the defaults of the invalid sections. The config will not be saved until fixed.")
+		cfg = *newCfg
+	default:
 		cfg = *newCfg
 	}
 
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dinkur/dinkur-desktop/pkg/app"
+	"github.com/dinkur/dinkur-desktop/pkg/calendar"
+	"github.com/dinkur/dinkur-desktop/pkg/entryname"
+	"github.com/dinkur/dinkur-desktop/pkg/rules"
+	"github.com/dinkur/dinkur/pkg/dinkur"
+	"github.com/spf13/cobra"
+)
+
+var rulesApplyFlags = struct {
+	from   string
+	to     string
+	dryRun bool
+}{}
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Manage categorization rules",
+}
+
+var rulesApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Reapply categorization rules to historic entries",
+	Long: `Adds the tags and projects from the categorization rules to the names
+of all entries within the given dates, so that the categorization is stored
+together with the entries.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cal, err := calendar.New(cfg.Calendar)
+		if err != nil {
+			return err
+		}
+		from, err := parseDateFlag(cal, "from", rulesApplyFlags.from)
+		if err != nil {
+			return err
+		}
+		to, err := parseDateFlag(cal, "to", rulesApplyFlags.to)
+		if err != nil {
+			return err
+		}
+		parser, err := entryname.New(entryname.Options{
+			TagPrefix:      cfg.Tags.TagPrefix,
+			ClientPrefix:   cfg.Tags.ClientPrefix,
+			TicketPatterns: cfg.Tags.TicketPatterns,
+		})
+		if err != nil {
+			return err
+		}
+		engine, err := rules.New(cfg.Categorization)
+		if err != nil {
+			return err
+		}
+		client, err := connectClient(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		end := to.AddDate(0, 0, 1)
+		entries, err := client.GetEntryList(cmd.Context(), dinkur.SearchEntry{
+			Start: &from,
+			End:   &end,
+			Limit: app.EntrySearchLimit,
+		})
+		if err != nil {
+			return fmt.Errorf("list entries: %w", err)
+		}
+		changes, err := engine.Reapply(cmd.Context(), client, parser, entries, rulesApplyFlags.dryRun)
+		for _, change := range changes {
+			fmt.Fprintf(cmd.OutOrStdout(), "#%d %q -> %q\n", change.Entry.ID, change.Entry.Name, change.NewName)
+		}
+		if err != nil {
+			return err
+		}
+		if rulesApplyFlags.dryRun {
+			fmt.Fprintf(cmd.OutOrStdout(), "Would rename %d entries.\n", len(changes))
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "Renamed %d entries.\n", len(changes))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rulesCmd)
+	rulesCmd.AddCommand(rulesApplyCmd)
+
+	rulesApplyCmd.Flags().StringVar(&rulesApplyFlags.from, "from", "", "first date to apply rules to, as YYYY-MM-DD")
+	rulesApplyCmd.Flags().StringVar(&rulesApplyFlags.to, "to", "", "last date to apply rules to, as YYYY-MM-DD")
+	rulesApplyCmd.Flags().BoolVar(&rulesApplyFlags.dryRun, "dry-run", false, "only print the changes, without saving them")
+	rulesApplyCmd.MarkFlagRequired("from")
+	rulesApplyCmd.MarkFlagRequired("to")
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/internal/clock"
+	"github.com/dinkur/dinkur-desktop/pkg/app"
+	"github.com/dinkur/dinkur-desktop/pkg/calendar"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+	"github.com/dinkur/dinkur-desktop/pkg/worklog"
+	"github.com/spf13/cobra"
+)
+
+var worklogSyncFlags = struct {
+	from   string
+	to     string
+	dryRun bool
+}{}
+
+var worklogCmd = &cobra.Command{
+	Use:   "worklog",
+	Short: "Push tracked time to Jira or Tempo as worklogs",
+}
+
+var worklogSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Push entries with ticket keys as worklogs",
+	Long: `Pushes the entries within the given dates whose names contain a ticket
+key of the Jira issue tracker set in the worklogs config, as worklogs to
+either Jira or Tempo.
+
+Which entries have been pushed is recorded in the data directory, so that no
+entry is logged twice. Entries that are edited or deleted after they were
+pushed are flagged with "!" and "-", and must be corrected by hand.
+New entries are marked with "+".`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cal, err := calendar.New(cfg.Calendar)
+		if err != nil {
+			return err
+		}
+		from, err := parseDateFlag(cal, "from", worklogSyncFlags.from)
+		if err != nil {
+			return err
+		}
+		to, err := parseDateFlag(cal, "to", worklogSyncFlags.to)
+		if err != nil {
+			return err
+		}
+		to = to.AddDate(0, 0, 1)
+		if err := cfg.LoadSecrets(config.Secrets()); err != nil {
+			return err
+		}
+		syncer, err := worklog.New(&cfg, app.WorklogPath(cfg.DataDir), clock.System)
+		if err != nil {
+			return err
+		}
+		entries, err := listEntries(cmd.Context(), from, to)
+		if err != nil {
+			return err
+		}
+		worklogEntries := app.WorklogEntries(entries)
+		for i := range worklogEntries {
+			// Worklogs are logged in the calendar's time zone.
+			worklogEntries[i].Start = worklogEntries[i].Start.In(cal.Location())
+			worklogEntries[i].End = worklogEntries[i].End.In(cal.Location())
+		}
+
+		plan, err := syncer.Plan(worklogEntries, from, to)
+		if err != nil {
+			return err
+		}
+		printWorklogPlan(cmd.OutOrStdout(), plan)
+		newCount := plan.Count(worklog.StatusNew)
+		if worklogSyncFlags.dryRun {
+			fmt.Fprintf(cmd.OutOrStdout(), "Would push %d worklogs.\n", newCount)
+			return nil
+		}
+		if newCount == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "Nothing to push.")
+			return nil
+		}
+		pushed, err := syncer.Push(cmd.Context(), worklogEntries, from, to)
+		failed := pushed.Count(worklog.StatusNew)
+		fmt.Fprintf(cmd.OutOrStdout(), "Pushed %d of %d worklogs.\n", newCount-failed, newCount)
+		return err
+	},
+}
+
+func printWorklogPlan(w io.Writer, plan worklog.Plan) {
+	for _, item := range plan.Items {
+		switch item.Status {
+		case worklog.StatusNew:
+			fmt.Fprintf(w, "+ #%d %s\n", item.EntryID, formatWorklog(item.Worklog))
+		case worklog.StatusPushed:
+			fmt.Fprintf(w, "  #%d %s\n", item.EntryID, formatWorklog(item.Worklog))
+		case worklog.StatusEdited:
+			fmt.Fprintf(w, "! #%d %s\n", item.EntryID, formatWorklog(item.Worklog))
+			fmt.Fprintf(w, "    was pushed as %s (worklog %s)\n", formatWorklog(item.Pushed.Worklog), item.Pushed.WorklogID)
+		case worklog.StatusDeleted:
+			fmt.Fprintf(w, "- #%d %s (worklog %s)\n", item.EntryID, formatWorklog(item.Pushed.Worklog), item.Pushed.WorklogID)
+		}
+	}
+}
+
+func formatWorklog(w worklog.Worklog) string {
+	key := w.Key
+	if key == "" {
+		key = "(no key)"
+	}
+	duration := (time.Duration(w.Seconds()) * time.Second).String()
+	return fmt.Sprintf("%s %s %s %q", key, w.Start.Format("2006-01-02 15:04"), duration, w.Comment)
+}
+
+func init() {
+	rootCmd.AddCommand(worklogCmd)
+	worklogCmd.AddCommand(worklogSyncCmd)
+
+	worklogSyncCmd.Flags().StringVar(&worklogSyncFlags.from, "from", "", "first date to push entries of, as YYYY-MM-DD")
+	worklogSyncCmd.Flags().StringVar(&worklogSyncFlags.to, "to", "", "last date to push entries of, as YYYY-MM-DD")
+	worklogSyncCmd.Flags().BoolVar(&worklogSyncFlags.dryRun, "dry-run", false, "only print what would be pushed, without pushing")
+	worklogSyncCmd.MarkFlagRequired("from")
+	worklogSyncCmd.MarkFlagRequired("to")
+}
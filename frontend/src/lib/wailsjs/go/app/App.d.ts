@@ -1,12 +1,113 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
-import {dinkur} from '../models';
 import {time} from '../models';
+import {app} from '../models';
+import {analyzer} from '../models';
+import {billing} from '../models';
+import {budget} from '../models';
+import {config} from '../models';
+import {dinkur} from '../models';
+import {worklog} from '../models';
+import {invoice} from '../models';
+import {rules} from '../models';
+import {issues} from '../models';
+
+export function AcceptGitSuggestion(arg1:time.Time,arg2:string):Promise<app.Entry>;
+
+export function AcceptMeetingSuggestion(arg1:time.Time,arg2:string):Promise<app.Entry>;
+
+export function AcknowledgeWorklog(arg1:number):Promise<void>;
+
+export function AnalyzeDay(arg1:time.Time):Promise<Array<analyzer.Issue>>;
+
+export function ApplyFix(arg1:time.Time,arg2:string):Promise<void>;
 
 export function ConnectDinkur():Promise<void>;
 
+export function CopyDay(arg1:time.Time,arg2:time.Time):Promise<Array<app.Entry>>;
+
 export function DisconnectDinkur():Promise<void>;
 
-export function GetActiveEntry():Promise<dinkur.Entry>;
+export function DismissMeetingSuggestion(arg1:time.Time,arg2:string):Promise<void>;
+
+export function ExportBillingCSV(arg1:time.Time,arg2:time.Time,arg3:boolean):Promise<string>;
+
+export function GetActiveEntry():Promise<app.Entry>;
+
+export function GetAutoActions():Promise<Array<app.AutoAction>>;
+
+export function GetBillingReport(arg1:time.Time,arg2:time.Time):Promise<billing.Report>;
+
+export function GetBudgetProgress():Promise<Array<budget.Progress>>;
+
+export function GetCalendarSettings():Promise<app.CalendarSettings>;
+
+export function GetEntriesForDay(arg1:time.Time):Promise<Array<app.Entry>>;
+
+export function GetFocusSessions(arg1:time.Time,arg2:time.Time):Promise<Array<app.FocusSession>>;
+
+export function GetFocusSummary(arg1:time.Time,arg2:time.Time):Promise<Array<app.FocusSummary>>;
+
+export function GetGitSuggestions(arg1:time.Time):Promise<app.GitSuggestions>;
+
+export function GetJournal():Promise<app.JournalHistory>;
+
+export function GetMeetingSuggestions(arg1:time.Time):Promise<app.MeetingSuggestions>;
+
+export function GetPendingIdle():Promise<app.PendingIdle>;
+
+export function GetPomodoroState():Promise<app.PomodoroState>;
+
+export function GetSummary(arg1:time.Time,arg2:time.Time,arg3:string):Promise<app.Summary>;
+
+export function GetTemplates():Promise<Array<config.EntryTemplate>>;
+
+export function GetUnrestoredEntry():Promise<dinkur.Entry>;
+
+export function GetWebhookDeliveries():Promise<Array<app.WebhookDelivery>>;
+
+export function MergeEntries(arg1:Array<number>):Promise<app.Entry>;
+
+export function ParseQuickEntry(arg1:string):Promise<app.QuickEntryPreview>;
+
+export function PlanWorklogs(arg1:time.Time,arg2:time.Time):Promise<worklog.Plan>;
+
+export function PreviewCopyDay(arg1:time.Time,arg2:time.Time):Promise<app.CopyDayPreview>;
+
+export function PreviewInvoice(arg1:string,arg2:time.Time,arg3:time.Time):Promise<invoice.Invoice>;
+
+export function PreviewRules(arg1:number):Promise<app.Entry>;
+
+export function PushWorklogs(arg1:time.Time,arg2:time.Time):Promise<worklog.Plan>;
+
+export function ReapplyRules(arg1:time.Time,arg2:time.Time,arg3:boolean):Promise<Array<rules.Change>>;
+
+export function Redo():Promise<app.JournalOperation>;
+
+export function ResolveIdle(arg1:string):Promise<void>;
+
+export function ResolveIssues(arg1:Array<string>):Promise<Array<issues.Issue>>;
+
+export function RestoreActiveEntry():Promise<dinkur.Entry>;
+
+export function RetryWebhookDelivery(arg1:string):Promise<void>;
+
+export function SaveInvoice(arg1:string,arg2:time.Time,arg3:time.Time,arg4:string):Promise<string>;
+
+export function SaveQuickEntry(arg1:string):Promise<app.Entry>;
+
+export function SplitEntry(arg1:number,arg2:time.Time,arg3:string):Promise<Array<app.Entry>>;
+
+export function StartEntry(arg1:string):Promise<app.Entry>;
+
+export function StartPomodoro(arg1:string):Promise<app.PomodoroState>;
+
+export function StartTemplate(arg1:string):Promise<app.Entry>;
+
+export function StopActiveEntry():Promise<app.Entry>;
+
+export function StopPomodoro():Promise<void>;
+
+export function Undo():Promise<app.JournalOperation>;
 
-export function GetEntriesForDay(arg1:time.Time):Promise<Array<dinkur.Entry>>;
+export function UndoAutoAction(arg1:number):Promise<dinkur.Entry>;
@@ -2,18 +2,202 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AcceptGitSuggestion(arg1, arg2) {
+  return window['go']['app']['App']['AcceptGitSuggestion'](arg1, arg2);
+}
+
+export function AcceptMeetingSuggestion(arg1, arg2) {
+  return window['go']['app']['App']['AcceptMeetingSuggestion'](arg1, arg2);
+}
+
+export function AcknowledgeWorklog(arg1) {
+  return window['go']['app']['App']['AcknowledgeWorklog'](arg1);
+}
+
+export function AnalyzeDay(arg1) {
+  return window['go']['app']['App']['AnalyzeDay'](arg1);
+}
+
+export function ApplyFix(arg1, arg2) {
+  return window['go']['app']['App']['ApplyFix'](arg1, arg2);
+}
+
 export function ConnectDinkur() {
   return window['go']['app']['App']['ConnectDinkur']();
 }
 
+export function CopyDay(arg1, arg2) {
+  return window['go']['app']['App']['CopyDay'](arg1, arg2);
+}
+
 export function DisconnectDinkur() {
   return window['go']['app']['App']['DisconnectDinkur']();
 }
 
+export function DismissMeetingSuggestion(arg1, arg2) {
+  return window['go']['app']['App']['DismissMeetingSuggestion'](arg1, arg2);
+}
+
+export function ExportBillingCSV(arg1, arg2, arg3) {
+  return window['go']['app']['App']['ExportBillingCSV'](arg1, arg2, arg3);
+}
+
 export function GetActiveEntry() {
   return window['go']['app']['App']['GetActiveEntry']();
 }
 
+export function GetAutoActions() {
+  return window['go']['app']['App']['GetAutoActions']();
+}
+
+export function GetBillingReport(arg1, arg2) {
+  return window['go']['app']['App']['GetBillingReport'](arg1, arg2);
+}
+
+export function GetBudgetProgress() {
+  return window['go']['app']['App']['GetBudgetProgress']();
+}
+
+export function GetCalendarSettings() {
+  return window['go']['app']['App']['GetCalendarSettings']();
+}
+
 export function GetEntriesForDay(arg1) {
   return window['go']['app']['App']['GetEntriesForDay'](arg1);
 }
+
+export function GetFocusSessions(arg1, arg2) {
+  return window['go']['app']['App']['GetFocusSessions'](arg1, arg2);
+}
+
+export function GetFocusSummary(arg1, arg2) {
+  return window['go']['app']['App']['GetFocusSummary'](arg1, arg2);
+}
+
+export function GetGitSuggestions(arg1) {
+  return window['go']['app']['App']['GetGitSuggestions'](arg1);
+}
+
+export function GetJournal() {
+  return window['go']['app']['App']['GetJournal']();
+}
+
+export function GetMeetingSuggestions(arg1) {
+  return window['go']['app']['App']['GetMeetingSuggestions'](arg1);
+}
+
+export function GetPendingIdle() {
+  return window['go']['app']['App']['GetPendingIdle']();
+}
+
+export function GetPomodoroState() {
+  return window['go']['app']['App']['GetPomodoroState']();
+}
+
+export function GetSummary(arg1, arg2, arg3) {
+  return window['go']['app']['App']['GetSummary'](arg1, arg2, arg3);
+}
+
+export function GetTemplates() {
+  return window['go']['app']['App']['GetTemplates']();
+}
+
+export function GetUnrestoredEntry() {
+  return window['go']['app']['App']['GetUnrestoredEntry']();
+}
+
+export function GetWebhookDeliveries() {
+  return window['go']['app']['App']['GetWebhookDeliveries']();
+}
+
+export function MergeEntries(arg1) {
+  return window['go']['app']['App']['MergeEntries'](arg1);
+}
+
+export function ParseQuickEntry(arg1) {
+  return window['go']['app']['App']['ParseQuickEntry'](arg1);
+}
+
+export function PlanWorklogs(arg1, arg2) {
+  return window['go']['app']['App']['PlanWorklogs'](arg1, arg2);
+}
+
+export function PreviewCopyDay(arg1, arg2) {
+  return window['go']['app']['App']['PreviewCopyDay'](arg1, arg2);
+}
+
+export function PreviewInvoice(arg1, arg2, arg3) {
+  return window['go']['app']['App']['PreviewInvoice'](arg1, arg2, arg3);
+}
+
+export function PreviewRules(arg1) {
+  return window['go']['app']['App']['PreviewRules'](arg1);
+}
+
+export function PushWorklogs(arg1, arg2) {
+  return window['go']['app']['App']['PushWorklogs'](arg1, arg2);
+}
+
+export function ReapplyRules(arg1, arg2, arg3) {
+  return window['go']['app']['App']['ReapplyRules'](arg1, arg2, arg3);
+}
+
+export function Redo() {
+  return window['go']['app']['App']['Redo']();
+}
+
+export function ResolveIdle(arg1) {
+  return window['go']['app']['App']['ResolveIdle'](arg1);
+}
+
+export function ResolveIssues(arg1) {
+  return window['go']['app']['App']['ResolveIssues'](arg1);
+}
+
+export function RestoreActiveEntry() {
+  return window['go']['app']['App']['RestoreActiveEntry']();
+}
+
+export function RetryWebhookDelivery(arg1) {
+  return window['go']['app']['App']['RetryWebhookDelivery'](arg1);
+}
+
+export function SaveInvoice(arg1, arg2, arg3, arg4) {
+  return window['go']['app']['App']['SaveInvoice'](arg1, arg2, arg3, arg4);
+}
+
+export function SaveQuickEntry(arg1) {
+  return window['go']['app']['App']['SaveQuickEntry'](arg1);
+}
+
+export function SplitEntry(arg1, arg2, arg3) {
+  return window['go']['app']['App']['SplitEntry'](arg1, arg2, arg3);
+}
+
+export function StartEntry(arg1) {
+  return window['go']['app']['App']['StartEntry'](arg1);
+}
+
+export function StartPomodoro(arg1) {
+  return window['go']['app']['App']['StartPomodoro'](arg1);
+}
+
+export function StartTemplate(arg1) {
+  return window['go']['app']['App']['StartTemplate'](arg1);
+}
+
+export function StopActiveEntry() {
+  return window['go']['app']['App']['StopActiveEntry']();
+}
+
+export function StopPomodoro() {
+  return window['go']['app']['App']['StopPomodoro']();
+}
+
+export function Undo() {
+  return window['go']['app']['App']['Undo']();
+}
+
+export function UndoAutoAction(arg1) {
+  return window['go']['app']['App']['UndoAutoAction'](arg1);
+}
@@ -1,3 +1,1108 @@
+export namespace analyzer {
+	
+	export class Change {
+	    op: string;
+	    entryId: number;
+	    name: string;
+	    start?: time.Time;
+	    end?: time.Time;
+	
+	    static createFrom(source: any = {}) {
+	        return new Change(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.op = source["op"];
+	        this.entryId = source["entryId"];
+	        this.name = source["name"];
+	        this.start = this.convertValues(source["start"], time.Time);
+	        this.end = this.convertValues(source["end"], time.Time);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Fix {
+	    id: string;
+	    kind: string;
+	    description: string;
+	    changes: Array<Change>;
+	
+	    static createFrom(source: any = {}) {
+	        return new Fix(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.kind = source["kind"];
+	        this.description = source["description"];
+	        this.changes = this.convertValues(source["changes"], Change);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Issue {
+	    id: string;
+	    kind: string;
+	    entryIds: Array<number>;
+	    start: time.Time;
+	    end: time.Time;
+	    message: string;
+	    fixes: Array<Fix>;
+	
+	    static createFrom(source: any = {}) {
+	        return new Issue(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.kind = source["kind"];
+	        this.entryIds = source["entryIds"];
+	        this.start = this.convertValues(source["start"], time.Time);
+	        this.end = this.convertValues(source["end"], time.Time);
+	        this.message = source["message"];
+	        this.fixes = this.convertValues(source["fixes"], Fix);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace app {
+	
+	export class AutoAction {
+	    id: number;
+	    time: time.Time;
+	    kind: string;
+	    reason: string;
+	    before: dinkur.Entry;
+	    after?: dinkur.Entry;
+	    undone: boolean;
+	    reopenedId?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AutoAction(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.time = this.convertValues(source["time"], time.Time);
+	        this.kind = source["kind"];
+	        this.reason = source["reason"];
+	        this.before = this.convertValues(source["before"], dinkur.Entry);
+	        this.after = this.convertValues(source["after"], dinkur.Entry);
+	        this.undone = source["undone"];
+	        this.reopenedId = source["reopenedId"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CalendarSettings {
+	    timeZone: string;
+	    weekStart: string;
+	    dayStartHour: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CalendarSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.timeZone = source["timeZone"];
+	        this.weekStart = source["weekStart"];
+	        this.dayStartHour = source["dayStartHour"];
+	    }
+	}
+	export class CopyDayPreview {
+	    entries: Array<Entry>;
+	    conflicts: Array<analyzer.Issue>;
+	
+	    static createFrom(source: any = {}) {
+	        return new CopyDayPreview(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.entries = this.convertValues(source["entries"], Entry);
+	        this.conflicts = this.convertValues(source["conflicts"], analyzer.Issue);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Entry {
+	    id: number;
+	    createdAt: time.Time;
+	    updatedAt: time.Time;
+	    name: string;
+	    start: time.Time;
+	    end?: time.Time;
+	    tags: Array<string>;
+	    client: string;
+	    tickets: Array<string>;
+	    project: string;
+	    billable: boolean;
+	    rules: Array<rules.Match>;
+	    issues: Array<issues.Issue>;
+	
+	    static createFrom(source: any = {}) {
+	        return new Entry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.createdAt = this.convertValues(source["createdAt"], time.Time);
+	        this.updatedAt = this.convertValues(source["updatedAt"], time.Time);
+	        this.name = source["name"];
+	        this.start = this.convertValues(source["start"], time.Time);
+	        this.end = this.convertValues(source["end"], time.Time);
+	        this.tags = source["tags"];
+	        this.client = source["client"];
+	        this.tickets = source["tickets"];
+	        this.project = source["project"];
+	        this.billable = source["billable"];
+	        this.rules = this.convertValues(source["rules"], rules.Match);
+	        this.issues = this.convertValues(source["issues"], issues.Issue);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class FocusSession {
+	    entryId: number;
+	    name: string;
+	    start: time.Time;
+	    end: time.Time;
+	    completed: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FocusSession(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.entryId = source["entryId"];
+	        this.name = source["name"];
+	        this.start = this.convertValues(source["start"], time.Time);
+	        this.end = this.convertValues(source["end"], time.Time);
+	        this.completed = source["completed"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class FocusSummary {
+	    name: string;
+	    completed: number;
+	    interrupted: number;
+	    focused: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new FocusSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.completed = source["completed"];
+	        this.interrupted = source["interrupted"];
+	        this.focused = source["focused"];
+	    }
+	}
+	export class GitSuggestion {
+	    id: string;
+	    name: string;
+	    start: time.Time;
+	    end: time.Time;
+	    repository: string;
+	    branch: string;
+	    ticket: string;
+	    commits: Array<gitsuggest.Commit>;
+	    gapId: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new GitSuggestion(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.start = this.convertValues(source["start"], time.Time);
+	        this.end = this.convertValues(source["end"], time.Time);
+	        this.repository = source["repository"];
+	        this.branch = source["branch"];
+	        this.ticket = source["ticket"];
+	        this.commits = this.convertValues(source["commits"], gitsuggest.Commit);
+	        this.gapId = source["gapId"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class GitSuggestions {
+	    gaps: Array<analyzer.Issue>;
+	    suggestions: Array<GitSuggestion>;
+	    errors: Array<string>;
+	
+	    static createFrom(source: any = {}) {
+	        return new GitSuggestions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.gaps = this.convertValues(source["gaps"], analyzer.Issue);
+	        this.suggestions = this.convertValues(source["suggestions"], GitSuggestion);
+	        this.errors = source["errors"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class IdlePeriod {
+	    start: time.Time;
+	    end: time.Time;
+	
+	    static createFrom(source: any = {}) {
+	        return new IdlePeriod(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.start = this.convertValues(source["start"], time.Time);
+	        this.end = this.convertValues(source["end"], time.Time);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class JournalHistory {
+	    undo: Array<JournalOperation>;
+	    redo: Array<JournalOperation>;
+	
+	    static createFrom(source: any = {}) {
+	        return new JournalHistory(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.undo = this.convertValues(source["undo"], JournalOperation);
+	        this.redo = this.convertValues(source["redo"], JournalOperation);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class JournalOperation {
+	    id: number;
+	    time: time.Time;
+	    description: string;
+	    steps: Array<JournalStep>;
+	
+	    static createFrom(source: any = {}) {
+	        return new JournalOperation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.time = this.convertValues(source["time"], time.Time);
+	        this.description = source["description"];
+	        this.steps = this.convertValues(source["steps"], JournalStep);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class JournalStep {
+	    kind: string;
+	    before?: dinkur.Entry;
+	    after?: dinkur.Entry;
+	
+	    static createFrom(source: any = {}) {
+	        return new JournalStep(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.before = this.convertValues(source["before"], dinkur.Entry);
+	        this.after = this.convertValues(source["after"], dinkur.Entry);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class MeetingSuggestion {
+	    id: string;
+	    name: string;
+	    summary: string;
+	    location: string;
+	    calendar: string;
+	    start: time.Time;
+	    end: time.Time;
+	    transparent: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new MeetingSuggestion(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.summary = source["summary"];
+	        this.location = source["location"];
+	        this.calendar = source["calendar"];
+	        this.start = this.convertValues(source["start"], time.Time);
+	        this.end = this.convertValues(source["end"], time.Time);
+	        this.transparent = source["transparent"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class MeetingSuggestions {
+	    suggestions: Array<MeetingSuggestion>;
+	    errors: Array<string>;
+	
+	    static createFrom(source: any = {}) {
+	        return new MeetingSuggestions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.suggestions = this.convertValues(source["suggestions"], MeetingSuggestion);
+	        this.errors = source["errors"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PendingIdle {
+	    entry: dinkur.Entry;
+	    period: IdlePeriod;
+	
+	    static createFrom(source: any = {}) {
+	        return new PendingIdle(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.entry = this.convertValues(source["entry"], dinkur.Entry);
+	        this.period = this.convertValues(source["period"], IdlePeriod);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PomodoroState {
+	    name: string;
+	    phase: string;
+	    cycle: number;
+	    cycles: number;
+	    phaseEnds: time.Time;
+	    entryId: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PomodoroState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.phase = source["phase"];
+	        this.cycle = source["cycle"];
+	        this.cycles = source["cycles"];
+	        this.phaseEnds = this.convertValues(source["phaseEnds"], time.Time);
+	        this.entryId = source["entryId"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class QuickEntryPreview {
+	    entry: Entry;
+	    conflicts: Array<analyzer.Issue>;
+	
+	    static createFrom(source: any = {}) {
+	        return new QuickEntryPreview(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.entry = this.convertValues(source["entry"], Entry);
+	        this.conflicts = this.convertValues(source["conflicts"], analyzer.Issue);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Summary {
+	    start: time.Time;
+	    end: time.Time;
+	    groupBy: string;
+	    total: number;
+	    rows: Array<SummaryRow>;
+	
+	    static createFrom(source: any = {}) {
+	        return new Summary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.start = this.convertValues(source["start"], time.Time);
+	        this.end = this.convertValues(source["end"], time.Time);
+	        this.groupBy = source["groupBy"];
+	        this.total = source["total"];
+	        this.rows = this.convertValues(source["rows"], SummaryRow);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SummaryRow {
+	    key: string;
+	    duration: number;
+	    entries: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SummaryRow(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.duration = source["duration"];
+	        this.entries = source["entries"];
+	    }
+	}
+	export class WebhookDelivery {
+	    id: string;
+	    webhook: string;
+	    url: string;
+	    event: string;
+	    payload: any;
+	    created: time.Time;
+	    status: string;
+	    attempts: number;
+	    nextAttempt: time.Time;
+	    statusCode: number;
+	    lastError: string;
+	    finished?: time.Time;
+	
+	    static createFrom(source: any = {}) {
+	        return new WebhookDelivery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.webhook = source["webhook"];
+	        this.url = source["url"];
+	        this.event = source["event"];
+	        this.payload = source["payload"];
+	        this.created = this.convertValues(source["created"], time.Time);
+	        this.status = source["status"];
+	        this.attempts = source["attempts"];
+	        this.nextAttempt = this.convertValues(source["nextAttempt"], time.Time);
+	        this.statusCode = source["statusCode"];
+	        this.lastError = source["lastError"];
+	        this.finished = this.convertValues(source["finished"], time.Time);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace billing {
+	
+	export class ClientTotal {
+	    client: string;
+	    duration: number;
+	    billed: number;
+	    amount: number;
+	    formattedAmount: string;
+	    days: Array<DayTotal>;
+	
+	    static createFrom(source: any = {}) {
+	        return new ClientTotal(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.client = source["client"];
+	        this.duration = source["duration"];
+	        this.billed = source["billed"];
+	        this.amount = source["amount"];
+	        this.formattedAmount = source["formattedAmount"];
+	        this.days = this.convertValues(source["days"], DayTotal);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DayTotal {
+	    date: time.Time;
+	    duration: number;
+	    billed: number;
+	    amount: number;
+	    formattedAmount: string;
+	    lines: Array<Line>;
+	
+	    static createFrom(source: any = {}) {
+	        return new DayTotal(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.date = this.convertValues(source["date"], time.Time);
+	        this.duration = source["duration"];
+	        this.billed = source["billed"];
+	        this.amount = source["amount"];
+	        this.formattedAmount = source["formattedAmount"];
+	        this.lines = this.convertValues(source["lines"], Line);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Line {
+	    entryId: number;
+	    name: string;
+	    start: time.Time;
+	    end: time.Time;
+	    rateName: string;
+	    rate: number;
+	    duration: number;
+	    billed: number;
+	    amount: number;
+	    formattedAmount: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Line(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.entryId = source["entryId"];
+	        this.name = source["name"];
+	        this.start = this.convertValues(source["start"], time.Time);
+	        this.end = this.convertValues(source["end"], time.Time);
+	        this.rateName = source["rateName"];
+	        this.rate = source["rate"];
+	        this.duration = source["duration"];
+	        this.billed = source["billed"];
+	        this.amount = source["amount"];
+	        this.formattedAmount = source["formattedAmount"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Report {
+	    start: time.Time;
+	    end: time.Time;
+	    currency: string;
+	    rounding: config.Rounding;
+	    duration: number;
+	    billed: number;
+	    amount: number;
+	    formattedAmount: string;
+	    nonBillable: number;
+	    clients: Array<ClientTotal>;
+	
+	    static createFrom(source: any = {}) {
+	        return new Report(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.start = this.convertValues(source["start"], time.Time);
+	        this.end = this.convertValues(source["end"], time.Time);
+	        this.currency = source["currency"];
+	        this.rounding = this.convertValues(source["rounding"], config.Rounding);
+	        this.duration = source["duration"];
+	        this.billed = source["billed"];
+	        this.amount = source["amount"];
+	        this.formattedAmount = source["formattedAmount"];
+	        this.nonBillable = source["nonBillable"];
+	        this.clients = this.convertValues(source["clients"], ClientTotal);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace budget {
+	
+	export class Progress {
+	    index: number;
+	    name: string;
+	    tag: string;
+	    project: string;
+	    period: string;
+	    kind: string;
+	    start: time.Time;
+	    end: time.Time;
+	    budget: number;
+	    used: number;
+	    remaining: number;
+	    percent: number;
+	    includesActive: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Progress(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.index = source["index"];
+	        this.name = source["name"];
+	        this.tag = source["tag"];
+	        this.project = source["project"];
+	        this.period = source["period"];
+	        this.kind = source["kind"];
+	        this.start = this.convertValues(source["start"], time.Time);
+	        this.end = this.convertValues(source["end"], time.Time);
+	        this.budget = source["budget"];
+	        this.used = source["used"];
+	        this.remaining = source["remaining"];
+	        this.percent = source["percent"];
+	        this.includesActive = source["includesActive"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace config {
+	
+	export class EntryTemplate {
+	    Name: string;
+	    At?: string;
+	    Duration: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new EntryTemplate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Name = source["Name"];
+	        this.At = source["At"];
+	        this.Duration = source["Duration"];
+	    }
+	}
+	export class Rounding {
+	    Mode: string;
+	    Increment: number;
+	    Scope: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Rounding(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Mode = source["Mode"];
+	        this.Increment = source["Increment"];
+	        this.Scope = source["Scope"];
+	    }
+	}
+
+}
+
 export namespace dinkur {
 	
 	export class Entry {
@@ -43,3 +1148,322 @@ export namespace dinkur {
 
 }
 
+export namespace gitsuggest {
+	
+	export class Commit {
+	    repository: string;
+	    hash: string;
+	    branch: string;
+	    message: string;
+	    time: time.Time;
+	
+	    static createFrom(source: any = {}) {
+	        return new Commit(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.repository = source["repository"];
+	        this.hash = source["hash"];
+	        this.branch = source["branch"];
+	        this.message = source["message"];
+	        this.time = this.convertValues(source["time"], time.Time);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace invoice {
+	
+	export class Invoice {
+	    number: string;
+	    date: time.Time;
+	    dueDate: time.Time;
+	    from: string;
+	    client: string;
+	    start: time.Time;
+	    end: time.Time;
+	    currency: string;
+	    items: Array<Item>;
+	    billed: number;
+	    total: number;
+	    formattedTotal: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Invoice(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.number = source["number"];
+	        this.date = this.convertValues(source["date"], time.Time);
+	        this.dueDate = this.convertValues(source["dueDate"], time.Time);
+	        this.from = source["from"];
+	        this.client = source["client"];
+	        this.start = this.convertValues(source["start"], time.Time);
+	        this.end = this.convertValues(source["end"], time.Time);
+	        this.currency = source["currency"];
+	        this.items = this.convertValues(source["items"], Item);
+	        this.billed = source["billed"];
+	        this.total = source["total"];
+	        this.formattedTotal = source["formattedTotal"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Item {
+	    task: string;
+	    billed: number;
+	    rate: number;
+	    formattedRate: string;
+	    amount: number;
+	    formattedAmount: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Item(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.task = source["task"];
+	        this.billed = source["billed"];
+	        this.rate = source["rate"];
+	        this.formattedRate = source["formattedRate"];
+	        this.amount = source["amount"];
+	        this.formattedAmount = source["formattedAmount"];
+	    }
+	}
+
+}
+
+export namespace issues {
+	
+	export class Issue {
+	    key: string;
+	    tracker: string;
+	    title: string;
+	    status: string;
+	    url: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Issue(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.tracker = source["tracker"];
+	        this.title = source["title"];
+	        this.status = source["status"];
+	        this.url = source["url"];
+	    }
+	}
+
+}
+
+export namespace rules {
+	
+	export class Change {
+	    entry: dinkur.Entry;
+	    newName: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Change(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.entry = this.convertValues(source["entry"], dinkur.Entry);
+	        this.newName = source["newName"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Match {
+	    index: number;
+	    name: string;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Match(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.index = source["index"];
+	        this.name = source["name"];
+	        this.text = source["text"];
+	    }
+	}
+
+}
+
+export namespace worklog {
+	
+	export class Item {
+	    key: string;
+	    comment: string;
+	    start: time.Time;
+	    end: time.Time;
+	    entryId: number;
+	    status: string;
+	    pushed?: Pushed;
+	
+	    static createFrom(source: any = {}) {
+	        return new Item(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.comment = source["comment"];
+	        this.start = this.convertValues(source["start"], time.Time);
+	        this.end = this.convertValues(source["end"], time.Time);
+	        this.entryId = source["entryId"];
+	        this.status = source["status"];
+	        this.pushed = this.convertValues(source["pushed"], Pushed);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Plan {
+	    items: Array<Item>;
+	
+	    static createFrom(source: any = {}) {
+	        return new Plan(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.items = this.convertValues(source["items"], Item);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Pushed {
+	    key: string;
+	    comment: string;
+	    start: time.Time;
+	    end: time.Time;
+	    entryId: number;
+	    worklogId: string;
+	    pushedAt: time.Time;
+	
+	    static createFrom(source: any = {}) {
+	        return new Pushed(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.comment = source["comment"];
+	        this.start = this.convertValues(source["start"], time.Time);
+	        this.end = this.convertValues(source["end"], time.Time);
+	        this.entryId = source["entryId"];
+	        this.worklogId = source["worklogId"];
+	        this.pushedAt = this.convertValues(source["pushedAt"], time.Time);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
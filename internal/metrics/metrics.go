@@ -0,0 +1,244 @@
+// Package metrics collects counters, gauges, and histograms, and writes them
+// in the Prometheus text exposition format.
+//
+// https://prometheus.io/docs/instrumenting/exposition_formats/
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the default upper bounds of histogram buckets, in
+// seconds, suitable for latencies of local calls.
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// Registry is a set of metrics that are written together.
+type Registry struct {
+	mu       sync.Mutex
+	families []*family
+}
+
+// NewRegistry creates a new empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Counter is a value that only goes up, such as a number of calls.
+type Counter struct{ f *family }
+
+// Gauge is a value that can go up and down, such as a duration so far.
+type Gauge struct{ f *family }
+
+// Histogram counts observed values, such as latencies, in buckets.
+type Histogram struct{ f *family }
+
+// NewCounter adds a new counter with the given label names.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{r.add(name, help, "counter", nil, labels)}
+}
+
+// NewGauge adds a new gauge with the given label names.
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{r.add(name, help, "gauge", nil, labels)}
+}
+
+// NewHistogram adds a new histogram with the given bucket upper bounds and
+// label names.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Histogram{r.add(name, help, "histogram", buckets, labels)}
+}
+
+func (r *Registry) add(name, help, kind string, buckets []float64, labels []string) *family {
+	f := &family{
+		name:    name,
+		help:    help,
+		kind:    kind,
+		labels:  labels,
+		buckets: buckets,
+		series:  map[string]*series{},
+	}
+	if len(labels) == 0 {
+		// Metrics without labels are always written, starting at zero.
+		f.series[""] = &series{counts: make([]uint64, len(buckets))}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.families = append(r.families, f)
+	return f
+}
+
+// Inc adds 1 to the counter with the given label values.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds the value, which must not be negative, to the counter with the
+// given label values.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	c.f.update(labelValues, func(s *series) { s.value += v })
+}
+
+// Set sets the gauge with the given label values.
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.f.update(labelValues, func(s *series) { s.value = v })
+}
+
+// Reset removes the values of all label values, such as before setting the
+// gauge for a new set of labels. Does nothing if the gauge has no labels.
+func (g *Gauge) Reset() {
+	if len(g.f.labels) == 0 {
+		return
+	}
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+	g.f.series = map[string]*series{}
+}
+
+// Observe adds the value to the histogram with the given label values.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.f.update(labelValues, func(s *series) {
+		if s.counts == nil {
+			s.counts = make([]uint64, len(h.f.buckets))
+		}
+		for i, upper := range h.f.buckets {
+			if v <= upper {
+				s.counts[i]++
+			}
+		}
+		s.sum += v
+		s.count++
+	})
+}
+
+// WriteText writes all metrics in the Prometheus text exposition format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	families := append([]*family(nil), r.families...)
+	r.mu.Unlock()
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+type family struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	counts      []uint64
+	sum         float64
+	count       uint64
+}
+
+func (f *family) update(labelValues []string, fn func(*series)) {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s has %d labels, got %d values", f.name, len(f.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		f.series[key] = s
+	}
+	fn(s)
+}
+
+func (f *family) write(w *bufio.Writer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := f.series[key]
+		if f.kind != "histogram" {
+			fmt.Fprintf(w, "%s%s %s\n", f.name, f.formatLabels(s.labelValues, ""), formatFloat(s.value))
+			continue
+		}
+		for i, upper := range f.buckets {
+			var count uint64
+			if s.counts != nil {
+				count = s.counts[i]
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, f.formatLabels(s.labelValues, formatFloat(upper)), count)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, f.formatLabels(s.labelValues, "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, f.formatLabels(s.labelValues, ""), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, f.formatLabels(s.labelValues, ""), s.count)
+	}
+}
+
+// formatLabels formats the labels as {a="1",b="2"}, with the "le" label of
+// histogram buckets added last if not empty.
+func (f *family) formatLabels(values []string, le string) string {
+	if len(values) == 0 && le == "" {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, name := range f.labels {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, "%s=\"%s\"", name, escapeLabelValue(values[i]))
+	}
+	if le != "" {
+		if len(f.labels) > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, "le=\"%s\"", le)
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+var (
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"bytes"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	calls := r.NewCounter("test_calls_total", "Number of calls.")
+	errs := r.NewCounter("test_errors_total", "Number of errors per method.", "method")
+	active := r.NewGauge("test_active_seconds", "Help with a backslash \\ and\na newline.")
+	tagged := r.NewGauge("test_tag_seconds", `Time per "tag".`, "tag")
+	special := r.NewGauge("test_special", "Special values.", "value")
+	latency := r.NewHistogram("test_latency_seconds", "Latency of calls.", []float64{1, 0.1}, "method")
+	r.NewCounter("test_unused_total", "Counter that is never incremented.")
+	r.NewCounter("test_unused_labeled_total", "Labeled counter that is never incremented.", "method")
+
+	calls.Inc()
+	calls.Add(2.5)
+	errs.Inc("Ping")
+	errs.Inc("Ping")
+	errs.Inc("GetEntry")
+	active.Set(90)
+	active.Set(120.5)
+	tagged.Set(1, `say "hi"`)
+	tagged.Set(2, `C:\work`)
+	tagged.Set(3, "two\nlines")
+	tagged.Set(4, "plain")
+	special.Set(math.Inf(1), "inf")
+	special.Set(math.Inf(-1), "-inf")
+	special.Set(math.NaN(), "nan")
+	special.Set(1e-9, "tiny")
+	latency.Observe(0.05, "Ping")
+	latency.Observe(0.5, "Ping")
+	latency.Observe(5, "Ping")
+	latency.Observe(0.1, "GetEntry")
+
+	var buf bytes.Buffer
+	if err := r.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "metrics.golden.txt")
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("metrics differ from %s, which is updated with -update:\n%s", golden, buf.String())
+	}
+}
+
+func TestGaugeReset(t *testing.T) {
+	r := NewRegistry()
+	g := r.NewGauge("test_tag_seconds", "Time per tag.", "tag")
+	g.Set(1, "old")
+	g.Reset()
+	g.Set(2, "new")
+	var buf bytes.Buffer
+	if err := r.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); strings.Contains(got, "old") || !strings.Contains(got, `test_tag_seconds{tag="new"} 2`) {
+		t.Errorf("want only the new tag, got:\n%s", got)
+	}
+}
+
+func TestWrongNumberOfLabelValuesPanics(t *testing.T) {
+	c := NewRegistry().NewCounter("test_errors_total", "Number of errors.", "method")
+	defer func() {
+		if recover() == nil {
+			t.Error("want panic for missing label value")
+		}
+	}()
+	c.Inc()
+}
@@ -0,0 +1,136 @@
+package notify
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/iver-wharf/wharf-core/v2/pkg/logger"
+)
+
+var log = logger.NewScoped("notify")
+
+const (
+	dbusDest      = "org.freedesktop.Notifications"
+	dbusPath      = "/org/freedesktop/Notifications"
+	dbusInterface = "org.freedesktop.Notifications"
+)
+
+// DBus is a [Notifier] that uses the freedesktop Notifications D-Bus
+// interface.
+//
+// https://specifications.freedesktop.org/notification-spec/latest/
+type DBus struct {
+	AppName string
+	AppIcon string
+
+	conn *dbus.Conn
+	obj  dbus.BusObject
+
+	mu        sync.Mutex
+	callbacks map[ID]func(key string)
+}
+
+// New connects to the session bus and returns a new D-Bus notifier. Call
+// [DBus.Stop] to release the connection.
+func New(appName, appIcon string) (*DBus, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("connect to session bus: %w", err)
+	}
+	if err := conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(dbusPath),
+		dbus.WithMatchInterface(dbusInterface),
+	); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("listen for notification signals: %w", err)
+	}
+	n := &DBus{
+		AppName:   appName,
+		AppIcon:   appIcon,
+		conn:      conn,
+		obj:       conn.Object(dbusDest, dbusPath),
+		callbacks: map[ID]func(string){},
+	}
+	ch := make(chan *dbus.Signal, 10)
+	conn.Signal(ch)
+	go n.handleSignals(ch)
+	return n, nil
+}
+
+// Notify shows a notification using org.freedesktop.Notifications.Notify.
+func (n *DBus) Notify(notif Notification) (ID, error) {
+	actions := make([]string, 0, len(notif.Actions)*2)
+	for _, a := range notif.Actions {
+		actions = append(actions, a.Key, a.Label)
+	}
+	timeoutMs := int32(-1)
+	if notif.Timeout > 0 {
+		timeoutMs = int32(notif.Timeout.Milliseconds())
+	}
+	var id uint32
+	err := n.obj.Call(dbusInterface+".Notify", 0,
+		n.AppName,
+		uint32(notif.ReplacesID),
+		n.AppIcon,
+		notif.Summary,
+		notif.Body,
+		actions,
+		map[string]dbus.Variant{},
+		timeoutMs,
+	).Store(&id)
+	if err != nil {
+		return 0, fmt.Errorf("send notification: %w", err)
+	}
+	if notif.OnAction != nil {
+		n.mu.Lock()
+		n.callbacks[ID(id)] = notif.OnAction
+		n.mu.Unlock()
+	}
+	return ID(id), nil
+}
+
+// Close removes a notification using
+// org.freedesktop.Notifications.CloseNotification.
+func (n *DBus) Close(id ID) error {
+	n.mu.Lock()
+	delete(n.callbacks, id)
+	n.mu.Unlock()
+	return n.obj.Call(dbusInterface+".CloseNotification", 0, uint32(id)).Err
+}
+
+// Stop closes the D-Bus connection.
+func (n *DBus) Stop() error {
+	return n.conn.Close()
+}
+
+func (n *DBus) handleSignals(ch <-chan *dbus.Signal) {
+	for signal := range ch {
+		switch signal.Name {
+		case dbusInterface + ".ActionInvoked":
+			var (
+				id  uint32
+				key string
+			)
+			if err := dbus.Store(signal.Body, &id, &key); err != nil {
+				log.Debug().WithError(err).Message("Malformed ActionInvoked signal.")
+				continue
+			}
+			n.mu.Lock()
+			callback := n.callbacks[ID(id)]
+			n.mu.Unlock()
+			if callback != nil {
+				go callback(key)
+			}
+		case dbusInterface + ".NotificationClosed":
+			var id, reason uint32
+			if err := dbus.Store(signal.Body, &id, &reason); err != nil {
+				log.Debug().WithError(err).Message("Malformed NotificationClosed signal.")
+				continue
+			}
+			n.mu.Lock()
+			delete(n.callbacks, ID(id))
+			n.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+
+package notify
+
+// DBus is a placeholder for the freedesktop D-Bus notifier, which is only
+// available on GNU/Linux.
+type DBus struct {
+	discard
+}
+
+// New always fails, as the freedesktop Notifications D-Bus interface is only
+// available on GNU/Linux.
+func New(appName, appIcon string) (*DBus, error) {
+	return nil, ErrUnsupported
+}
+
+// Stop does nothing.
+func (*DBus) Stop() error {
+	return nil
+}
@@ -0,0 +1,108 @@
+// Package notify sends desktop notifications.
+package notify
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Errors specific to notifiers.
+var (
+	ErrUnsupported = errors.New("desktop notifications are not supported on this system")
+)
+
+// Notifier sends desktop notifications.
+type Notifier interface {
+	// Notify shows a desktop notification. If the notification has any
+	// actions, then the OnAction callback is called when the user clicks on
+	// one of the actions.
+	Notify(n Notification) (ID, error)
+	// Close removes a notification that was previously shown.
+	Close(id ID) error
+}
+
+// ID is the identifier of a shown notification.
+type ID uint32
+
+// Notification is a desktop notification.
+type Notification struct {
+	Summary string
+	Body    string
+	// ReplacesID is the ID of a previous notification to replace, or 0 to
+	// show a new notification.
+	ReplacesID ID
+	// Timeout is how long to show the notification. Zero means the
+	// notification server decides.
+	Timeout time.Duration
+	Actions []Action
+	// OnAction is called with the key of the action that was invoked.
+	OnAction func(key string)
+}
+
+// Action is a button shown on a notification.
+type Action struct {
+	Key   string
+	Label string
+}
+
+// Fake is a [Notifier] that keeps the notifications in memory. It is meant
+// to be used in tests.
+type Fake struct {
+	mu     sync.Mutex
+	lastID ID
+	shown  map[ID]Notification
+	// Sent contains all notifications that has been sent, in order.
+	Sent []Notification
+}
+
+// Notify records the notification.
+func (f *Fake) Notify(n Notification) (ID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.shown == nil {
+		f.shown = map[ID]Notification{}
+	}
+	id := n.ReplacesID
+	if id == 0 {
+		f.lastID++
+		id = f.lastID
+	}
+	f.shown[id] = n
+	f.Sent = append(f.Sent, n)
+	return id, nil
+}
+
+// Close removes the notification.
+func (f *Fake) Close(id ID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.shown, id)
+	return nil
+}
+
+// Invoke simulates the user clicking on an action of a shown notification.
+func (f *Fake) Invoke(id ID, key string) bool {
+	f.mu.Lock()
+	n, ok := f.shown[id]
+	delete(f.shown, id)
+	f.mu.Unlock()
+	if !ok || n.OnAction == nil {
+		return false
+	}
+	n.OnAction(key)
+	return true
+}
+
+// Discard is a [Notifier] that drops all notifications.
+var Discard Notifier = discard{}
+
+type discard struct{}
+
+func (discard) Notify(Notification) (ID, error) {
+	return 0, ErrUnsupported
+}
+
+func (discard) Close(ID) error {
+	return nil
+}
@@ -0,0 +1,171 @@
+// Package pdf is a minimal PDF writer that only supports drawing text and
+// lines with the standard PDF fonts, which PDF readers provide themselves,
+// so no fonts need to be embedded.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Page sizes in points, where 1 point is 1/72 of an inch.
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Font is one of the standard PDF fonts.
+type Font int
+
+const (
+	Helvetica Font = iota
+	HelveticaBold
+	Courier
+)
+
+var fontNames = []string{
+	Helvetica:     "Helvetica",
+	HelveticaBold: "Helvetica-Bold",
+	Courier:       "Courier",
+}
+
+// courierWidth is the width of every glyph in the monospaced Courier font,
+// in thousandths of the font size.
+const courierWidth = 600
+
+// Document is a PDF document with A4 sized pages.
+type Document struct {
+	Title string
+	pages []*Page
+}
+
+// Page is a single page in a document. Coordinates are in points, with the
+// origin in the bottom left corner.
+type Page struct {
+	content bytes.Buffer
+}
+
+// AddPage adds a new empty page to the end of the document.
+func (d *Document) AddPage() *Page {
+	p := &Page{}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Text draws the text with its baseline starting at the given position.
+// Characters that cannot be encoded in the Windows-1252 character set are
+// drawn as question marks.
+func (p *Page) Text(x, y float64, font Font, size float64, text string) {
+	fmt.Fprintf(&p.content, "BT /F%d %.2f Tf %.2f %.2f Td (%s) Tj ET\n",
+		font+1, size, x, y, escape(encodeWinAnsi(text)))
+}
+
+// TextRight draws the text in the Courier font, with the end of the text at
+// the given position. Only Courier is supported, as it is monospaced, which
+// makes the width of the text known without any font metrics.
+func (p *Page) TextRight(x, y, size float64, text string) {
+	encoded := encodeWinAnsi(text)
+	width := float64(len(encoded)) * size * courierWidth / 1000
+	p.Text(x-width, y, Courier, size, text)
+}
+
+// Line draws a thin black line between the two positions.
+func (p *Page) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&p.content, "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, y1, x2, y2)
+}
+
+// WriteTo writes the document as PDF.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var (
+		buf     bytes.Buffer
+		offsets []int
+	)
+	obj := func(format string, args ...any) int {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n", len(offsets))
+		fmt.Fprintf(&buf, format, args...)
+		buf.WriteString("\nendobj\n")
+		return len(offsets)
+	}
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Object numbers are assigned in order, so the page tree and fonts are
+	// referenced by their known numbers before the pages are written.
+	const (
+		catalogID   = 1
+		pageTreeID  = 2
+		infoID      = 3
+		firstFontID = 4
+	)
+	firstPageID := firstFontID + len(fontNames)
+	var kids []string
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPageID+i*2))
+	}
+	obj("<< /Type /Catalog /Pages %d 0 R >>", pageTreeID)
+	obj("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages))
+	obj("<< /Title (%s) /Producer (Dinkur desktop) >>", escape(encodeWinAnsi(d.Title)))
+	var fonts []string
+	for i, name := range fontNames {
+		id := obj("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name)
+		fonts = append(fonts, fmt.Sprintf("/F%d %d 0 R", i+1, id))
+	}
+	for i, p := range d.pages {
+		obj("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			pageTreeID, A4Width, A4Height, strings.Join(fonts, " "), firstPageID+i*2+1)
+		obj("<< /Length %d >>\nstream\n%sendstream", p.content.Len(), p.content.Bytes())
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(offsets)+1, catalogID, infoID, xref)
+	return buf.WriteTo(w)
+}
+
+// encodeWinAnsi encodes the text in Windows-1252, which is what the
+// standard fonts use with the WinAnsiEncoding.
+func encodeWinAnsi(text string) []byte {
+	b := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r == '€':
+			b = append(b, 0x80)
+		case r == '\u202f' || r == '\u2009':
+			// Narrow spaces are used as thousands separators in some locales
+			b = append(b, ' ')
+		case r == '…':
+			b = append(b, 0x85)
+		case r == '–':
+			b = append(b, 0x96)
+		case r == '—':
+			b = append(b, 0x97)
+		case r < 0x80 || (r >= 0xa0 && r <= 0xff):
+			b = append(b, byte(r))
+		default:
+			b = append(b, '?')
+		}
+	}
+	return b
+}
+
+func escape(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		switch c {
+		case '\\', '(', ')':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case '\n', '\r', '\t':
+			sb.WriteByte(' ')
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,276 @@
+// Package analyzer finds overlapping entries, gaps between entries, and
+// entries without any length, and proposes fixes for them.
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dinkur/dinkur/pkg/dinkur"
+)
+
+// Options configures the analysis.
+type Options struct {
+	// GapThreshold is the shortest gap between two entries that is reported.
+	GapThreshold time.Duration
+	// FillName is the name of new entries proposed to fill gaps. No such fix
+	// is proposed if empty.
+	FillName string
+	// Now is the end time used for the active entry.
+	Now time.Time
+}
+
+// IssueKind is the type of problem found with entries.
+type IssueKind string
+
+const (
+	// IssueOverlap is two entries that overlap in time.
+	IssueOverlap IssueKind = "overlap"
+	// IssueGap is a span of untracked time between two entries.
+	IssueGap IssueKind = "gap"
+	// IssueZeroLength is an entry that ends at or before it starts.
+	IssueZeroLength IssueKind = "zeroLength"
+)
+
+// Issue is a problem found with one or more entries.
+type Issue struct {
+	// ID identifies the issue, based on its kind and the IDs of its entries.
+	ID       string    `json:"id"`
+	Kind     IssueKind `json:"kind"`
+	EntryIDs []uint    `json:"entryIds"`
+	// Start and End is the time span of the overlap or gap, or of the entry
+	// for zero-length entries.
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Message string    `json:"message"`
+	Fixes   []Fix     `json:"fixes"`
+}
+
+// FixKind is the type of a proposed fix.
+type FixKind string
+
+const (
+	// FixTrim shortens one entry so it no longer overlaps another, or
+	// extends an entry to cover a gap.
+	FixTrim FixKind = "trim"
+	// FixMerge combines two entries with the same name into one.
+	FixMerge FixKind = "merge"
+	// FixFill creates a new entry to cover a gap.
+	FixFill FixKind = "fill"
+	// FixDelete removes an entry.
+	FixDelete FixKind = "delete"
+)
+
+// Fix is a proposed solution to an issue, as a list of changes to entries.
+type Fix struct {
+	// ID identifies the fix, and starts with the ID of its issue.
+	ID          string   `json:"id"`
+	Kind        FixKind  `json:"kind"`
+	Description string   `json:"description"`
+	Changes     []Change `json:"changes"`
+}
+
+// ChangeOp is the type of change made to an entry.
+type ChangeOp string
+
+const (
+	ChangeUpdate ChangeOp = "update"
+	ChangeCreate ChangeOp = "create"
+	ChangeDelete ChangeOp = "delete"
+)
+
+// Change is a single change to an entry. For updates, only the non-nil
+// start and end times are changed.
+type Change struct {
+	Op ChangeOp `json:"op"`
+	// EntryID is the entry to update or delete. Zero for created entries.
+	EntryID uint       `json:"entryId"`
+	Name    string     `json:"name"`
+	Start   *time.Time `json:"start"`
+	End     *time.Time `json:"end"`
+}
+
+// Analyze finds overlaps, gaps longer than the threshold, and zero-length
+// entries among the entries, which are typically a single day's entries.
+func Analyze(entries []dinkur.Entry, opts Options) []Issue {
+	sorted := make([]dinkur.Entry, 0, len(entries))
+	var issues []Issue
+	for _, entry := range entries {
+		if entry.End != nil && !entry.End.After(entry.Start) {
+			issues = append(issues, zeroLengthIssue(entry))
+			continue
+		}
+		sorted = append(sorted, entry)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+	end := func(e dinkur.Entry) time.Time {
+		if e.End == nil {
+			return opts.Now
+		}
+		return *e.End
+	}
+
+	for i, a := range sorted {
+		for _, b := range sorted[i+1:] {
+			if !b.Start.Before(end(a)) {
+				break
+			}
+			issues = append(issues, overlapIssue(a, b, end))
+		}
+	}
+
+	// The previous entry is the one that ends last among all entries before
+	// the next one, so that gaps hidden by overlaps are not reported.
+	for i := 1; i < len(sorted); i++ {
+		prev, next := sorted[0], sorted[i]
+		for _, e := range sorted[1:i] {
+			if end(e).After(end(prev)) {
+				prev = e
+			}
+		}
+		gap := next.Start.Sub(end(prev))
+		if prev.End != nil && gap > 0 && gap >= opts.GapThreshold {
+			issues = append(issues, gapIssue(prev, next, opts))
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Start.Before(issues[j].Start)
+	})
+	return issues
+}
+
+// FindFix returns the fix with the given ID among the issues.
+func FindFix(issues []Issue, id string) (Fix, bool) {
+	for _, issue := range issues {
+		for _, fix := range issue.Fixes {
+			if fix.ID == id {
+				return fix, true
+			}
+		}
+	}
+	return Fix{}, false
+}
+
+func zeroLengthIssue(entry dinkur.Entry) Issue {
+	id := fmt.Sprintf("zeroLength-%d", entry.ID)
+	return Issue{
+		ID:       id,
+		Kind:     IssueZeroLength,
+		EntryIDs: []uint{entry.ID},
+		Start:    entry.Start,
+		End:      *entry.End,
+		Message:  fmt.Sprintf("%q has no length.", entry.Name),
+		Fixes: []Fix{{
+			ID:          id + "/delete",
+			Kind:        FixDelete,
+			Description: fmt.Sprintf("Delete %q.", entry.Name),
+			Changes:     []Change{{Op: ChangeDelete, EntryID: entry.ID, Name: entry.Name}},
+		}},
+	}
+}
+
+func overlapIssue(a, b dinkur.Entry, end func(dinkur.Entry) time.Time) Issue {
+	id := fmt.Sprintf("overlap-%d-%d", a.ID, b.ID)
+	overlapEnd := end(a)
+	contained := !end(b).After(end(a))
+	if contained {
+		overlapEnd = end(b)
+	}
+	issue := Issue{
+		ID:       id,
+		Kind:     IssueOverlap,
+		EntryIDs: []uint{a.ID, b.ID},
+		Start:    b.Start,
+		End:      overlapEnd,
+		Message: fmt.Sprintf("%q and %q overlap for %s.",
+			a.Name, b.Name, overlapEnd.Sub(b.Start).Round(time.Second)),
+	}
+	if contained {
+		issue.Fixes = append(issue.Fixes, Fix{
+			ID:          id + "/delete-second",
+			Kind:        FixDelete,
+			Description: fmt.Sprintf("Delete %q, which lies within %q.", b.Name, a.Name),
+			Changes:     []Change{{Op: ChangeDelete, EntryID: b.ID, Name: b.Name}},
+		})
+	} else if a.End != nil {
+		// The active entry cannot be trimmed, as that would stop it
+		issue.Fixes = append(issue.Fixes,
+			Fix{
+				ID:          id + "/trim-first",
+				Kind:        FixTrim,
+				Description: fmt.Sprintf("End %q when %q starts.", a.Name, b.Name),
+				Changes:     []Change{{Op: ChangeUpdate, EntryID: a.ID, Name: a.Name, End: timePtr(b.Start)}},
+			},
+			Fix{
+				ID:          id + "/trim-second",
+				Kind:        FixTrim,
+				Description: fmt.Sprintf("Start %q when %q ends.", b.Name, a.Name),
+				Changes:     []Change{{Op: ChangeUpdate, EntryID: b.ID, Name: b.Name, Start: timePtr(*a.End)}},
+			})
+	}
+	if strings.EqualFold(a.Name, b.Name) && (b.End != nil || a.End == nil) {
+		changes := []Change{}
+		if !contained {
+			changes = append(changes, Change{Op: ChangeUpdate, EntryID: a.ID, Name: a.Name, End: timePtr(*b.End)})
+		}
+		changes = append(changes, Change{Op: ChangeDelete, EntryID: b.ID, Name: b.Name})
+		issue.Fixes = append(issue.Fixes, Fix{
+			ID:          id + "/merge",
+			Kind:        FixMerge,
+			Description: fmt.Sprintf("Merge the two %q entries into one.", a.Name),
+			Changes:     changes,
+		})
+	}
+	return issue
+}
+
+func gapIssue(prev, next dinkur.Entry, opts Options) Issue {
+	id := fmt.Sprintf("gap-%d-%d", prev.ID, next.ID)
+	gapStart, gapEnd := *prev.End, next.Start
+	issue := Issue{
+		ID:       id,
+		Kind:     IssueGap,
+		EntryIDs: []uint{prev.ID, next.ID},
+		Start:    gapStart,
+		End:      gapEnd,
+		Message: fmt.Sprintf("%s untracked between %q and %q.",
+			gapEnd.Sub(gapStart).Round(time.Second), prev.Name, next.Name),
+		Fixes: []Fix{
+			{
+				ID:          id + "/extend-previous",
+				Kind:        FixTrim,
+				Description: fmt.Sprintf("End %q when %q starts.", prev.Name, next.Name),
+				Changes:     []Change{{Op: ChangeUpdate, EntryID: prev.ID, Name: prev.Name, End: timePtr(gapEnd)}},
+			},
+			{
+				ID:          id + "/extend-next",
+				Kind:        FixTrim,
+				Description: fmt.Sprintf("Start %q when %q ends.", next.Name, prev.Name),
+				Changes:     []Change{{Op: ChangeUpdate, EntryID: next.ID, Name: next.Name, Start: timePtr(gapStart)}},
+			},
+		},
+	}
+	if opts.FillName != "" {
+		issue.Fixes = append(issue.Fixes, Fix{
+			ID:          id + "/fill",
+			Kind:        FixFill,
+			Description: fmt.Sprintf("Add a %q entry for the gap.", opts.FillName),
+			Changes: []Change{{
+				Op:    ChangeCreate,
+				Name:  opts.FillName,
+				Start: timePtr(gapStart),
+				End:   timePtr(gapEnd),
+			}},
+		})
+	}
+	return issue
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
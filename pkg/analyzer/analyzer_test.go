@@ -0,0 +1,190 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dinkur/dinkur/pkg/dinkur"
+)
+
+var testDay = time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// testEntry returns an entry between the given hours of the test day, which
+// is active if end is negative.
+func testEntry(id uint, name string, start, end float64) dinkur.Entry {
+	e := dinkur.Entry{Name: name, Start: testDay.Add(time.Duration(start * float64(time.Hour)))}
+	e.ID = id
+	if end >= 0 {
+		e.End = timePtr(testDay.Add(time.Duration(end * float64(time.Hour))))
+	}
+	return e
+}
+
+// summarize returns each issue as its ID followed by the IDs of its fixes.
+func summarize(issues []Issue) []string {
+	var result []string
+	for _, issue := range issues {
+		s := issue.ID + ":"
+		for _, fix := range issue.Fixes {
+			s += " " + fix.ID
+		}
+		result = append(result, s)
+	}
+	return result
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []dinkur.Entry
+		now     float64
+		want    []string
+	}{
+		{
+			name: "adjacent",
+			entries: []dinkur.Entry{
+				testEntry(1, "Coding", 9, 10),
+				testEntry(2, "Meeting", 10, 11),
+			},
+		},
+		{
+			name: "gap",
+			entries: []dinkur.Entry{
+				testEntry(1, "Coding", 9, 10),
+				testEntry(2, "Meeting", 10.5, 11),
+			},
+			want: []string{"gap-1-2: gap-1-2/extend-previous gap-1-2/extend-next gap-1-2/fill"},
+		},
+		{
+			name: "gap below threshold",
+			entries: []dinkur.Entry{
+				testEntry(1, "Coding", 9, 10),
+				testEntry(2, "Meeting", 10.1, 11),
+			},
+		},
+		{
+			name: "overlap",
+			entries: []dinkur.Entry{
+				testEntry(1, "Coding", 9, 10.5),
+				testEntry(2, "Meeting", 10, 11),
+			},
+			want: []string{"overlap-1-2: overlap-1-2/trim-first overlap-1-2/trim-second"},
+		},
+		{
+			name: "overlap with same name",
+			entries: []dinkur.Entry{
+				testEntry(1, "Coding", 9, 10.5),
+				testEntry(2, "coding", 10, 11),
+			},
+			want: []string{"overlap-1-2: overlap-1-2/trim-first overlap-1-2/trim-second overlap-1-2/merge"},
+		},
+		{
+			name: "enclosed",
+			entries: []dinkur.Entry{
+				testEntry(1, "Coding", 9, 12),
+				testEntry(2, "Meeting", 10, 11),
+			},
+			want: []string{"overlap-1-2: overlap-1-2/delete-second"},
+		},
+		{
+			name: "enclosed with same name",
+			entries: []dinkur.Entry{
+				testEntry(1, "Coding", 9, 12),
+				testEntry(2, "Coding", 10, 11),
+			},
+			want: []string{"overlap-1-2: overlap-1-2/delete-second overlap-1-2/merge"},
+		},
+		{
+			name: "gap after enclosing entry",
+			entries: []dinkur.Entry{
+				testEntry(1, "Coding", 9, 12),
+				testEntry(2, "Meeting", 10, 11),
+				testEntry(3, "Lunch", 12.5, 13),
+			},
+			want: []string{
+				"overlap-1-2: overlap-1-2/delete-second",
+				"gap-1-3: gap-1-3/extend-previous gap-1-3/extend-next gap-1-3/fill",
+			},
+		},
+		{
+			name: "gap before active entry",
+			entries: []dinkur.Entry{
+				testEntry(1, "Coding", 9, 10),
+				testEntry(2, "Meeting", 11, -1),
+			},
+			now:  12,
+			want: []string{"gap-1-2: gap-1-2/extend-previous gap-1-2/extend-next gap-1-2/fill"},
+		},
+		{
+			name: "active entry ends now",
+			entries: []dinkur.Entry{
+				testEntry(1, "Coding", 9, -1),
+				testEntry(2, "Meeting", 11, 12),
+			},
+			now: 10,
+		},
+		{
+			name: "active entry encloses",
+			entries: []dinkur.Entry{
+				testEntry(1, "Coding", 9, -1),
+				testEntry(2, "Meeting", 10, 11),
+			},
+			now:  12,
+			want: []string{"overlap-1-2: overlap-1-2/delete-second"},
+		},
+		{
+			name: "active entry overlaps",
+			entries: []dinkur.Entry{
+				testEntry(1, "Coding", 9, -1),
+				testEntry(2, "Meeting", 10, 13),
+			},
+			now: 12,
+			// The active entry can neither be trimmed nor deleted.
+			want: []string{"overlap-1-2:"},
+		},
+		{
+			name: "overlaps active entry",
+			entries: []dinkur.Entry{
+				testEntry(1, "Coding", 9, 11),
+				testEntry(2, "Coding", 10, -1),
+			},
+			now:  12,
+			want: []string{"overlap-1-2: overlap-1-2/trim-first overlap-1-2/trim-second"},
+		},
+		{
+			name: "zero length",
+			entries: []dinkur.Entry{
+				testEntry(1, "Coding", 9, 9),
+				testEntry(2, "Meeting", 10, 11),
+			},
+			want: []string{"zeroLength-1: zeroLength-1/delete"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			issues := Analyze(tc.entries, Options{
+				GapThreshold: 15 * time.Minute,
+				FillName:     "Untracked",
+				Now:          testDay.Add(time.Duration(tc.now * float64(time.Hour))),
+			})
+			if got := summarize(issues); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want issues %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestAnalyzeOverlapSpan(t *testing.T) {
+	issues := Analyze([]dinkur.Entry{
+		testEntry(1, "Coding", 9, -1),
+		testEntry(2, "Meeting", 10, 11),
+	}, Options{Now: testDay.Add(12 * time.Hour)})
+	if len(issues) != 1 {
+		t.Fatalf("want 1 issue, got %v", summarize(issues))
+	}
+	wantStart, wantEnd := testDay.Add(10*time.Hour), testDay.Add(11*time.Hour)
+	if !issues[0].Start.Equal(wantStart) || !issues[0].End.Equal(wantEnd) {
+		t.Errorf("want overlap %v-%v, got %v-%v", wantStart, wantEnd, issues[0].Start, issues[0].End)
+	}
+}
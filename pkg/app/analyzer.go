@@ -0,0 +1,90 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/analyzer"
+	"github.com/dinkur/dinkur/pkg/dinkur"
+)
+
+// AnalyzeDay finds overlapping entries, gaps between entries, and entries
+// without any length on the calendar day that contains the given time,
+// together with proposed fixes.
+func (a *App) AnalyzeDay(day time.Time) ([]analyzer.Issue, error) {
+	start, end := a.calendar.Day(day)
+	entries, err := a.dinkur.GetEntryList(a.ctx, dinkur.SearchEntry{
+		Limit: EntrySearchLimit,
+		Start: &start,
+		End:   &end,
+	})
+	if err != nil {
+		return nil, err
+	}
+	issues := analyzer.Analyze(entries, a.analyzerOptions(a.clock.Now()))
+	if issues == nil {
+		issues = []analyzer.Issue{}
+	}
+	return issues, nil
+}
+
+func (a *App) analyzerOptions(now time.Time) analyzer.Options {
+	return analyzer.Options{
+		GapThreshold: a.cfg.Analyzer.GapThreshold,
+		FillName:     a.cfg.Analyzer.FillName,
+		Now:          now,
+	}
+}
+
+// ApplyFix applies a fix proposed by [App.AnalyzeDay]. The day is analyzed
+// again first, so that a fix based on outdated entries is never applied.
+func (a *App) ApplyFix(day time.Time, fixID string) error {
+	issues, err := a.AnalyzeDay(day)
+	if err != nil {
+		return err
+	}
+	fix, ok := analyzer.FindFix(issues, fixID)
+	if !ok {
+		return fmt.Errorf("fix %q no longer applies, the entries may have changed", fixID)
+	}
+	if err := a.mutate(fix.Description, func(ctx context.Context) error {
+		return a.applyChanges(ctx, fix.Changes)
+	}); err != nil {
+		return err
+	}
+	log.Info().
+		WithString("fix", fix.ID).
+		WithInt("changes", len(fix.Changes)).
+		Message("Applied fix.")
+	return nil
+}
+
+func (a *App) applyChanges(ctx context.Context, changes []analyzer.Change) error {
+	for _, change := range changes {
+		switch change.Op {
+		case analyzer.ChangeUpdate:
+			if _, err := a.dinkur.UpdateEntry(ctx, dinkur.EditEntry{
+				IDOrZero: change.EntryID,
+				Start:    change.Start,
+				End:      change.End,
+			}); err != nil {
+				return fmt.Errorf("update entry %d: %w", change.EntryID, err)
+			}
+		case analyzer.ChangeDelete:
+			if _, err := a.dinkur.DeleteEntry(ctx, change.EntryID); err != nil {
+				return fmt.Errorf("delete entry %d: %w", change.EntryID, err)
+			}
+		case analyzer.ChangeCreate:
+			if change.Start == nil || change.End == nil {
+				return fmt.Errorf("create entry %q: missing start or end time", change.Name)
+			}
+			if _, err := a.createPastEntry(ctx, change.Name, *change.Start, *change.End); err != nil {
+				return fmt.Errorf("create entry %q: %w", change.Name, err)
+			}
+		default:
+			return fmt.Errorf("unknown change: %q", change.Op)
+		}
+	}
+	return nil
+}
@@ -3,15 +3,24 @@ package app
 import (
 	"context"
 	"embed"
+	"errors"
 	"fmt"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"fyne.io/systray"
+	"github.com/dinkur/dinkur-desktop/internal/clock"
+	"github.com/dinkur/dinkur-desktop/internal/notify"
 	"github.com/dinkur/dinkur-desktop/internal/wailsutil"
+	"github.com/dinkur/dinkur-desktop/pkg/billing"
+	"github.com/dinkur/dinkur-desktop/pkg/calendar"
 	"github.com/dinkur/dinkur-desktop/pkg/config"
+	"github.com/dinkur/dinkur-desktop/pkg/entryname"
+	"github.com/dinkur/dinkur-desktop/pkg/issues"
+	"github.com/dinkur/dinkur-desktop/pkg/rules"
 	"github.com/dinkur/dinkur/pkg/dinkur"
 	"github.com/dinkur/dinkur/pkg/dinkurdb"
-	"github.com/dinkur/dinkur/pkg/timeutil"
 	"github.com/iver-wharf/wharf-core/v2/pkg/logger"
 	"github.com/wailsapp/wails/v2"
 	wailslogger "github.com/wailsapp/wails/v2/pkg/logger"
@@ -26,7 +35,12 @@ var IconBytes []byte
 
 var log = logger.NewScoped("Dinkur desktop")
 
+const trayTitle = "Dinkur desktop"
+
 func Run(cfg *config.Config) error {
+	if err := cfg.LoadSecrets(config.Secrets()); err != nil {
+		log.Warn().WithError(err).Message("Failed to load secrets. Integrations may fail to authenticate.")
+	}
 	app := New(cfg)
 
 	// Create application with options
@@ -57,9 +71,40 @@ func Run(cfg *config.Config) error {
 type App struct {
 	cfg    *config.Config
 	ctx    context.Context
+	cancel context.CancelFunc
 	dinkur dinkur.Client
+	clock  clock.Clock
+
+	notifier notify.Notifier
 
 	trayCheckOut *systray.MenuItem
+
+	idleSource   IdleSource
+	idleDetector *IdleDetector
+	idleMutex    sync.Mutex
+	pendingIdle  *PendingIdle
+
+	longRunning      *LongRunningWatcher
+	autoActionsMutex sync.Mutex
+	autoActions      *AutoActionStore
+
+	restoreMutex sync.Mutex
+
+	pomodoro *PomodoroTimer
+	budgets  *BudgetWatcher
+
+	nameParser *entryname.Parser
+	rules      *rules.Engine
+	billing    *billing.Calculator
+	calendar   *calendar.Calendar
+	journal    *Journal
+	metrics    *appMetrics
+
+	entryWatcher *EntryWatcher
+	dbusService  *DBusService
+	webhooks     *WebhookSender
+	meetings     *MeetingCalendars
+	issues       *issues.Resolver
 }
 
 // New creates a new App application struct
@@ -67,31 +112,113 @@ func New(cfg *config.Config) *App {
 	opt := dinkurdb.Options{
 		MkdirAll: cfg.Sqlite.Mkdir,
 	}
-	return &App{
-		cfg:    cfg,
-		dinkur: dinkurdb.NewClient(cfg.Sqlite.Path, opt),
+	metrics := newAppMetrics()
+	client := &metricsClient{Client: dinkurdb.NewClient(cfg.Sqlite.Path, opt), metrics: metrics}
+	journal := NewJournal(filepath.Join(cfg.DataDir, "journal.json"), cfg.Journal.Size, client, clock.System)
+	cal := newCalendar(cfg.Calendar)
+	a := &App{
+		cfg:        cfg,
+		dinkur:     &journalClient{Client: client, journal: journal},
+		clock:      clock.System,
+		idleSource: newSystemIdleSource(),
+		nameParser: newEntryNameParser(entryname.Options{
+			TagPrefix:      cfg.Tags.TagPrefix,
+			ClientPrefix:   cfg.Tags.ClientPrefix,
+			TicketPatterns: cfg.Tags.TicketPatterns,
+		}),
+		rules:    newRulesEngine(cfg.Categorization),
+		billing:  newBillingCalculator(cfg.Billing, cal),
+		calendar: cal,
+		journal:  journal,
+		metrics:  metrics,
 	}
+	journal.OnChange = a.onJournalChange
+	journal.OnRecreate = a.entryRecreated
+	journal.OnRestoreFailed = a.keepUnrestored
+	a.pomodoro = a.newPomodoroTimer()
+	a.autoActions = NewAutoActionStore(filepath.Join(cfg.DataDir, "auto-actions.json"))
+	a.entryWatcher = NewEntryWatcher(a.dinkur, a.toEntry)
+	a.webhooks = NewWebhookSender(filepath.Join(cfg.DataDir, "webhook-outbox.json"), cfg.Webhooks, a.clock)
+	a.meetings = NewMeetingCalendars(filepath.Join(cfg.DataDir, "meetings.json"), cfg.Meetings, a.clock, cal.Location())
+	a.issues = newIssueResolver(filepath.Join(cfg.DataDir, "issue-cache.json"), cfg.IssueTrackers, a.clock)
+	return a
 }
 
 // onStartup is called when the app starts. The context is saved
 // so we can call the runtime methods
 func (a *App) onStartup(ctx context.Context) {
-	a.ctx = ctx
+	a.ctx, a.cancel = context.WithCancel(ctx)
 	go systray.Run(a.onSystrayReady, a.onSystrayExit)
 	a.ConnectDinkur()
+	a.startNotifier()
+	a.startIdleDetector(a.ctx)
+	a.startReminders(a.ctx)
+	a.startLongRunningWatcher(a.ctx)
+	a.startBudgetWatcher(a.ctx)
+	a.startMeetingWatcher(a.ctx)
+	a.startIssueResolver(a.ctx)
+	a.startHTTPAPI(a.ctx)
+	a.startDBusService()
+	a.startHookRunner(a.ctx)
+	a.startWebhookSender(a.ctx)
+	a.startEntryWatcher(a.ctx)
 }
 
 func (a *App) onShutdown(ctx context.Context) {
-	if err := a.cfg.Save(); err != nil {
-		log.Error().WithError(err).Message("Failed to save config before exiting.")
-	}
+	a.cancel()
+	a.stopNotifier()
+	a.stopDBusService()
+	a.saveConfig()
 	systray.Quit()
 	a.DisconnectDinkur()
 }
 
+// saveConfig saves the config, unless it could not be fully loaded, so that
+// a config file with an invalid value is never replaced by default values.
+func (a *App) saveConfig() {
+	err := a.cfg.Save()
+	if errors.Is(err, config.ErrConfigNotLoaded) {
+		log.Warn().WithError(a.cfg.LoadError()).
+			Message("Not saving config, as the config file has errors.")
+		return
+	}
+	if err != nil {
+		log.Error().WithError(err).Message("Failed to save config before exiting.")
+	}
+}
+
+func (a *App) startNotifier() {
+	if a.notifier != nil {
+		return
+	}
+	n, err := notify.New("Dinkur desktop", "dinkur-small")
+	if err != nil {
+		log.Warn().WithError(err).Message("Failed to set up desktop notifications.")
+		a.notifier = notify.Discard
+		return
+	}
+	a.notifier = n
+}
+
+func (a *App) stopNotifier() {
+	if n, ok := a.notifier.(*notify.DBus); ok {
+		if err := n.Stop(); err != nil {
+			log.Warn().WithError(err).Message("Failed to close desktop notifications connection.")
+		}
+	}
+}
+
+func (a *App) startEntryWatcher(ctx context.Context) {
+	go func() {
+		if err := a.entryWatcher.Run(ctx); err != nil {
+			log.Warn().WithError(err).Message("Failed to watch for started and stopped entries.")
+		}
+	}()
+}
+
 func (a *App) onSystrayReady() {
 	systray.SetTemplateIcon(IconBytes, IconBytes)
-	systray.SetTitle("Dinkur desktop")
+	systray.SetTitle(trayTitle)
 	systray.SetTooltip("Placeholder tooltip")
 
 	a.trayCheckOut = systray.AddMenuItem("No active entry", "You have no active entry tracking time right now.")
@@ -118,42 +245,49 @@ func (a *App) onSystrayExit() {
 func (a *App) ConnectDinkur() error {
 	if err := a.dinkur.Connect(a.ctx); err != nil {
 		log.Error().WithError(err).Message("Failed to connect to Dinkur database.")
+		a.metrics.observeConnect(err)
 		return err
 	}
 	if err := a.dinkur.Ping(a.ctx); err != nil {
 		log.Error().WithError(err).Message("Failed to ping Dinkur database.")
 		a.dinkur.Close()
+		a.metrics.observeConnect(err)
 		return fmt.Errorf("ping: %w", err)
 	}
+	a.metrics.observeConnect(nil)
 	log.Info().Message("Successfully connected to Dinkur!")
 	return nil
 }
 
 func (a *App) DisconnectDinkur() error {
 	err := a.dinkur.Close()
+	a.metrics.connected.Set(0)
 	if err != nil {
 		log.Error().WithError(err).Message("Failed to close connection to Dinkur database.")
 	}
 	return err
 }
 
-func (a *App) GetActiveEntry() (*dinkur.Entry, error) {
-	return a.dinkur.GetActiveEntry(a.ctx)
+func (a *App) GetActiveEntry() (*Entry, error) {
+	entry, err := a.dinkur.GetActiveEntry(a.ctx)
+	return a.toEntryPtr(entry), err
 }
 
-func (a *App) GetEntriesForDay(day time.Time) ([]dinkur.Entry, error) {
-	span := timeutil.Day(day)
-	log.Debug().WithString("day", day.Format(time.DateOnly)).
-		WithTime("start", *span.Start).
-		WithTime("end", *span.End).
+// GetEntriesForDay returns the entries on the calendar day that contains
+// the given time.
+func (a *App) GetEntriesForDay(day time.Time) ([]Entry, error) {
+	start, end := a.calendar.Day(day)
+	log.Debug().WithString("day", a.calendar.Date(day).Format(time.DateOnly)).
+		WithTime("start", start).
+		WithTime("end", end).
 		Message("Getting entries for day.")
 	entries, err := a.dinkur.GetEntryList(context.Background(), dinkur.SearchEntry{
-		Limit: 100,
-		Start: span.Start,
-		End:   span.End,
+		Limit: EntrySearchLimit,
+		Start: &start,
+		End:   &end,
 	})
 	log.Debug().WithError(err).
 		WithInt("count", len(entries)).
 		Message("Got entries response.")
-	return entries, err
+	return a.toEntries(entries), err
 }
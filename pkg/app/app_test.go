@@ -0,0 +1,85 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/internal/clock"
+	"github.com/dinkur/dinkur-desktop/internal/notify"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+	"github.com/dinkur/dinkur/pkg/dinkur"
+	"github.com/spf13/viper"
+)
+
+// newTestApp creates an app with an empty database in a temporary directory.
+// The frontend events are not sent, as there is no Wails runtime in tests.
+func newTestApp(t *testing.T, now time.Time) (*App, *clock.Fake) {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := config.Default
+	cfg.DataDir = dir
+	cfg.Sqlite.Path = filepath.Join(dir, "dinkur.db")
+	cfg.Sqlite.Mkdir = true
+	cfg.Calendar.TimeZone = "UTC"
+	a := New(&cfg)
+	clk := clock.NewFake(now)
+	a.clock = clk
+	a.journal.clock = clk
+	a.journal.OnChange = nil
+	a.notifier = notify.Discard
+	a.ctx = context.Background()
+	if err := a.dinkur.Connect(a.ctx); err != nil {
+		if strings.Contains(err.Error(), "no such module: fts5") {
+			t.Skip("Database tests need SQLite full-text search. Run with: go test -tags=fts5")
+		}
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { a.dinkur.Close() })
+	return a, clk
+}
+
+// createTestEntry creates an entry, or an active entry if end is nil.
+func createTestEntry(t *testing.T, a *App, name string, start time.Time, end *time.Time) dinkur.Entry {
+	t.Helper()
+	res, err := a.dinkur.CreateEntry(a.ctx, dinkur.NewEntry{Name: name, Start: &start, End: end})
+	if err != nil {
+		t.Fatalf("create entry %q: %v", name, err)
+	}
+	return res.Started
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestShutdownKeepsInvalidConfigFile(t *testing.T) {
+	const content = "categorization:\n  rules:\n    - match: \"ab[c\"\n      project: x\n"
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dinkur-desktop.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	oldPath := config.Path
+	config.Path = path
+	t.Cleanup(func() { config.Path = oldPath })
+
+	cfg, err := config.ReadFile(viper.New(), path)
+	if err == nil {
+		t.Fatal("want validation error")
+	}
+	cfg.DataDir = dir
+	a := New(cfg)
+	a.saveConfig()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != content {
+		t.Errorf("want config file to be unchanged, got:\n%s", b)
+	}
+}
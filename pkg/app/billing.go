@@ -0,0 +1,97 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/billing"
+	"github.com/dinkur/dinkur-desktop/pkg/calendar"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+func newBillingCalculator(cfg config.Billing, cal *calendar.Calendar) *billing.Calculator {
+	calc, err := billing.New(cfg, cal)
+	if err != nil {
+		log.Warn().WithError(err).Message("Invalid billing config. Using default billing config.")
+		calc, _ = billing.New(config.Default.Billing, cal)
+	}
+	return calc
+}
+
+// BillingEntries converts the entries for use in billing reports. Active
+// entries are billed up until now.
+func BillingEntries(entries []Entry, now time.Time) []billing.Entry {
+	result := make([]billing.Entry, len(entries))
+	for i, entry := range entries {
+		end := now
+		if entry.End != nil {
+			end = *entry.End
+		}
+		client := entry.Client
+		if client == "" {
+			client = entry.Project
+		}
+		result[i] = billing.Entry{
+			ID:       entry.ID,
+			Name:     entry.Name,
+			Client:   client,
+			Project:  entry.Project,
+			Tags:     entry.Tags,
+			Start:    entry.Start,
+			End:      end,
+			Billable: entry.Billable,
+		}
+	}
+	return result
+}
+
+// GetBillingReport returns the billable totals per client from the calendar
+// day that contains the start time, up to and including the day that
+// contains the end time, using the rates and rounding from the billing
+// config.
+func (a *App) GetBillingReport(start, end time.Time) (billing.Report, error) {
+	start, end = a.daySpan(start, end)
+	entries, err := a.listEntries(a.ctx, start, end)
+	if err != nil {
+		return billing.Report{}, err
+	}
+	return a.billing.Report(BillingEntries(entries, a.clock.Now()), start, end), nil
+}
+
+// ExportBillingCSV asks where to save and then writes the billing report
+// for the days from the start time to the end time as CSV. If detailed is
+// true, then each billable entry gets its own row, instead of one row per
+// client. Returns the path of the saved file, or empty string if the user
+// cancelled.
+func (a *App) ExportBillingCSV(start, end time.Time, detailed bool) (string, error) {
+	report, err := a.GetBillingReport(start, end)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := billing.WriteCSV(&buf, report, detailed); err != nil {
+		return "", err
+	}
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title: "Export billing report",
+		DefaultFilename: fmt.Sprintf("billing-%s-%s.csv",
+			a.calendar.Date(start).Format(time.DateOnly), a.calendar.Date(end).Format(time.DateOnly)),
+		Filters: []runtime.FileFilter{
+			{DisplayName: "CSV files (*.csv)", Pattern: "*.csv"},
+		},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("write billing report: %w", err)
+	}
+	log.Info().
+		WithString("path", path).
+		WithInt("clients", len(report.Clients)).
+		Message("Exported billing report.")
+	return path, nil
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBillingReportIncludesEnclosingEntries(t *testing.T) {
+	day := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	a, _ := newTestApp(t, day.AddDate(0, 0, 3))
+	createTestEntry(t, a, "Deploy", day.Add(-time.Hour), timePtr(day.AddDate(0, 0, 1).Add(time.Hour)))
+	createTestEntry(t, a, "Support", day.AddDate(0, 0, 2).Add(9*time.Hour), nil)
+
+	report, err := a.GetBillingReport(day, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := report.Duration + report.NonBillable; got != 24*time.Hour {
+		t.Errorf("want the whole day of the enclosing entry, got %v", got)
+	}
+
+	// The active entry has been running for a day when the report is made.
+	report, err = a.GetBillingReport(day.AddDate(0, 0, 2), day.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := report.Duration + report.NonBillable; got != 15*time.Hour {
+		t.Errorf("want the active entry up until the end of the day, got %v", got)
+	}
+}
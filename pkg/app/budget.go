@@ -0,0 +1,213 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"fyne.io/systray"
+	"github.com/dinkur/dinkur-desktop/internal/clock"
+	"github.com/dinkur/dinkur-desktop/internal/notify"
+	"github.com/dinkur/dinkur-desktop/pkg/budget"
+	"github.com/dinkur/dinkur-desktop/pkg/calendar"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// BudgetEntryLister lists the entries that overlap with a time span.
+type BudgetEntryLister func(ctx context.Context, start, end time.Time) ([]Entry, error)
+
+// BudgetWatcher periodically calculates the progress of all budgets, and
+// sends desktop notifications when a budget reaches one of the
+// [budget.Thresholds].
+type BudgetWatcher struct {
+	listEntries BudgetEntryLister
+	notifier    notify.Notifier
+	clock       clock.Clock
+	calendar    *calendar.Calendar
+	cfg         config.Budgets
+
+	// OnProgress is called with the progress of all budgets after each check.
+	OnProgress func([]budget.Progress)
+
+	mu       sync.Mutex
+	notified map[budgetThreshold]bool
+}
+
+type budgetThreshold struct {
+	index     int
+	start     time.Time
+	threshold float64
+}
+
+// NewBudgetWatcher creates a new budget watcher.
+func NewBudgetWatcher(listEntries BudgetEntryLister, notifier notify.Notifier, clk clock.Clock, cal *calendar.Calendar, cfg config.Budgets) *BudgetWatcher {
+	return &BudgetWatcher{
+		listEntries: listEntries,
+		notifier:    notifier,
+		clock:       clk,
+		calendar:    cal,
+		cfg:         cfg,
+		notified:    map[budgetThreshold]bool{},
+	}
+}
+
+// Run checks the budgets on the given interval until the context is
+// cancelled.
+func (w *BudgetWatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := w.clock.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			if err := w.Check(ctx); err != nil {
+				log.Warn().WithError(err).Message("Failed to check budgets.")
+			}
+		}
+	}
+}
+
+// Progress calculates the progress of all budgets within their current
+// periods, including the active entry.
+func (w *BudgetWatcher) Progress(ctx context.Context) ([]budget.Progress, error) {
+	now := w.clock.Now()
+	entries, err := w.listEntries(ctx, budget.EarliestStart(w.calendar, w.cfg.Items, now), now)
+	if err != nil {
+		return nil, fmt.Errorf("list entries: %w", err)
+	}
+	return budget.Compute(w.calendar, w.cfg.Items, budgetEntries(entries, now), now), nil
+}
+
+// Check calculates the progress of all budgets, and sends a notification for
+// each budget that has reached a new threshold. Only the highest reached
+// threshold is notified, so a budget that is already over 100% on startup
+// only gets one notification.
+func (w *BudgetWatcher) Check(ctx context.Context) error {
+	progress, err := w.Progress(ctx)
+	if err != nil {
+		return err
+	}
+	for _, p := range progress {
+		if threshold, ok := w.newThreshold(p); ok {
+			w.notify(p, threshold)
+		}
+	}
+	if w.OnProgress != nil {
+		w.OnProgress(progress)
+	}
+	return nil
+}
+
+func (w *BudgetWatcher) newThreshold(p budget.Progress) (float64, bool) {
+	thresholds := append([]float64(nil), budget.Thresholds...)
+	sort.Sort(sort.Reverse(sort.Float64Slice(thresholds)))
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, threshold := range thresholds {
+		if p.Percent < threshold {
+			continue
+		}
+		key := budgetThreshold{p.Index, p.Start, threshold}
+		if w.notified[key] {
+			return 0, false
+		}
+		for _, lower := range thresholds {
+			if lower <= threshold {
+				w.notified[budgetThreshold{p.Index, p.Start, lower}] = true
+			}
+		}
+		return threshold, true
+	}
+	return 0, false
+}
+
+func (w *BudgetWatcher) notify(p budget.Progress, threshold float64) {
+	var summary, body string
+	used, total := budget.FormatDuration(p.Used), budget.FormatDuration(p.Budget)
+	switch {
+	case p.Kind == config.BudgetKindCap && threshold >= 100:
+		summary = "Budget exceeded: " + p.Name
+		body = fmt.Sprintf("You've used %s of the %s %s cap.", used, total, periodAdjective(p.Period))
+	case p.Kind == config.BudgetKindCap:
+		summary = "Budget nearly used: " + p.Name
+		body = fmt.Sprintf("You've used %s of the %s %s cap, %s remaining.",
+			used, total, periodAdjective(p.Period), budget.FormatDuration(p.Remaining))
+	case threshold >= 100:
+		summary = "Goal reached: " + p.Name
+		body = fmt.Sprintf("You've reached the %s %s target.", total, periodAdjective(p.Period))
+	default:
+		summary = "Almost there: " + p.Name
+		body = fmt.Sprintf("You've tracked %s of the %s %s target, %s remaining.",
+			used, total, periodAdjective(p.Period), budget.FormatDuration(p.Remaining))
+	}
+	log.Info().
+		WithString("budget", p.Name).
+		WithDuration("used", p.Used).
+		WithDuration("budget", p.Budget).
+		Messagef("Budget reached %.0f%%.", threshold)
+	if _, err := w.notifier.Notify(notify.Notification{Summary: summary, Body: body}); err != nil {
+		log.Debug().WithError(err).Message("Failed to send budget notification.")
+	}
+}
+
+func periodAdjective(period config.BudgetPeriod) string {
+	switch period {
+	case config.BudgetPeriodWeek:
+		return "weekly"
+	case config.BudgetPeriodMonth:
+		return "monthly"
+	default:
+		return "daily"
+	}
+}
+
+// budgetEntries converts the entries for use in budgets. Active entries
+// are counted up until now.
+func budgetEntries(entries []Entry, now time.Time) []budget.Entry {
+	result := make([]budget.Entry, len(entries))
+	for i, entry := range entries {
+		end := now
+		if entry.End != nil {
+			end = *entry.End
+		}
+		result[i] = budget.Entry{
+			Project: entry.Project,
+			Client:  entry.Client,
+			Tags:    entry.Tags,
+			Start:   entry.Start,
+			End:     end,
+			Active:  entry.End == nil,
+		}
+	}
+	return result
+}
+
+func (a *App) startBudgetWatcher(ctx context.Context) {
+	if len(a.cfg.Budgets.Items) == 0 {
+		return
+	}
+	w := NewBudgetWatcher(a.listEntries, a.notifier, a.clock, a.calendar, a.cfg.Budgets)
+	w.OnProgress = a.onBudgetProgress
+	a.budgets = w
+	go w.Run(ctx, a.cfg.Budgets.CheckInterval)
+}
+
+func (a *App) onBudgetProgress(progress []budget.Progress) {
+	if goal, ok := budget.MainGoal(progress, a.cfg.Budgets.MainGoal); ok {
+		systray.SetTooltip(goal.Summary())
+	}
+	runtime.EventsEmit(a.ctx, "budget:progress", progress)
+}
+
+// GetBudgetProgress returns how much of each budget has been used within its
+// current day, week, or month, including the active entry.
+func (a *App) GetBudgetProgress() ([]budget.Progress, error) {
+	if a.budgets == nil {
+		return []budget.Progress{}, nil
+	}
+	return a.budgets.Progress(a.ctx)
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/internal/clock"
+	"github.com/dinkur/dinkur-desktop/pkg/calendar"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+)
+
+type fakeBudgetEntries []Entry
+
+func (f *fakeBudgetEntries) add(start time.Time, end *time.Time) {
+	var entry Entry
+	entry.Start = start
+	entry.End = end
+	*f = append(*f, entry)
+}
+
+func (f *fakeBudgetEntries) list(_ context.Context, start, end time.Time) ([]Entry, error) {
+	var result []Entry
+	for _, entry := range *f {
+		if entry.Start.Before(end) && (entry.End == nil || entry.End.After(start)) {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
+
+func newTestBudgetWatcher(t *testing.T, now time.Time, weekStart time.Weekday, budgets ...config.Budget) (*BudgetWatcher, *fakeBudgetEntries, *recordingNotifier, *clock.Fake) {
+	t.Helper()
+	cal, err := calendar.New(config.Calendar{TimeZone: "UTC", WeekStart: config.Weekday(weekStart)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := &fakeBudgetEntries{}
+	notifier := &recordingNotifier{}
+	clk := clock.NewFake(now)
+	w := NewBudgetWatcher(entries.list, notifier, clk, cal, config.Budgets{Items: budgets})
+	return w, entries, notifier, clk
+}
+
+func checkBudgetNotifications(t *testing.T, w *BudgetWatcher, notifier *recordingNotifier, want ...string) {
+	t.Helper()
+	notifier.notifications = nil
+	if err := w.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, n := range notifier.notifications {
+		got = append(got, n.Summary)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("want notifications %q, got %q", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("want notifications %q, got %q", want, got)
+			return
+		}
+	}
+}
+
+func TestBudgetWatcherNotifiesOncePerPeriod(t *testing.T) {
+	now := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	w, entries, notifier, clk := newTestBudgetWatcher(t, now, time.Monday, config.Budget{
+		Name:     "Daily goal",
+		Period:   config.BudgetPeriodDay,
+		Kind:     config.BudgetKindTarget,
+		Duration: time.Hour,
+	})
+
+	entries.add(now.Add(-time.Hour), timePtr(now.Add(-10*time.Minute)))
+	checkBudgetNotifications(t, w, notifier, "Almost there: Daily goal")
+	checkBudgetNotifications(t, w, notifier)
+
+	entries.add(now, nil)
+	clk.Advance(10 * time.Minute)
+	checkBudgetNotifications(t, w, notifier, "Goal reached: Daily goal")
+	clk.Advance(time.Hour)
+	checkBudgetNotifications(t, w, notifier)
+
+	// Stop the active entry, and reach the goal the day after. Only the
+	// highest threshold is notified in the new period.
+	(*entries)[1].End = timePtr(clk.Now())
+	tomorrow := now.AddDate(0, 0, 1)
+	entries.add(tomorrow.Add(-2*time.Hour), timePtr(tomorrow))
+	clk.Set(tomorrow)
+	checkBudgetNotifications(t, w, notifier, "Goal reached: Daily goal")
+	checkBudgetNotifications(t, w, notifier)
+}
+
+func TestBudgetWatcherWeekStartsFromCalendar(t *testing.T) {
+	// 2023-03-04 is a Saturday.
+	saturday := time.Date(2023, 3, 4, 12, 0, 0, 0, time.UTC)
+	sunday := saturday.AddDate(0, 0, 1)
+	budget := config.Budget{
+		Name:     "Weekly cap",
+		Period:   config.BudgetPeriodWeek,
+		Kind:     config.BudgetKindCap,
+		Duration: 2 * time.Hour,
+	}
+	tests := []struct {
+		name      string
+		weekStart time.Weekday
+		want      []string
+	}{
+		{"new week on Sunday", time.Sunday, []string{"Budget exceeded: Weekly cap"}},
+		{"same week on Sunday", time.Monday, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w, entries, notifier, clk := newTestBudgetWatcher(t, saturday, tc.weekStart, budget)
+			entries.add(saturday.Add(-2*time.Hour), timePtr(saturday))
+			checkBudgetNotifications(t, w, notifier, "Budget exceeded: Weekly cap")
+
+			entries.add(sunday.Add(-2*time.Hour), timePtr(sunday))
+			clk.Set(sunday)
+			checkBudgetNotifications(t, w, notifier, tc.want...)
+		})
+	}
+}
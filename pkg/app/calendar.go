@@ -0,0 +1,48 @@
+package app
+
+import (
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/calendar"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+)
+
+func newCalendar(cfg config.Calendar) *calendar.Calendar {
+	cal, err := calendar.New(cfg)
+	if err != nil {
+		log.Warn().WithError(err).Message("Invalid calendar config. Using default calendar config.")
+		cal, _ = calendar.New(config.Default.Calendar)
+	}
+	return cal
+}
+
+// CalendarSettings are the settings used for all date math, such as which
+// entries belong to a day.
+type CalendarSettings struct {
+	// TimeZone is the IANA name of the time zone that dates and times are
+	// shown in, or empty to use the system's time zone.
+	TimeZone     string         `json:"timeZone"`
+	WeekStart    config.Weekday `json:"weekStart"`
+	DayStartHour int            `json:"dayStartHour"`
+}
+
+// GetCalendarSettings returns the time zone, first day of the week, and
+// hour when days start, as used for date math.
+func (a *App) GetCalendarSettings() CalendarSettings {
+	settings := CalendarSettings{
+		WeekStart:    config.Weekday(a.calendar.WeekStart()),
+		DayStartHour: a.calendar.DayStartHour(),
+	}
+	if loc := a.calendar.Location(); loc != time.Local {
+		settings.TimeZone = loc.String()
+	}
+	return settings
+}
+
+// daySpan returns the start of the day that contains "first" and the end of
+// the day that contains "last".
+func (a *App) daySpan(first, last time.Time) (time.Time, time.Time) {
+	start, _ := a.calendar.Day(first)
+	_, end := a.calendar.Day(last)
+	return start, end
+}
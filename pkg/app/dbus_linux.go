@@ -0,0 +1,177 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+)
+
+const (
+	dbusServiceName      = "org.dinkur.Desktop"
+	dbusServicePath      = "/org/dinkur/Desktop"
+	dbusServiceInterface = "org.dinkur.Desktop"
+)
+
+// Entries are sent over D-Bus as dictionaries (a{sv}), with the keys "id",
+// "name", "start", "end", "tags", "client", and "project". Times are in
+// seconds since the Unix epoch, and "end" is left out for active entries. An
+// empty dictionary means there is no entry.
+const dbusServiceIntrospection = `
+<node>
+	<interface name="` + dbusServiceInterface + `">
+		<method name="Start">
+			<arg name="name" direction="in" type="s"/>
+			<arg name="entry" direction="out" type="a{sv}"/>
+		</method>
+		<method name="Stop">
+			<arg name="entry" direction="out" type="a{sv}"/>
+		</method>
+		<method name="GetActive">
+			<arg name="entry" direction="out" type="a{sv}"/>
+		</method>
+		<signal name="EntryStarted">
+			<arg name="entry" type="a{sv}"/>
+		</signal>
+		<signal name="EntryStopped">
+			<arg name="entry" type="a{sv}"/>
+		</signal>
+	</interface>` + introspect.IntrospectDataString + `</node>`
+
+// DBusService exports the org.dinkur.Desktop D-Bus service on the session
+// bus, which lets other programs, such as desktop widgets, control tracking.
+type DBusService struct {
+	app  *App
+	conn *dbus.Conn
+}
+
+// NewDBusService connects to the session bus and exports the
+// org.dinkur.Desktop service. Call [DBusService.Stop] to release the
+// connection.
+func NewDBusService(a *App) (*DBusService, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("connect to session bus: %w", err)
+	}
+	s, err := newDBusService(a, conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func newDBusService(a *App, conn *dbus.Conn) (*DBusService, error) {
+	s := &DBusService{app: a, conn: conn}
+	methods := map[string]any{
+		"Start":     s.start,
+		"Stop":      s.stop,
+		"GetActive": s.getActive,
+	}
+	if err := conn.ExportMethodTable(methods, dbusServicePath, dbusServiceInterface); err != nil {
+		return nil, fmt.Errorf("export methods: %w", err)
+	}
+	if err := conn.Export(introspect.Introspectable(dbusServiceIntrospection),
+		dbusServicePath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		return nil, fmt.Errorf("export introspection: %w", err)
+	}
+	reply, err := conn.RequestName(dbusServiceName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		return nil, fmt.Errorf("request name %s: %w", dbusServiceName, err)
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		return nil, fmt.Errorf("request name %s: already taken", dbusServiceName)
+	}
+	return s, nil
+}
+
+// Stop releases the name and closes the connection to the session bus.
+func (s *DBusService) Stop() error {
+	s.conn.ReleaseName(dbusServiceName)
+	return s.conn.Close()
+}
+
+func (s *DBusService) start(name string) (map[string]dbus.Variant, *dbus.Error) {
+	entry, err := s.app.StartEntry(name)
+	if err != nil {
+		return nil, dbus.MakeFailedError(err)
+	}
+	return dbusEntry(entry), nil
+}
+
+func (s *DBusService) stop() (map[string]dbus.Variant, *dbus.Error) {
+	entry, err := s.app.StopActiveEntry()
+	if err != nil {
+		return nil, dbus.MakeFailedError(err)
+	}
+	return dbusEntry(entry), nil
+}
+
+func (s *DBusService) getActive() (map[string]dbus.Variant, *dbus.Error) {
+	entry, err := s.app.GetActiveEntry()
+	if err != nil {
+		return nil, dbus.MakeFailedError(err)
+	}
+	return dbusEntry(entry), nil
+}
+
+// EntryStarted emits the EntryStarted signal.
+func (s *DBusService) EntryStarted(entry Entry, stopped *Entry) {
+	s.emit("EntryStarted", entry)
+}
+
+// EntryStopped emits the EntryStopped signal.
+func (s *DBusService) EntryStopped(entry Entry) {
+	s.emit("EntryStopped", entry)
+}
+
+func (s *DBusService) emit(signal string, entry Entry) {
+	if err := s.conn.Emit(dbusServicePath, dbusServiceInterface+"."+signal, dbusEntry(&entry)); err != nil {
+		log.Debug().WithError(err).WithString("signal", signal).Message("Failed to emit D-Bus signal.")
+	}
+}
+
+func dbusEntry(entry *Entry) map[string]dbus.Variant {
+	if entry == nil {
+		return map[string]dbus.Variant{}
+	}
+	tags := entry.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	m := map[string]dbus.Variant{
+		"id":      dbus.MakeVariant(uint32(entry.ID)),
+		"name":    dbus.MakeVariant(entry.Name),
+		"start":   dbus.MakeVariant(entry.Start.Unix()),
+		"tags":    dbus.MakeVariant(tags),
+		"client":  dbus.MakeVariant(entry.Client),
+		"project": dbus.MakeVariant(entry.Project),
+	}
+	if entry.End != nil {
+		m["end"] = dbus.MakeVariant(entry.End.Unix())
+	}
+	return m
+}
+
+func (a *App) startDBusService() {
+	if !a.cfg.DBus.Enabled {
+		return
+	}
+	s, err := NewDBusService(a)
+	if err != nil {
+		log.Warn().WithError(err).Message("Failed to export D-Bus service.")
+		return
+	}
+	a.dbusService = s
+	a.entryWatcher.AddListener(s)
+	log.Info().WithString("name", dbusServiceName).Message("Exported D-Bus service.")
+}
+
+func (a *App) stopDBusService() {
+	if a.dbusService == nil {
+		return
+	}
+	if err := a.dbusService.Stop(); err != nil {
+		log.Warn().WithError(err).Message("Failed to close D-Bus service connection.")
+	}
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// startTestBus starts a private session bus, and returns its address.
+func startTestBus(t *testing.T) string {
+	t.Helper()
+	path, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not found")
+	}
+	cmd := exec.Command(path, "--session", "--nofork", "--nopidfile", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("start dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("read bus address: %v", err)
+	}
+	return strings.TrimSpace(address)
+}
+
+func connectTestBus(t *testing.T, address string) *dbus.Conn {
+	t.Helper()
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatalf("connect to bus: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestDBusService(t *testing.T) {
+	address := startTestBus(t)
+	// Stopping uses the app's clock, and starting uses the system clock.
+	a, _ := newTestApp(t, time.Now().Add(time.Hour))
+	s, err := newDBusService(a, connectTestBus(t, address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+	a.entryWatcher.AddListener(s)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go a.entryWatcher.Run(ctx)
+	// Wait for the watcher to start streaming.
+	time.Sleep(100 * time.Millisecond)
+
+	client := connectTestBus(t, address)
+	if err := client.AddMatchSignal(
+		dbus.WithMatchObjectPath(dbusServicePath),
+		dbus.WithMatchInterface(dbusServiceInterface),
+	); err != nil {
+		t.Fatal(err)
+	}
+	signals := make(chan *dbus.Signal, 10)
+	client.Signal(signals)
+	obj := client.Object(dbusServiceName, dbusServicePath)
+	call := func(method string, args ...any) map[string]dbus.Variant {
+		t.Helper()
+		var entry map[string]dbus.Variant
+		if err := obj.Call(dbusServiceInterface+"."+method, 0, args...).Store(&entry); err != nil {
+			t.Fatalf("call %s: %v", method, err)
+		}
+		return entry
+	}
+	nextSignal := func() (string, map[string]dbus.Variant) {
+		t.Helper()
+		for {
+			select {
+			case sig := <-signals:
+				if !strings.HasPrefix(sig.Name, dbusServiceInterface+".") {
+					continue
+				}
+				entry, _ := sig.Body[0].(map[string]dbus.Variant)
+				return strings.TrimPrefix(sig.Name, dbusServiceInterface+"."), entry
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for signal")
+				return "", nil
+			}
+		}
+	}
+
+	if entry := call("GetActive"); len(entry) != 0 {
+		t.Errorf("want no active entry, got %v", entry)
+	}
+
+	started := call("Start", "Coding #work")
+	if name := started["name"].Value(); name != "Coding #work" {
+		t.Errorf("want started entry name %q, got %v", "Coding #work", name)
+	}
+	if tags, _ := started["tags"].Value().([]string); len(tags) != 1 || tags[0] != "work" {
+		t.Errorf("want tags [work], got %v", started["tags"].Value())
+	}
+	if _, ok := started["end"]; ok {
+		t.Error("want no end time for started entry")
+	}
+	if signal, entry := nextSignal(); signal != "EntryStarted" || entry["id"].Value() != started["id"].Value() {
+		t.Errorf("want EntryStarted for entry %v, got %s for %v", started["id"].Value(), signal, entry["id"].Value())
+	}
+
+	if active := call("GetActive"); active["id"].Value() != started["id"].Value() {
+		t.Errorf("want active entry %v, got %v", started["id"].Value(), active["id"].Value())
+	}
+
+	stopped := call("Stop")
+	if _, ok := stopped["end"]; !ok {
+		t.Error("want end time for stopped entry")
+	}
+	if signal, entry := nextSignal(); signal != "EntryStopped" || entry["id"].Value() != started["id"].Value() {
+		t.Errorf("want EntryStopped for entry %v, got %s for %v", started["id"].Value(), signal, entry["id"].Value())
+	}
+	if entry := call("GetActive"); len(entry) != 0 {
+		t.Errorf("want no active entry after stopping, got %v", entry)
+	}
+}
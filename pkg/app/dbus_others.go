@@ -0,0 +1,11 @@
+//go:build !linux
+
+package app
+
+// DBusService is a placeholder for the org.dinkur.Desktop D-Bus service,
+// which is only available on GNU/Linux.
+type DBusService struct{}
+
+func (a *App) startDBusService() {}
+
+func (a *App) stopDBusService() {}
@@ -0,0 +1,288 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+	"github.com/dinkur/dinkur-desktop/pkg/entryname"
+	"github.com/dinkur/dinkur-desktop/pkg/issues"
+	"github.com/dinkur/dinkur-desktop/pkg/rules"
+	"github.com/dinkur/dinkur-desktop/pkg/worklog"
+	"github.com/dinkur/dinkur/pkg/dinkur"
+)
+
+// EntrySearchLimit is the maximum number of entries fetched when listing
+// entries over longer time spans, such as for summaries or when reapplying
+// categorization rules.
+const EntrySearchLimit = 10000
+
+// Entry is a Dinkur entry together with the metadata parsed from its name
+// and the result of the categorization rules.
+type Entry struct {
+	dinkur.Entry
+	entryname.Parsed
+	// Project is the project set by the categorization rules, or the entry's
+	// client if no rule sets a project.
+	Project  string `json:"project"`
+	Billable bool   `json:"billable"`
+	// Rules are the categorization rules that matched this entry.
+	Rules []rules.Match `json:"rules"`
+	// Issues are the cached issues of the entry's ticket keys. Keys that
+	// are not yet cached are looked up in the background, followed by an
+	// "issues:changed" event.
+	Issues []issues.Issue `json:"issues"`
+}
+
+func newEntryNameParser(opts entryname.Options) *entryname.Parser {
+	parser, err := entryname.New(opts)
+	if err != nil {
+		log.Warn().WithError(err).Message("Invalid tags config. Ignoring ticket patterns.")
+		opts.TicketPatterns = nil
+		parser, _ = entryname.New(opts)
+	}
+	return parser
+}
+
+func newRulesEngine(cfg config.Categorization) *rules.Engine {
+	engine, err := rules.New(cfg)
+	if err != nil {
+		log.Warn().WithError(err).Message("Invalid categorization rules. Ignoring all rules.")
+		cfg.Rules = nil
+		engine, _ = rules.New(cfg)
+	}
+	return engine
+}
+
+func (a *App) toEntry(entry dinkur.Entry) Entry {
+	// Times are shown in the calendar's time zone.
+	entry.Start = entry.Start.In(a.calendar.Location())
+	if entry.End != nil {
+		end := entry.End.In(a.calendar.Location())
+		entry.End = &end
+	}
+	e := ParseEntry(a.nameParser, a.rules, entry)
+	e.Issues = a.issues.Lookup(e.Tickets)
+	return e
+}
+
+// ParseEntry parses the tags, client, and ticket keys from the entry name,
+// and categorizes the entry using the rules engine.
+func ParseEntry(parser *entryname.Parser, engine *rules.Engine, entry dinkur.Entry) Entry {
+	parsed := parser.Parse(entry.Name)
+	result := engine.Evaluate(entry.Name)
+	for _, tag := range result.Tags {
+		if !containsFold(parsed.Tags, tag) {
+			parsed.Tags = append(parsed.Tags, tag)
+		}
+	}
+	project := result.Project
+	if project == "" {
+		project = parsed.Client
+	}
+	return Entry{
+		Entry:    entry,
+		Parsed:   parsed,
+		Project:  project,
+		Billable: result.Billable,
+		Rules:    result.Matches,
+	}
+}
+
+func (a *App) toEntryPtr(entry *dinkur.Entry) *Entry {
+	if entry == nil {
+		return nil
+	}
+	e := a.toEntry(*entry)
+	return &e
+}
+
+func (a *App) toEntries(entries []dinkur.Entry) []Entry {
+	result := make([]Entry, len(entries))
+	for i, entry := range entries {
+		result[i] = a.toEntry(entry)
+	}
+	return result
+}
+
+// StartEntry starts a new entry, stopping the currently active entry if any.
+func (a *App) StartEntry(name string) (*Entry, error) {
+	now := a.clock.Now()
+	started, err := a.dinkur.CreateEntry(a.ctx, dinkur.NewEntry{Name: name, Start: &now})
+	if err != nil {
+		return nil, err
+	}
+	entry := a.toEntry(started.Started)
+	log.Info().
+		WithUint("id", entry.ID).
+		WithString("name", entry.Name).
+		WithString("project", entry.Project).
+		WithInt("rules", len(entry.Rules)).
+		Message("Started entry.")
+	return &entry, nil
+}
+
+// StopActiveEntry stops the currently active entry, if any.
+func (a *App) StopActiveEntry() (*Entry, error) {
+	stopped, err := a.dinkur.StopActiveEntry(a.ctx, a.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	return a.toEntryPtr(stopped), nil
+}
+
+// PreviewRules returns the entry with the given ID, including which
+// categorization rules matched it.
+func (a *App) PreviewRules(id uint) (Entry, error) {
+	entry, err := a.dinkur.GetEntry(a.ctx, id)
+	if err != nil {
+		return Entry{}, err
+	}
+	return a.toEntry(entry), nil
+}
+
+// ReapplyRules adds the tags and projects from the categorization rules to
+// the names of all entries within the given time span. If dryRun is true,
+// then the changes are only returned and not saved.
+func (a *App) ReapplyRules(start, end time.Time, dryRun bool) ([]rules.Change, error) {
+	var changes []rules.Change
+	err := a.mutate("Reapply categorization rules", func(ctx context.Context) error {
+		entries, err := a.dinkur.GetEntryList(ctx, dinkur.SearchEntry{
+			Limit: EntrySearchLimit,
+			Start: &start,
+			End:   &end,
+		})
+		if err != nil {
+			return fmt.Errorf("list entries: %w", err)
+		}
+		changes, err = a.rules.Reapply(ctx, a.dinkur, a.nameParser, entries, dryRun)
+		return err
+	})
+	return changes, err
+}
+
+// createPastEntry creates an entry that has already ended. The Dinkur
+// client always stops the active entry when creating an entry, and cannot
+// unset an entry's end time, so the active entry, if any, is deleted and
+// then recreated afterwards, which gives it a new ID. The entry watcher's
+// listeners are not told about this, and the new ID is passed to
+// [App.entryRecreated]. If the active entry cannot be created again, then it
+// is kept for [App.RestoreActiveEntry].
+func (a *App) createPastEntry(ctx context.Context, name string, start, end time.Time) (dinkur.Entry, error) {
+	active, err := a.dinkur.GetActiveEntry(ctx)
+	if err != nil {
+		return dinkur.Entry{}, fmt.Errorf("get active entry: %w", err)
+	}
+	if active == nil {
+		created, err := a.dinkur.CreateEntry(ctx, dinkur.NewEntry{Name: name, Start: &start, End: &end})
+		if err != nil {
+			return dinkur.Entry{}, err
+		}
+		return created.Started, nil
+	}
+	a.entryWatcher.Recreating(*active)
+	if _, err := a.dinkur.DeleteEntry(ctx, active.ID); err != nil {
+		a.entryWatcher.RecreateFailed(*active)
+		return dinkur.Entry{}, fmt.Errorf("set aside active entry: %w", err)
+	}
+	created, err := a.dinkur.CreateEntry(ctx, dinkur.NewEntry{Name: name, Start: &start, End: &end})
+	restored, restoreErr := recreateActive(ctx, a.dinkur, *active)
+	if restoreErr != nil {
+		a.entryWatcher.RecreateFailed(*active)
+		a.keepUnrestored(*active, restoreErr)
+		return dinkur.Entry{}, errors.Join(err, fmt.Errorf("restore active entry %q, kept to be restored later: %w", active.Name, restoreErr))
+	}
+	a.entryRecreated(*active, restored)
+	if err != nil {
+		return dinkur.Entry{}, err
+	}
+	return created.Started, nil
+}
+
+// entryRecreated updates everything that refers to entries by ID after an
+// entry has been deleted and created again with a new ID, so that the new
+// entry is still treated as the same entry.
+func (a *App) entryRecreated(old, recreated dinkur.Entry) {
+	log.Debug().
+		WithUint("old", old.ID).
+		WithUint("new", recreated.ID).
+		Message("Entry recreated with new ID.")
+	a.pomodoro.Remap(old.ID, recreated.ID)
+	if a.longRunning != nil {
+		a.longRunning.Remap(old.ID, recreated.ID)
+	}
+	if err := a.autoActions.Remap(old, recreated); err != nil {
+		log.Warn().WithError(err).Message("Failed to update automatic actions of recreated entry.")
+	}
+	syncer, err := a.worklogSyncer()
+	if err == nil {
+		err = syncer.Remap(old.ID, recreated.ID)
+	}
+	if err != nil && !errors.Is(err, worklog.ErrNotConfigured) {
+		log.Warn().WithError(err).Message("Failed to update pushed worklog of recreated entry.")
+	}
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// listOverlapping returns the entries that overlap with the time span,
+// including the entries that enclose it. Dinkur only lists the entries that
+// start or end within the span, so the search is widened back to the start
+// of the previous day and the overlaps are found here. The active entry is
+// always included if it overlaps, as it may have started before that.
+func (a *App) listOverlapping(ctx context.Context, start, end time.Time) ([]dinkur.Entry, error) {
+	searchStart, _ := a.calendar.Day(start)
+	searchStart = a.calendar.AddDays(searchStart, -1)
+	entries, err := a.dinkur.GetEntryList(ctx, dinkur.SearchEntry{
+		Limit: EntrySearchLimit,
+		Start: &searchStart,
+		End:   &end,
+	})
+	if err != nil {
+		return nil, err
+	}
+	active, err := a.dinkur.GetActiveEntry(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get active entry: %w", err)
+	}
+	if active != nil {
+		entries = append(entries, *active)
+	}
+	now := a.clock.Now()
+	seen := map[uint]bool{}
+	var overlapping []dinkur.Entry
+	for _, entry := range entries {
+		if seen[entry.ID] {
+			continue
+		}
+		seen[entry.ID] = true
+		entryEnd := now
+		if entry.End != nil {
+			entryEnd = *entry.End
+		}
+		if entry.Start.Before(end) && entryEnd.After(start) {
+			overlapping = append(overlapping, entry)
+		}
+	}
+	return overlapping, nil
+}
+
+// listEntries returns all entries that overlap with the given time span,
+// parsed the same way as [App.listOverlapping].
+func (a *App) listEntries(ctx context.Context, start, end time.Time) ([]Entry, error) {
+	entries, err := a.listOverlapping(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return a.toEntries(entries), nil
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/internal/notify"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+	"github.com/dinkur/dinkur/pkg/dinkur"
+)
+
+func TestCreatePastEntryRemapsActiveEntry(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	a, clk := newTestApp(t, now)
+	ctx := context.Background()
+	a.pomodoro = newTestPomodoro(t, a)
+	state, err := a.pomodoro.Start(ctx, "Write report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.pomodoro.cancel()
+	a.longRunning = NewLongRunningWatcher(a.dinkur, notify.Discard, clk, config.LongRunning{}, time.UTC)
+	a.longRunning.Ignore(state.EntryID)
+
+	if _, err := a.createPastEntry(ctx, "Standup", now.Add(-3*time.Hour), now.Add(-2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	active, err := a.dinkur.GetActiveEntry(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active == nil || active.ID == state.EntryID {
+		t.Fatalf("want active entry recreated with a new ID, got %+v", active)
+	}
+	if got := a.pomodoro.State(); got == nil || got.EntryID != active.ID {
+		t.Errorf("want pomodoro entry ID %d, got %+v", active.ID, got)
+	}
+	if !a.longRunning.ignored[active.ID] || a.longRunning.ignored[state.EntryID] {
+		t.Errorf("want recreated entry %d to stay ignored, got %v", active.ID, a.longRunning.ignored)
+	}
+
+	clk.Advance(10 * time.Minute)
+	if err := a.pomodoro.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	stopped, err := a.dinkur.GetEntry(ctx, active.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stopped.End == nil || !stopped.End.Equal(clk.Now()) {
+		t.Errorf("want pomodoro to stop the recreated entry at %v, got %v", clk.Now(), stopped.End)
+	}
+}
+
+// failingRestoreClient fails to create active entries a number of times.
+type failingRestoreClient struct {
+	dinkur.Client
+	failures int
+}
+
+func (c *failingRestoreClient) CreateEntry(ctx context.Context, entry dinkur.NewEntry) (dinkur.StartedEntry, error) {
+	if entry.End == nil && c.failures > 0 {
+		c.failures--
+		return dinkur.StartedEntry{}, errors.New("database is locked")
+	}
+	return c.Client.CreateEntry(ctx, entry)
+}
+
+func TestCreatePastEntryRetriesRestore(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	a, _ := newTestApp(t, now)
+	ctx := context.Background()
+	active := createTestEntry(t, a, "Coding #backend", now.Add(-time.Hour), nil)
+	a.dinkur = &failingRestoreClient{Client: a.dinkur, failures: restoreAttempts - 1}
+
+	if _, err := a.createPastEntry(ctx, "Standup", now.Add(-3*time.Hour), now.Add(-2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := a.dinkur.GetActiveEntry(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored == nil || restored.Name != active.Name || !restored.Start.Equal(active.Start) {
+		t.Errorf("want %q restored from %v, got %+v", active.Name, active.Start, restored)
+	}
+}
+
+func TestCreatePastEntryKeepsUnrestoredEntry(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	a, _ := newTestApp(t, now)
+	notifier := &notify.Fake{}
+	a.notifier = notifier
+	ctx := context.Background()
+	active := createTestEntry(t, a, "Coding #backend", now.Add(-time.Hour), nil)
+	client := &failingRestoreClient{Client: a.dinkur, failures: restoreAttempts}
+	a.dinkur = client
+
+	if _, err := a.createPastEntry(ctx, "Standup", now.Add(-3*time.Hour), now.Add(-2*time.Hour)); err == nil {
+		t.Fatal("want error when the active entry cannot be restored")
+	}
+	unrestored, err := a.GetUnrestoredEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unrestored == nil || unrestored.Name != active.Name || !unrestored.Start.Equal(active.Start) {
+		t.Fatalf("want %q kept to be restored, got %+v", active.Name, unrestored)
+	}
+	if len(notifier.Sent) != 1 {
+		t.Fatalf("want the user notified once, got %+v", notifier.Sent)
+	}
+
+	// Retry from the notification, now that the database works again.
+	if !notifier.Invoke(1, unrestoredActionRetry) {
+		t.Fatal("want notification with retry action")
+	}
+	restored, err := a.dinkur.GetActiveEntry(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored == nil || restored.Name != active.Name || !restored.Start.Equal(active.Start) {
+		t.Errorf("want %q restored from %v, got %+v", active.Name, active.Start, restored)
+	}
+	if unrestored, err := a.GetUnrestoredEntry(); err != nil || unrestored != nil {
+		t.Errorf("want restored entry forgotten, got %+v, %v", unrestored, err)
+	}
+	if _, err := a.RestoreActiveEntry(); !errors.Is(err, ErrNothingToRestore) {
+		t.Errorf("want %v, got %v", ErrNothingToRestore, err)
+	}
+}
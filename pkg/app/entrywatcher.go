@@ -0,0 +1,151 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/dinkur/dinkur/pkg/dinkur"
+)
+
+// EntryListener is notified when entries are started and stopped, no
+// matter if that's done from the UI, the API, or automatically.
+//
+// Listeners are called one at a time, in the order the changes were made,
+// and must not block.
+type EntryListener interface {
+	// EntryStarted is called when an entry is started. If starting the entry
+	// stopped another entry, then that entry is passed as stopped, and
+	// EntryStopped has already been called for it.
+	EntryStarted(entry Entry, stopped *Entry)
+	// EntryStopped is called when the active entry is stopped or deleted.
+	EntryStopped(entry Entry)
+}
+
+// EntryWatcher streams changes to entries from Dinkur, and notifies its
+// listeners when entries are started and stopped.
+type EntryWatcher struct {
+	entries dinkur.Entries
+	toEntry func(dinkur.Entry) Entry
+
+	mu          sync.Mutex
+	listeners   []EntryListener
+	active      *dinkur.Entry
+	lastStopped *dinkur.Entry
+	// recreating are the active entries that are deleted to be created
+	// again, by ID.
+	recreating map[uint]dinkur.Entry
+	// setAside is the active entry that has been deleted to be created
+	// again, and which listeners have not been told about.
+	setAside *dinkur.Entry
+}
+
+// NewEntryWatcher creates a new entry watcher. The toEntry function is used
+// to parse the entries before passing them to listeners.
+func NewEntryWatcher(entries dinkur.Entries, toEntry func(dinkur.Entry) Entry) *EntryWatcher {
+	return &EntryWatcher{
+		entries:    entries,
+		toEntry:    toEntry,
+		recreating: map[uint]dinkur.Entry{},
+	}
+}
+
+// Recreating tells the watcher that the active entry is about to be deleted
+// and created again with a new ID, so that listeners are not told that it
+// stopped and started again.
+func (w *EntryWatcher) Recreating(entry dinkur.Entry) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.recreating[entry.ID] = entry
+}
+
+// RecreateFailed tells the watcher that the entry passed to
+// [EntryWatcher.Recreating] was deleted but could not be created again, so
+// that listeners are told that it stopped.
+func (w *EntryWatcher) RecreateFailed(entry dinkur.Entry) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.recreating, entry.ID)
+	if w.setAside != nil && w.setAside.ID == entry.ID {
+		w.notifyStopped(*w.setAside)
+		w.setAside = nil
+	}
+}
+
+// AddListener adds a listener that is notified of started and stopped
+// entries.
+func (w *EntryWatcher) AddListener(listener EntryListener) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.listeners = append(w.listeners, listener)
+}
+
+// Run watches for changes to entries until the context is cancelled. Must be
+// called after the Dinkur client has connected.
+func (w *EntryWatcher) Run(ctx context.Context) error {
+	active, err := w.entries.GetActiveEntry(ctx)
+	if err != nil {
+		return fmt.Errorf("get active entry: %w", err)
+	}
+	w.mu.Lock()
+	w.active = active
+	w.mu.Unlock()
+	ch, err := w.entries.StreamEntry(ctx)
+	if err != nil {
+		return fmt.Errorf("stream entries: %w", err)
+	}
+	for ev := range ch {
+		w.handle(ev)
+	}
+	return nil
+}
+
+func (w *EntryWatcher) handle(ev dinkur.StreamedEntry) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	entry := ev.Entry
+	isActive := w.active != nil && w.active.ID == entry.ID
+	_, recreating := w.recreating[entry.ID]
+	switch {
+	case ev.Event == dinkur.EventDeleted && isActive && recreating:
+		delete(w.recreating, entry.ID)
+		w.active = nil
+		w.setAside = &entry
+	case ev.Event == dinkur.EventDeleted && isActive,
+		ev.Event == dinkur.EventUpdated && isActive && entry.End != nil:
+		w.active = nil
+		w.lastStopped = &entry
+		w.notifyStopped(entry)
+	case ev.Event != dinkur.EventDeleted && !isActive && entry.End == nil &&
+		w.setAside != nil && w.setAside.Name == entry.Name && w.setAside.Start.Equal(entry.Start):
+		// The set aside entry was created again.
+		w.active = &entry
+		w.setAside = nil
+	case ev.Event != dinkur.EventDeleted && !isActive && entry.End == nil:
+		if w.setAside != nil {
+			w.notifyStopped(*w.setAside)
+			w.setAside = nil
+		}
+		var stopped *Entry
+		// Starting an entry stops the previous one at the same time.
+		if w.lastStopped != nil && w.lastStopped.End.Equal(entry.Start) {
+			e := w.toEntry(*w.lastStopped)
+			stopped = &e
+		}
+		w.active = &entry
+		w.lastStopped = nil
+		started := w.toEntry(entry)
+		for _, l := range w.listeners {
+			l.EntryStarted(started, stopped)
+		}
+	case isActive:
+		w.active = &entry
+	}
+}
+
+func (w *EntryWatcher) notifyStopped(entry dinkur.Entry) {
+	stopped := w.toEntry(entry)
+	for _, l := range w.listeners {
+		l.EntryStopped(stopped)
+	}
+}
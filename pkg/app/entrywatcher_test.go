@@ -0,0 +1,70 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type recordingListener struct {
+	events chan string
+}
+
+func (l recordingListener) EntryStarted(entry Entry, stopped *Entry) {
+	l.events <- fmt.Sprintf("started %s", entry.Name)
+}
+
+func (l recordingListener) EntryStopped(entry Entry) {
+	l.events <- fmt.Sprintf("stopped %s", entry.Name)
+}
+
+func (l recordingListener) next(t *testing.T) string {
+	t.Helper()
+	select {
+	case ev := <-l.events:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for entry event")
+		return ""
+	}
+}
+
+func TestCreatePastEntryKeepsActiveEntryRunning(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	a, _ := newTestApp(t, now)
+	listener := recordingListener{events: make(chan string, 10)}
+	a.entryWatcher.AddListener(listener)
+	ctx, cancel := context.WithCancel(a.ctx)
+	defer cancel()
+	go a.entryWatcher.Run(ctx)
+	// Wait for the watcher to start streaming.
+	time.Sleep(100 * time.Millisecond)
+
+	active := createTestEntry(t, a, "Coding", now.Add(-time.Hour), nil)
+	if ev := listener.next(t); ev != "started Coding" {
+		t.Fatalf("want Coding started, got %q", ev)
+	}
+
+	past, err := a.createPastEntry(a.ctx, "Standup", now.Add(-3*time.Hour), now.Add(-2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if past.End == nil || !past.End.Equal(now.Add(-2*time.Hour)) {
+		t.Errorf("want past entry to end at %v, got %v", now.Add(-2*time.Hour), past.End)
+	}
+	restored, err := a.dinkur.GetActiveEntry(a.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored == nil || restored.Name != active.Name || !restored.Start.Equal(active.Start) {
+		t.Fatalf("want %q to still be active from %v, got %+v", active.Name, active.Start, restored)
+	}
+
+	if _, err := a.dinkur.StopActiveEntry(a.ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	if ev := listener.next(t); ev != "stopped Coding" {
+		t.Errorf("want only Coding stopped after creating past entry, got %q", ev)
+	}
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/analyzer"
+	"github.com/dinkur/dinkur-desktop/pkg/gitsuggest"
+	"github.com/dinkur/dinkur/pkg/dinkur"
+)
+
+// GitSuggestion is an entry proposed from Git commits, fitted into the
+// untracked time of a day.
+type GitSuggestion struct {
+	gitsuggest.Suggestion
+	// GapID is the ID of the gap issue from [App.AnalyzeDay] that the
+	// suggestion lies within, or empty if it lies before the day's first
+	// entry or after its last.
+	GapID string `json:"gapId"`
+}
+
+// GitSuggestions are the gaps between a day's entries, together with
+// entries proposed from the commits made that day.
+type GitSuggestions struct {
+	Gaps        []analyzer.Issue `json:"gaps"`
+	Suggestions []GitSuggestion  `json:"suggestions"`
+	// Errors are the repositories that could not be scanned.
+	Errors []string `json:"errors"`
+}
+
+// GetGitSuggestions scans the configured Git repositories for commits made
+// on the calendar day that contains the given time, and proposes entries for
+// the time that isn't already tracked.
+func (a *App) GetGitSuggestions(day time.Time) (GitSuggestions, error) {
+	result := GitSuggestions{
+		Gaps:        []analyzer.Issue{},
+		Suggestions: []GitSuggestion{},
+		Errors:      []string{},
+	}
+	start, end := a.calendar.Day(day)
+	entries, err := a.dinkur.GetEntryList(a.ctx, dinkur.SearchEntry{
+		Limit: EntrySearchLimit,
+		Start: &start,
+		End:   &end,
+	})
+	if err != nil {
+		return result, err
+	}
+	now := a.clock.Now()
+	for _, issue := range analyzer.Analyze(entries, a.analyzerOptions(now)) {
+		if issue.Kind == analyzer.IssueGap {
+			result.Gaps = append(result.Gaps, issue)
+		}
+	}
+	if len(a.cfg.Git.Repositories) == 0 {
+		return result, nil
+	}
+
+	suggestions, err := gitsuggest.Suggest(a.gitSuggestOptions(), start, end)
+	if err != nil {
+		log.Warn().WithError(err).Message("Failed to scan some Git repositories.")
+		result.Errors = append(result.Errors, err.Error())
+	}
+	tracked := make([]gitsuggest.Span, len(entries))
+	for i, entry := range entries {
+		tracked[i] = gitsuggest.Span{Start: entry.Start, End: now}
+		if entry.End != nil {
+			tracked[i].End = *entry.End
+		}
+	}
+	if now.Before(end) {
+		end = now
+	}
+	loc := a.calendar.Location()
+	for _, s := range gitsuggest.Fit(suggestions, tracked, start, end) {
+		s.Start, s.End = s.Start.In(loc), s.End.In(loc)
+		suggestion := GitSuggestion{Suggestion: s}
+		for _, gap := range result.Gaps {
+			if !s.Start.Before(gap.Start) && !s.End.After(gap.End) {
+				suggestion.GapID = gap.ID
+				break
+			}
+		}
+		result.Suggestions = append(result.Suggestions, suggestion)
+	}
+	return result, nil
+}
+
+// AcceptGitSuggestion adds an entry from a suggestion by
+// [App.GetGitSuggestions]. The suggestions are made again first, so that a
+// suggestion that no longer fits the day's entries is never added.
+func (a *App) AcceptGitSuggestion(day time.Time, id string) (*Entry, error) {
+	suggestions, err := a.GetGitSuggestions(day)
+	if err != nil {
+		return nil, err
+	}
+	var suggestion *GitSuggestion
+	for i := range suggestions.Suggestions {
+		if suggestions.Suggestions[i].ID == id {
+			suggestion = &suggestions.Suggestions[i]
+			break
+		}
+	}
+	if suggestion == nil {
+		return nil, fmt.Errorf("suggestion %q no longer applies, the entries may have changed", id)
+	}
+	var created dinkur.Entry
+	err = a.mutate(fmt.Sprintf("Add %q from Git commits", suggestion.Name), func(ctx context.Context) error {
+		created, err = a.createPastEntry(ctx, suggestion.Name, suggestion.Start, suggestion.End)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		WithString("suggestion", id).
+		WithInt("commits", len(suggestion.Commits)).
+		Message("Added entry from Git commits.")
+	return a.toEntryPtr(&created), nil
+}
+
+func (a *App) gitSuggestOptions() gitsuggest.Options {
+	return gitsuggest.Options{
+		Repositories: a.cfg.Git.Repositories,
+		Authors:      a.cfg.Git.Authors,
+		SessionGap:   a.cfg.Git.SessionGap,
+		LeadTime:     a.cfg.Git.LeadTime,
+		Names:        a.nameParser,
+	}
+}
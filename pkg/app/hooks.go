@@ -0,0 +1,210 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+)
+
+const (
+	defaultHookTimeout = 30 * time.Second
+	// hookQueueSize is how many hook runs can be queued before new ones are
+	// dropped, so that slow hooks never block starting and stopping entries.
+	hookQueueSize = 100
+	// maxHookOutput is how much of a hook's output is logged.
+	maxHookOutput = 4096
+)
+
+// HookEvent is the type of change to entries that triggers a hook.
+type HookEvent string
+
+const (
+	HookEventStart  HookEvent = "start"
+	HookEventStop   HookEvent = "stop"
+	HookEventSwitch HookEvent = "switch"
+)
+
+type hookRun struct {
+	event    HookEvent
+	hooks    []config.Hook
+	entry    Entry
+	previous *Entry
+}
+
+// HookRunner runs the configured commands when entries are started and
+// stopped. Hooks run one at a time in the background, in the order the
+// entries were changed.
+type HookRunner struct {
+	cfg   config.Hooks
+	queue chan hookRun
+}
+
+// NewHookRunner creates a new hook runner. Call [HookRunner.Run] to start
+// running hooks.
+func NewHookRunner(cfg config.Hooks) *HookRunner {
+	return &HookRunner{
+		cfg:   cfg,
+		queue: make(chan hookRun, hookQueueSize),
+	}
+}
+
+// Run runs the queued hooks until the context is cancelled.
+func (r *HookRunner) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case run := <-r.queue:
+			for _, hook := range run.hooks {
+				runHook(ctx, hook, run)
+			}
+		}
+	}
+}
+
+// EntryStarted queues the start hooks, or the switch hooks if another entry
+// was stopped and there are any switch hooks.
+func (r *HookRunner) EntryStarted(entry Entry, stopped *Entry) {
+	if stopped != nil && len(r.cfg.OnSwitch) > 0 {
+		r.enqueue(hookRun{HookEventSwitch, r.cfg.OnSwitch, entry, stopped})
+		return
+	}
+	r.enqueue(hookRun{HookEventStart, r.cfg.OnStart, entry, stopped})
+}
+
+// EntryStopped queues the stop hooks.
+func (r *HookRunner) EntryStopped(entry Entry) {
+	r.enqueue(hookRun{HookEventStop, r.cfg.OnStop, entry, nil})
+}
+
+func (r *HookRunner) enqueue(run hookRun) {
+	if len(run.hooks) == 0 {
+		return
+	}
+	select {
+	case r.queue <- run:
+	default:
+		log.Warn().WithString("event", string(run.event)).
+			WithUint("id", run.entry.ID).
+			Message("Too many hooks queued. Skipping hooks.")
+	}
+}
+
+// hookResult is the outcome of running a hook command.
+type hookResult struct {
+	// Output is the combined stdout and stderr of the command, trimmed and
+	// truncated to [maxHookOutput] bytes.
+	Output   string
+	Duration time.Duration
+	Err      error
+	// TimedOut is true if the command was killed after the hook's timeout.
+	TimedOut bool
+}
+
+func runHook(ctx context.Context, hook config.Hook, run hookRun) {
+	res := execHook(ctx, hook, run)
+	ev := log.Info()
+	if res.Err != nil {
+		ev = log.Warn().WithError(res.Err)
+		if res.TimedOut {
+			ev = ev.WithDuration("timeout", hookTimeout(hook))
+		}
+	}
+	ev = ev.WithString("event", string(run.event)).
+		WithString("command", hook.Command).
+		WithDuration("duration", res.Duration)
+	if res.Output != "" {
+		ev = ev.WithString("output", res.Output)
+	}
+	if res.Err != nil {
+		ev.Message("Hook failed.")
+	} else {
+		ev.Message("Ran hook.")
+	}
+}
+
+func execHook(ctx context.Context, hook config.Hook, run hookRun) hookResult {
+	ctx, cancel := context.WithTimeout(ctx, hookTimeout(hook))
+	defer cancel()
+	cmd := exec.CommandContext(ctx, hook.Command, hook.Args...)
+	cmd.Env = append(os.Environ(), hookEnv(run)...)
+	for key, value := range hook.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	// Don't wait forever on output from any processes started by the hook.
+	cmd.WaitDelay = time.Second
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	start := time.Now()
+	err := cmd.Run()
+	return hookResult{
+		Output:   truncateOutput(strings.TrimSpace(output.String()), maxHookOutput),
+		Duration: time.Since(start),
+		Err:      err,
+		TimedOut: err != nil && ctx.Err() == context.DeadlineExceeded,
+	}
+}
+
+func hookTimeout(hook config.Hook) time.Duration {
+	if hook.Timeout <= 0 {
+		return defaultHookTimeout
+	}
+	return hook.Timeout
+}
+
+// truncateOutput shortens the output to at most max bytes, plus an
+// ellipsis, without splitting a multi-byte character.
+func truncateOutput(out string, max int) string {
+	if len(out) <= max {
+		return out
+	}
+	for max > 0 && !utf8.RuneStart(out[max]) {
+		max--
+	}
+	return out[:max] + "…"
+}
+
+// hookEnv returns the environment variables that describe the entry, and
+// the previous entry when switching entries.
+func hookEnv(run hookRun) []string {
+	env := []string{"DINKUR_EVENT=" + string(run.event)}
+	env = append(env, hookEntryEnv("DINKUR_ENTRY_", run.entry)...)
+	if run.previous != nil {
+		env = append(env, hookEntryEnv("DINKUR_PREVIOUS_", *run.previous)...)
+	}
+	return env
+}
+
+func hookEntryEnv(prefix string, entry Entry) []string {
+	env := []string{
+		prefix + "ID=" + fmt.Sprint(entry.ID),
+		prefix + "NAME=" + entry.Name,
+		prefix + "START=" + entry.Start.Format(time.RFC3339),
+		prefix + "TAGS=" + strings.Join(entry.Tags, ","),
+		prefix + "CLIENT=" + entry.Client,
+		prefix + "PROJECT=" + entry.Project,
+		prefix + "TICKETS=" + strings.Join(entry.Tickets, ","),
+	}
+	if entry.End != nil {
+		env = append(env, prefix+"END="+entry.End.Format(time.RFC3339))
+	}
+	return env
+}
+
+func (a *App) startHookRunner(ctx context.Context) {
+	hooks := a.cfg.Hooks
+	if len(hooks.OnStart) == 0 && len(hooks.OnStop) == 0 && len(hooks.OnSwitch) == 0 {
+		return
+	}
+	r := NewHookRunner(hooks)
+	a.entryWatcher.AddListener(r)
+	go r.Run(ctx)
+}
@@ -0,0 +1,217 @@
+package app
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+)
+
+func testHookEntry(id uint, name string, start time.Time, end *time.Time) Entry {
+	var entry Entry
+	entry.ID = id
+	entry.Name = name
+	entry.Start = start
+	entry.End = end
+	return entry
+}
+
+func envMap(env []string) map[string]string {
+	m := map[string]string{}
+	for _, kv := range env {
+		key, value, _ := strings.Cut(kv, "=")
+		m[key] = value
+	}
+	return m
+}
+
+func TestHookEnv(t *testing.T) {
+	start := time.Date(2023, 3, 1, 9, 0, 0, 0, time.UTC)
+	entry := testHookEntry(2, "Review ABC-1 #review #code @acme", start.Add(time.Hour), nil)
+	entry.Tags = []string{"review", "code"}
+	entry.Client = "acme"
+	entry.Project = "Acme Corp"
+	entry.Tickets = []string{"ABC-1"}
+	previous := testHookEntry(1, "Standup", start, timePtr(start.Add(time.Hour)))
+
+	env := envMap(hookEnv(hookRun{event: HookEventSwitch, entry: entry, previous: &previous}))
+	want := map[string]string{
+		"DINKUR_EVENT":            "switch",
+		"DINKUR_ENTRY_ID":         "2",
+		"DINKUR_ENTRY_NAME":       "Review ABC-1 #review #code @acme",
+		"DINKUR_ENTRY_START":      "2023-03-01T10:00:00Z",
+		"DINKUR_ENTRY_TAGS":       "review,code",
+		"DINKUR_ENTRY_CLIENT":     "acme",
+		"DINKUR_ENTRY_PROJECT":    "Acme Corp",
+		"DINKUR_ENTRY_TICKETS":    "ABC-1",
+		"DINKUR_PREVIOUS_ID":      "1",
+		"DINKUR_PREVIOUS_NAME":    "Standup",
+		"DINKUR_PREVIOUS_START":   "2023-03-01T09:00:00Z",
+		"DINKUR_PREVIOUS_END":     "2023-03-01T10:00:00Z",
+		"DINKUR_PREVIOUS_TAGS":    "",
+		"DINKUR_PREVIOUS_CLIENT":  "",
+		"DINKUR_PREVIOUS_PROJECT": "",
+		"DINKUR_PREVIOUS_TICKETS": "",
+	}
+	for key, value := range want {
+		if got, ok := env[key]; !ok || got != value {
+			t.Errorf("want %s=%q, got %q (set: %t)", key, value, got, ok)
+		}
+	}
+	if len(env) != len(want) {
+		t.Errorf("want %d variables, got %d: %v", len(want), len(env), env)
+	}
+	if _, ok := env["DINKUR_ENTRY_END"]; ok {
+		t.Error("want no end of active entry")
+	}
+
+	env = envMap(hookEnv(hookRun{event: HookEventStop, entry: previous}))
+	if env["DINKUR_EVENT"] != "stop" || env["DINKUR_ENTRY_END"] != "2023-03-01T10:00:00Z" {
+		t.Errorf("want stop event with end, got %v", env)
+	}
+	for key := range env {
+		if strings.HasPrefix(key, "DINKUR_PREVIOUS_") {
+			t.Errorf("want no previous entry without switch, got %s", key)
+		}
+	}
+}
+
+func TestHookRunnerSelectsHooks(t *testing.T) {
+	start := []config.Hook{{Command: "start"}}
+	switchHooks := []config.Hook{{Command: "switch"}}
+	stopped := testHookEntry(1, "Standup", time.Time{}, nil)
+	tests := []struct {
+		name      string
+		cfg       config.Hooks
+		stopped   *Entry
+		wantEvent HookEvent
+		wantHooks []config.Hook
+	}{
+		{"start", config.Hooks{OnStart: start, OnSwitch: switchHooks}, nil, HookEventStart, start},
+		{"switch", config.Hooks{OnStart: start, OnSwitch: switchHooks}, &stopped, HookEventSwitch, switchHooks},
+		{"start when no switch hooks", config.Hooks{OnStart: start}, &stopped, HookEventStart, start},
+		{"nothing without hooks", config.Hooks{OnSwitch: switchHooks}, nil, "", nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewHookRunner(tc.cfg)
+			r.EntryStarted(testHookEntry(2, "Coding", time.Time{}, nil), tc.stopped)
+			select {
+			case run := <-r.queue:
+				if run.event != tc.wantEvent || len(run.hooks) != 1 || run.hooks[0].Command != tc.wantHooks[0].Command {
+					t.Errorf("want %s hooks %v, got %s hooks %v", tc.wantEvent, tc.wantHooks, run.event, run.hooks)
+				}
+				if run.entry.ID != 2 || run.previous != tc.stopped {
+					t.Errorf("want entry 2 after %v, got %d after %v", tc.stopped, run.entry.ID, run.previous)
+				}
+			default:
+				if tc.wantEvent != "" {
+					t.Errorf("want %s hooks queued, got none", tc.wantEvent)
+				}
+			}
+		})
+	}
+}
+
+func TestHookRunnerSkipsWhenQueueIsFull(t *testing.T) {
+	r := NewHookRunner(config.Hooks{OnStop: []config.Hook{{Command: "stop"}}})
+	for i := 0; i < hookQueueSize+10; i++ {
+		r.EntryStopped(testHookEntry(uint(i+1), "Coding", time.Time{}, nil))
+	}
+	if len(r.queue) != hookQueueSize {
+		t.Errorf("want %d queued runs, got %d", hookQueueSize, len(r.queue))
+	}
+}
+
+func lookPathShell(t *testing.T) string {
+	t.Helper()
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+	return sh
+}
+
+func TestExecHook(t *testing.T) {
+	sh := lookPathShell(t)
+	hook := config.Hook{
+		Command: sh,
+		Args:    []string{"-c", `echo "$DINKUR_EVENT $DINKUR_ENTRY_NAME $GREETING"; echo oops >&2`},
+		Env:     map[string]string{"GREETING": "hello"},
+	}
+	run := hookRun{event: HookEventStart, entry: testHookEntry(1, "Coding", time.Now(), nil)}
+	res := execHook(context.Background(), hook, run)
+	if res.Err != nil || res.TimedOut {
+		t.Fatalf("want no error, got %v (timed out: %t)", res.Err, res.TimedOut)
+	}
+	if want := "start Coding hello\noops"; res.Output != want {
+		t.Errorf("want output %q, got %q", want, res.Output)
+	}
+}
+
+func TestExecHookTimeout(t *testing.T) {
+	sh := lookPathShell(t)
+	hook := config.Hook{
+		Command: sh,
+		Args:    []string{"-c", "echo started; exec sleep 30"},
+		Timeout: 100 * time.Millisecond,
+	}
+	res := execHook(context.Background(), hook, hookRun{event: HookEventStart})
+	if res.Err == nil || !res.TimedOut {
+		t.Errorf("want timeout error, got %v (timed out: %t)", res.Err, res.TimedOut)
+	}
+	if res.Duration > 5*time.Second {
+		t.Errorf("want hook killed after timeout, ran for %v", res.Duration)
+	}
+	if res.Output != "started" {
+		t.Errorf("want output before timeout, got %q", res.Output)
+	}
+}
+
+func TestExecHookTruncatesOutput(t *testing.T) {
+	sh := lookPathShell(t)
+	// Each "é" is 2 bytes, and the leading "a" makes the limit fall in the
+	// middle of one.
+	hook := config.Hook{
+		Command: sh,
+		Args:    []string{"-c", `printf a; i=0; while [ $i -lt 3000 ]; do printf 'é'; i=$((i+1)); done`},
+	}
+	res := execHook(context.Background(), hook, hookRun{event: HookEventStart})
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	if !utf8.ValidString(res.Output) || !strings.HasSuffix(res.Output, "é…") {
+		t.Errorf("want valid output ending in an ellipsis, got %q", res.Output[len(res.Output)-10:])
+	}
+	if n := len(strings.TrimSuffix(res.Output, "…")); n != maxHookOutput-1 {
+		t.Errorf("want %d bytes of output, got %d", maxHookOutput-1, n)
+	}
+}
+
+func TestTruncateOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		max  int
+		want string
+	}{
+		{"short", "hello", 10, "hello"},
+		{"exact", "hello", 5, "hello"},
+		{"ascii", "hello world", 5, "hello…"},
+		{"at rune boundary", "héllo", 3, "hé…"},
+		{"inside rune", "héllo", 2, "h…"},
+		{"inside 4 byte rune", "a😀b", 3, "a…"},
+		{"inside first rune", "😀b", 2, "…"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := truncateOutput(tc.out, tc.max); got != tc.want {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
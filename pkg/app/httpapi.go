@@ -0,0 +1,290 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/budget"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+)
+
+// OpenAPI is the OpenAPI document of the local HTTP API.
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
+
+// APIStatus is a summary of what's being tracked right now, meant for
+// status bars.
+type APIStatus struct {
+	// Active is the active entry, or nil if no entry is active.
+	Active *Entry `json:"active"`
+	// Elapsed is how long the active entry has been running.
+	Elapsed time.Duration `json:"elapsed"`
+	// Text is a short human readable summary, such as "Code review (1h12m)".
+	Text     string           `json:"text"`
+	Pomodoro *PomodoroState   `json:"pomodoro"`
+	Goal     *budget.Progress `json:"goal"`
+}
+
+// maxAPIRequestBody is the largest request body accepted by the HTTP API.
+const maxAPIRequestBody = 64 << 10
+
+type apiStartRequest struct {
+	Name string `json:"name"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// apiHandler serves the local HTTP API by calling the same methods as the
+// frontend.
+type apiHandler struct {
+	app   *App
+	token string
+	mux   *http.ServeMux
+}
+
+func newAPIHandler(a *App, token string) *apiHandler {
+	h := &apiHandler{app: a, token: token, mux: http.NewServeMux()}
+	h.mux.HandleFunc("/openapi.yaml", h.openAPI)
+	h.mux.HandleFunc("/v1/status", h.auth(http.MethodGet, h.status))
+	h.mux.HandleFunc("/v1/active", h.auth(http.MethodGet, h.active))
+	h.mux.HandleFunc("/v1/start", h.auth(http.MethodPost, h.start))
+	h.mux.HandleFunc("/v1/stop", h.auth(http.MethodPost, h.stop))
+	h.mux.HandleFunc("/v1/entries", h.auth(http.MethodGet, h.entries))
+	if a.cfg.API.Metrics {
+		h.mux.HandleFunc("/metrics", h.auth(http.MethodGet, h.metrics))
+	}
+	return h
+}
+
+func (h *apiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// auth only lets through requests with the given method and a valid bearer
+// token, and limits the size of their bodies.
+func (h *apiHandler) auth(method string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeAPIError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="dinkur-desktop"`)
+			writeAPIError(w, http.StatusUnauthorized, errors.New("missing or invalid token"))
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxAPIRequestBody)
+		next(w, r)
+	}
+}
+
+func (h *apiHandler) openAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(OpenAPI)
+}
+
+func (h *apiHandler) status(w http.ResponseWriter, r *http.Request) {
+	active, err := h.app.GetActiveEntry()
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	status := APIStatus{
+		Active:   active,
+		Text:     "No active entry",
+		Pomodoro: h.app.GetPomodoroState(),
+	}
+	if active != nil {
+		status.Elapsed = h.app.clock.Now().Sub(active.Start)
+		status.Text = fmt.Sprintf("%s (%s)", active.Name, budget.FormatDuration(status.Elapsed))
+	}
+	if progress, err := h.app.GetBudgetProgress(); err == nil {
+		if goal, ok := budget.MainGoal(progress, h.app.cfg.Budgets.MainGoal); ok {
+			status.Goal = &goal
+		}
+	}
+	writeAPIJSON(w, http.StatusOK, status)
+}
+
+func (h *apiHandler) active(w http.ResponseWriter, r *http.Request) {
+	active, err := h.app.GetActiveEntry()
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if active == nil {
+		writeAPIError(w, http.StatusNotFound, errors.New("no active entry"))
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, active)
+}
+
+func (h *apiHandler) start(w http.ResponseWriter, r *http.Request) {
+	var req apiStartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		status := http.StatusBadRequest
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeAPIError(w, status, fmt.Errorf("decode request body: %w", err))
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		writeAPIError(w, http.StatusBadRequest, errors.New("name must not be empty"))
+		return
+	}
+	entry, err := h.app.StartEntry(req.Name)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusCreated, entry)
+}
+
+func (h *apiHandler) stop(w http.ResponseWriter, r *http.Request) {
+	entry, err := h.app.StopActiveEntry()
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if entry == nil {
+		writeAPIError(w, http.StatusNotFound, errors.New("no active entry"))
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, entry)
+}
+
+func (h *apiHandler) entries(w http.ResponseWriter, r *http.Request) {
+	day := h.app.clock.Now()
+	if value := r.URL.Query().Get("day"); value != "" {
+		date, err := h.app.calendar.ParseDate(value)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid day %q, must be in the format YYYY-MM-DD", value))
+			return
+		}
+		day = h.app.calendar.StartOfDate(date)
+	}
+	entries, err := h.app.GetEntriesForDay(day)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, entries)
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Debug().WithError(err).Message("Failed to write API response.")
+	}
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeAPIJSON(w, status, apiError{Error: err.Error()})
+}
+
+// loadAPIToken reads the API token from the file, or creates the file with
+// a new random token if it doesn't exist.
+func loadAPIToken(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err == nil {
+		if token := strings.TrimSpace(string(b)); token != "" {
+			return token, nil
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(random)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(token+"\n"), 0o600); err != nil {
+		return "", err
+	}
+	log.Info().WithString("path", path).Message("Created new API token.")
+	return token, nil
+}
+
+func listenAPI(cfg config.API) (net.Listener, error) {
+	if cfg.Socket == "" {
+		return net.Listen("tcp", cfg.Address)
+	}
+	// Remove any socket left behind from a crash, but never any other file.
+	info, err := os.Lstat(cfg.Socket)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, err
+	case info.Mode()&fs.ModeSocket == 0:
+		return nil, fmt.Errorf("%s already exists and is not a socket", cfg.Socket)
+	default:
+		if err := os.Remove(cfg.Socket); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	listener, err := net.Listen("unix", cfg.Socket)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(cfg.Socket, 0o600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+func (a *App) startHTTPAPI(ctx context.Context) {
+	if !a.cfg.API.Enabled {
+		return
+	}
+	token, err := loadAPIToken(config.APITokenPath)
+	if err != nil {
+		log.Error().WithError(err).Message("Failed to load API token. Not starting HTTP API.")
+		return
+	}
+	listener, err := listenAPI(a.cfg.API)
+	if err != nil {
+		log.Error().WithError(err).Message("Failed to listen for HTTP API.")
+		return
+	}
+	server := &http.Server{
+		Handler:           newAPIHandler(a, token),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		log.Info().WithString("address", listener.Addr().String()).Message("Serving HTTP API.")
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().WithError(err).Message("HTTP API stopped.")
+		}
+	}()
+}
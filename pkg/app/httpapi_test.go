@@ -0,0 +1,313 @@
+package app
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+)
+
+// shortTempDir returns a temporary directory with a path short enough for
+// Unix sockets.
+func shortTempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "dinkur")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestListenAPIReplacesStaleSocket(t *testing.T) {
+	path := filepath.Join(shortTempDir(t), "api.sock")
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix sockets not supported: %v", err)
+	}
+	// Leave the socket file behind, as after a crash.
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := listenAPI(config.API{Socket: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("dial new socket: %v", err)
+	}
+	conn.Close()
+}
+
+func TestListenAPIKeepsOtherFiles(t *testing.T) {
+	path := filepath.Join(shortTempDir(t), "api.sock")
+	if err := os.WriteFile(path, []byte("important"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if listener, err := listenAPI(config.API{Socket: path}); err == nil {
+		listener.Close()
+		t.Fatal("want error when path is a regular file")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil || string(b) != "important" {
+		t.Errorf("want file left untouched, got %q, %v", b, err)
+	}
+}
+
+const testAPIToken = "secret"
+
+// serveAPI sends a request to the API handler, with the token if not empty,
+// and decodes the JSON response into v if not nil.
+func serveAPI(t *testing.T, h http.Handler, method, target, token, body string, v any) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if v != nil {
+		if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+			t.Fatalf("%s %s: decode response: %v", method, target, err)
+		}
+	}
+	return rec
+}
+
+func TestAPIAuth(t *testing.T) {
+	a, _ := newTestApp(t, time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC))
+	h := newAPIHandler(a, testAPIToken)
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"missing token", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer wrong", http.StatusUnauthorized},
+		{"token prefix", "Bearer " + testAPIToken[:3], http.StatusUnauthorized},
+		{"wrong scheme", "Basic " + testAPIToken, http.StatusUnauthorized},
+		{"valid token", "Bearer " + testAPIToken, http.StatusOK},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/status", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tc.want {
+				t.Fatalf("want status %d, got %d: %s", tc.want, rec.Code, rec.Body)
+			}
+			if tc.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("want WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestAPIEndpointsNeedToken(t *testing.T) {
+	a, _ := newTestApp(t, time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC))
+	a.cfg.API.Metrics = true
+	h := newAPIHandler(a, testAPIToken)
+	tests := []struct {
+		method string
+		target string
+	}{
+		{http.MethodGet, "/v1/status"},
+		{http.MethodGet, "/v1/active"},
+		{http.MethodPost, "/v1/start"},
+		{http.MethodPost, "/v1/stop"},
+		{http.MethodGet, "/v1/entries"},
+		{http.MethodGet, "/metrics"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.target, func(t *testing.T) {
+			rec := serveAPI(t, h, tc.method, tc.target, "", `{"name":"Coding"}`, nil)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("want status %d, got %d", http.StatusUnauthorized, rec.Code)
+			}
+		})
+	}
+	if active, err := a.GetActiveEntry(); err != nil || active != nil {
+		t.Errorf("want no entry started without token, got %+v, %v", active, err)
+	}
+}
+
+func TestAPIMethodNotAllowed(t *testing.T) {
+	a, _ := newTestApp(t, time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC))
+	h := newAPIHandler(a, testAPIToken)
+	rec := serveAPI(t, h, http.MethodGet, "/v1/start", testAPIToken, "", nil)
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != http.MethodPost {
+		t.Errorf("want status %d allowing POST, got %d allowing %q",
+			http.StatusMethodNotAllowed, rec.Code, rec.Header().Get("Allow"))
+	}
+}
+
+func TestAPIOpenAPI(t *testing.T) {
+	a, _ := newTestApp(t, time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC))
+	h := newAPIHandler(a, testAPIToken)
+	rec := serveAPI(t, h, http.MethodGet, "/openapi.yaml", "", "", nil)
+	if rec.Code != http.StatusOK || rec.Body.String() != string(OpenAPI) {
+		t.Errorf("want OpenAPI document without token, got status %d", rec.Code)
+	}
+}
+
+func TestAPIStartAndStop(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	a, clk := newTestApp(t, now)
+	h := newAPIHandler(a, testAPIToken)
+
+	var apiErr apiError
+	if rec := serveAPI(t, h, http.MethodGet, "/v1/active", testAPIToken, "", &apiErr); rec.Code != http.StatusNotFound {
+		t.Fatalf("active: want status %d, got %d", http.StatusNotFound, rec.Code)
+	}
+	if rec := serveAPI(t, h, http.MethodPost, "/v1/stop", testAPIToken, "", &apiErr); rec.Code != http.StatusNotFound {
+		t.Fatalf("stop: want status %d, got %d", http.StatusNotFound, rec.Code)
+	}
+	var status APIStatus
+	if rec := serveAPI(t, h, http.MethodGet, "/v1/status", testAPIToken, "", &status); rec.Code != http.StatusOK {
+		t.Fatalf("status: want status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if status.Active != nil || status.Text != "No active entry" {
+		t.Errorf("want no active entry, got %+v", status)
+	}
+
+	var started Entry
+	if rec := serveAPI(t, h, http.MethodPost, "/v1/start", testAPIToken, `{"name":"Review #review @acme"}`, &started); rec.Code != http.StatusCreated {
+		t.Fatalf("start: want status %d, got %d", http.StatusCreated, rec.Code)
+	}
+	if started.Name != "Review #review @acme" || started.Client != "acme" || started.End != nil {
+		t.Errorf("want active entry for acme, got %+v", started)
+	}
+
+	clk.Advance(72 * time.Minute)
+	if rec := serveAPI(t, h, http.MethodGet, "/v1/status", testAPIToken, "", &status); rec.Code != http.StatusOK {
+		t.Fatalf("status: want status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if status.Active == nil || status.Active.ID != started.ID || status.Text != "Review #review @acme (1h12m)" {
+		t.Errorf("want status of started entry, got %+v", status)
+	}
+	var active Entry
+	if rec := serveAPI(t, h, http.MethodGet, "/v1/active", testAPIToken, "", &active); rec.Code != http.StatusOK || active.ID != started.ID {
+		t.Errorf("active: want entry %d, got status %d and %+v", started.ID, rec.Code, active)
+	}
+
+	var stopped Entry
+	if rec := serveAPI(t, h, http.MethodPost, "/v1/stop", testAPIToken, "", &stopped); rec.Code != http.StatusOK {
+		t.Fatalf("stop: want status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if stopped.ID != started.ID || stopped.End == nil || !stopped.End.Equal(clk.Now()) {
+		t.Errorf("want entry %d stopped at %v, got %+v", started.ID, clk.Now(), stopped)
+	}
+}
+
+func TestAPIStartBadInput(t *testing.T) {
+	a, _ := newTestApp(t, time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC))
+	h := newAPIHandler(a, testAPIToken)
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"empty body", "", http.StatusBadRequest},
+		{"invalid JSON", `{"name":`, http.StatusBadRequest},
+		{"wrong type", `{"name":42}`, http.StatusBadRequest},
+		{"missing name", `{}`, http.StatusBadRequest},
+		{"blank name", `{"name":"  "}`, http.StatusBadRequest},
+		{"too large", `{"name":"` + strings.Repeat("a", maxAPIRequestBody) + `"}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var apiErr apiError
+			rec := serveAPI(t, h, http.MethodPost, "/v1/start", testAPIToken, tc.body, &apiErr)
+			if rec.Code != tc.want || apiErr.Error == "" {
+				t.Errorf("want status %d with error, got %d and %+v", tc.want, rec.Code, apiErr)
+			}
+		})
+	}
+	if active, err := a.GetActiveEntry(); err != nil || active != nil {
+		t.Errorf("want no entry started, got %+v, %v", active, err)
+	}
+}
+
+func TestAPIEntries(t *testing.T) {
+	now := time.Date(2023, 3, 2, 12, 0, 0, 0, time.UTC)
+	a, _ := newTestApp(t, now)
+	h := newAPIHandler(a, testAPIToken)
+	yesterday := createTestEntry(t, a, "Yesterday", now.Add(-26*time.Hour), timePtr(now.Add(-25*time.Hour)))
+	today := createTestEntry(t, a, "Today", now.Add(-2*time.Hour), timePtr(now.Add(-time.Hour)))
+
+	tests := []struct {
+		name   string
+		target string
+		want   []uint
+	}{
+		{"today by default", "/v1/entries", []uint{today.ID}},
+		{"given day", "/v1/entries?day=2023-03-01", []uint{yesterday.ID}},
+		{"day without entries", "/v1/entries?day=2023-02-01", nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var entries []Entry
+			if rec := serveAPI(t, h, http.MethodGet, tc.target, testAPIToken, "", &entries); rec.Code != http.StatusOK {
+				t.Fatalf("want status %d, got %d", http.StatusOK, rec.Code)
+			}
+			var got []uint
+			for _, entry := range entries {
+				got = append(got, entry.ID)
+			}
+			if len(got) != len(tc.want) || (len(got) > 0 && got[0] != tc.want[0]) {
+				t.Errorf("want entries %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	for _, day := range []string{"yesterday", "2023-13-01", "01/03/2023"} {
+		t.Run("invalid day "+day, func(t *testing.T) {
+			var apiErr apiError
+			rec := serveAPI(t, h, http.MethodGet, "/v1/entries?day="+day, testAPIToken, "", &apiErr)
+			if rec.Code != http.StatusBadRequest || apiErr.Error == "" {
+				t.Errorf("want status %d with error, got %d and %+v", http.StatusBadRequest, rec.Code, apiErr)
+			}
+		})
+	}
+}
+
+func TestAPIMetrics(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	a, _ := newTestApp(t, now)
+	createTestEntry(t, a, "Review #review", now.Add(-3*time.Hour), timePtr(now.Add(-2*time.Hour)))
+	createTestEntry(t, a, "Call #support", now.Add(-30*time.Minute), nil)
+
+	if rec := serveAPI(t, newAPIHandler(a, testAPIToken), http.MethodGet, "/metrics", testAPIToken, "", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("want status %d when metrics are disabled, got %d", http.StatusNotFound, rec.Code)
+	}
+	a.cfg.API.Metrics = true
+	rec := serveAPI(t, newAPIHandler(a, testAPIToken), http.MethodGet, "/metrics", testAPIToken, "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("want status %d, got %d", http.StatusOK, rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE dinkur_desktop_active_entry_seconds gauge\ndinkur_desktop_active_entry_seconds 1800\n",
+		"dinkur_desktop_tracked_today_seconds 5400\n",
+		"dinkur_desktop_tracked_today_tag_seconds{tag=\"review\"} 3600\n",
+		"dinkur_desktop_tracked_today_tag_seconds{tag=\"support\"} 1800\n",
+		"dinkur_desktop_connected 1\n",
+		"# TYPE dinkur_desktop_client_call_duration_seconds histogram\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("want metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+}
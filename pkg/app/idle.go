@@ -0,0 +1,321 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/internal/clock"
+	"github.com/dinkur/dinkur/pkg/dinkur"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Errors specific to idle detection.
+var (
+	ErrIdleSourceUnavailable = errors.New("no idle time source available")
+	ErrNoPendingIdle         = errors.New("no pending idle time to resolve")
+)
+
+// IdleSource reports how long the user has been idle, i.e how long it's been
+// since the last keyboard or mouse input.
+type IdleSource interface {
+	IdleTime() (time.Duration, error)
+}
+
+// fallbackIdleSource tries the idle source that last succeeded first, and
+// then the other sources in order. Sources are never dropped, so a source
+// that fails once is tried again when the others fail.
+type fallbackIdleSource struct {
+	mu      sync.Mutex
+	sources []IdleSource
+	current int
+}
+
+func (s *fallbackIdleSource) IdleTime() (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.sources {
+		index := (s.current + i) % len(s.sources)
+		idle, err := s.sources[index].IdleTime()
+		if err == nil {
+			s.current = index
+			return idle, nil
+		}
+		log.Debug().WithError(err).
+			WithStringf("source", "%T", s.sources[index]).
+			Message("Idle source failed. Trying next one.")
+	}
+	return 0, ErrIdleSourceUnavailable
+}
+
+// FakeIdleSource is an [IdleSource] where the idle time is set manually.
+// It is meant to be used in tests.
+type FakeIdleSource struct {
+	mu   sync.Mutex
+	idle time.Duration
+	err  error
+}
+
+// Set changes the idle time reported by this source.
+func (s *FakeIdleSource) Set(idle time.Duration) {
+	s.mu.Lock()
+	s.idle = idle
+	s.err = nil
+	s.mu.Unlock()
+}
+
+// SetError makes this source report an error until [FakeIdleSource.Set]
+// is called.
+func (s *FakeIdleSource) SetError(err error) {
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+}
+
+func (s *FakeIdleSource) IdleTime() (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.idle, s.err
+}
+
+// IdlePeriod is a span of time where the user was idle.
+type IdlePeriod struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Duration returns the length of the idle period.
+func (p IdlePeriod) Duration() time.Duration {
+	return p.End.Sub(p.Start)
+}
+
+// IdleDetector polls an [IdleSource] and reports when the user goes away and
+// when the user comes back.
+type IdleDetector struct {
+	source    IdleSource
+	clock     clock.Clock
+	threshold time.Duration
+
+	// OnAway is called when the user has been idle longer than the threshold.
+	OnAway func(since time.Time)
+	// OnReturn is called when the user returns after having been away.
+	OnReturn func(period IdlePeriod)
+
+	mu         sync.Mutex
+	awaySince  *time.Time
+	lastActive time.Time
+}
+
+// NewIdleDetector creates a new idle detector.
+func NewIdleDetector(source IdleSource, clk clock.Clock, threshold time.Duration) *IdleDetector {
+	return &IdleDetector{
+		source:     source,
+		clock:      clk,
+		threshold:  threshold,
+		lastActive: clk.Now(),
+	}
+}
+
+// Run polls the idle source on the given interval until the context is
+// cancelled.
+func (d *IdleDetector) Run(ctx context.Context, interval time.Duration) {
+	ticker := d.clock.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			d.Poll()
+		}
+	}
+}
+
+// Poll checks the idle source once, and triggers the OnAway or OnReturn
+// callbacks if the user's idle state changed.
+func (d *IdleDetector) Poll() {
+	idle, err := d.source.IdleTime()
+	if err != nil {
+		log.Debug().WithError(err).Message("Failed to get idle time.")
+		return
+	}
+	now := d.clock.Now()
+	d.mu.Lock()
+	var (
+		away     *time.Time
+		returned *IdlePeriod
+	)
+	if idle < d.threshold {
+		d.lastActive = now.Add(-idle)
+		if d.awaySince != nil {
+			returned = &IdlePeriod{Start: *d.awaySince, End: d.lastActive}
+			d.awaySince = nil
+		}
+	} else if d.awaySince == nil {
+		since := now.Add(-idle)
+		d.awaySince = &since
+		away = &since
+	}
+	d.mu.Unlock()
+
+	if away != nil && d.OnAway != nil {
+		d.OnAway(*away)
+	}
+	if returned != nil && d.OnReturn != nil {
+		d.OnReturn(*returned)
+	}
+}
+
+// LastActive returns the last time the user was seen active.
+func (d *IdleDetector) LastActive() time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.lastActive
+}
+
+// IdleChoice is what to do with the idle time of an active entry.
+type IdleChoice string
+
+const (
+	// IdleChoiceKeep keeps the idle time as part of the active entry.
+	IdleChoiceKeep IdleChoice = "keep"
+	// IdleChoiceDiscard ends the active entry when the idle time started.
+	IdleChoiceDiscard IdleChoice = "discard"
+	// IdleChoiceSplit ends the active entry when the idle time started, moves
+	// the idle time into a new entry, and then continues the active entry
+	// as a new entry.
+	IdleChoiceSplit IdleChoice = "split"
+)
+
+// PendingIdle is sent to the frontend via the "idle:returned" event when the
+// user returns after being idle while an entry was active.
+type PendingIdle struct {
+	Entry  dinkur.Entry `json:"entry"`
+	Period IdlePeriod   `json:"period"`
+}
+
+func (a *App) startIdleDetector(ctx context.Context) {
+	if !a.cfg.Idle.Enabled {
+		return
+	}
+	if a.idleSource == nil {
+		log.Warn().Message("No idle detection available for this OS.")
+		return
+	}
+	d := NewIdleDetector(a.idleSource, a.clock, a.cfg.Idle.Threshold)
+	d.OnAway = a.onIdleAway
+	d.OnReturn = a.onIdleReturn
+	a.idleDetector = d
+	go d.Run(ctx, a.cfg.Idle.PollInterval)
+}
+
+func (a *App) onIdleAway(since time.Time) {
+	log.Debug().WithTime("since", since).Message("User is away.")
+	if err := a.dinkur.SetStatus(a.ctx, dinkur.EditStatus{AFKSince: &since}); err != nil {
+		log.Warn().WithError(err).Message("Failed to set AFK status.")
+	}
+}
+
+func (a *App) onIdleReturn(period IdlePeriod) {
+	log.Debug().
+		WithTime("start", period.Start).
+		WithTime("end", period.End).
+		Message("User returned from being away.")
+	if err := a.dinkur.SetStatus(a.ctx, dinkur.EditStatus{BackSince: &period.End}); err != nil {
+		log.Warn().WithError(err).Message("Failed to set back-from-AFK status.")
+	}
+	entry, err := a.dinkur.GetActiveEntry(a.ctx)
+	if err != nil {
+		log.Warn().WithError(err).Message("Failed to get active entry after returning from being away.")
+		return
+	}
+	if entry == nil || !entry.Start.Before(period.Start) {
+		return
+	}
+	pending := PendingIdle{Entry: *entry, Period: period}
+	a.idleMutex.Lock()
+	a.pendingIdle = &pending
+	a.idleMutex.Unlock()
+	runtime.Show(a.ctx)
+	runtime.EventsEmit(a.ctx, "idle:returned", pending)
+}
+
+// GetPendingIdle returns the idle time that is waiting to be resolved via
+// [App.ResolveIdle], or nil if there is none.
+func (a *App) GetPendingIdle() *PendingIdle {
+	a.idleMutex.Lock()
+	defer a.idleMutex.Unlock()
+	return a.pendingIdle
+}
+
+// ResolveIdle decides what to do with the idle time reported by the
+// "idle:returned" event. The choice is one of "keep", "discard", or "split".
+// The idle time stays pending until it has been resolved successfully.
+func (a *App) ResolveIdle(choice IdleChoice) error {
+	a.idleMutex.Lock()
+	pending := a.pendingIdle
+	a.idleMutex.Unlock()
+	if pending == nil {
+		return ErrNoPendingIdle
+	}
+	switch choice {
+	case IdleChoiceKeep, IdleChoiceDiscard, IdleChoiceSplit:
+	default:
+		return fmt.Errorf("unknown idle choice: %q, must be one of: keep, discard, split", choice)
+	}
+	log.Info().
+		WithString("choice", string(choice)).
+		WithUint("entry", pending.Entry.ID).
+		WithDuration("idle", pending.Period.Duration()).
+		Message("Resolving idle time.")
+	if choice != IdleChoiceKeep {
+		active, err := a.dinkur.GetActiveEntry(a.ctx)
+		if err != nil {
+			return fmt.Errorf("get active entry: %w", err)
+		}
+		if active == nil || active.ID != pending.Entry.ID {
+			return fmt.Errorf("entry %d is no longer active", pending.Entry.ID)
+		}
+		description := fmt.Sprintf("Discard idle time from %q", pending.Entry.Name)
+		if choice == IdleChoiceSplit {
+			description = fmt.Sprintf("Split idle time from %q", pending.Entry.Name)
+		}
+		if err := a.mutate(description, func(ctx context.Context) error {
+			return a.resolveIdle(ctx, pending, choice)
+		}); err != nil {
+			return err
+		}
+	}
+	a.idleMutex.Lock()
+	// Only clear it if it hasn't been replaced by a newer idle period.
+	if a.pendingIdle == pending {
+		a.pendingIdle = nil
+	}
+	a.idleMutex.Unlock()
+	return nil
+}
+
+func (a *App) resolveIdle(ctx context.Context, pending *PendingIdle, choice IdleChoice) error {
+	if _, err := a.dinkur.StopActiveEntry(ctx, pending.Period.Start); err != nil {
+		return fmt.Errorf("stop entry at idle start: %w", err)
+	}
+	if choice == IdleChoiceDiscard {
+		return nil
+	}
+	if _, err := a.dinkur.CreateEntry(ctx, dinkur.NewEntry{
+		Name:  a.cfg.Idle.SplitName,
+		Start: &pending.Period.Start,
+		End:   &pending.Period.End,
+	}); err != nil {
+		return fmt.Errorf("create entry for idle time: %w", err)
+	}
+	if _, err := a.dinkur.CreateEntry(ctx, dinkur.NewEntry{
+		Name:  pending.Entry.Name,
+		Start: &pending.Period.End,
+	}); err != nil {
+		return fmt.Errorf("continue entry after idle time: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func newSystemIdleSource() IdleSource {
+	return &fallbackIdleSource{
+		sources: []IdleSource{
+			mutterIdleSource{},
+			screenSaverIdleSource{},
+			xprintidleSource{},
+		},
+	}
+}
+
+// mutterIdleSource gets the idle time from GNOME's Mutter, which works on
+// both X11 and Wayland.
+//
+// https://gitlab.gnome.org/GNOME/mutter/-/blob/41.2/src/org.gnome.Mutter.IdleMonitor.xml#L14-16
+type mutterIdleSource struct{}
+
+func (mutterIdleSource) IdleTime() (time.Duration, error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return 0, fmt.Errorf("connect to session bus: %w", err)
+	}
+	var idleMs uint64
+	obj := conn.Object("org.gnome.Mutter.IdleMonitor", "/org/gnome/Mutter/IdleMonitor/Core")
+	if err := obj.Call("org.gnome.Mutter.IdleMonitor.GetIdletime", 0).Store(&idleMs); err != nil {
+		return 0, fmt.Errorf("org.gnome.Mutter.IdleMonitor: %w", err)
+	}
+	return time.Duration(idleMs) * time.Millisecond, nil
+}
+
+// screenSaverIdleSource gets the idle time from the freedesktop screensaver
+// interface, as implemented by KDE Plasma on both X11 and Wayland.
+type screenSaverIdleSource struct{}
+
+func (screenSaverIdleSource) IdleTime() (time.Duration, error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return 0, fmt.Errorf("connect to session bus: %w", err)
+	}
+	var idleMs uint32
+	obj := conn.Object("org.freedesktop.ScreenSaver", "/org/freedesktop/ScreenSaver")
+	if err := obj.Call("org.freedesktop.ScreenSaver.GetSessionIdleTime", 0).Store(&idleMs); err != nil {
+		return 0, fmt.Errorf("org.freedesktop.ScreenSaver: %w", err)
+	}
+	return time.Duration(idleMs) * time.Millisecond, nil
+}
+
+// xprintidleSource gets the idle time from the X11 screensaver extension
+// via the xprintidle command.
+type xprintidleSource struct{}
+
+func (xprintidleSource) IdleTime() (time.Duration, error) {
+	if os.Getenv("DISPLAY") == "" {
+		return 0, fmt.Errorf("xprintidle: no X11 display")
+	}
+	out, err := exec.Command("xprintidle").Output()
+	if err != nil {
+		return 0, fmt.Errorf("xprintidle: %w", err)
+	}
+	idleMs, err := strconv.ParseUint(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("xprintidle: parse output: %w", err)
+	}
+	return time.Duration(idleMs) * time.Millisecond, nil
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package app
+
+func newSystemIdleSource() IdleSource {
+	return nil
+}
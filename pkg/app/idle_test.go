@@ -0,0 +1,135 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/internal/clock"
+)
+
+func TestIdleDetector(t *testing.T) {
+	start := time.Date(2023, 3, 1, 9, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	source := &FakeIdleSource{}
+	d := NewIdleDetector(source, clk, 5*time.Minute)
+	var away []time.Time
+	var returned []IdlePeriod
+	d.OnAway = func(since time.Time) { away = append(away, since) }
+	d.OnReturn = func(period IdlePeriod) { returned = append(returned, period) }
+
+	clk.Advance(4 * time.Minute)
+	source.Set(4 * time.Minute)
+	d.Poll()
+	if len(away) != 0 {
+		t.Fatalf("want not away below threshold, got away since %v", away)
+	}
+
+	clk.Advance(2 * time.Minute)
+	source.Set(6 * time.Minute)
+	d.Poll()
+	clk.Advance(10 * time.Minute)
+	source.Set(16 * time.Minute)
+	d.Poll()
+	if len(away) != 1 || !away[0].Equal(start) {
+		t.Fatalf("want away once since %v, got %v", start, away)
+	}
+
+	// Errors are ignored, and don't count as the user returning.
+	source.SetError(errors.New("no display"))
+	d.Poll()
+	if len(returned) != 0 {
+		t.Fatalf("want no return on error, got %v", returned)
+	}
+
+	clk.Advance(time.Minute)
+	source.Set(30 * time.Second)
+	d.Poll()
+	wantEnd := start.Add(16*time.Minute + 30*time.Second)
+	if len(returned) != 1 || !returned[0].Start.Equal(start) || !returned[0].End.Equal(wantEnd) {
+		t.Fatalf("want returned from %v to %v, got %v", start, wantEnd, returned)
+	}
+	if got := d.LastActive(); !got.Equal(wantEnd) {
+		t.Errorf("want last active %v, got %v", wantEnd, got)
+	}
+}
+
+func TestFallbackIdleSourceRetriesFailedSources(t *testing.T) {
+	first := &FakeIdleSource{}
+	second := &FakeIdleSource{}
+	second.Set(time.Minute)
+	s := &fallbackIdleSource{sources: []IdleSource{first, second}}
+
+	first.SetError(errors.New("temporary"))
+	if idle, err := s.IdleTime(); err != nil || idle != time.Minute {
+		t.Fatalf("want fallback to second source, got %v, %v", idle, err)
+	}
+
+	second.SetError(errors.New("temporary"))
+	first.Set(2 * time.Minute)
+	if idle, err := s.IdleTime(); err != nil || idle != 2*time.Minute {
+		t.Fatalf("want first source to be tried again, got %v, %v", idle, err)
+	}
+
+	first.SetError(errors.New("temporary"))
+	if _, err := s.IdleTime(); !errors.Is(err, ErrIdleSourceUnavailable) {
+		t.Fatalf("want %v when all sources fail, got %v", ErrIdleSourceUnavailable, err)
+	}
+}
+
+func TestResolveIdleKeepsPendingOnFailure(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	a, _ := newTestApp(t, now)
+	entry := createTestEntry(t, a, "Coding", now.Add(-3*time.Hour), nil)
+	pending := &PendingIdle{
+		Entry:  entry,
+		Period: IdlePeriod{Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)},
+	}
+	a.pendingIdle = pending
+
+	if err := a.ResolveIdle("nap"); err == nil {
+		t.Fatal("want error on unknown choice")
+	}
+	if a.GetPendingIdle() != pending {
+		t.Fatal("want idle time still pending after unknown choice")
+	}
+
+	if _, err := a.dinkur.StopActiveEntry(a.ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.ResolveIdle(IdleChoiceSplit); err == nil {
+		t.Fatal("want error when entry is no longer active")
+	}
+	if a.GetPendingIdle() != pending {
+		t.Fatal("want idle time still pending after failure")
+	}
+}
+
+func TestResolveIdleSplit(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	a, _ := newTestApp(t, now)
+	entry := createTestEntry(t, a, "Coding", now.Add(-3*time.Hour), nil)
+	idleStart, idleEnd := now.Add(-2*time.Hour), now.Add(-time.Hour)
+	a.pendingIdle = &PendingIdle{Entry: entry, Period: IdlePeriod{Start: idleStart, End: idleEnd}}
+
+	if err := a.ResolveIdle(IdleChoiceSplit); err != nil {
+		t.Fatal(err)
+	}
+	if a.GetPendingIdle() != nil {
+		t.Fatal("want no pending idle time after resolving")
+	}
+	stopped, err := a.dinkur.GetEntry(a.ctx, entry.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stopped.End == nil || !stopped.End.Equal(idleStart) {
+		t.Errorf("want entry stopped at %v, got %v", idleStart, stopped.End)
+	}
+	active, err := a.dinkur.GetActiveEntry(a.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active == nil || active.Name != "Coding" || !active.Start.Equal(idleEnd) {
+		t.Errorf("want entry continued at %v, got %+v", idleEnd, active)
+	}
+}
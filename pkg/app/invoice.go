@@ -0,0 +1,87 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/invoice"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+var unsafeFilenameChars = regexp.MustCompile(`[^\w.-]+`)
+
+func (a *App) invoiceNumbers() *invoice.NumberStore {
+	return invoice.NewNumberStore(filepath.Join(a.cfg.DataDir, "invoice-number.json"))
+}
+
+func (a *App) newInvoice(client string, start, end time.Time) (invoice.Invoice, int, error) {
+	number, err := a.invoiceNumbers().Next()
+	if err != nil {
+		return invoice.Invoice{}, 0, err
+	}
+	report, err := a.GetBillingReport(start, end)
+	if err != nil {
+		return invoice.Invoice{}, 0, err
+	}
+	inv, err := invoice.New(a.billing, report, client, invoice.Options{
+		Number:  invoice.FormatNumber(a.cfg.Invoice.NumberFormat, number),
+		Date:    a.clock.Now(),
+		DueDays: a.cfg.Invoice.DueDays,
+		From:    a.cfg.Invoice.From,
+	})
+	return inv, number, err
+}
+
+// PreviewInvoice returns the invoice draft for the client for the days from
+// the start time to the end time, without using up its invoice number.
+func (a *App) PreviewInvoice(client string, start, end time.Time) (invoice.Invoice, error) {
+	inv, _, err := a.newInvoice(client, start, end)
+	return inv, err
+}
+
+// SaveInvoice asks where to save and then writes the invoice draft for the
+// client for the days from the start time to the end time, as either "html"
+// or "pdf". The invoice number is only used up if the invoice is saved, and
+// saving fails if another invoice has been saved with the same number since.
+// Returns the path of the saved file, or empty string if the user
+// cancelled.
+func (a *App) SaveInvoice(client string, start, end time.Time, format invoice.Format) (string, error) {
+	inv, number, err := a.newInvoice(client, start, end)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := invoice.Write(&buf, inv, format, a.cfg.Invoice.Template); err != nil {
+		return "", err
+	}
+	ext := string(format)
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Save invoice",
+		DefaultFilename: unsafeFilenameChars.ReplaceAllString(inv.Number+" "+inv.Client, "_") + "." + ext,
+		Filters: []runtime.FileFilter{
+			{DisplayName: fmt.Sprintf("%s files (*.%s)", strings.ToUpper(ext), ext), Pattern: "*." + ext},
+		},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+	// The number is used up first, so that a failed write leaves a gap in
+	// the numbers rather than two invoices with the same number.
+	if err := a.invoiceNumbers().Use(number); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("write invoice: %w", err)
+	}
+	log.Info().
+		WithString("number", inv.Number).
+		WithString("client", inv.Client).
+		WithString("path", path).
+		Message("Saved invoice.")
+	return path, nil
+}
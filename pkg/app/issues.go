@@ -0,0 +1,43 @@
+package app
+
+import (
+	"context"
+
+	"github.com/dinkur/dinkur-desktop/internal/clock"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+	"github.com/dinkur/dinkur-desktop/pkg/issues"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+func newIssueResolver(path string, cfg config.IssueTrackers, clk clock.Clock) *issues.Resolver {
+	trackers, err := issues.NewTrackers(cfg)
+	if err != nil {
+		log.Warn().WithError(err).Message("Invalid issue trackers config. Ignoring all issue trackers.")
+		trackers = nil
+	}
+	return issues.NewResolver(trackers, path, cfg.CacheTTL, clk)
+}
+
+func (a *App) startIssueResolver(ctx context.Context) {
+	if len(a.cfg.IssueTrackers.Items) == 0 {
+		return
+	}
+	a.issues.OnResolve = a.onIssuesResolved
+	go a.issues.Run(ctx)
+}
+
+func (a *App) onIssuesResolved(resolved []issues.Issue, err error) {
+	if err != nil {
+		log.Warn().WithError(err).Message("Failed to look up issues.")
+	}
+	if len(resolved) > 0 {
+		runtime.EventsEmit(a.ctx, "issues:changed", resolved)
+	}
+}
+
+// ResolveIssues looks up the ticket keys in the issue trackers, and returns
+// the issues that were found. Issues cached within the cache TTL are not
+// fetched again. Keys that no issue tracker matches are skipped.
+func (a *App) ResolveIssues(keys []string) ([]issues.Issue, error) {
+	return a.issues.Resolve(a.ctx, keys)
+}
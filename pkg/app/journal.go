@@ -0,0 +1,625 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/internal/clock"
+	"github.com/dinkur/dinkur-desktop/internal/jsonfile"
+	"github.com/dinkur/dinkur/pkg/dinkur"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Errors specific to the undo journal.
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+// JournalStepKind is the type of change made to an entry.
+type JournalStepKind string
+
+const (
+	JournalStepCreate JournalStepKind = "create"
+	JournalStepUpdate JournalStepKind = "update"
+	JournalStepDelete JournalStepKind = "delete"
+)
+
+// JournalStep is a single change to an entry. Before is nil for created
+// entries, and After is nil for deleted entries.
+type JournalStep struct {
+	Kind   JournalStepKind `json:"kind"`
+	Before *dinkur.Entry   `json:"before,omitempty"`
+	After  *dinkur.Entry   `json:"after,omitempty"`
+}
+
+// JournalOperation is a change to entries made through Dinkur desktop, such
+// as starting or merging entries, which is undone and redone as a whole.
+type JournalOperation struct {
+	ID          uint          `json:"id"`
+	Time        time.Time     `json:"time"`
+	Description string        `json:"description"`
+	Steps       []JournalStep `json:"steps"`
+}
+
+// JournalHistory is the operations that can be undone and redone, with the
+// most recent operation first.
+type JournalHistory struct {
+	Undo []JournalOperation `json:"undo"`
+	Redo []JournalOperation `json:"redo"`
+}
+
+type journalState struct {
+	LastID uint               `json:"lastId"`
+	Undo   []JournalOperation `json:"undo"`
+	Redo   []JournalOperation `json:"redo"`
+}
+
+// Journal records changes to entries together with their previous values,
+// so that they can be undone and redone. The journal is persisted as a JSON
+// file.
+//
+// The Dinkur client cannot unset an entry's end time nor recreate an entry
+// with its old ID, so undoing some changes deletes and recreates entries.
+// The journal then updates its own references to the new IDs.
+type Journal struct {
+	path    string
+	size    int
+	entries dinkur.Entries
+	clock   clock.Clock
+
+	// OnChange is called with the new history after each recorded, undone,
+	// or redone operation.
+	OnChange func(JournalHistory)
+	// OnRecreate is called when undoing or redoing has recreated an entry
+	// with a new ID.
+	OnRecreate func(old, recreated dinkur.Entry)
+	// OnRestoreFailed is called when an active entry that was set aside
+	// could not be created again.
+	OnRestoreFailed func(entry dinkur.Entry, err error)
+
+	mu sync.Mutex
+}
+
+// NewJournal creates a new journal that persists to the given file and keeps
+// at most size operations. The entries client is used when undoing and
+// redoing, and must not record changes to the journal itself.
+func NewJournal(path string, size int, entries dinkur.Entries, clk clock.Clock) *Journal {
+	return &Journal{
+		path:    path,
+		size:    size,
+		entries: entries,
+		clock:   clk,
+	}
+}
+
+// Record adds an operation with the given steps, and clears the operations
+// that could be redone. The oldest operations are dropped once the journal
+// is full.
+func (j *Journal) Record(description string, steps []JournalStep) error {
+	if j.size <= 0 || len(steps) == 0 {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	state, err := j.read()
+	if err != nil {
+		return err
+	}
+	state.LastID++
+	state.Undo = append(state.Undo, JournalOperation{
+		ID:          state.LastID,
+		Time:        j.clock.Now(),
+		Description: description,
+		Steps:       steps,
+	})
+	if len(state.Undo) > j.size {
+		state.Undo = state.Undo[len(state.Undo)-j.size:]
+	}
+	state.Redo = nil
+	return j.write(state)
+}
+
+// History returns the operations that can be undone and redone.
+func (j *Journal) History() (JournalHistory, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	state, err := j.read()
+	if err != nil {
+		return JournalHistory{}, err
+	}
+	return state.history(), nil
+}
+
+// Undo reverts the most recent operation. This fails with [ErrEntryChanged]
+// if any of its entries have been changed since, in which case nothing is
+// reverted. If reverting fails partway, then the reverted steps are split
+// off into an operation that can be redone, and the rest can be undone.
+func (j *Journal) Undo(ctx context.Context) (JournalOperation, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	state, err := j.read()
+	if err != nil {
+		return JournalOperation{}, err
+	}
+	if len(state.Undo) == 0 {
+		return JournalOperation{}, ErrNothingToUndo
+	}
+	op := &state.Undo[len(state.Undo)-1]
+	if err := j.checkUnchanged(ctx, latestSnapshots(op.Steps)); err != nil {
+		return JournalOperation{}, err
+	}
+	for i := len(op.Steps) - 1; i >= 0; i-- {
+		if err := j.undoStep(ctx, &state, &op.Steps[i]); err != nil {
+			// Move the steps that were undone over to be redone, so that
+			// the journal matches the entries
+			undone := op.Steps[i+1:]
+			op.Steps = op.Steps[:i+1]
+			state.Redo = state.splitOperation(state.Redo, *op, undone)
+			if werr := j.write(state); werr != nil {
+				log.Error().WithError(werr).Message("Failed to write journal.")
+			}
+			return JournalOperation{}, fmt.Errorf("undo %q: %w", op.Description, err)
+		}
+	}
+	undone := *op
+	state.Undo = state.Undo[:len(state.Undo)-1]
+	state.Redo = append(state.Redo, undone)
+	return undone, j.write(state)
+}
+
+// Redo applies the most recently undone operation again. This fails with
+// [ErrEntryChanged] if any of its entries have been changed since it was
+// undone, in which case nothing is applied. If applying fails partway, then
+// the applied steps are split off into an operation that can be undone, and
+// the rest can be redone.
+func (j *Journal) Redo(ctx context.Context) (JournalOperation, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	state, err := j.read()
+	if err != nil {
+		return JournalOperation{}, err
+	}
+	if len(state.Redo) == 0 {
+		return JournalOperation{}, ErrNothingToRedo
+	}
+	op := &state.Redo[len(state.Redo)-1]
+	if err := j.checkUnchanged(ctx, earliestSnapshots(op.Steps)); err != nil {
+		return JournalOperation{}, err
+	}
+	for i := range op.Steps {
+		if err := j.redoStep(ctx, &state, &op.Steps[i]); err != nil {
+			redone := op.Steps[:i]
+			op.Steps = op.Steps[i:]
+			state.Undo = state.splitOperation(state.Undo, *op, redone)
+			if werr := j.write(state); werr != nil {
+				log.Error().WithError(werr).Message("Failed to write journal.")
+			}
+			return JournalOperation{}, fmt.Errorf("redo %q: %w", op.Description, err)
+		}
+	}
+	redone := *op
+	state.Redo = state.Redo[:len(state.Redo)-1]
+	state.Undo = append(state.Undo, redone)
+	return redone, j.write(state)
+}
+
+func (j *Journal) undoStep(ctx context.Context, state *journalState, step *JournalStep) error {
+	switch step.Kind {
+	case JournalStepCreate:
+		_, err := j.entries.DeleteEntry(ctx, step.After.ID)
+		return err
+	case JournalStepDelete:
+		created, err := j.createEntry(ctx, state, *step.Before)
+		if err != nil {
+			return err
+		}
+		j.remap(state, *step.Before, created)
+		step.Before = &created
+	case JournalStepUpdate:
+		restored, err := j.restoreEntry(ctx, state, *step.After, *step.Before)
+		if err != nil {
+			return err
+		}
+		j.remap(state, *step.Before, restored)
+		step.Before = &restored
+	default:
+		return fmt.Errorf("unknown journal step: %q", step.Kind)
+	}
+	return nil
+}
+
+func (j *Journal) redoStep(ctx context.Context, state *journalState, step *JournalStep) error {
+	switch step.Kind {
+	case JournalStepCreate:
+		created, err := j.createEntry(ctx, state, *step.After)
+		if err != nil {
+			return err
+		}
+		j.remap(state, *step.After, created)
+		step.After = &created
+	case JournalStepDelete:
+		_, err := j.entries.DeleteEntry(ctx, step.Before.ID)
+		return err
+	case JournalStepUpdate:
+		restored, err := j.restoreEntry(ctx, state, *step.Before, *step.After)
+		if err != nil {
+			return err
+		}
+		j.remap(state, *step.After, restored)
+		step.After = &restored
+	default:
+		return fmt.Errorf("unknown journal step: %q", step.Kind)
+	}
+	return nil
+}
+
+// createEntry creates the entry again. The Dinkur client always stops the
+// active entry when creating an entry, so when creating an entry that has
+// already ended, the active entry is set aside and recreated afterwards, the
+// same way as [App.createPastEntry].
+func (j *Journal) createEntry(ctx context.Context, state *journalState, entry dinkur.Entry) (dinkur.Entry, error) {
+	active, err := j.entries.GetActiveEntry(ctx)
+	if err != nil {
+		return dinkur.Entry{}, fmt.Errorf("get active entry: %w", err)
+	}
+	if active != nil && entry.End == nil {
+		return dinkur.Entry{}, ErrOtherEntryActive
+	}
+	if active != nil {
+		if _, err := j.entries.DeleteEntry(ctx, active.ID); err != nil {
+			return dinkur.Entry{}, fmt.Errorf("set aside active entry: %w", err)
+		}
+		defer func() {
+			restored, err := recreateActive(ctx, j.entries, *active)
+			if err != nil {
+				if j.OnRestoreFailed != nil {
+					j.OnRestoreFailed(*active, err)
+				}
+				return
+			}
+			j.remap(state, *active, restored)
+		}()
+	}
+	created, err := j.entries.CreateEntry(ctx, dinkur.NewEntry{
+		Name:  entry.Name,
+		Start: &entry.Start,
+		End:   entry.End,
+	})
+	if err != nil {
+		return dinkur.Entry{}, fmt.Errorf("create entry %q: %w", entry.Name, err)
+	}
+	return created.Started, nil
+}
+
+// restoreEntry changes the entry back to the name and times of the
+// snapshot. Restoring the end time of an active entry deletes and recreates
+// the entry, as the Dinkur client cannot unset an entry's end time.
+func (j *Journal) restoreEntry(ctx context.Context, state *journalState, current, snapshot dinkur.Entry) (dinkur.Entry, error) {
+	if snapshot.End == nil && current.End != nil {
+		if _, err := j.entries.DeleteEntry(ctx, current.ID); err != nil {
+			return dinkur.Entry{}, fmt.Errorf("delete entry %d: %w", current.ID, err)
+		}
+		return j.createEntry(ctx, state, snapshot)
+	}
+	updated, err := j.entries.UpdateEntry(ctx, dinkur.EditEntry{
+		IDOrZero: current.ID,
+		Name:     &snapshot.Name,
+		Start:    &snapshot.Start,
+		End:      snapshot.End,
+	})
+	if err != nil {
+		return dinkur.Entry{}, fmt.Errorf("update entry %d: %w", current.ID, err)
+	}
+	return updated.After, nil
+}
+
+// checkUnchanged returns [ErrEntryChanged] if any entry no longer matches
+// its snapshot.
+func (j *Journal) checkUnchanged(ctx context.Context, snapshots []dinkur.Entry) error {
+	for _, snapshot := range snapshots {
+		current, err := j.entries.GetEntry(ctx, snapshot.ID)
+		if errors.Is(err, dinkur.ErrNotFound) {
+			return fmt.Errorf("%w: %q has been deleted", ErrEntryChanged, snapshot.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("get entry %d: %w", snapshot.ID, err)
+		}
+		if !current.UpdatedAt.Equal(snapshot.UpdatedAt) {
+			return fmt.Errorf("%w: %q", ErrEntryChanged, current.Name)
+		}
+	}
+	return nil
+}
+
+// latestSnapshots returns how each entry looked after the steps, excluding
+// deleted entries, i.e what the entries should look like before undoing.
+func latestSnapshots(steps []JournalStep) []dinkur.Entry {
+	latest := map[uint]*dinkur.Entry{}
+	var order []uint
+	for _, step := range steps {
+		id := step.entryID()
+		if _, ok := latest[id]; !ok {
+			order = append(order, id)
+		}
+		latest[id] = step.After
+	}
+	return snapshotsInOrder(latest, order)
+}
+
+// earliestSnapshots returns how each entry looked before the steps,
+// excluding created entries, i.e what the entries should look like before
+// redoing.
+func earliestSnapshots(steps []JournalStep) []dinkur.Entry {
+	earliest := map[uint]*dinkur.Entry{}
+	var order []uint
+	for _, step := range steps {
+		id := step.entryID()
+		if _, ok := earliest[id]; ok {
+			continue
+		}
+		order = append(order, id)
+		earliest[id] = step.Before
+	}
+	return snapshotsInOrder(earliest, order)
+}
+
+func snapshotsInOrder(snapshots map[uint]*dinkur.Entry, order []uint) []dinkur.Entry {
+	var result []dinkur.Entry
+	for _, id := range order {
+		if snapshot := snapshots[id]; snapshot != nil {
+			result = append(result, *snapshot)
+		}
+	}
+	return result
+}
+
+func (s JournalStep) entryID() uint {
+	if s.After != nil {
+		return s.After.ID
+	}
+	return s.Before.ID
+}
+
+// remap updates the journal's references to an entry that has been
+// recreated or restored, and tells [Journal.OnRecreate] if its ID changed.
+func (j *Journal) remap(state *journalState, old, recreated dinkur.Entry) {
+	state.remap(old, recreated)
+	if old.ID != recreated.ID && j.OnRecreate != nil {
+		j.OnRecreate(old, recreated)
+	}
+}
+
+// remap updates all references to an entry that has been recreated with a
+// new ID, or restored to how it looked in a snapshot. Snapshots that matched
+// the old entry are updated to match the new entry, so that the entry isn't
+// considered changed by the user.
+func (s *journalState) remap(old, recreated dinkur.Entry) {
+	update := func(snapshot *dinkur.Entry) {
+		if snapshot == nil || snapshot.ID != old.ID {
+			return
+		}
+		snapshot.ID = recreated.ID
+		if snapshot.UpdatedAt.Equal(old.UpdatedAt) {
+			snapshot.UpdatedAt = recreated.UpdatedAt
+		}
+	}
+	for _, ops := range [][]JournalOperation{s.Undo, s.Redo} {
+		for i := range ops {
+			for k := range ops[i].Steps {
+				update(ops[i].Steps[k].Before)
+				update(ops[i].Steps[k].After)
+			}
+		}
+	}
+}
+
+// splitOperation adds the steps of the operation that were applied before
+// it failed, if any, to the stack as an operation of their own.
+func (s *journalState) splitOperation(stack []JournalOperation, op JournalOperation, applied []JournalStep) []JournalOperation {
+	if len(applied) == 0 {
+		return stack
+	}
+	s.LastID++
+	return append(stack, JournalOperation{
+		ID:          s.LastID,
+		Time:        op.Time,
+		Description: op.Description,
+		Steps:       append([]JournalStep(nil), applied...),
+	})
+}
+
+func (s journalState) history() JournalHistory {
+	return JournalHistory{
+		Undo: reversedOperations(s.Undo),
+		Redo: reversedOperations(s.Redo),
+	}
+}
+
+func reversedOperations(ops []JournalOperation) []JournalOperation {
+	result := make([]JournalOperation, len(ops))
+	for i, op := range ops {
+		result[len(ops)-1-i] = op
+	}
+	return result
+}
+
+func (j *Journal) read() (journalState, error) {
+	var state journalState
+	if err := jsonfile.Read(j.path, &state); err != nil {
+		return journalState{}, fmt.Errorf("read journal: %w", err)
+	}
+	return state, nil
+}
+
+func (j *Journal) write(state journalState) error {
+	if err := jsonfile.Write(j.path, state); err != nil {
+		return fmt.Errorf("write journal: %w", err)
+	}
+	if j.OnChange != nil {
+		j.OnChange(state.history())
+	}
+	return nil
+}
+
+type journalOperationKey struct{}
+
+// journalClient is a Dinkur client that records all changes to entries in
+// the journal. Changes made with a context from [App.mutate] are grouped
+// into a single operation, while other changes get an operation each.
+type journalClient struct {
+	dinkur.Client
+	journal *Journal
+}
+
+func (c *journalClient) record(ctx context.Context, description string, steps ...JournalStep) {
+	if op, ok := ctx.Value(journalOperationKey{}).(*JournalOperation); ok {
+		op.Steps = append(op.Steps, steps...)
+		return
+	}
+	if err := c.journal.Record(description, steps); err != nil {
+		log.Warn().WithError(err).Message("Failed to record change in journal.")
+	}
+}
+
+func (c *journalClient) CreateEntry(ctx context.Context, entry dinkur.NewEntry) (dinkur.StartedEntry, error) {
+	active := c.activeEntry(ctx)
+	started, err := c.Client.CreateEntry(ctx, entry)
+	if err != nil {
+		return started, err
+	}
+	var steps []JournalStep
+	if started.Stopped != nil {
+		steps = append(steps, stopStep(active, c.reload(ctx, *started.Stopped)))
+	}
+	created := started.Started
+	steps = append(steps, JournalStep{Kind: JournalStepCreate, After: &created})
+	description := fmt.Sprintf("Start %q", created.Name)
+	if created.End != nil {
+		description = fmt.Sprintf("Add %q", created.Name)
+	}
+	c.record(ctx, description, steps...)
+	return started, nil
+}
+
+func (c *journalClient) UpdateEntry(ctx context.Context, edit dinkur.EditEntry) (dinkur.UpdatedEntry, error) {
+	updated, err := c.Client.UpdateEntry(ctx, edit)
+	if err != nil {
+		return updated, err
+	}
+	before, after := updated.Before, updated.After
+	c.record(ctx, fmt.Sprintf("Edit %q", before.Name),
+		JournalStep{Kind: JournalStepUpdate, Before: &before, After: &after})
+	return updated, nil
+}
+
+func (c *journalClient) DeleteEntry(ctx context.Context, id uint) (dinkur.Entry, error) {
+	deleted, err := c.Client.DeleteEntry(ctx, id)
+	if err != nil {
+		return deleted, err
+	}
+	c.record(ctx, fmt.Sprintf("Delete %q", deleted.Name),
+		JournalStep{Kind: JournalStepDelete, Before: &deleted})
+	return deleted, nil
+}
+
+func (c *journalClient) StopActiveEntry(ctx context.Context, endTime time.Time) (*dinkur.Entry, error) {
+	active := c.activeEntry(ctx)
+	stopped, err := c.Client.StopActiveEntry(ctx, endTime)
+	if err != nil || stopped == nil {
+		return stopped, err
+	}
+	c.record(ctx, fmt.Sprintf("Stop %q", stopped.Name), stopStep(active, c.reload(ctx, *stopped)))
+	return stopped, nil
+}
+
+// activeEntry returns the active entry, or nil if there is none or it could
+// not be read. The Dinkur client only returns stopped entries after they
+// have been stopped, so this is needed to know how they looked before.
+func (c *journalClient) activeEntry(ctx context.Context) *dinkur.Entry {
+	active, err := c.Client.GetActiveEntry(ctx)
+	if err != nil {
+		log.Debug().WithError(err).Message("Failed to get active entry for journal.")
+		return nil
+	}
+	return active
+}
+
+// reload reads the entry again. The Dinkur client returns stopped entries
+// with the update time from before they were stopped, which would make them
+// look changed when undoing.
+func (c *journalClient) reload(ctx context.Context, entry dinkur.Entry) dinkur.Entry {
+	reloaded, err := c.Client.GetEntry(ctx, entry.ID)
+	if err != nil {
+		log.Debug().WithError(err).WithUint("id", entry.ID).Message("Failed to reload entry for journal.")
+		return entry
+	}
+	return reloaded
+}
+
+// stopStep returns the step of stopping the entry. If the entry as it
+// looked before being stopped is unknown, then only the end time is
+// considered changed.
+func stopStep(active *dinkur.Entry, stopped dinkur.Entry) JournalStep {
+	if active == nil || active.ID != stopped.ID {
+		before := stopped
+		before.End = nil
+		active = &before
+	}
+	return JournalStep{Kind: JournalStepUpdate, Before: active, After: &stopped}
+}
+
+// mutate runs fn with a context that groups all changes to entries made
+// with it into a single operation in the journal, so that they are undone
+// together.
+func (a *App) mutate(description string, fn func(ctx context.Context) error) error {
+	op := &JournalOperation{}
+	err := fn(context.WithValue(a.ctx, journalOperationKey{}, op))
+	// Also record failed operations, as their changes may only have been
+	// partially rolled back
+	if jerr := a.journal.Record(description, op.Steps); jerr != nil {
+		log.Warn().WithError(jerr).Message("Failed to record change in journal.")
+	}
+	return err
+}
+
+func (a *App) onJournalChange(history JournalHistory) {
+	runtime.EventsEmit(a.ctx, "journal:changed", history)
+}
+
+// GetJournal returns the changes to entries that can be undone and redone,
+// with the most recent change first.
+func (a *App) GetJournal() (JournalHistory, error) {
+	return a.journal.History()
+}
+
+// Undo reverts the most recent change to entries made through Dinkur
+// desktop. This fails if any of the changed entries have been changed since.
+func (a *App) Undo() (JournalOperation, error) {
+	op, err := a.journal.Undo(a.ctx)
+	if err != nil {
+		return JournalOperation{}, err
+	}
+	log.Info().
+		WithUint("id", op.ID).
+		WithString("description", op.Description).
+		Message("Undid change.")
+	return op, nil
+}
+
+// Redo applies the most recently undone change again. This fails if any of
+// the changed entries have been changed since the change was undone.
+func (a *App) Redo() (JournalOperation, error) {
+	op, err := a.journal.Redo(a.ctx)
+	if err != nil {
+		return JournalOperation{}, err
+	}
+	log.Info().
+		WithUint("id", op.ID).
+		WithString("description", op.Description).
+		Message("Redid change.")
+	return op, nil
+}
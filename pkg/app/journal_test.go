@@ -0,0 +1,222 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dinkur/dinkur/pkg/dinkur"
+)
+
+// failingDeleteClient fails to delete the entry with the given ID.
+type failingDeleteClient struct {
+	dinkur.Entries
+	failID uint
+}
+
+func (c *failingDeleteClient) DeleteEntry(ctx context.Context, id uint) (dinkur.Entry, error) {
+	if id == c.failID {
+		return dinkur.Entry{}, errors.New("database is locked")
+	}
+	return c.Entries.DeleteEntry(ctx, id)
+}
+
+func historyDescriptions(t *testing.T, a *App) (undo, redo []string) {
+	t.Helper()
+	history, err := a.GetJournal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range history.Undo {
+		undo = append(undo, op.Description)
+	}
+	for _, op := range history.Redo {
+		redo = append(redo, op.Description)
+	}
+	return undo, redo
+}
+
+func getTestEntry(t *testing.T, a *App, id uint) *dinkur.Entry {
+	t.Helper()
+	entry, err := a.dinkur.GetEntry(a.ctx, id)
+	if errors.Is(err, dinkur.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &entry
+}
+
+func TestJournalUndoRedo(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	a, _ := newTestApp(t, now)
+	created := createTestEntry(t, a, "Coding", now.Add(-2*time.Hour), timePtr(now.Add(-time.Hour)))
+	newName := "Coding #backend"
+	if _, err := a.dinkur.UpdateEntry(a.ctx, dinkur.EditEntry{IDOrZero: created.ID, Name: &newName}); err != nil {
+		t.Fatal(err)
+	}
+	other := createTestEntry(t, a, "Meeting", now.Add(-time.Hour), timePtr(now))
+	if _, err := a.dinkur.DeleteEntry(a.ctx, other.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{`Delete "Meeting"`, `Add "Meeting"`, `Edit "Coding"`, `Add "Coding"`} {
+		op, err := a.Undo()
+		if err != nil {
+			t.Fatalf("undo %s: %v", want, err)
+		}
+		if op.Description != want {
+			t.Errorf("want to undo %s, got %s", want, op.Description)
+		}
+	}
+	if e := getTestEntry(t, a, created.ID); e != nil {
+		t.Errorf("want %q removed, got %+v", created.Name, e)
+	}
+	if _, err := a.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("want %v, got %v", ErrNothingToUndo, err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, err := a.Redo(); err != nil {
+			t.Fatalf("redo: %v", err)
+		}
+	}
+	if _, err := a.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Errorf("want %v, got %v", ErrNothingToRedo, err)
+	}
+	entries, err := a.dinkur.GetEntryList(a.ctx, dinkur.SearchEntry{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name != newName ||
+		!entries[0].Start.Equal(created.Start) || !entries[0].End.Equal(*created.End) {
+		t.Errorf("want only the edited %q, got %+v", newName, entries)
+	}
+}
+
+func TestJournalUndoRefusesChangedEntry(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	a, _ := newTestApp(t, now)
+	created := createTestEntry(t, a, "Coding", now.Add(-2*time.Hour), timePtr(now.Add(-time.Hour)))
+
+	// Changed outside of Dinkur desktop, so not recorded in the journal.
+	time.Sleep(time.Millisecond)
+	newName := "Coding #backend"
+	if _, err := a.journal.entries.UpdateEntry(a.ctx, dinkur.EditEntry{IDOrZero: created.ID, Name: &newName}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Undo(); !errors.Is(err, ErrEntryChanged) {
+		t.Fatalf("want %v, got %v", ErrEntryChanged, err)
+	}
+	if e := getTestEntry(t, a, created.ID); e == nil || e.Name != newName {
+		t.Errorf("want entry left as %q, got %+v", newName, e)
+	}
+	if undo, redo := historyDescriptions(t, a); len(undo) != 1 || len(redo) != 0 {
+		t.Errorf("want history unchanged, got undo %q and redo %q", undo, redo)
+	}
+}
+
+func TestJournalRemapsRecreatedEntry(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	a, _ := newTestApp(t, now)
+	started := createTestEntry(t, a, "Coding", now.Add(-time.Hour), nil)
+	if _, err := a.dinkur.StopActiveEntry(a.ctx, now); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopening the stopped entry recreates it with a new ID.
+	if _, err := a.Undo(); err != nil {
+		t.Fatalf("undo stop: %v", err)
+	}
+	reopened, err := a.dinkur.GetActiveEntry(a.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened == nil || reopened.ID == started.ID {
+		t.Fatalf("want entry reopened with a new ID, got %+v", reopened)
+	}
+	// The start is undone on the recreated entry.
+	if _, err := a.Undo(); err != nil {
+		t.Fatalf("undo start: %v", err)
+	}
+	if e := getTestEntry(t, a, reopened.ID); e != nil {
+		t.Errorf("want reopened entry removed, got %+v", e)
+	}
+
+	if _, err := a.Redo(); err != nil {
+		t.Fatalf("redo start: %v", err)
+	}
+	if _, err := a.Redo(); err != nil {
+		t.Fatalf("redo stop: %v", err)
+	}
+	entries, err := a.dinkur.GetEntryList(a.ctx, dinkur.SearchEntry{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].End == nil || !entries[0].End.Equal(now) {
+		t.Errorf("want one entry stopped at %v, got %+v", now, entries)
+	}
+}
+
+func TestJournalUndoFailsPartway(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	a, _ := newTestApp(t, now)
+	var first, second dinkur.StartedEntry
+	if err := a.mutate("Add two entries", func(ctx context.Context) error {
+		var err error
+		first, err = a.dinkur.CreateEntry(ctx, dinkur.NewEntry{
+			Name: "Coding", Start: timePtr(now.Add(-2 * time.Hour)), End: timePtr(now.Add(-time.Hour)),
+		})
+		if err != nil {
+			return err
+		}
+		second, err = a.dinkur.CreateEntry(ctx, dinkur.NewEntry{
+			Name: "Meeting", Start: timePtr(now.Add(-time.Hour)), End: timePtr(now),
+		})
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	entries := a.journal.entries
+	a.journal.entries = &failingDeleteClient{Entries: entries, failID: first.Started.ID}
+
+	// The second entry is removed first, and then removing the first fails.
+	if _, err := a.Undo(); err == nil {
+		t.Fatal("want undo to fail")
+	}
+	if getTestEntry(t, a, first.Started.ID) == nil || getTestEntry(t, a, second.Started.ID) != nil {
+		t.Fatalf("want only %q to be removed", second.Started.Name)
+	}
+	history, err := a.GetJournal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Undo) != 1 || len(history.Undo[0].Steps) != 1 || history.Undo[0].Steps[0].After.ID != first.Started.ID {
+		t.Errorf("want adding %q left to undo, got %+v", first.Started.Name, history.Undo)
+	}
+	if len(history.Redo) != 1 || len(history.Redo[0].Steps) != 1 || history.Redo[0].Steps[0].After.ID != second.Started.ID {
+		t.Errorf("want adding %q to redo, got %+v", second.Started.Name, history.Redo)
+	}
+
+	a.journal.entries = entries
+	if _, err := a.Undo(); err != nil {
+		t.Fatalf("undo rest: %v", err)
+	}
+	if getTestEntry(t, a, first.Started.ID) != nil {
+		t.Errorf("want %q removed", first.Started.Name)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := a.Redo(); err != nil {
+			t.Fatalf("redo: %v", err)
+		}
+	}
+	list, err := a.dinkur.GetEntryList(a.ctx, dinkur.SearchEntry{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Errorf("want both entries back, got %+v", list)
+	}
+}
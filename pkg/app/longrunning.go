@@ -0,0 +1,522 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/internal/clock"
+	"github.com/dinkur/dinkur-desktop/internal/jsonfile"
+	"github.com/dinkur/dinkur-desktop/internal/notify"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+	"github.com/dinkur/dinkur/pkg/dinkur"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Errors specific to automatic actions.
+var (
+	ErrAutoActionNotFound      = errors.New("automatic action not found")
+	ErrAutoActionAlreadyUndone = errors.New("automatic action has already been undone")
+	ErrAutoActionNotUndoable   = errors.New("automatic action cannot be undone")
+	ErrEntryChanged            = errors.New("entry has been changed since")
+	ErrOtherEntryActive        = errors.New("another entry is active")
+)
+
+const longRunningActionStop = "stop"
+
+// maxAutoActions is the number of automatic actions that are kept.
+const maxAutoActions = 100
+
+// AutoActionKind is the type of an automatic action.
+type AutoActionKind string
+
+const (
+	// AutoActionWarned means a notification was sent about a long-running
+	// entry.
+	AutoActionWarned AutoActionKind = "warned"
+	// AutoActionStopped means a long-running entry was stopped.
+	AutoActionStopped AutoActionKind = "stopped"
+)
+
+// AutoAction is an action that Dinkur desktop performed automatically on an
+// entry, such as stopping a long-running entry.
+type AutoAction struct {
+	ID     int            `json:"id"`
+	Time   time.Time      `json:"time"`
+	Kind   AutoActionKind `json:"kind"`
+	Reason string         `json:"reason"`
+	// Before is the entry before the action was performed.
+	Before dinkur.Entry `json:"before"`
+	// After is the entry after the action was performed, if it was changed.
+	After  *dinkur.Entry `json:"after"`
+	Undone bool          `json:"undone"`
+	// ReopenedID is the new ID of the entry after the action was undone, as
+	// reopening an entry recreates it.
+	ReopenedID uint `json:"reopenedId,omitempty"`
+}
+
+type autoActionsFile struct {
+	NextID  int          `json:"nextId"`
+	Actions []AutoAction `json:"actions"`
+}
+
+// AutoActionStore persists automatic actions as a JSON file, so that they
+// can be undone after a restart. Only the most recent actions are kept.
+type AutoActionStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewAutoActionStore creates a new automatic action store that persists to
+// the given file.
+func NewAutoActionStore(path string) *AutoActionStore {
+	return &AutoActionStore{path: path}
+}
+
+// Add records a new action, and returns it with its ID set.
+func (s *AutoActionStore) Add(action AutoAction) (AutoAction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, err := s.read()
+	if err != nil {
+		return AutoAction{}, err
+	}
+	action.ID = state.NextID
+	state.NextID++
+	state.Actions = append(state.Actions, action)
+	if len(state.Actions) > maxAutoActions {
+		state.Actions = state.Actions[len(state.Actions)-maxAutoActions:]
+	}
+	return action, jsonfile.Write(s.path, state)
+}
+
+// List returns all recorded actions, oldest first.
+func (s *AutoActionStore) List() ([]AutoAction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return state.Actions, nil
+}
+
+// Get returns the action with the given ID.
+func (s *AutoActionStore) Get(id int) (AutoAction, error) {
+	actions, err := s.List()
+	if err != nil {
+		return AutoAction{}, err
+	}
+	for _, action := range actions {
+		if action.ID == id {
+			return action, nil
+		}
+	}
+	return AutoAction{}, ErrAutoActionNotFound
+}
+
+// MarkUndone records that the action with the given ID has been undone, and
+// the new ID of the reopened entry.
+func (s *AutoActionStore) MarkUndone(id int, reopenedID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, err := s.read()
+	if err != nil {
+		return err
+	}
+	for i := range state.Actions {
+		if state.Actions[i].ID == id {
+			state.Actions[i].Undone = true
+			state.Actions[i].ReopenedID = reopenedID
+			return jsonfile.Write(s.path, state)
+		}
+	}
+	return ErrAutoActionNotFound
+}
+
+// Remap updates the actions on an entry that has been recreated with a new
+// ID. Snapshots that matched the old entry are updated to match the new
+// entry, so that the entry isn't considered changed when undoing.
+func (s *AutoActionStore) Remap(old, recreated dinkur.Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, err := s.read()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for i := range state.Actions {
+		action := &state.Actions[i]
+		if action.Before.ID == old.ID {
+			action.Before.ID = recreated.ID
+			changed = true
+		}
+		if action.After != nil && action.After.ID == old.ID {
+			action.After.ID = recreated.ID
+			if action.After.UpdatedAt.Equal(old.UpdatedAt) {
+				action.After.UpdatedAt = recreated.UpdatedAt
+			}
+			changed = true
+		}
+		if action.ReopenedID == old.ID {
+			action.ReopenedID = recreated.ID
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return jsonfile.Write(s.path, state)
+}
+
+func (s *AutoActionStore) read() (autoActionsFile, error) {
+	state := autoActionsFile{NextID: 1}
+	if err := jsonfile.Read(s.path, &state); err != nil {
+		return autoActionsFile{}, fmt.Errorf("read automatic actions: %w", err)
+	}
+	return state, nil
+}
+
+// LongRunningWatcher warns about and automatically stops entries that have
+// been running for too long.
+type LongRunningWatcher struct {
+	entries  dinkur.Entries
+	notifier notify.Notifier
+	clock    clock.Clock
+	cfg      config.LongRunning
+	// loc is the time zone of the auto-stop time of day.
+	loc *time.Location
+
+	// LastActive returns the last time the user was active. Used when
+	// backdating automatically stopped entries.
+	LastActive func() time.Time
+	// OnAction is called after each automatic action.
+	OnAction func(AutoAction)
+
+	mu       sync.Mutex
+	warnedID uint
+	ignored  map[uint]bool
+}
+
+// NewLongRunningWatcher creates a new long-running entry watcher. The
+// auto-stop time of day is in the given time zone.
+func NewLongRunningWatcher(entries dinkur.Entries, notifier notify.Notifier, clk clock.Clock, cfg config.LongRunning, loc *time.Location) *LongRunningWatcher {
+	return &LongRunningWatcher{
+		entries:  entries,
+		notifier: notifier,
+		clock:    clk,
+		cfg:      cfg,
+		loc:      loc,
+		ignored:  map[uint]bool{},
+	}
+}
+
+// Ignore makes the watcher never automatically stop the entry with the
+// given ID. Used when the user has undone an automatic stop.
+func (w *LongRunningWatcher) Ignore(id uint) {
+	w.mu.Lock()
+	w.ignored[id] = true
+	w.mu.Unlock()
+}
+
+// Remap updates the watcher's references to an entry that has been
+// recreated with a new ID, so that an ignored entry stays ignored and is not
+// warned about again.
+func (w *LongRunningWatcher) Remap(oldID, newID uint) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.ignored[oldID] {
+		delete(w.ignored, oldID)
+		w.ignored[newID] = true
+	}
+	if w.warnedID == oldID {
+		w.warnedID = newID
+	}
+}
+
+// Run checks the active entry on the given interval until the context is
+// cancelled.
+func (w *LongRunningWatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := w.clock.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			if err := w.Check(ctx); err != nil {
+				log.Warn().WithError(err).Message("Failed to check long-running entry.")
+			}
+		}
+	}
+}
+
+// Check warns about or stops the active entry if it has been running for
+// too long.
+func (w *LongRunningWatcher) Check(ctx context.Context) error {
+	active, err := w.entries.GetActiveEntry(ctx)
+	if err != nil {
+		return fmt.Errorf("get active entry: %w", err)
+	}
+	if active == nil {
+		return nil
+	}
+	now := w.clock.Now()
+	w.mu.Lock()
+	ignored := w.ignored[active.ID]
+	w.mu.Unlock()
+	if stopAt, reason, ok := w.autoStopTime(*active, now); ok && !ignored {
+		return w.stop(ctx, *active, stopAt, reason)
+	}
+	elapsed := now.Sub(active.Start)
+	if w.cfg.WarnAfter > 0 && elapsed >= w.cfg.WarnAfter {
+		w.mu.Lock()
+		alreadyWarned := w.warnedID == active.ID
+		w.warnedID = active.ID
+		w.mu.Unlock()
+		if !alreadyWarned {
+			w.warn(ctx, *active, elapsed)
+		}
+	}
+	return nil
+}
+
+func (w *LongRunningWatcher) autoStopTime(entry dinkur.Entry, now time.Time) (time.Time, string, bool) {
+	var (
+		stopAt time.Time
+		reason string
+	)
+	if w.cfg.AutoStop.At.IsSet() {
+		local := now.In(w.loc)
+		at := w.cfg.AutoStop.At.On(local)
+		if at.After(now) {
+			at = w.cfg.AutoStop.At.On(local.AddDate(0, 0, -1))
+		}
+		if at.After(entry.Start) {
+			stopAt = at
+			reason = fmt.Sprintf("Entry was still running at %s.", w.cfg.AutoStop.At)
+		}
+	}
+	if w.cfg.AutoStop.MaxDuration > 0 {
+		maxEnd := entry.Start.Add(w.cfg.AutoStop.MaxDuration)
+		if !maxEnd.After(now) && (stopAt.IsZero() || maxEnd.Before(stopAt)) {
+			stopAt = maxEnd
+			reason = fmt.Sprintf("Entry ran for longer than %s.", w.cfg.AutoStop.MaxDuration)
+		}
+	}
+	if stopAt.IsZero() {
+		return time.Time{}, "", false
+	}
+	if w.cfg.AutoStop.BackdateToLastActive && w.LastActive != nil {
+		lastActive := w.LastActive()
+		if lastActive.After(entry.Start) && lastActive.Before(stopAt) {
+			stopAt = lastActive
+			reason += " End time was set to when you were last active."
+		}
+	}
+	return stopAt, reason, true
+}
+
+func (w *LongRunningWatcher) stop(ctx context.Context, entry dinkur.Entry, stopAt time.Time, reason string) error {
+	stopped, err := w.entries.StopActiveEntry(ctx, stopAt)
+	if err != nil {
+		return fmt.Errorf("auto-stop entry: %w", err)
+	}
+	if stopped != nil {
+		// Record the entry as stored, as its updated time is compared when
+		// undoing the action.
+		stored, err := w.entries.GetEntry(ctx, stopped.ID)
+		if err != nil {
+			return fmt.Errorf("get auto-stopped entry: %w", err)
+		}
+		stopped = &stored
+	}
+	log.Info().
+		WithUint("entry", entry.ID).
+		WithString("name", entry.Name).
+		WithTime("end", stopAt).
+		WithString("reason", reason).
+		Message("Automatically stopped long-running entry.")
+	w.notify(notify.Notification{
+		Summary: "Stopped long-running entry",
+		Body:    fmt.Sprintf("%q was stopped automatically. %s", entry.Name, reason),
+	})
+	w.emit(AutoAction{
+		Time:   w.clock.Now(),
+		Kind:   AutoActionStopped,
+		Reason: reason,
+		Before: entry,
+		After:  stopped,
+	})
+	return nil
+}
+
+func (w *LongRunningWatcher) warn(ctx context.Context, entry dinkur.Entry, elapsed time.Duration) {
+	reason := fmt.Sprintf("Entry has been running for %s.", elapsed.Truncate(time.Minute))
+	log.Info().
+		WithUint("entry", entry.ID).
+		WithString("name", entry.Name).
+		WithDuration("elapsed", elapsed).
+		Message("Warning about long-running entry.")
+	id := entry.ID
+	w.notify(notify.Notification{
+		Summary: "Long-running entry",
+		Body:    fmt.Sprintf("%q %s", entry.Name, reason),
+		Actions: []notify.Action{
+			{Key: longRunningActionStop, Label: "Stop now"},
+		},
+		OnAction: func(key string) {
+			if key != longRunningActionStop {
+				return
+			}
+			active, err := w.entries.GetActiveEntry(ctx)
+			if err != nil || active == nil || active.ID != id {
+				return
+			}
+			// The user chose to stop it, so it's a manual stop, and not an
+			// automatic action to be undone.
+			if _, err := w.entries.StopActiveEntry(ctx, w.clock.Now()); err != nil {
+				log.Warn().WithError(err).Message("Failed to stop entry from notification.")
+				return
+			}
+			log.Info().
+				WithUint("entry", active.ID).
+				WithString("name", active.Name).
+				Message("Stopped long-running entry from notification.")
+		},
+	})
+	w.emit(AutoAction{
+		Time:   w.clock.Now(),
+		Kind:   AutoActionWarned,
+		Reason: reason,
+		Before: entry,
+	})
+}
+
+func (w *LongRunningWatcher) notify(n notify.Notification) {
+	if _, err := w.notifier.Notify(n); err != nil {
+		log.Debug().WithError(err).Message("Failed to send long-running entry notification.")
+	}
+}
+
+func (w *LongRunningWatcher) emit(action AutoAction) {
+	if w.OnAction != nil {
+		w.OnAction(action)
+	}
+}
+
+func (a *App) startLongRunningWatcher(ctx context.Context) {
+	cfg := a.cfg.LongRunning
+	if cfg.WarnAfter <= 0 && !cfg.AutoStop.At.IsSet() && cfg.AutoStop.MaxDuration <= 0 {
+		return
+	}
+	w := NewLongRunningWatcher(a.dinkur, a.notifier, a.clock, cfg, a.calendar.Location())
+	w.LastActive = a.lastActive
+	w.OnAction = a.addAutoAction
+	// Keep the entries that were reopened before a restart running.
+	actions, err := a.autoActions.List()
+	if err != nil {
+		log.Warn().WithError(err).Message("Failed to read automatic actions.")
+	}
+	for _, action := range actions {
+		if action.ReopenedID != 0 {
+			w.Ignore(action.ReopenedID)
+		}
+	}
+	a.longRunning = w
+	go w.Run(ctx, cfg.CheckInterval)
+}
+
+// lastActive returns the last time the user was seen active, falling back
+// to now if idle detection is unavailable.
+func (a *App) lastActive() time.Time {
+	if a.idleDetector != nil {
+		return a.idleDetector.LastActive()
+	}
+	return a.clock.Now()
+}
+
+func (a *App) addAutoAction(action AutoAction) {
+	action, err := a.autoActions.Add(action)
+	if err != nil {
+		log.Warn().WithError(err).Message("Failed to record automatic action.")
+		return
+	}
+	runtime.EventsEmit(a.ctx, "autoaction:added", action)
+}
+
+// GetAutoActions returns the most recent actions that Dinkur desktop has
+// performed automatically, such as stopping long-running entries.
+func (a *App) GetAutoActions() ([]AutoAction, error) {
+	return a.autoActions.List()
+}
+
+// UndoAutoAction reverts an automatically stopped entry back to being active.
+// This fails if the entry has been changed since, or if another entry has
+// been started.
+func (a *App) UndoAutoAction(id int) (*dinkur.Entry, error) {
+	a.autoActionsMutex.Lock()
+	defer a.autoActionsMutex.Unlock()
+	action, err := a.autoActions.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if action.Undone {
+		return nil, ErrAutoActionAlreadyUndone
+	}
+	if action.Kind != AutoActionStopped || action.After == nil {
+		return nil, ErrAutoActionNotUndoable
+	}
+	current, err := a.dinkur.GetEntry(a.ctx, action.After.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get stopped entry: %w", err)
+	}
+	if !current.UpdatedAt.Equal(action.After.UpdatedAt) {
+		return nil, ErrEntryChanged
+	}
+	var reopened *dinkur.Entry
+	if err := a.mutate(fmt.Sprintf("Reopen %q", current.Name), func(ctx context.Context) error {
+		reopened, err = a.reopenEntry(ctx, current)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if err := a.autoActions.MarkUndone(id, reopened.ID); err != nil {
+		log.Warn().WithError(err).Message("Failed to record undone automatic action.")
+	}
+	if a.longRunning != nil {
+		a.longRunning.Ignore(reopened.ID)
+	}
+	log.Info().
+		WithUint("entry", reopened.ID).
+		WithString("name", reopened.Name).
+		Message("Undid automatic stop of entry.")
+	return reopened, nil
+}
+
+// reopenEntry turns a stopped entry back into the active entry. As the
+// Dinkur client cannot unset an entry's end time, this is done by deleting
+// the entry and creating it again, which gives it a new ID.
+func (a *App) reopenEntry(ctx context.Context, entry dinkur.Entry) (*dinkur.Entry, error) {
+	active, err := a.dinkur.GetActiveEntry(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get active entry: %w", err)
+	}
+	if active != nil {
+		return nil, ErrOtherEntryActive
+	}
+	if _, err := a.dinkur.DeleteEntry(ctx, entry.ID); err != nil {
+		return nil, fmt.Errorf("delete stopped entry: %w", err)
+	}
+	started, err := a.dinkur.CreateEntry(ctx, dinkur.NewEntry{
+		Name:  entry.Name,
+		Start: &entry.Start,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("recreate entry as active: %w", err)
+	}
+	a.entryRecreated(entry, started.Started)
+	return &started.Started, nil
+}
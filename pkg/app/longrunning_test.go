@@ -0,0 +1,186 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/internal/notify"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+	"github.com/dinkur/dinkur/pkg/dinkur"
+)
+
+type recordingNotifier struct {
+	mu            sync.Mutex
+	notifications []notify.Notification
+}
+
+func (n *recordingNotifier) Notify(notification notify.Notification) (notify.ID, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.notifications = append(n.notifications, notification)
+	return notify.ID(len(n.notifications)), nil
+}
+
+func (n *recordingNotifier) Close(notify.ID) error {
+	return nil
+}
+
+func TestLongRunningStopFromNotificationIsManual(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	a, clk := newTestApp(t, now)
+	entry := createTestEntry(t, a, "Coding", now.Add(-2*time.Hour), nil)
+	notifier := &recordingNotifier{}
+	w := NewLongRunningWatcher(a.dinkur, notifier, clk, config.LongRunning{WarnAfter: time.Hour}, time.UTC)
+	var actions []AutoAction
+	w.OnAction = func(action AutoAction) { actions = append(actions, action) }
+
+	if err := w.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(notifier.notifications) != 1 || len(actions) != 1 || actions[0].Kind != AutoActionWarned {
+		t.Fatalf("want one warning, got %d notifications and actions %+v", len(notifier.notifications), actions)
+	}
+
+	clk.Advance(time.Minute)
+	notifier.notifications[0].OnAction(longRunningActionStop)
+	stopped, err := a.dinkur.GetEntry(a.ctx, entry.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stopped.End == nil || !stopped.End.Equal(clk.Now()) {
+		t.Errorf("want entry stopped at %v, got %v", clk.Now(), stopped.End)
+	}
+	if len(actions) != 1 {
+		t.Errorf("want no automatic action for stop from notification, got %+v", actions[1:])
+	}
+}
+
+func TestAutoActionStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auto-actions.json")
+	s := NewAutoActionStore(path)
+	for i := 0; i < maxAutoActions+5; i++ {
+		action, err := s.Add(AutoAction{Kind: AutoActionWarned})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if action.ID != i+1 {
+			t.Fatalf("want ID %d, got %d", i+1, action.ID)
+		}
+	}
+
+	// A new store, as after a restart, reads the same actions.
+	s = NewAutoActionStore(path)
+	actions, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != maxAutoActions || actions[0].ID != 6 {
+		t.Fatalf("want the %d most recent actions starting at ID 6, got %d starting at %d",
+			maxAutoActions, len(actions), actions[0].ID)
+	}
+	if _, err := s.Get(1); !errors.Is(err, ErrAutoActionNotFound) {
+		t.Errorf("want %v for trimmed action, got %v", ErrAutoActionNotFound, err)
+	}
+	if err := s.MarkUndone(10, 42); err != nil {
+		t.Fatal(err)
+	}
+	action, err := s.Get(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !action.Undone || action.ReopenedID != 42 {
+		t.Errorf("want action undone with reopened ID 42, got %+v", action)
+	}
+}
+
+func TestUndoAutoActionAfterRestart(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	a, clk := newTestApp(t, now)
+	entry := createTestEntry(t, a, "Coding", now.Add(-10*time.Hour), nil)
+	w := NewLongRunningWatcher(a.dinkur, notify.Discard, clk, config.LongRunning{
+		AutoStop: config.AutoStop{MaxDuration: 8 * time.Hour},
+	}, time.UTC)
+	w.OnAction = func(action AutoAction) {
+		if _, err := a.autoActions.Add(action); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	a.autoActions = NewAutoActionStore(a.autoActions.path)
+	actions, err := a.GetAutoActions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 1 || actions[0].Kind != AutoActionStopped {
+		t.Fatalf("want one stop action, got %+v", actions)
+	}
+	reopened, err := a.UndoAutoAction(actions[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.End != nil || !reopened.Start.Equal(entry.Start) {
+		t.Errorf("want entry reopened from %v, got %+v", entry.Start, reopened)
+	}
+	if _, err := a.UndoAutoAction(actions[0].ID); !errors.Is(err, ErrAutoActionAlreadyUndone) {
+		t.Errorf("want %v, got %v", ErrAutoActionAlreadyUndone, err)
+	}
+}
+
+func TestLongRunningAutoStopAtUsesTimeZone(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+	// 20:30 in New York, where the entry started at 09:00.
+	now := time.Date(2023, 3, 2, 1, 30, 0, 0, time.UTC)
+	a, clk := newTestApp(t, now)
+	entry := createTestEntry(t, a, "Coding", time.Date(2023, 3, 1, 9, 0, 0, 0, newYork), nil)
+	w := NewLongRunningWatcher(a.dinkur, notify.Discard, clk, config.LongRunning{
+		AutoStop: config.AutoStop{At: config.NewTimeOfDay(20, 0)},
+	}, newYork)
+	if err := w.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	stopped, err := a.dinkur.GetEntry(a.ctx, entry.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2023, 3, 1, 20, 0, 0, 0, newYork)
+	if stopped.End == nil || !stopped.End.Equal(want) {
+		t.Errorf("want entry stopped at %v, got %v", want, stopped.End)
+	}
+}
+
+func TestAutoActionStoreRemap(t *testing.T) {
+	s := NewAutoActionStore(filepath.Join(t.TempDir(), "auto-actions.json"))
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	before := dinkur.Entry{Name: "Coding"}
+	before.ID = 1
+	before.UpdatedAt = now
+	after := before
+	after.End = &now
+	action, err := s.Add(AutoAction{Kind: AutoActionStopped, Before: before, After: &after})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recreated := after
+	recreated.ID = 2
+	recreated.UpdatedAt = now.Add(time.Minute)
+	if err := s.Remap(after, recreated); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Get(action.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Before.ID != 2 || got.After.ID != 2 || !got.After.UpdatedAt.Equal(recreated.UpdatedAt) {
+		t.Errorf("want action remapped to entry 2 updated at %v, got %+v", recreated.UpdatedAt, got)
+	}
+}
@@ -0,0 +1,445 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/internal/clock"
+	"github.com/dinkur/dinkur-desktop/internal/jsonfile"
+	"github.com/dinkur/dinkur-desktop/internal/notify"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+	"github.com/dinkur/dinkur-desktop/pkg/ics"
+	"github.com/dinkur/dinkur/pkg/dinkur"
+)
+
+// Errors specific to meeting suggestions.
+var (
+	ErrMeetingNotFound   = errors.New("meeting not found")
+	ErrMeetingNotStarted = errors.New("meeting has not started yet")
+)
+
+const (
+	meetingCheckInterval = time.Minute
+	// meetingStateKeep is how long accepted and dismissed meetings are
+	// remembered after they started.
+	meetingStateKeep = 90 * 24 * time.Hour
+	// maxCalendarSize limits how much is downloaded from a calendar URL.
+	maxCalendarSize = 32 * 1024 * 1024
+)
+
+// MeetingStatus is whether a meeting has been turned into an entry.
+type MeetingStatus string
+
+const (
+	MeetingPending   MeetingStatus = "pending"
+	MeetingAccepted  MeetingStatus = "accepted"
+	MeetingDismissed MeetingStatus = "dismissed"
+)
+
+// MeetingSuggestion is an entry proposed from a calendar event.
+type MeetingSuggestion struct {
+	// ID identifies the meeting, based on the event's UID and start time.
+	ID string `json:"id"`
+	// Name is the name of the entry, i.e the event's summary followed by the
+	// meeting tag.
+	Name     string    `json:"name"`
+	Summary  string    `json:"summary"`
+	Location string    `json:"location"`
+	Calendar string    `json:"calendar"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	// Transparent is set if the event does not block time in the calendar.
+	Transparent bool `json:"transparent"`
+}
+
+// MeetingSuggestions are the meetings of a day that have neither been
+// accepted nor dismissed.
+type MeetingSuggestions struct {
+	Suggestions []MeetingSuggestion `json:"suggestions"`
+	// Errors are the calendars that could not be read.
+	Errors []string `json:"errors"`
+}
+
+type handledMeeting struct {
+	Status MeetingStatus
+	Start  time.Time
+}
+
+type meetingState struct {
+	// Handled are the accepted and dismissed meetings, by ID.
+	Handled map[string]handledMeeting
+}
+
+type calendarDownload struct {
+	events     []ics.Event
+	downloaded time.Time
+}
+
+// MeetingCalendars reads the meetings of the configured calendars, and
+// remembers which meetings have been accepted or dismissed.
+type MeetingCalendars struct {
+	path   string
+	cfg    config.Meetings
+	clock  clock.Clock
+	loc    *time.Location
+	client *http.Client
+
+	mu        sync.Mutex
+	downloads map[string]calendarDownload
+}
+
+// NewMeetingCalendars creates a new set of meeting calendars that keeps the
+// accepted and dismissed meetings in the file at the path. Times without a
+// time zone in the calendars are read in the given location.
+func NewMeetingCalendars(path string, cfg config.Meetings, clk clock.Clock, loc *time.Location) *MeetingCalendars {
+	return &MeetingCalendars{
+		path:      path,
+		cfg:       cfg,
+		clock:     clk,
+		loc:       loc,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		downloads: map[string]calendarDownload{},
+	}
+}
+
+// Meetings returns the meetings of all calendars between from and to, with
+// their status. Meetings from calendars that could be read are returned
+// together with the errors of the others.
+func (m *MeetingCalendars) Meetings(ctx context.Context, from, to time.Time) ([]MeetingSuggestion, map[string]MeetingStatus, error) {
+	var (
+		meetings []MeetingSuggestion
+		errs     []error
+		seen     = map[string]bool{}
+	)
+	for _, cal := range m.cfg.Calendars {
+		events, err := m.events(ctx, cal)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("read calendar %q: %w", cal, err))
+			continue
+		}
+		for _, o := range ics.Expand(events, from, to) {
+			if o.AllDay {
+				continue
+			}
+			id := meetingID(o)
+			// The same meeting may be in several calendars.
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			meetings = append(meetings, MeetingSuggestion{
+				ID:          id,
+				Summary:     o.Summary,
+				Location:    o.Location,
+				Calendar:    cal,
+				Start:       o.Start,
+				End:         o.End,
+				Transparent: o.Transparent,
+			})
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state, err := m.read()
+	if err != nil {
+		return nil, nil, err
+	}
+	statuses := make(map[string]MeetingStatus, len(meetings))
+	for _, meeting := range meetings {
+		statuses[meeting.ID] = MeetingPending
+		if handled, ok := state.Handled[meeting.ID]; ok {
+			statuses[meeting.ID] = handled.Status
+		}
+	}
+	return meetings, statuses, errors.Join(errs...)
+}
+
+// SetStatus remembers the meeting as accepted or dismissed, so that it's no
+// longer suggested.
+func (m *MeetingCalendars) SetStatus(meeting MeetingSuggestion, status MeetingStatus) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state, err := m.read()
+	if err != nil {
+		return err
+	}
+	state.Handled[meeting.ID] = handledMeeting{Status: status, Start: meeting.Start}
+	keepAfter := m.clock.Now().Add(-meetingStateKeep)
+	for id, handled := range state.Handled {
+		if handled.Start.Before(keepAfter) {
+			delete(state.Handled, id)
+		}
+	}
+	return jsonfile.Write(m.path, state)
+}
+
+func (m *MeetingCalendars) read() (meetingState, error) {
+	state := meetingState{}
+	if err := jsonfile.Read(m.path, &state); err != nil {
+		return meetingState{}, fmt.Errorf("read meeting state: %w", err)
+	}
+	if state.Handled == nil {
+		state.Handled = map[string]handledMeeting{}
+	}
+	return state, nil
+}
+
+// events reads the events of a calendar. Calendars from URLs are downloaded
+// again after the refresh interval, and the previous download is used if
+// downloading fails.
+func (m *MeetingCalendars) events(ctx context.Context, cal string) ([]ics.Event, error) {
+	u, err := url.Parse(cal)
+	if err != nil || len(u.Scheme) <= 1 {
+		// Not a URL, or a path with a drive letter on Windows.
+		return m.readFile(cal)
+	}
+	m.mu.Lock()
+	download, ok := m.downloads[cal]
+	m.mu.Unlock()
+	if ok && m.clock.Now().Sub(download.downloaded) < m.cfg.RefreshInterval {
+		return download.events, nil
+	}
+	events, err := m.download(ctx, u)
+	if err != nil {
+		if ok {
+			log.Warn().WithError(err).
+				WithString("calendar", cal).
+				Message("Failed to download calendar. Using the previous download.")
+			return download.events, nil
+		}
+		return nil, err
+	}
+	m.mu.Lock()
+	m.downloads[cal] = calendarDownload{events: events, downloaded: m.clock.Now()}
+	m.mu.Unlock()
+	return events, nil
+}
+
+func (m *MeetingCalendars) readFile(path string) ([]ics.Event, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return m.parse(path, file)
+}
+
+func (m *MeetingCalendars) download(ctx context.Context, u *url.URL) ([]ics.Event, error) {
+	if u.Scheme == "webcal" {
+		https := *u
+		https.Scheme = "https"
+		u = &https
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "dinkur-desktop")
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return m.parse(u.Redacted(), io.LimitReader(resp.Body, maxCalendarSize))
+}
+
+// parse reads the events, and only logs events that can't be read so that
+// the rest of the calendar can still be used.
+func (m *MeetingCalendars) parse(name string, r io.Reader) ([]ics.Event, error) {
+	events, err := ics.Parse(r, m.loc)
+	if err != nil && events == nil {
+		return nil, err
+	}
+	if err != nil {
+		log.Debug().WithError(err).
+			WithString("calendar", name).
+			Message("Skipped some events in calendar.")
+	}
+	return events, nil
+}
+
+func meetingID(o ics.Occurrence) string {
+	uid := o.UID
+	if uid == "" {
+		uid = o.Summary
+	}
+	return fmt.Sprintf("%s/%d", uid, o.Start.Unix())
+}
+
+// GetMeetingSuggestions returns the meetings on the calendar day that
+// contains the given time, that have neither been accepted nor dismissed.
+func (a *App) GetMeetingSuggestions(day time.Time) (MeetingSuggestions, error) {
+	result := MeetingSuggestions{
+		Suggestions: []MeetingSuggestion{},
+		Errors:      []string{},
+	}
+	start, end := a.calendar.Day(day)
+	meetings, statuses, err := a.meetings.Meetings(a.ctx, start, end)
+	if statuses == nil && err != nil {
+		return result, err
+	}
+	if err != nil {
+		log.Warn().WithError(err).Message("Failed to read some calendars.")
+		result.Errors = append(result.Errors, err.Error())
+	}
+	for _, meeting := range meetings {
+		if statuses[meeting.ID] == MeetingPending {
+			result.Suggestions = append(result.Suggestions, a.toMeetingSuggestion(meeting))
+		}
+	}
+	return result, nil
+}
+
+// AcceptMeetingSuggestion adds an entry for a meeting suggested by
+// [App.GetMeetingSuggestions]. The entry is started if the meeting is still
+// ongoing.
+func (a *App) AcceptMeetingSuggestion(day time.Time, id string) (*Entry, error) {
+	meeting, err := a.findMeeting(day, id)
+	if err != nil {
+		return nil, err
+	}
+	now := a.clock.Now()
+	if meeting.Start.After(now) {
+		return nil, ErrMeetingNotStarted
+	}
+	var saved dinkur.Entry
+	err = a.mutate(fmt.Sprintf("Add %q from calendar", meeting.Name), func(ctx context.Context) error {
+		if meeting.End.After(now) {
+			started, err := a.dinkur.CreateEntry(ctx, dinkur.NewEntry{
+				Name:  meeting.Name,
+				Start: &meeting.Start,
+			})
+			saved = started.Started
+			return err
+		}
+		saved, err = a.createPastEntry(ctx, meeting.Name, meeting.Start, meeting.End)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := a.meetings.SetStatus(meeting, MeetingAccepted); err != nil {
+		log.Warn().WithError(err).Message("Failed to remember accepted meeting.")
+	}
+	log.Info().WithString("meeting", id).Message("Added entry from calendar.")
+	return a.toEntryPtr(&saved), nil
+}
+
+// DismissMeetingSuggestion stops suggesting the meeting, and from starting
+// it automatically.
+func (a *App) DismissMeetingSuggestion(day time.Time, id string) error {
+	meeting, err := a.findMeeting(day, id)
+	if err != nil {
+		return err
+	}
+	return a.meetings.SetStatus(meeting, MeetingDismissed)
+}
+
+func (a *App) findMeeting(day time.Time, id string) (MeetingSuggestion, error) {
+	suggestions, err := a.GetMeetingSuggestions(day)
+	if err != nil {
+		return MeetingSuggestion{}, err
+	}
+	for _, meeting := range suggestions.Suggestions {
+		if meeting.ID == id {
+			return meeting, nil
+		}
+	}
+	return MeetingSuggestion{}, fmt.Errorf("%w: %q", ErrMeetingNotFound, id)
+}
+
+func (a *App) toMeetingSuggestion(meeting MeetingSuggestion) MeetingSuggestion {
+	meeting.Name = strings.TrimSpace(meeting.Summary)
+	if a.cfg.Meetings.Tag != "" {
+		if tag := a.nameParser.FormatTag(a.cfg.Meetings.Tag); tag != "" {
+			meeting.Name += " " + tag
+		}
+	}
+	meeting.Start = meeting.Start.In(a.calendar.Location())
+	meeting.End = meeting.End.In(a.calendar.Location())
+	return meeting
+}
+
+func (a *App) startMeetingWatcher(ctx context.Context) {
+	if !a.cfg.Meetings.AutoStart || len(a.cfg.Meetings.Calendars) == 0 {
+		return
+	}
+	go func() {
+		ticker := a.clock.NewTicker(meetingCheckInterval)
+		defer ticker.Stop()
+		// Also start meetings that started just before the app did.
+		since := a.clock.Now().Add(-meetingCheckInterval)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+				now := a.clock.Now()
+				if err := a.autoStartMeeting(ctx, since, now); err != nil {
+					log.Warn().WithError(err).Message("Failed to start entry from calendar.")
+				}
+				since = now
+			}
+		}
+	}()
+}
+
+// autoStartMeeting starts an entry for the last meeting that started after
+// since, unless it has been dismissed. Meetings that don't block time in the
+// calendar are not started.
+func (a *App) autoStartMeeting(ctx context.Context, since, now time.Time) error {
+	meetings, statuses, err := a.meetings.Meetings(ctx, since, now)
+	if err != nil {
+		log.Warn().WithError(err).Message("Failed to read some calendars.")
+	}
+	var meeting *MeetingSuggestion
+	for i, m := range meetings {
+		if m.Start.After(since) && !m.Start.After(now) && !m.Transparent &&
+			statuses[m.ID] == MeetingPending {
+			meeting = &meetings[i]
+		}
+	}
+	if meeting == nil {
+		return nil
+	}
+	*meeting = a.toMeetingSuggestion(*meeting)
+	active, err := a.dinkur.GetActiveEntry(ctx)
+	if err != nil {
+		return fmt.Errorf("get active entry: %w", err)
+	}
+	if active != nil && active.Name == meeting.Name {
+		return nil
+	}
+	err = a.mutate(fmt.Sprintf("Start %q from calendar", meeting.Name), func(ctx context.Context) error {
+		_, err := a.dinkur.CreateEntry(ctx, dinkur.NewEntry{
+			Name:  meeting.Name,
+			Start: &meeting.Start,
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := a.meetings.SetStatus(*meeting, MeetingAccepted); err != nil {
+		log.Warn().WithError(err).Message("Failed to remember accepted meeting.")
+	}
+	log.Info().WithString("meeting", meeting.ID).Message("Started entry from calendar.")
+	if _, err := a.notifier.Notify(notify.Notification{
+		Summary: "Meeting started",
+		Body:    fmt.Sprintf("Started %q from your calendar.", meeting.Name),
+	}); err != nil {
+		log.Warn().WithError(err).Message("Failed to send notification.")
+	}
+	return nil
+}
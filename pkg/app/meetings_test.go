@@ -0,0 +1,114 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/internal/clock"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+)
+
+const testCalendar = "BEGIN:VCALENDAR\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup\r\n" +
+	"SUMMARY:Standup\r\n" +
+	"DTSTART;TZID=Europe/Stockholm:20240102T091500\r\n" +
+	"DTEND;TZID=Europe/Stockholm:20240102T093000\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:holiday\r\n" +
+	"SUMMARY:Holiday\r\n" +
+	"DTSTART;VALUE=DATE:20240103\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestMeetingCalendarsDownload(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		requests  int
+		available = true
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		if !available {
+			http.Error(w, "maintenance", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/calendar")
+		w.Write([]byte(testCalendar))
+	}))
+	defer srv.Close()
+	requested := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+
+	clk := clock.NewFake(time.Date(2024, 1, 3, 8, 0, 0, 0, time.UTC))
+	cfg := config.Default.Meetings
+	cfg.Calendars = []string{srv.URL + "/calendar.ics"}
+	cfg.RefreshInterval = 15 * time.Minute
+	m := NewMeetingCalendars(filepath.Join(t.TempDir(), "meetings.json"), cfg, clk, time.UTC)
+
+	from := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+	meetings := func() []MeetingSuggestion {
+		t.Helper()
+		meetings, _, err := m.Meetings(context.Background(), from, from.AddDate(0, 0, 1))
+		if err != nil {
+			t.Fatalf("meetings: %v", err)
+		}
+		return meetings
+	}
+
+	got := meetings()
+	if len(got) != 1 {
+		t.Fatalf("want 1 meeting, got %+v", got)
+	}
+	if got[0].Summary != "Standup" || !got[0].Start.Equal(time.Date(2024, 1, 3, 8, 15, 0, 0, time.UTC)) {
+		t.Errorf("unexpected meeting: %+v", got[0])
+	}
+
+	meetings()
+	if n := requested(); n != 1 {
+		t.Errorf("want the download to be reused within the refresh interval, got %d requests", n)
+	}
+
+	// The previous download is used if the calendar can't be downloaded.
+	mu.Lock()
+	available = false
+	mu.Unlock()
+	clk.Advance(cfg.RefreshInterval)
+	if got := meetings(); len(got) != 1 {
+		t.Errorf("want 1 meeting from the previous download, got %+v", got)
+	}
+	if n := requested(); n != 2 {
+		t.Errorf("want the calendar to be downloaded again, got %d requests", n)
+	}
+}
+
+func TestMeetingCalendarsDownloadError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	clk := clock.NewFake(time.Date(2024, 1, 3, 8, 0, 0, 0, time.UTC))
+	cfg := config.Default.Meetings
+	cfg.Calendars = []string{srv.URL + "/missing.ics"}
+	m := NewMeetingCalendars(filepath.Join(t.TempDir(), "meetings.json"), cfg, clk, time.UTC)
+
+	from := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+	meetings, statuses, err := m.Meetings(context.Background(), from, from.AddDate(0, 0, 1))
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if len(meetings) != 0 || statuses == nil {
+		t.Errorf("want no meetings but a status map, got %+v and %v", meetings, statuses)
+	}
+}
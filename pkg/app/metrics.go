@@ -0,0 +1,197 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/internal/metrics"
+	"github.com/dinkur/dinkur/pkg/dinkur"
+)
+
+// appMetrics are the metrics served on the /metrics endpoint of the local
+// HTTP API.
+type appMetrics struct {
+	registry *metrics.Registry
+
+	activeEntry     *metrics.Gauge
+	trackedToday    *metrics.Gauge
+	trackedTodayTag *metrics.Gauge
+	connected       *metrics.Gauge
+	connectAttempts *metrics.Counter
+	connectFailures *metrics.Counter
+	clientCalls     *metrics.Histogram
+	clientErrors    *metrics.Counter
+}
+
+func newAppMetrics() *appMetrics {
+	r := metrics.NewRegistry()
+	return &appMetrics{
+		registry: r,
+		activeEntry: r.NewGauge("dinkur_desktop_active_entry_seconds",
+			"How long the active entry has been running, or 0 if no entry is active."),
+		trackedToday: r.NewGauge("dinkur_desktop_tracked_today_seconds",
+			"Total tracked time today, including the active entry."),
+		trackedTodayTag: r.NewGauge("dinkur_desktop_tracked_today_tag_seconds",
+			"Tracked time today per tag, including the active entry.", "tag"),
+		connected: r.NewGauge("dinkur_desktop_connected",
+			"Whether the Dinkur database responds to pings, 1 if it does, otherwise 0."),
+		connectAttempts: r.NewCounter("dinkur_desktop_connect_attempts_total",
+			"Number of attempts to connect to the Dinkur database."),
+		connectFailures: r.NewCounter("dinkur_desktop_connect_failures_total",
+			"Number of failed attempts to connect to the Dinkur database."),
+		clientCalls: r.NewHistogram("dinkur_desktop_client_call_duration_seconds",
+			"Latency of calls to the Dinkur client.", metrics.DefaultBuckets, "method"),
+		clientErrors: r.NewCounter("dinkur_desktop_client_call_errors_total",
+			"Number of calls to the Dinkur client that failed.", "method"),
+	}
+}
+
+func (m *appMetrics) observeCall(method string, start time.Time, err error) {
+	m.clientCalls.Observe(time.Since(start).Seconds(), method)
+	if err != nil {
+		m.clientErrors.Inc(method)
+	}
+}
+
+func (m *appMetrics) observeConnect(err error) {
+	m.connectAttempts.Inc()
+	if err != nil {
+		m.connectFailures.Inc()
+		m.connected.Set(0)
+	} else {
+		m.connected.Set(1)
+	}
+}
+
+// updateMetrics sets the gauges that are calculated when scraped.
+func (a *App) updateMetrics(ctx context.Context) {
+	m := a.metrics
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := a.dinkur.Ping(pingCtx); err != nil {
+		m.connected.Set(0)
+		return
+	}
+	m.connected.Set(1)
+	now := a.clock.Now()
+	entries, err := a.GetEntriesForDay(now)
+	if err != nil {
+		log.Debug().WithError(err).Message("Failed to list today's entries for metrics.")
+		return
+	}
+	dayStart, dayEnd := a.calendar.Day(now)
+	var total time.Duration
+	tags := map[string]time.Duration{}
+	m.activeEntry.Set(0)
+	for _, entry := range entries {
+		start, end := entry.Start, now
+		if entry.End != nil {
+			end = *entry.End
+		} else {
+			m.activeEntry.Set(now.Sub(entry.Start).Seconds())
+		}
+		if start.Before(dayStart) {
+			start = dayStart
+		}
+		if end.After(dayEnd) {
+			end = dayEnd
+		}
+		if !end.After(start) {
+			continue
+		}
+		total += end.Sub(start)
+		for _, tag := range entry.Tags {
+			tags[tag] += end.Sub(start)
+		}
+	}
+	m.trackedToday.Set(total.Seconds())
+	m.trackedTodayTag.Reset()
+	for tag, d := range tags {
+		m.trackedTodayTag.Set(d.Seconds(), tag)
+	}
+}
+
+func (h *apiHandler) metrics(w http.ResponseWriter, r *http.Request) {
+	h.app.updateMetrics(r.Context())
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := h.app.metrics.registry.WriteText(w); err != nil {
+		log.Debug().WithError(err).Message("Failed to write metrics.")
+	}
+}
+
+// metricsClient is a Dinkur client that records the latency of each call.
+type metricsClient struct {
+	dinkur.Client
+	metrics *appMetrics
+}
+
+func (c *metricsClient) Ping(ctx context.Context) error {
+	start := time.Now()
+	err := c.Client.Ping(ctx)
+	c.metrics.observeCall("Ping", start, err)
+	return err
+}
+
+func (c *metricsClient) GetEntry(ctx context.Context, id uint) (dinkur.Entry, error) {
+	start := time.Now()
+	entry, err := c.Client.GetEntry(ctx, id)
+	c.metrics.observeCall("GetEntry", start, err)
+	return entry, err
+}
+
+func (c *metricsClient) GetEntryList(ctx context.Context, search dinkur.SearchEntry) ([]dinkur.Entry, error) {
+	start := time.Now()
+	entries, err := c.Client.GetEntryList(ctx, search)
+	c.metrics.observeCall("GetEntryList", start, err)
+	return entries, err
+}
+
+func (c *metricsClient) GetActiveEntry(ctx context.Context) (*dinkur.Entry, error) {
+	start := time.Now()
+	entry, err := c.Client.GetActiveEntry(ctx)
+	c.metrics.observeCall("GetActiveEntry", start, err)
+	return entry, err
+}
+
+func (c *metricsClient) UpdateEntry(ctx context.Context, edit dinkur.EditEntry) (dinkur.UpdatedEntry, error) {
+	start := time.Now()
+	updated, err := c.Client.UpdateEntry(ctx, edit)
+	c.metrics.observeCall("UpdateEntry", start, err)
+	return updated, err
+}
+
+func (c *metricsClient) DeleteEntry(ctx context.Context, id uint) (dinkur.Entry, error) {
+	start := time.Now()
+	entry, err := c.Client.DeleteEntry(ctx, id)
+	c.metrics.observeCall("DeleteEntry", start, err)
+	return entry, err
+}
+
+func (c *metricsClient) CreateEntry(ctx context.Context, entry dinkur.NewEntry) (dinkur.StartedEntry, error) {
+	start := time.Now()
+	started, err := c.Client.CreateEntry(ctx, entry)
+	c.metrics.observeCall("CreateEntry", start, err)
+	return started, err
+}
+
+func (c *metricsClient) StopActiveEntry(ctx context.Context, endTime time.Time) (*dinkur.Entry, error) {
+	start := time.Now()
+	entry, err := c.Client.StopActiveEntry(ctx, endTime)
+	c.metrics.observeCall("StopActiveEntry", start, err)
+	return entry, err
+}
+
+func (c *metricsClient) GetStatus(ctx context.Context) (dinkur.Status, error) {
+	start := time.Now()
+	status, err := c.Client.GetStatus(ctx)
+	c.metrics.observeCall("GetStatus", start, err)
+	return status, err
+}
+
+func (c *metricsClient) SetStatus(ctx context.Context, edit dinkur.EditStatus) error {
+	start := time.Now()
+	err := c.Client.SetStatus(ctx, edit)
+	c.metrics.observeCall("SetStatus", start, err)
+	return err
+}
@@ -0,0 +1,451 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"fyne.io/systray"
+	"github.com/dinkur/dinkur-desktop/internal/clock"
+	"github.com/dinkur/dinkur-desktop/internal/jsonfile"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+	"github.com/dinkur/dinkur/pkg/dinkur"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Errors specific to the pomodoro timer.
+var (
+	ErrPomodoroRunning    = errors.New("a pomodoro is already running")
+	ErrPomodoroNotRunning = errors.New("no pomodoro is running")
+)
+
+// PomodoroPhase is the current phase of a pomodoro.
+type PomodoroPhase string
+
+const (
+	// PomodoroFocus is when the entry is being tracked.
+	PomodoroFocus PomodoroPhase = "focus"
+	// PomodoroBreak is the break between two focus intervals.
+	PomodoroBreak PomodoroPhase = "break"
+	// PomodoroDone is when the pomodoro has ended.
+	PomodoroDone PomodoroPhase = "done"
+)
+
+// PomodoroState is the state of a running pomodoro.
+type PomodoroState struct {
+	Name      string        `json:"name"`
+	Phase     PomodoroPhase `json:"phase"`
+	Cycle     int           `json:"cycle"`
+	Cycles    int           `json:"cycles"`
+	PhaseEnds time.Time     `json:"phaseEnds"`
+	EntryID   uint          `json:"entryId"`
+}
+
+// Remaining returns how much time is left of the current phase.
+func (s PomodoroState) Remaining(now time.Time) time.Duration {
+	if s.Phase == PomodoroDone || now.After(s.PhaseEnds) {
+		return 0
+	}
+	return s.PhaseEnds.Sub(now)
+}
+
+// FocusSession is a recorded pomodoro focus interval.
+type FocusSession struct {
+	EntryID   uint      `json:"entryId"`
+	Name      string    `json:"name"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Completed bool      `json:"completed"`
+}
+
+// FocusSummary is the number of focus sessions for a given entry name.
+type FocusSummary struct {
+	Name        string        `json:"name"`
+	Completed   int           `json:"completed"`
+	Interrupted int           `json:"interrupted"`
+	Focused     time.Duration `json:"focused"`
+}
+
+// FocusSessionStore persists focus sessions as a JSON file.
+type FocusSessionStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFocusSessionStore creates a new focus session store that persists to
+// the given file.
+func NewFocusSessionStore(path string) *FocusSessionStore {
+	return &FocusSessionStore{path: path}
+}
+
+// Add records a new focus session.
+func (s *FocusSessionStore) Add(session FocusSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var sessions []FocusSession
+	if err := jsonfile.Read(s.path, &sessions); err != nil {
+		return err
+	}
+	sessions = append(sessions, session)
+	return jsonfile.Write(s.path, sessions)
+}
+
+// Remap moves the sessions of an entry that has been recreated with a new
+// ID over to the new ID.
+func (s *FocusSessionStore) Remap(oldID, newID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var sessions []FocusSession
+	if err := jsonfile.Read(s.path, &sessions); err != nil {
+		return err
+	}
+	changed := false
+	for i := range sessions {
+		if sessions[i].EntryID == oldID {
+			sessions[i].EntryID = newID
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return jsonfile.Write(s.path, sessions)
+}
+
+// List returns all focus sessions that started within the given time span.
+func (s *FocusSessionStore) List(start, end time.Time) ([]FocusSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var sessions []FocusSession
+	if err := jsonfile.Read(s.path, &sessions); err != nil {
+		return nil, err
+	}
+	var result []FocusSession
+	for _, session := range sessions {
+		if !session.Start.Before(start) && session.Start.Before(end) {
+			result = append(result, session)
+		}
+	}
+	return result, nil
+}
+
+// PomodoroTimer runs pomodoros, by starting and stopping entries on focus
+// intervals and breaks.
+type PomodoroTimer struct {
+	entries  dinkur.Entries
+	clock    clock.Clock
+	cfg      config.Pomodoro
+	sessions *FocusSessionStore
+
+	// OnTick is called every second while a pomodoro is running.
+	OnTick func(PomodoroState)
+	// OnPhase is called when the pomodoro changes phase.
+	OnPhase func(PomodoroState)
+
+	mu         sync.Mutex
+	state      *PomodoroState
+	focusStart time.Time
+	cancel     context.CancelFunc
+}
+
+// NewPomodoroTimer creates a new pomodoro timer.
+func NewPomodoroTimer(entries dinkur.Entries, clk clock.Clock, cfg config.Pomodoro, sessions *FocusSessionStore) *PomodoroTimer {
+	return &PomodoroTimer{
+		entries:  entries,
+		clock:    clk,
+		cfg:      cfg,
+		sessions: sessions,
+	}
+}
+
+// State returns the current pomodoro state, or nil if no pomodoro is running.
+func (p *PomodoroTimer) State() *PomodoroState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return nil
+	}
+	state := *p.state
+	return &state
+}
+
+// Remap updates the pomodoro's entry after it has been recreated with a new
+// ID, so that the pomodoro still stops it, along with its focus sessions.
+func (p *PomodoroTimer) Remap(oldID, newID uint) {
+	p.mu.Lock()
+	if p.state != nil && p.state.EntryID == oldID {
+		p.state.EntryID = newID
+	}
+	p.mu.Unlock()
+	if p.sessions == nil {
+		return
+	}
+	if err := p.sessions.Remap(oldID, newID); err != nil {
+		log.Warn().WithError(err).Message("Failed to update focus sessions of recreated entry.")
+	}
+}
+
+// Start begins a new pomodoro by starting a new entry with the given name.
+func (p *PomodoroTimer) Start(ctx context.Context, name string) (PomodoroState, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state != nil {
+		return PomodoroState{}, ErrPomodoroRunning
+	}
+	now := p.clock.Now()
+	started, err := p.entries.CreateEntry(ctx, dinkur.NewEntry{Name: name, Start: &now})
+	if err != nil {
+		return PomodoroState{}, fmt.Errorf("start pomodoro entry: %w", err)
+	}
+	p.state = &PomodoroState{
+		Name:      name,
+		Phase:     PomodoroFocus,
+		Cycle:     1,
+		Cycles:    p.cfg.Cycles,
+		PhaseEnds: now.Add(p.cfg.Interval),
+		EntryID:   started.Started.ID,
+	}
+	p.focusStart = now
+	runCtx, cancel := context.WithCancel(ctx)
+	p.cancel = cancel
+	go p.run(runCtx)
+	p.emitPhase(*p.state)
+	return *p.state, nil
+}
+
+// Stop ends the running pomodoro. If in the middle of a focus interval, then
+// the entry is stopped and the session is recorded as interrupted.
+func (p *PomodoroTimer) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return ErrPomodoroNotRunning
+	}
+	p.cancel()
+	if p.state.Phase == PomodoroFocus {
+		now := p.clock.Now()
+		p.stopEntry(ctx, now)
+		p.record(now, false)
+	}
+	p.finish()
+	return nil
+}
+
+func (p *PomodoroTimer) run(ctx context.Context) {
+	ticker := p.clock.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C():
+			p.tick(ctx, now)
+		}
+	}
+}
+
+func (p *PomodoroTimer) tick(ctx context.Context, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return
+	}
+	if now.Before(p.state.PhaseEnds) {
+		if p.OnTick != nil {
+			p.OnTick(*p.state)
+		}
+		return
+	}
+	switch p.state.Phase {
+	case PomodoroFocus:
+		end := p.state.PhaseEnds
+		p.stopEntry(ctx, end)
+		p.record(end, true)
+		if p.cfg.OnIntervalEnd == config.PomodoroEndStop || p.state.Cycle >= p.state.Cycles {
+			p.cancel()
+			p.finish()
+			return
+		}
+		p.state.Phase = PomodoroBreak
+		p.state.PhaseEnds = end.Add(p.cfg.Break)
+		p.state.EntryID = 0
+	case PomodoroBreak:
+		active, err := p.entries.GetActiveEntry(ctx)
+		if err != nil {
+			log.Warn().WithError(err).Message("Failed to get active entry to resume pomodoro after break.")
+			p.cancel()
+			p.finish()
+			return
+		}
+		if active != nil {
+			// Resuming would stop and backdate the entry the user started
+			// during the break, so end the pomodoro instead.
+			log.Info().
+				WithUint("entry", active.ID).
+				WithString("name", active.Name).
+				Message("Another entry was started during the pomodoro break. Ending the pomodoro.")
+			p.cancel()
+			p.finish()
+			return
+		}
+		start := p.state.PhaseEnds
+		started, err := p.entries.CreateEntry(ctx, dinkur.NewEntry{Name: p.state.Name, Start: &start})
+		if err != nil {
+			log.Warn().WithError(err).Message("Failed to resume pomodoro entry after break.")
+			p.cancel()
+			p.finish()
+			return
+		}
+		p.state.Phase = PomodoroFocus
+		p.state.Cycle++
+		p.state.PhaseEnds = start.Add(p.cfg.Interval)
+		p.state.EntryID = started.Started.ID
+		p.focusStart = start
+	}
+	p.emitPhase(*p.state)
+}
+
+func (p *PomodoroTimer) stopEntry(ctx context.Context, end time.Time) {
+	active, err := p.entries.GetActiveEntry(ctx)
+	if err != nil {
+		log.Warn().WithError(err).Message("Failed to get active entry to stop for pomodoro.")
+		return
+	}
+	if active == nil || active.ID != p.state.EntryID {
+		// User has already stopped or switched entry
+		return
+	}
+	if _, err := p.entries.StopActiveEntry(ctx, end); err != nil {
+		log.Warn().WithError(err).Message("Failed to stop pomodoro entry.")
+	}
+}
+
+func (p *PomodoroTimer) record(end time.Time, completed bool) {
+	session := FocusSession{
+		EntryID:   p.state.EntryID,
+		Name:      p.state.Name,
+		Start:     p.focusStart,
+		End:       end,
+		Completed: completed,
+	}
+	log.Debug().
+		WithString("name", session.Name).
+		WithBool("completed", completed).
+		Message("Recording focus session.")
+	if p.sessions == nil {
+		return
+	}
+	if err := p.sessions.Add(session); err != nil {
+		log.Warn().WithError(err).Message("Failed to record focus session.")
+	}
+}
+
+func (p *PomodoroTimer) finish() {
+	state := *p.state
+	state.Phase = PomodoroDone
+	state.EntryID = 0
+	p.state = nil
+	p.emitPhase(state)
+}
+
+func (p *PomodoroTimer) emitPhase(state PomodoroState) {
+	if p.OnPhase != nil {
+		p.OnPhase(state)
+	}
+}
+
+// SummarizeFocusSessions counts the focus sessions per entry name, sorted by
+// most focused time first.
+func SummarizeFocusSessions(sessions []FocusSession) []FocusSummary {
+	byName := map[string]*FocusSummary{}
+	var summaries []*FocusSummary
+	for _, session := range sessions {
+		summary, ok := byName[session.Name]
+		if !ok {
+			summary = &FocusSummary{Name: session.Name}
+			byName[session.Name] = summary
+			summaries = append(summaries, summary)
+		}
+		if session.Completed {
+			summary.Completed++
+		} else {
+			summary.Interrupted++
+		}
+		summary.Focused += session.End.Sub(session.Start)
+	}
+	result := make([]FocusSummary, len(summaries))
+	for i, summary := range summaries {
+		result[i] = *summary
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Focused > result[j].Focused
+	})
+	return result
+}
+
+func (a *App) newPomodoroTimer() *PomodoroTimer {
+	sessions := NewFocusSessionStore(filepath.Join(a.cfg.DataDir, "focus-sessions.json"))
+	p := NewPomodoroTimer(a.dinkur, a.clock, a.cfg.Pomodoro, sessions)
+	p.OnTick = a.onPomodoroTick
+	p.OnPhase = a.onPomodoroPhase
+	return p
+}
+
+func (a *App) onPomodoroTick(state PomodoroState) {
+	remaining := state.Remaining(a.clock.Now()).Round(time.Second)
+	minutes := int(remaining / time.Minute)
+	seconds := int(remaining % time.Minute / time.Second)
+	icon := "🍅"
+	if state.Phase == PomodoroBreak {
+		icon = "☕"
+	}
+	systray.SetTitle(fmt.Sprintf("%s %02d:%02d", icon, minutes, seconds))
+}
+
+func (a *App) onPomodoroPhase(state PomodoroState) {
+	log.Info().
+		WithString("name", state.Name).
+		WithString("phase", string(state.Phase)).
+		WithInt("cycle", state.Cycle).
+		Message("Pomodoro changed phase.")
+	if state.Phase == PomodoroDone {
+		systray.SetTitle(trayTitle)
+	}
+	runtime.EventsEmit(a.ctx, "pomodoro:phase", state)
+}
+
+// StartPomodoro starts a new entry and tracks it in focus intervals.
+func (a *App) StartPomodoro(name string) (PomodoroState, error) {
+	return a.pomodoro.Start(a.ctx, name)
+}
+
+// StopPomodoro ends the running pomodoro, stopping its entry.
+func (a *App) StopPomodoro() error {
+	return a.pomodoro.Stop(a.ctx)
+}
+
+// GetPomodoroState returns the state of the running pomodoro, or nil if no
+// pomodoro is running.
+func (a *App) GetPomodoroState() *PomodoroState {
+	return a.pomodoro.State()
+}
+
+// GetFocusSessions returns the recorded pomodoro focus sessions that started
+// within the given time span.
+func (a *App) GetFocusSessions(start, end time.Time) ([]FocusSession, error) {
+	return a.pomodoro.sessions.List(start, end)
+}
+
+// GetFocusSummary returns the number of completed and interrupted pomodoro
+// focus sessions per entry name within the given time span.
+func (a *App) GetFocusSummary(start, end time.Time) ([]FocusSummary, error) {
+	sessions, err := a.pomodoro.sessions.List(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return SummarizeFocusSessions(sessions), nil
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+)
+
+func newTestPomodoro(t *testing.T, a *App) *PomodoroTimer {
+	t.Helper()
+	sessions := NewFocusSessionStore(filepath.Join(t.TempDir(), "focus-sessions.json"))
+	return NewPomodoroTimer(a.dinkur, a.clock, config.Default.Pomodoro, sessions)
+}
+
+func TestPomodoroResumesAfterBreak(t *testing.T) {
+	now := time.Date(2023, 3, 1, 9, 0, 0, 0, time.UTC)
+	a, clk := newTestApp(t, now)
+	p := newTestPomodoro(t, a)
+	ctx := context.Background()
+	if _, err := p.Start(ctx, "Write report"); err != nil {
+		t.Fatal(err)
+	}
+	// Drive the timer by hand instead of by its ticker.
+	p.cancel()
+
+	clk.Advance(25 * time.Minute)
+	p.tick(ctx, clk.Now())
+	if state := p.State(); state == nil || state.Phase != PomodoroBreak {
+		t.Fatalf("want break, got %+v", state)
+	}
+	clk.Advance(5 * time.Minute)
+	p.tick(ctx, clk.Now())
+	state := p.State()
+	if state == nil || state.Phase != PomodoroFocus || state.Cycle != 2 {
+		t.Fatalf("want second focus interval, got %+v", state)
+	}
+	active, err := a.dinkur.GetActiveEntry(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active == nil || active.ID != state.EntryID || !active.Start.Equal(clk.Now()) {
+		t.Errorf("want pomodoro entry resumed at %v, got %+v", clk.Now(), active)
+	}
+}
+
+func TestPomodoroEndsWhenEntryStartedDuringBreak(t *testing.T) {
+	now := time.Date(2023, 3, 1, 9, 0, 0, 0, time.UTC)
+	a, clk := newTestApp(t, now)
+	p := newTestPomodoro(t, a)
+	ctx := context.Background()
+	if _, err := p.Start(ctx, "Write report"); err != nil {
+		t.Fatal(err)
+	}
+	p.cancel()
+
+	clk.Advance(25 * time.Minute)
+	p.tick(ctx, clk.Now())
+	clk.Advance(2 * time.Minute)
+	other := createTestEntry(t, a, "Phone call", clk.Now(), nil)
+	clk.Advance(3 * time.Minute)
+	p.tick(ctx, clk.Now())
+
+	if state := p.State(); state != nil {
+		t.Errorf("want pomodoro ended, got %+v", state)
+	}
+	active, err := a.dinkur.GetActiveEntry(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active == nil || active.ID != other.ID || !active.Start.Equal(other.Start) {
+		t.Errorf("want %q to still be active from %v, got %+v", other.Name, other.Start, active)
+	}
+}
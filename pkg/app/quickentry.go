@@ -0,0 +1,71 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dinkur/dinkur-desktop/pkg/analyzer"
+	"github.com/dinkur/dinkur-desktop/pkg/quickentry"
+	"github.com/dinkur/dinkur/pkg/dinkur"
+)
+
+// QuickEntryPreview is an entry parsed from text, before it is saved.
+type QuickEntryPreview struct {
+	// Entry is the parsed entry, which doesn't have an ID yet. The end time
+	// is nil if the entry would be started as the active entry.
+	Entry Entry `json:"entry"`
+	// Conflicts are the overlaps the entry would have with existing entries.
+	Conflicts []analyzer.Issue `json:"conflicts"`
+}
+
+// ParseQuickEntry parses text such as "yesterday 13:00-14:30 code review" or
+// "2h on INC-44 this morning" into an entry, relative to the current time,
+// without saving it.
+func (a *App) ParseQuickEntry(text string) (QuickEntryPreview, error) {
+	parsed, err := quickentry.Parse(text, a.clock.Now(), a.calendar)
+	if err != nil {
+		return QuickEntryPreview{}, err
+	}
+	entry := dinkur.Entry{Name: parsed.Name, Start: parsed.Start, End: parsed.End}
+	conflicts, err := a.findConflicts([]dinkur.Entry{entry})
+	if err != nil {
+		return QuickEntryPreview{}, err
+	}
+	return QuickEntryPreview{
+		Entry:     a.toEntry(entry),
+		Conflicts: conflicts,
+	}, nil
+}
+
+// SaveQuickEntry parses the text the same way as [App.ParseQuickEntry] and
+// saves the entry. Entries without an end time are started as the active
+// entry, which stops the currently active entry. Overlaps with existing
+// entries are not prevented, so check the preview first.
+func (a *App) SaveQuickEntry(text string) (*Entry, error) {
+	parsed, err := quickentry.Parse(text, a.clock.Now(), a.calendar)
+	if err != nil {
+		return nil, err
+	}
+	var saved dinkur.Entry
+	err = a.mutate(fmt.Sprintf("Add %q", parsed.Name), func(ctx context.Context) error {
+		if parsed.End != nil {
+			saved, err = a.createPastEntry(ctx, parsed.Name, parsed.Start, *parsed.End)
+			return err
+		}
+		started, err := a.dinkur.CreateEntry(ctx, dinkur.NewEntry{
+			Name:  parsed.Name,
+			Start: &parsed.Start,
+		})
+		saved = started.Started
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		WithUint("id", saved.ID).
+		WithString("name", saved.Name).
+		WithTime("start", saved.Start).
+		Message("Added quick entry.")
+	return a.toEntryPtr(&saved), nil
+}
@@ -0,0 +1,170 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/internal/clock"
+	"github.com/dinkur/dinkur-desktop/internal/notify"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+	"github.com/dinkur/dinkur/pkg/dinkur"
+	"github.com/dinkur/dinkur/pkg/timeutil"
+)
+
+const reminderActionStartLast = "start-last"
+
+// ReminderScheduler periodically checks if there's an active entry during
+// working hours, and sends a desktop notification if there's not.
+type ReminderScheduler struct {
+	entries      dinkur.Entries
+	notifier     notify.Notifier
+	clock        clock.Clock
+	workingHours config.WorkingHours
+	snooze       time.Duration
+	// loc is the time zone of the working hours.
+	loc *time.Location
+
+	mu           sync.Mutex
+	snoozedUntil time.Time
+	lastID       notify.ID
+}
+
+// NewReminderScheduler creates a new reminder scheduler. The working hours
+// are in the given time zone.
+func NewReminderScheduler(entries dinkur.Entries, notifier notify.Notifier, clk clock.Clock, workingHours config.WorkingHours, snooze time.Duration, loc *time.Location) *ReminderScheduler {
+	return &ReminderScheduler{
+		entries:      entries,
+		notifier:     notifier,
+		clock:        clk,
+		workingHours: workingHours,
+		snooze:       snooze,
+		loc:          loc,
+	}
+}
+
+// Run checks for an active entry on the given interval until the context is
+// cancelled.
+func (s *ReminderScheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := s.clock.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			if err := s.Check(ctx); err != nil {
+				log.Warn().WithError(err).Message("Failed to check if reminder is needed.")
+			}
+		}
+	}
+}
+
+// Check sends a reminder notification if there's no active entry, it's
+// within working hours, and the previous reminder is no longer snoozed.
+func (s *ReminderScheduler) Check(ctx context.Context) error {
+	now := s.clock.Now()
+	if !s.workingHours.Contains(now.In(s.loc)) {
+		return nil
+	}
+	s.mu.Lock()
+	snoozed := now.Before(s.snoozedUntil)
+	s.mu.Unlock()
+	if snoozed {
+		return nil
+	}
+	active, err := s.entries.GetActiveEntry(ctx)
+	if err != nil {
+		return fmt.Errorf("get active entry: %w", err)
+	}
+	if active != nil {
+		s.closeLast()
+		return nil
+	}
+	return s.remind(ctx, now)
+}
+
+func (s *ReminderScheduler) remind(ctx context.Context, now time.Time) error {
+	notif := notify.Notification{
+		Summary: "You're not tracking any time",
+		Body:    "There's no active entry in Dinkur right now.",
+	}
+	last, err := s.lastEntry(ctx)
+	if err != nil {
+		log.Warn().WithError(err).Message("Failed to get last entry for reminder.")
+	} else if last != nil {
+		notif.Body = fmt.Sprintf("There's no active entry in Dinkur right now. Last entry was %q.", last.Name)
+		notif.Actions = []notify.Action{
+			{Key: reminderActionStartLast, Label: "Start last entry"},
+		}
+		name := last.Name
+		notif.OnAction = func(key string) {
+			if key != reminderActionStartLast {
+				return
+			}
+			if err := s.startEntry(ctx, name); err != nil {
+				log.Warn().WithError(err).Message("Failed to start last entry from reminder.")
+			}
+		}
+	}
+
+	s.mu.Lock()
+	notif.ReplacesID = s.lastID
+	s.snoozedUntil = now.Add(s.snooze)
+	s.mu.Unlock()
+
+	id, err := s.notifier.Notify(notif)
+	if err != nil {
+		return fmt.Errorf("send reminder notification: %w", err)
+	}
+	s.mu.Lock()
+	s.lastID = id
+	s.mu.Unlock()
+	log.Debug().Message("Sent reminder that no entry is active.")
+	return nil
+}
+
+func (s *ReminderScheduler) closeLast() {
+	s.mu.Lock()
+	id := s.lastID
+	s.lastID = 0
+	s.mu.Unlock()
+	if id == 0 {
+		return
+	}
+	if err := s.notifier.Close(id); err != nil {
+		log.Debug().WithError(err).Message("Failed to close reminder notification.")
+	}
+}
+
+func (s *ReminderScheduler) lastEntry(ctx context.Context) (*dinkur.Entry, error) {
+	entries, err := s.entries.GetEntryList(ctx, dinkur.SearchEntry{
+		Shorthand: timeutil.TimeSpanNone,
+		Limit:     1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	return &entries[0], nil
+}
+
+func (s *ReminderScheduler) startEntry(ctx context.Context, name string) error {
+	_, err := s.entries.CreateEntry(ctx, dinkur.NewEntry{Name: name})
+	if err != nil {
+		return err
+	}
+	s.closeLast()
+	return nil
+}
+
+func (a *App) startReminders(ctx context.Context) {
+	if !a.cfg.Reminder.Enabled {
+		return
+	}
+	s := NewReminderScheduler(a.dinkur, a.notifier, a.clock, a.cfg.WorkingHours, a.cfg.Reminder.Snooze, a.calendar.Location())
+	go s.Run(ctx, a.cfg.Reminder.CheckInterval)
+}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+)
+
+func TestReminderUsesWorkingHoursTimeZone(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{"before working hours", time.Date(2023, 3, 1, 13, 0, 0, 0, time.UTC), false},
+		{"start of working hours", time.Date(2023, 3, 1, 14, 0, 0, 0, time.UTC), true},
+		{"afternoon", time.Date(2023, 3, 1, 20, 0, 0, 0, time.UTC), true},
+		{"after working hours", time.Date(2023, 3, 1, 22, 0, 0, 0, time.UTC), false},
+		// Wednesday in UTC, but still Tuesday evening in New York.
+		{"previous day", time.Date(2023, 3, 1, 2, 0, 0, 0, time.UTC), false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			a, clk := newTestApp(t, tc.now)
+			notifier := &recordingNotifier{}
+			s := NewReminderScheduler(a.dinkur, notifier, clk, config.Default.WorkingHours, time.Hour, newYork)
+			if err := s.Check(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if got := len(notifier.notifications) > 0; got != tc.want {
+				t.Errorf("want reminder %t, got %t", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/dinkur/dinkur-desktop/internal/jsonfile"
+	"github.com/dinkur/dinkur-desktop/internal/notify"
+	"github.com/dinkur/dinkur/pkg/dinkur"
+)
+
+// ErrNothingToRestore is returned when restoring an active entry that could
+// not be created again, but there is no such entry.
+var ErrNothingToRestore = errors.New("no entry waiting to be restored")
+
+// restoreAttempts is how many times an active entry that has been set aside
+// is created again before giving up.
+const restoreAttempts = 3
+
+const unrestoredActionRetry = "retry"
+
+// recreateActive creates an active entry that has been set aside again,
+// with the same name, and thereby tags, and start time.
+func recreateActive(ctx context.Context, entries dinkur.Entries, entry dinkur.Entry) (dinkur.Entry, error) {
+	var err error
+	for attempt := 1; attempt <= restoreAttempts; attempt++ {
+		var restored dinkur.StartedEntry
+		restored, err = entries.CreateEntry(ctx, dinkur.NewEntry{
+			Name:  entry.Name,
+			Start: &entry.Start,
+		})
+		if err == nil {
+			return restored.Started, nil
+		}
+		log.Warn().WithError(err).
+			WithInt("attempt", attempt).
+			WithString("name", entry.Name).
+			WithTime("start", entry.Start).
+			Message("Failed to restore active entry.")
+	}
+	return dinkur.Entry{}, err
+}
+
+func (a *App) unrestoredPath() string {
+	return filepath.Join(a.cfg.DataDir, "unrestored-entry.json")
+}
+
+// keepUnrestored saves an active entry that was set aside but could not be
+// created again, so that it can be restored later with
+// [App.RestoreActiveEntry], and tells the user about it.
+func (a *App) keepUnrestored(entry dinkur.Entry, err error) {
+	log.Error().WithError(err).
+		WithString("name", entry.Name).
+		WithTime("start", entry.Start).
+		Message("Failed to restore active entry. Keeping it to be restored later.")
+	a.restoreMutex.Lock()
+	werr := jsonfile.Write(a.unrestoredPath(), &entry)
+	a.restoreMutex.Unlock()
+	if werr != nil {
+		log.Error().WithError(werr).Message("Failed to save entry that could not be restored.")
+	}
+	if a.notifier == nil {
+		return
+	}
+	_, nerr := a.notifier.Notify(notify.Notification{
+		Summary: "Failed to restore active entry",
+		Body:    fmt.Sprintf("%q was set aside while adding an entry, but could not be started again.", entry.Name),
+		Actions: []notify.Action{{Key: unrestoredActionRetry, Label: "Retry"}},
+		OnAction: func(key string) {
+			if key != unrestoredActionRetry {
+				return
+			}
+			if _, err := a.RestoreActiveEntry(); err != nil {
+				log.Warn().WithError(err).Message("Failed to restore active entry from notification.")
+			}
+		},
+	})
+	if nerr != nil {
+		log.Warn().WithError(nerr).Message("Failed to notify about entry that could not be restored.")
+	}
+}
+
+// GetUnrestoredEntry returns the active entry that was set aside but could
+// not be created again, or nil if there is none.
+func (a *App) GetUnrestoredEntry() (*dinkur.Entry, error) {
+	a.restoreMutex.Lock()
+	defer a.restoreMutex.Unlock()
+	return a.readUnrestored()
+}
+
+// RestoreActiveEntry starts the entry returned by [App.GetUnrestoredEntry]
+// again, from its original start time. This fails if another entry has been
+// started since.
+func (a *App) RestoreActiveEntry() (*dinkur.Entry, error) {
+	entry, err := a.GetUnrestoredEntry()
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, ErrNothingToRestore
+	}
+	var restored dinkur.Entry
+	if err := a.mutate(fmt.Sprintf("Restore %q", entry.Name), func(ctx context.Context) error {
+		active, err := a.dinkur.GetActiveEntry(ctx)
+		if err != nil {
+			return fmt.Errorf("get active entry: %w", err)
+		}
+		if active != nil {
+			return ErrOtherEntryActive
+		}
+		restored, err = recreateActive(ctx, a.dinkur, *entry)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	a.clearUnrestored(*entry)
+	a.entryRecreated(*entry, restored)
+	log.Info().
+		WithUint("entry", restored.ID).
+		WithString("name", restored.Name).
+		Message("Restored active entry.")
+	return &restored, nil
+}
+
+// clearUnrestored forgets the entry that could not be restored, unless
+// another entry has failed to be restored since.
+func (a *App) clearUnrestored(entry dinkur.Entry) {
+	a.restoreMutex.Lock()
+	defer a.restoreMutex.Unlock()
+	current, err := a.readUnrestored()
+	if err == nil && (current == nil || current.ID != entry.ID) {
+		return
+	}
+	if err := jsonfile.Write(a.unrestoredPath(), nil); err != nil {
+		log.Warn().WithError(err).Message("Failed to clear restored entry.")
+	}
+}
+
+func (a *App) readUnrestored() (*dinkur.Entry, error) {
+	var entry *dinkur.Entry
+	if err := jsonfile.Read(a.unrestoredPath(), &entry); err != nil {
+		return nil, fmt.Errorf("read unrestored entry: %w", err)
+	}
+	return entry, nil
+}
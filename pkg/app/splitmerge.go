@@ -0,0 +1,238 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dinkur/dinkur/pkg/dinkur"
+)
+
+// Errors specific to splitting and merging entries.
+var (
+	ErrSplitOutsideEntry   = errors.New("split time must be after the entry's start and before its end")
+	ErrMergeTooFewEntries  = errors.New("at least two different entries are needed to merge")
+	ErrMergeNamesDiffer    = errors.New("only entries with the same name can be merged")
+	ErrMergeNotAdjacent    = errors.New("entries to merge must be adjacent, without other entries in between")
+	ErrMergeMultipleActive = errors.New("cannot merge more than one active entry")
+)
+
+// rollback is a list of steps that undo changes made so far, in case a later
+// change fails. The Dinkur client has no transactions, so this is as close to
+// atomic as multi-step operations get.
+type rollback []func() error
+
+func (r *rollback) add(step func() error) {
+	*r = append(*r, step)
+}
+
+// run undoes all changes in reverse order. Failures are logged, as there's
+// nothing more to do about them.
+func (r rollback) run() {
+	for i := len(r) - 1; i >= 0; i-- {
+		if err := r[i](); err != nil {
+			log.Error().WithError(err).Message("Failed to roll back change to entries.")
+		}
+	}
+}
+
+// SplitEntry splits the entry in two at the given time. The first part keeps
+// the entry's ID and name, and the second part gets the new name, or the same
+// name if empty. Splitting the active entry keeps the second part active.
+func (a *App) SplitEntry(id uint, at time.Time, newName string) ([]Entry, error) {
+	entry, err := a.dinkur.GetEntry(a.ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	end := a.clock.Now()
+	if entry.End != nil {
+		end = *entry.End
+	}
+	if !at.After(entry.Start) || !at.Before(end) {
+		return nil, ErrSplitOutsideEntry
+	}
+	if strings.TrimSpace(newName) == "" {
+		newName = entry.Name
+	}
+
+	var parts []dinkur.Entry
+	if err := a.mutate(fmt.Sprintf("Split %q", entry.Name), func(ctx context.Context) error {
+		parts, err = a.splitEntry(ctx, entry, at, end, newName)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	log.Info().
+		WithUint("first", parts[0].ID).
+		WithUint("second", parts[1].ID).
+		WithTime("at", at).
+		Message("Split entry.")
+	return a.toEntries(parts), nil
+}
+
+func (a *App) splitEntry(ctx context.Context, entry dinkur.Entry, at, end time.Time, newName string) ([]dinkur.Entry, error) {
+	if entry.End == nil {
+		// Starting a new entry stops the active entry in the same call, which
+		// makes this split atomic.
+		started, err := a.dinkur.CreateEntry(ctx, dinkur.NewEntry{
+			Name:  newName,
+			Start: &at,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("start second part: %w", err)
+		}
+		if started.Stopped == nil {
+			return nil, fmt.Errorf("entry %d was no longer active", entry.ID)
+		}
+		return []dinkur.Entry{*started.Stopped, started.Started}, nil
+	}
+
+	var undo rollback
+	updated, err := a.dinkur.UpdateEntry(ctx, dinkur.EditEntry{
+		IDOrZero: entry.ID,
+		End:      &at,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("end first part: %w", err)
+	}
+	undo.add(func() error {
+		_, err := a.dinkur.UpdateEntry(ctx, dinkur.EditEntry{IDOrZero: entry.ID, End: &end})
+		return err
+	})
+	second, err := a.createPastEntry(ctx, newName, at, end)
+	if err != nil {
+		undo.run()
+		return nil, fmt.Errorf("create second part: %w", err)
+	}
+	return []dinkur.Entry{updated.After, second}, nil
+}
+
+// MergeEntries merges adjacent entries with the same name into one entry
+// that spans all of them. The entry that ends last is kept, with its start
+// time moved to the earliest start, and the other entries are deleted. If a
+// step fails, then the changes made so far are rolled back, although deleted
+// entries get new IDs when restored.
+func (a *App) MergeEntries(ids []uint) (*Entry, error) {
+	entries, err := a.getUniqueEntries(ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) < 2 {
+		return nil, ErrMergeTooFewEntries
+	}
+	now := a.clock.Now()
+	end := func(e dinkur.Entry) time.Time {
+		if e.End == nil {
+			return now
+		}
+		return *e.End
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return end(entries[i]).Before(end(entries[j]))
+	})
+	keep := entries[len(entries)-1]
+	start := keep.Start
+	for _, e := range entries {
+		if !strings.EqualFold(e.Name, keep.Name) {
+			return nil, ErrMergeNamesDiffer
+		}
+		if e.End == nil && e.ID != keep.ID {
+			return nil, ErrMergeMultipleActive
+		}
+		if e.Start.Before(start) {
+			start = e.Start
+		}
+	}
+	if err := a.checkAdjacent(entries, start, end(keep)); err != nil {
+		return nil, err
+	}
+
+	description := fmt.Sprintf("Merge %d %q entries", len(entries), keep.Name)
+	if err := a.mutate(description, func(ctx context.Context) error {
+		return a.mergeEntries(ctx, keep, entries[:len(entries)-1], start)
+	}); err != nil {
+		return nil, err
+	}
+	merged, err := a.dinkur.GetEntry(a.ctx, keep.ID)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		WithUint("id", merged.ID).
+		WithInt("merged", len(entries)).
+		Message("Merged entries.")
+	return a.toEntryPtr(&merged), nil
+}
+
+func (a *App) mergeEntries(ctx context.Context, keep dinkur.Entry, others []dinkur.Entry, start time.Time) error {
+	var undo rollback
+	if start.Before(keep.Start) {
+		oldStart := keep.Start
+		if _, err := a.dinkur.UpdateEntry(ctx, dinkur.EditEntry{
+			IDOrZero: keep.ID,
+			Start:    &start,
+		}); err != nil {
+			return fmt.Errorf("extend entry %d: %w", keep.ID, err)
+		}
+		undo.add(func() error {
+			_, err := a.dinkur.UpdateEntry(ctx, dinkur.EditEntry{IDOrZero: keep.ID, Start: &oldStart})
+			return err
+		})
+	}
+	for _, e := range others {
+		if _, err := a.dinkur.DeleteEntry(ctx, e.ID); err != nil {
+			undo.run()
+			return fmt.Errorf("delete merged entry %d: %w", e.ID, err)
+		}
+		deleted := e
+		undo.add(func() error {
+			created, err := a.createPastEntry(ctx, deleted.Name, deleted.Start, *deleted.End)
+			if err != nil {
+				return err
+			}
+			a.entryRecreated(deleted, created)
+			return nil
+		})
+	}
+	return nil
+}
+
+func (a *App) getUniqueEntries(ids []uint) ([]dinkur.Entry, error) {
+	seen := map[uint]bool{}
+	var entries []dinkur.Entry
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		entry, err := a.dinkur.GetEntry(a.ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("get entry %d: %w", id, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// checkAdjacent returns an error if any entry other than the given entries
+// overlaps with the time span.
+func (a *App) checkAdjacent(entries []dinkur.Entry, start, end time.Time) error {
+	others, err := a.listOverlapping(a.ctx, start, end)
+	if err != nil {
+		return fmt.Errorf("list entries between merged entries: %w", err)
+	}
+	ids := map[uint]bool{}
+	for _, e := range entries {
+		ids[e.ID] = true
+	}
+	for _, other := range others {
+		if ids[other.ID] {
+			continue
+		}
+		return fmt.Errorf("%w: %q is in between", ErrMergeNotAdjacent, other.Name)
+	}
+	return nil
+}
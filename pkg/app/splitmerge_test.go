@@ -0,0 +1,46 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMergeEntries(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	day := now.Truncate(24 * time.Hour)
+	at := func(hour int) *time.Time {
+		t := day.Add(time.Duration(hour) * time.Hour)
+		return &t
+	}
+	a, _ := newTestApp(t, now)
+	first := createTestEntry(t, a, "Coding", *at(8), at(9))
+	second := createTestEntry(t, a, "Coding", *at(9), at(10))
+
+	merged, err := a.MergeEntries([]uint{first.ID, second.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !merged.Start.Equal(*at(8)) || merged.End == nil || !merged.End.Equal(*at(10)) {
+		t.Errorf("want merged entry from 08:00 to 10:00, got %v to %v", merged.Start, merged.End)
+	}
+}
+
+func TestMergeEntriesRejectsEnclosingEntry(t *testing.T) {
+	now := time.Date(2023, 3, 2, 12, 0, 0, 0, time.UTC)
+	day := now.Truncate(24 * time.Hour)
+	at := func(hour int) *time.Time {
+		t := day.Add(time.Duration(hour) * time.Hour)
+		return &t
+	}
+	a, _ := newTestApp(t, now)
+	// Starts the day before and ends after both entries, so it neither starts
+	// nor ends between them.
+	createTestEntry(t, a, "Conference", day.Add(-2*time.Hour), at(11))
+	first := createTestEntry(t, a, "Coding", *at(8), at(9))
+	second := createTestEntry(t, a, "Coding", *at(9), at(10))
+
+	if _, err := a.MergeEntries([]uint{first.ID, second.ID}); !errors.Is(err, ErrMergeNotAdjacent) {
+		t.Errorf("want %v, got %v", ErrMergeNotAdjacent, err)
+	}
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SummaryGroupBy is what to group entries by in a summary.
+type SummaryGroupBy string
+
+const (
+	// SummaryByName groups entries by their full name.
+	SummaryByName SummaryGroupBy = "name"
+	// SummaryByTag groups entries by their tags. Entries with multiple tags
+	// are counted once per tag.
+	SummaryByTag SummaryGroupBy = "tag"
+	// SummaryByClient groups entries by their client.
+	SummaryByClient SummaryGroupBy = "client"
+	// SummaryByTicket groups entries by their ticket keys. Entries with
+	// multiple ticket keys are counted once per ticket key.
+	SummaryByTicket SummaryGroupBy = "ticket"
+)
+
+// SummaryRow is the total tracked time of a group of entries.
+type SummaryRow struct {
+	// Key is the name, tag, client, or ticket key of the group. Empty for the
+	// group of entries without a tag, client, or ticket key.
+	Key      string        `json:"key"`
+	Duration time.Duration `json:"duration"`
+	Entries  int           `json:"entries"`
+}
+
+// Summary is the total tracked time within a time span, grouped by some
+// property of the entries.
+type Summary struct {
+	Start   time.Time      `json:"start"`
+	End     time.Time      `json:"end"`
+	GroupBy SummaryGroupBy `json:"groupBy"`
+	Total   time.Duration  `json:"total"`
+	Rows    []SummaryRow   `json:"rows"`
+}
+
+// Summarize groups the entries and sums up their durations. Only the part
+// of each entry that lies within the start and end time is counted.
+func Summarize(entries []Entry, start, end time.Time, groupBy SummaryGroupBy, now time.Time) (Summary, error) {
+	summary := Summary{Start: start, End: end, GroupBy: groupBy}
+	rows := map[string]*SummaryRow{}
+	for _, entry := range entries {
+		keys, err := summaryKeys(entry, groupBy)
+		if err != nil {
+			return Summary{}, err
+		}
+		dur := clampedDuration(entry, start, end, now)
+		summary.Total += dur
+		for _, key := range keys {
+			row, ok := rows[key]
+			if !ok {
+				row = &SummaryRow{Key: key}
+				rows[key] = row
+			}
+			row.Duration += dur
+			row.Entries++
+		}
+	}
+	summary.Rows = make([]SummaryRow, 0, len(rows))
+	for _, row := range rows {
+		summary.Rows = append(summary.Rows, *row)
+	}
+	sort.Slice(summary.Rows, func(i, j int) bool {
+		if summary.Rows[i].Duration != summary.Rows[j].Duration {
+			return summary.Rows[i].Duration > summary.Rows[j].Duration
+		}
+		return summary.Rows[i].Key < summary.Rows[j].Key
+	})
+	return summary, nil
+}
+
+func summaryKeys(entry Entry, groupBy SummaryGroupBy) ([]string, error) {
+	var keys []string
+	switch groupBy {
+	case SummaryByName:
+		return []string{entry.Name}, nil
+	case SummaryByTag:
+		keys = entry.Tags
+	case SummaryByClient:
+		if entry.Client != "" {
+			keys = []string{entry.Client}
+		}
+	case SummaryByTicket:
+		keys = entry.Tickets
+	default:
+		return nil, fmt.Errorf("unknown summary grouping: %q, must be one of: name, tag, client, ticket", groupBy)
+	}
+	if len(keys) == 0 {
+		return []string{""}, nil
+	}
+	return keys, nil
+}
+
+// clampedDuration returns how much of the entry lies within the start and
+// end time. Active entries are counted up until now.
+func clampedDuration(entry Entry, start, end, now time.Time) time.Duration {
+	entryStart := entry.Start
+	entryEnd := now
+	if entry.End != nil {
+		entryEnd = *entry.End
+	}
+	if entryStart.Before(start) {
+		entryStart = start
+	}
+	if entryEnd.After(end) {
+		entryEnd = end
+	}
+	if entryEnd.Before(entryStart) {
+		return 0
+	}
+	return entryEnd.Sub(entryStart)
+}
+
+// GetSummary returns the total tracked time between the start and end time,
+// grouped by "name", "tag", "client", or "ticket".
+func (a *App) GetSummary(start, end time.Time, groupBy SummaryGroupBy) (Summary, error) {
+	entries, err := a.listEntries(a.ctx, start, end)
+	if err != nil {
+		return Summary{}, err
+	}
+	return Summarize(entries, start, end, groupBy, a.clock.Now())
+}
@@ -0,0 +1,219 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/analyzer"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+	"github.com/dinkur/dinkur/pkg/dinkur"
+)
+
+// Errors specific to entry templates and copying entries.
+var (
+	ErrTemplateNotFound = errors.New("template not found")
+	ErrCopyConflicts    = errors.New("copied entries would overlap with existing entries")
+	ErrCopyNoEntries    = errors.New("no stopped entries to copy on that day")
+)
+
+// GetTemplates returns the entry templates from the config.
+func (a *App) GetTemplates() []config.EntryTemplate {
+	if a.cfg.Templates == nil {
+		return []config.EntryTemplate{}
+	}
+	return a.cfg.Templates
+}
+
+// StartTemplate starts a new entry from the template with the given name.
+// The entry starts at the template's time of day if that has passed, and is
+// added as a stopped entry if the template's duration has also passed.
+func (a *App) StartTemplate(name string) (*Entry, error) {
+	tmpl, ok := a.findTemplate(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrTemplateNotFound, name)
+	}
+	now := a.clock.Now().In(a.calendar.Location())
+	start := now
+	if tmpl.At != nil && tmpl.At.IsSet() {
+		if at := tmpl.At.On(now); at.Before(now) {
+			start = at
+		}
+	}
+	var entry dinkur.Entry
+	err := a.mutate(fmt.Sprintf("Start template %q", tmpl.Name), func(ctx context.Context) error {
+		if tmpl.Duration > 0 && !start.Add(tmpl.Duration).After(now) {
+			created, err := a.createPastEntry(ctx, tmpl.Name, start, start.Add(tmpl.Duration))
+			entry = created
+			return err
+		}
+		started, err := a.dinkur.CreateEntry(ctx, dinkur.NewEntry{
+			Name:  tmpl.Name,
+			Start: &start,
+		})
+		entry = started.Started
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("start template %q: %w", tmpl.Name, err)
+	}
+	log.Info().
+		WithUint("id", entry.ID).
+		WithString("template", tmpl.Name).
+		WithTime("start", entry.Start).
+		Message("Started entry from template.")
+	return a.toEntryPtr(&entry), nil
+}
+
+func (a *App) findTemplate(name string) (config.EntryTemplate, bool) {
+	for _, tmpl := range a.cfg.Templates {
+		if strings.EqualFold(strings.TrimSpace(tmpl.Name), strings.TrimSpace(name)) {
+			return tmpl, true
+		}
+	}
+	return config.EntryTemplate{}, false
+}
+
+// CopyDayPreview is the entries that would be copied from one day to
+// another, and any overlaps they would have with existing entries.
+type CopyDayPreview struct {
+	// Entries are the copies, which don't have IDs yet.
+	Entries   []Entry          `json:"entries"`
+	Conflicts []analyzer.Issue `json:"conflicts"`
+}
+
+// PreviewCopyDay returns the stopped entries that start on the calendar day
+// that contains "from", moved to the same times of day on the day that
+// contains "to", together with the overlaps they would have with the
+// entries already on that day.
+func (a *App) PreviewCopyDay(from, to time.Time) (CopyDayPreview, error) {
+	copies, conflicts, err := a.planCopyDay(from, to)
+	if err != nil {
+		return CopyDayPreview{}, err
+	}
+	if conflicts == nil {
+		conflicts = []analyzer.Issue{}
+	}
+	return CopyDayPreview{
+		Entries:   a.toEntries(copies),
+		Conflicts: conflicts,
+	}, nil
+}
+
+// CopyDay copies the stopped entries that start on the calendar day that
+// contains "from" to the same times of day on the day that contains "to".
+// Nothing is copied if any of the copies would overlap with existing
+// entries, as reported by [App.PreviewCopyDay].
+func (a *App) CopyDay(from, to time.Time) ([]Entry, error) {
+	copies, conflicts, err := a.planCopyDay(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(copies) == 0 {
+		return nil, ErrCopyNoEntries
+	}
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrCopyConflicts, conflicts[0].Message)
+	}
+	fromDate := a.calendar.Date(from).Format(time.DateOnly)
+	toDate := a.calendar.Date(to).Format(time.DateOnly)
+	description := fmt.Sprintf("Copy entries from %s to %s", fromDate, toDate)
+	var created []dinkur.Entry
+	err = a.mutate(description, func(ctx context.Context) error {
+		for _, entry := range copies {
+			c, err := a.createPastEntry(ctx, entry.Name, entry.Start, *entry.End)
+			if err != nil {
+				return fmt.Errorf("copy entry %q: %w", entry.Name, err)
+			}
+			created = append(created, c)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		WithString("from", fromDate).
+		WithString("to", toDate).
+		WithInt("entries", len(created)).
+		Message("Copied entries.")
+	return a.toEntries(created), nil
+}
+
+// planCopyDay returns the copies of the entries on the day "from", and the
+// overlaps the copies would have on the day "to".
+func (a *App) planCopyDay(from, to time.Time) ([]dinkur.Entry, []analyzer.Issue, error) {
+	fromStart, fromEnd := a.calendar.Day(from)
+	entries, err := a.dinkur.GetEntryList(a.ctx, dinkur.SearchEntry{
+		Limit: EntrySearchLimit,
+		Start: &fromStart,
+		End:   &fromEnd,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("list entries to copy: %w", err)
+	}
+	days := a.calendar.DaysBetween(from, to)
+	var copies []dinkur.Entry
+	for _, entry := range entries {
+		if entry.End == nil || entry.Start.Before(fromStart) {
+			continue
+		}
+		start := a.calendar.AddDays(entry.Start, days)
+		end := start.Add(entry.End.Sub(entry.Start))
+		copies = append(copies, dinkur.Entry{Name: entry.Name, Start: start, End: &end})
+	}
+	if len(copies) == 0 {
+		return copies, nil, nil
+	}
+	conflicts, err := a.findConflicts(copies)
+	if err != nil {
+		return nil, nil, err
+	}
+	return copies, conflicts, nil
+}
+
+// findConflicts returns the overlaps that the new entries, which don't have
+// IDs yet, would have with existing entries or with each other.
+func (a *App) findConflicts(newEntries []dinkur.Entry) ([]analyzer.Issue, error) {
+	now := a.clock.Now()
+	start, end := newEntries[0].Start, newEntries[0].Start
+	for _, entry := range newEntries {
+		entryEnd := now
+		if entry.End != nil {
+			entryEnd = *entry.End
+		}
+		if entry.Start.Before(start) {
+			start = entry.Start
+		}
+		if entryEnd.After(end) {
+			end = entryEnd
+		}
+	}
+	existing, err := a.listOverlapping(a.ctx, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("list existing entries: %w", err)
+	}
+	issues := analyzer.Analyze(append(existing, newEntries...), analyzer.Options{Now: now})
+	conflicts := []analyzer.Issue{}
+	for _, issue := range issues {
+		// Only overlaps with new entries are conflicts, and fixes can't be
+		// applied to entries that don't exist yet.
+		if issue.Kind != analyzer.IssueOverlap || !containsUint(issue.EntryIDs, 0) {
+			continue
+		}
+		issue.Fixes = []analyzer.Fix{}
+		conflicts = append(conflicts, issue)
+	}
+	return conflicts, nil
+}
+
+func containsUint(values []uint, value uint) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPreviewCopyDayFindsEnclosingEntries(t *testing.T) {
+	now := time.Date(2023, 3, 3, 18, 0, 0, 0, time.UTC)
+	from := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 3, 2, 0, 0, 0, 0, time.UTC)
+	a, _ := newTestApp(t, now)
+	createTestEntry(t, a, "Standup", from.Add(10*time.Hour), timePtr(from.Add(11*time.Hour)))
+	// Encloses the copy, so it neither starts nor ends within it.
+	createTestEntry(t, a, "Workshop", to.Add(9*time.Hour), timePtr(to.Add(12*time.Hour)))
+
+	preview, err := a.PreviewCopyDay(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview.Entries) != 1 {
+		t.Fatalf("want 1 copy, got %d", len(preview.Entries))
+	}
+	if len(preview.Conflicts) != 1 {
+		t.Errorf("want 1 conflict with the enclosing entry, got %+v", preview.Conflicts)
+	}
+}
+
+func TestPreviewCopyDayWithoutConflicts(t *testing.T) {
+	now := time.Date(2023, 3, 3, 18, 0, 0, 0, time.UTC)
+	from := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 3, 2, 0, 0, 0, 0, time.UTC)
+	a, _ := newTestApp(t, now)
+	createTestEntry(t, a, "Standup", from.Add(10*time.Hour), timePtr(from.Add(11*time.Hour)))
+	createTestEntry(t, a, "Lunch", to.Add(11*time.Hour), timePtr(to.Add(12*time.Hour)))
+
+	preview, err := a.PreviewCopyDay(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview.Entries) != 1 || len(preview.Conflicts) != 0 {
+		t.Errorf("want 1 copy without conflicts, got %d copies and conflicts %+v",
+			len(preview.Entries), preview.Conflicts)
+	}
+}
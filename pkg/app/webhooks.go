@@ -0,0 +1,506 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/internal/clock"
+	"github.com/dinkur/dinkur-desktop/internal/jsonfile"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Errors specific to webhooks.
+var (
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrWebhookDeliveryPending  = errors.New("webhook delivery is still pending")
+	ErrWebhookRemoved          = errors.New("webhook has been removed from the config")
+)
+
+const (
+	// webhookCheckInterval is how often the outbox is checked for
+	// deliveries that are due to be retried.
+	webhookCheckInterval = 5 * time.Second
+	// webhookQueueSize is how many events can wait to be added to the outbox
+	// before new ones are dropped, so that writing the outbox never blocks
+	// starting and stopping entries.
+	webhookQueueSize = 100
+)
+
+// WebhookDeliveryStatus is the state of delivering an event to a webhook.
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookPending means the event is in the outbox, waiting to be sent
+	// or retried.
+	WebhookPending WebhookDeliveryStatus = "pending"
+	// WebhookDelivered means the webhook responded with a 2xx status code.
+	WebhookDelivered WebhookDeliveryStatus = "delivered"
+	// WebhookFailed means all attempts failed.
+	WebhookFailed WebhookDeliveryStatus = "failed"
+)
+
+// WebhookPayload is the JSON body sent to webhooks.
+type WebhookPayload struct {
+	ID    string    `json:"id"`
+	Event HookEvent `json:"event"`
+	Time  time.Time `json:"time"`
+	Entry Entry     `json:"entry"`
+	// Previous is the entry that was stopped by starting this entry, if any.
+	Previous *Entry `json:"previous,omitempty"`
+}
+
+// WebhookDelivery is an event to deliver to a single webhook, together with
+// the result of the attempts so far.
+type WebhookDelivery struct {
+	ID string `json:"id"`
+	// Webhook is the name of the webhook in the config. The delivery is sent
+	// to the webhook's current URL, which is recorded in URL on each attempt.
+	Webhook string    `json:"webhook"`
+	URL     string    `json:"url"`
+	Event   HookEvent `json:"event"`
+	// Payload is the request body, which is kept between attempts so that
+	// retries are identical.
+	Payload     json.RawMessage       `json:"payload"`
+	Created     time.Time             `json:"created"`
+	Status      WebhookDeliveryStatus `json:"status"`
+	Attempts    int                   `json:"attempts"`
+	NextAttempt time.Time             `json:"nextAttempt"`
+	// StatusCode is the HTTP status code of the last attempt, or 0 if no
+	// response was received.
+	StatusCode int    `json:"statusCode"`
+	LastError  string `json:"lastError"`
+	// Finished is when the event was delivered or the last attempt failed.
+	Finished *time.Time `json:"finished"`
+}
+
+type webhookEvent struct {
+	event    HookEvent
+	entry    Entry
+	previous *Entry
+}
+
+type webhookState struct {
+	// Deliveries are the pending deliveries, i.e the outbox, and the log of
+	// finished deliveries, oldest first.
+	Deliveries []WebhookDelivery
+}
+
+// WebhookSender sends entry events to the configured webhooks. Events are
+// first written to an outbox file, so that they survive restarts and are
+// retried with backoff until delivered.
+type WebhookSender struct {
+	path   string
+	cfg    config.Webhooks
+	clock  clock.Clock
+	client *http.Client
+
+	// OnChange is called with the delivery log after each change.
+	OnChange func([]WebhookDelivery)
+
+	mu    sync.Mutex
+	wake  chan struct{}
+	queue chan webhookEvent
+}
+
+// NewWebhookSender creates a new webhook sender that keeps its outbox in the
+// file at the path.
+func NewWebhookSender(path string, cfg config.Webhooks, clk clock.Clock) *WebhookSender {
+	return &WebhookSender{
+		path:   path,
+		cfg:    cfg,
+		clock:  clk,
+		client: &http.Client{Timeout: cfg.Timeout},
+		wake:   make(chan struct{}, 1),
+		queue:  make(chan webhookEvent, webhookQueueSize),
+	}
+}
+
+// Run adds the queued events to the outbox, and sends the events in the
+// outbox as they become due, until the context is cancelled.
+func (s *WebhookSender) Run(ctx context.Context) {
+	go s.runQueue(ctx)
+	ticker := s.clock.NewTicker(webhookCheckInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.SendDue(ctx); err != nil {
+			log.Warn().WithError(err).Message("Failed to send webhooks.")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		case <-s.wake:
+		}
+	}
+}
+
+// runQueue adds the events queued by the entry listener methods to the
+// outbox, until the context is cancelled.
+func (s *WebhookSender) runQueue(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-s.queue:
+			if err := s.Enqueue(ev.event, ev.entry, ev.previous); err != nil {
+				log.Warn().WithError(err).
+					WithString("event", string(ev.event)).
+					WithUint("id", ev.entry.ID).
+					Message("Failed to add webhook event to outbox.")
+			}
+		}
+	}
+}
+
+// EntryStarted queues a "start" event for all webhooks, or a "switch" event
+// if starting the entry stopped another entry.
+func (s *WebhookSender) EntryStarted(entry Entry, stopped *Entry) {
+	if stopped != nil {
+		s.enqueue(webhookEvent{HookEventSwitch, entry, stopped})
+		return
+	}
+	s.enqueue(webhookEvent{HookEventStart, entry, nil})
+}
+
+// EntryStopped queues a "stop" event for all webhooks.
+func (s *WebhookSender) EntryStopped(entry Entry) {
+	s.enqueue(webhookEvent{HookEventStop, entry, nil})
+}
+
+func (s *WebhookSender) enqueue(ev webhookEvent) {
+	if len(s.cfg.Items) == 0 {
+		return
+	}
+	select {
+	case s.queue <- ev:
+	default:
+		log.Warn().WithString("event", string(ev.event)).
+			WithUint("id", ev.entry.ID).
+			Message("Too many webhook events queued. Skipping event.")
+	}
+}
+
+// Enqueue adds the event to the outbox once for each webhook, and wakes up
+// the sender.
+func (s *WebhookSender) Enqueue(event HookEvent, entry Entry, previous *Entry) error {
+	if len(s.cfg.Items) == 0 {
+		return nil
+	}
+	now := s.clock.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, err := s.read()
+	if err != nil {
+		return err
+	}
+	for _, hook := range s.cfg.Items {
+		id, err := newDeliveryID()
+		if err != nil {
+			return err
+		}
+		payload, err := json.Marshal(WebhookPayload{
+			ID:       id,
+			Event:    event,
+			Time:     now,
+			Entry:    entry,
+			Previous: previous,
+		})
+		if err != nil {
+			return fmt.Errorf("encode webhook payload: %w", err)
+		}
+		state.Deliveries = append(state.Deliveries, WebhookDelivery{
+			ID:          id,
+			Webhook:     hook.Name,
+			URL:         hook.URL,
+			Event:       event,
+			Payload:     payload,
+			Created:     now,
+			Status:      WebhookPending,
+			NextAttempt: now,
+		})
+	}
+	if err := s.write(state); err != nil {
+		return err
+	}
+	s.notify()
+	return nil
+}
+
+// SendDue attempts to send all pending deliveries that are due. Each
+// delivery is attempted at most once per call.
+func (s *WebhookSender) SendDue(ctx context.Context) error {
+	s.mu.Lock()
+	state, err := s.read()
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	now := s.clock.Now()
+	for _, d := range state.Deliveries {
+		if d.Status != WebhookPending || d.NextAttempt.After(now) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		url, statusCode, err := s.send(ctx, d)
+		if err := s.update(d.ID, url, statusCode, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send attempts to deliver the event to the webhook's current URL, and
+// returns the URL.
+func (s *WebhookSender) send(ctx context.Context, d WebhookDelivery) (string, int, error) {
+	hook, ok := s.findWebhook(d.Webhook)
+	if !ok {
+		return d.URL, 0, ErrWebhookRemoved
+	}
+	// The outbox file is indented, which also indents the stored payload.
+	var body bytes.Buffer
+	if err := json.Compact(&body, d.Payload); err != nil {
+		return hook.URL, 0, fmt.Errorf("compact webhook payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return hook.URL, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dinkur-desktop")
+	req.Header.Set("X-Dinkur-Event", string(d.Event))
+	req.Header.Set("X-Dinkur-Delivery", d.ID)
+	if hook.Secret != "" {
+		req.Header.Set("X-Dinkur-Signature", SignWebhookPayload(hook.Secret, body.Bytes()))
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return hook.URL, 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return hook.URL, resp.StatusCode, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return hook.URL, resp.StatusCode, nil
+}
+
+// update records the result of an attempt, and schedules a retry with
+// exponential backoff if it failed and there are attempts left.
+func (s *WebhookSender) update(id, url string, statusCode int, sendErr error) error {
+	now := s.clock.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, err := s.read()
+	if err != nil {
+		return err
+	}
+	i := state.index(id)
+	if i == -1 {
+		return nil
+	}
+	d := &state.Deliveries[i]
+	d.Attempts++
+	d.URL = url
+	d.StatusCode = statusCode
+	d.LastError = ""
+	switch {
+	case sendErr == nil:
+		d.Status = WebhookDelivered
+		d.Finished = &now
+	case d.Attempts >= s.cfg.MaxAttempts || errors.Is(sendErr, ErrWebhookRemoved):
+		d.Status = WebhookFailed
+		d.LastError = sendErr.Error()
+		d.Finished = &now
+	default:
+		d.LastError = sendErr.Error()
+		d.NextAttempt = now.Add(s.retryDelay(d.Attempts))
+	}
+	ev := log.Debug()
+	if d.Status == WebhookFailed {
+		ev = log.Warn()
+	}
+	ev.WithString("webhook", d.Webhook).
+		WithString("delivery", d.ID).
+		WithString("status", string(d.Status)).
+		WithInt("attempts", d.Attempts).
+		WithString("error", d.LastError).
+		Message("Attempted webhook delivery.")
+	state.trim(s.cfg.LogSize)
+	return s.write(state)
+}
+
+func (s *WebhookSender) retryDelay(attempts int) time.Duration {
+	delay := s.cfg.RetryDelay
+	for i := 1; i < attempts && delay < s.cfg.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.cfg.MaxRetryDelay {
+		delay = s.cfg.MaxRetryDelay
+	}
+	return delay
+}
+
+// Deliveries returns the pending and finished deliveries, most recent first.
+func (s *WebhookSender) Deliveries() ([]WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return state.log(), nil
+}
+
+// Retry moves a failed delivery back to the outbox, to be sent again.
+func (s *WebhookSender) Retry(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, err := s.read()
+	if err != nil {
+		return err
+	}
+	i := state.index(id)
+	if i == -1 {
+		return fmt.Errorf("%w: %q", ErrWebhookDeliveryNotFound, id)
+	}
+	d := &state.Deliveries[i]
+	if d.Status == WebhookPending {
+		return fmt.Errorf("%w: %q", ErrWebhookDeliveryPending, id)
+	}
+	d.Status = WebhookPending
+	d.Attempts = 0
+	d.NextAttempt = s.clock.Now()
+	d.Finished = nil
+	if err := s.write(state); err != nil {
+		return err
+	}
+	s.notify()
+	return nil
+}
+
+func (s *WebhookSender) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *WebhookSender) findWebhook(name string) (config.Webhook, bool) {
+	for _, hook := range s.cfg.Items {
+		if hook.Name == name {
+			return hook, true
+		}
+	}
+	return config.Webhook{}, false
+}
+
+func (s *WebhookSender) read() (webhookState, error) {
+	var state webhookState
+	if err := jsonfile.Read(s.path, &state); err != nil {
+		return webhookState{}, fmt.Errorf("read webhook outbox: %w", err)
+	}
+	return state, nil
+}
+
+func (s *WebhookSender) write(state webhookState) error {
+	if err := jsonfile.Write(s.path, state); err != nil {
+		return fmt.Errorf("write webhook outbox: %w", err)
+	}
+	if s.OnChange != nil {
+		s.OnChange(state.log())
+	}
+	return nil
+}
+
+func (state webhookState) index(id string) int {
+	for i, d := range state.Deliveries {
+		if d.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// trim drops the oldest finished deliveries, keeping at most size of them.
+// Pending deliveries are never dropped.
+func (state *webhookState) trim(size int) {
+	finished := 0
+	for _, d := range state.Deliveries {
+		if d.Status != WebhookPending {
+			finished++
+		}
+	}
+	kept := state.Deliveries[:0]
+	for _, d := range state.Deliveries {
+		if d.Status != WebhookPending && finished > size {
+			finished--
+			continue
+		}
+		kept = append(kept, d)
+	}
+	state.Deliveries = kept
+}
+
+func (state webhookState) log() []WebhookDelivery {
+	deliveries := append([]WebhookDelivery{}, state.Deliveries...)
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].Created.After(deliveries[j].Created)
+	})
+	return deliveries
+}
+
+// SignWebhookPayload returns the value of the X-Dinkur-Signature header,
+// which is the HMAC-SHA256 of the payload as "sha256=<hex>".
+func SignWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newDeliveryID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (a *App) startWebhookSender(ctx context.Context) {
+	if len(a.cfg.Webhooks.Items) == 0 {
+		return
+	}
+	s := a.webhooks
+	s.OnChange = a.onWebhookChange
+	a.entryWatcher.AddListener(s)
+	go s.Run(ctx)
+}
+
+func (a *App) onWebhookChange(deliveries []WebhookDelivery) {
+	runtime.EventsEmit(a.ctx, "webhooks:changed", deliveries)
+}
+
+// GetWebhookDeliveries returns the webhook delivery log, including the
+// events still waiting in the outbox, most recent first.
+func (a *App) GetWebhookDeliveries() ([]WebhookDelivery, error) {
+	return a.webhooks.Deliveries()
+}
+
+// RetryWebhookDelivery sends a failed webhook delivery again.
+func (a *App) RetryWebhookDelivery(id string) error {
+	return a.webhooks.Retry(id)
+}
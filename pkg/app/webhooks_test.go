@@ -0,0 +1,290 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/internal/clock"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+	"github.com/dinkur/dinkur/pkg/dinkur"
+)
+
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookServer records the requests it receives, and responds with the
+// status codes in order, followed by 200 OK.
+type webhookServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	requests []webhookRequest
+}
+
+func newWebhookServer(t *testing.T, statuses ...int) *webhookServer {
+	srv := &webhookServer{statuses: statuses}
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		srv.requests = append(srv.requests, webhookRequest{r.Header.Clone(), body})
+		status := http.StatusOK
+		if len(srv.statuses) > 0 {
+			status, srv.statuses = srv.statuses[0], srv.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (srv *webhookServer) received() []webhookRequest {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return append([]webhookRequest(nil), srv.requests...)
+}
+
+func newTestWebhookSender(t *testing.T, path string, clk clock.Clock, items ...config.Webhook) *WebhookSender {
+	t.Helper()
+	cfg := config.Default.Webhooks
+	cfg.MaxAttempts = 3
+	cfg.RetryDelay = time.Minute
+	cfg.MaxRetryDelay = 90 * time.Second
+	cfg.Items = items
+	return NewWebhookSender(path, cfg, clk)
+}
+
+func testWebhookEntry(id uint, name string) Entry {
+	return Entry{Entry: dinkur.Entry{CommonFields: dinkur.CommonFields{ID: id}, Name: name}}
+}
+
+func sendDue(t *testing.T, s *WebhookSender) []WebhookDelivery {
+	t.Helper()
+	if err := s.SendDue(context.Background()); err != nil {
+		t.Fatalf("send due: %v", err)
+	}
+	deliveries, err := s.Deliveries()
+	if err != nil {
+		t.Fatalf("deliveries: %v", err)
+	}
+	return deliveries
+}
+
+func TestWebhookSenderSignsPayload(t *testing.T) {
+	srv := newWebhookServer(t)
+	clk := clock.NewFake(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC))
+	s := newTestWebhookSender(t, filepath.Join(t.TempDir(), "webhooks.json"), clk,
+		config.Webhook{Name: "ci", URL: srv.URL, Secret: "s3cret"})
+
+	previous := testWebhookEntry(1, "Review")
+	if err := s.Enqueue(HookEventSwitch, testWebhookEntry(2, "Coding"), &previous); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	deliveries := sendDue(t, s)
+
+	reqs := srv.received()
+	if len(reqs) != 1 {
+		t.Fatalf("want 1 request, got %d", len(reqs))
+	}
+	req := reqs[0]
+	if got, want := req.header.Get("X-Dinkur-Signature"), SignWebhookPayload("s3cret", req.body); got != want {
+		t.Errorf("signature: want %q, got %q", want, got)
+	}
+	if got := req.header.Get("X-Dinkur-Event"); got != string(HookEventSwitch) {
+		t.Errorf("event header: want %q, got %q", HookEventSwitch, got)
+	}
+	var payload WebhookPayload
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if payload.Entry.Name != "Coding" || payload.Previous == nil || payload.Previous.Name != "Review" {
+		t.Errorf("unexpected payload: %s", req.body)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != WebhookDelivered {
+		t.Fatalf("want 1 delivered delivery, got %+v", deliveries)
+	}
+	if req.header.Get("X-Dinkur-Delivery") != deliveries[0].ID || payload.ID != deliveries[0].ID {
+		t.Errorf("delivery ID: want %q in header and payload", deliveries[0].ID)
+	}
+}
+
+func TestWebhookSenderUnsignedWithoutSecret(t *testing.T) {
+	srv := newWebhookServer(t)
+	clk := clock.NewFake(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC))
+	s := newTestWebhookSender(t, filepath.Join(t.TempDir(), "webhooks.json"), clk,
+		config.Webhook{Name: "ci", URL: srv.URL})
+
+	if err := s.Enqueue(HookEventStop, testWebhookEntry(1, "Coding"), nil); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	sendDue(t, s)
+	reqs := srv.received()
+	if len(reqs) != 1 {
+		t.Fatalf("want 1 request, got %d", len(reqs))
+	}
+	if sig := reqs[0].header.Get("X-Dinkur-Signature"); sig != "" {
+		t.Errorf("want no signature, got %q", sig)
+	}
+}
+
+func TestWebhookSenderRetriesWithBackoff(t *testing.T) {
+	srv := newWebhookServer(t, http.StatusInternalServerError, http.StatusBadGateway)
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	s := newTestWebhookSender(t, filepath.Join(t.TempDir(), "webhooks.json"), clk,
+		config.Webhook{Name: "ci", URL: srv.URL})
+
+	if err := s.Enqueue(HookEventStart, testWebhookEntry(1, "Coding"), nil); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	d := sendDue(t, s)[0]
+	if d.Status != WebhookPending || d.Attempts != 1 || d.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("after 1st attempt: %+v", d)
+	}
+	if want := start.Add(time.Minute); !d.NextAttempt.Equal(want) {
+		t.Errorf("next attempt: want %s, got %s", want, d.NextAttempt)
+	}
+
+	clk.Advance(59 * time.Second)
+	sendDue(t, s)
+	if n := len(srv.received()); n != 1 {
+		t.Fatalf("want no retry before the delay, got %d requests", n)
+	}
+
+	clk.Advance(time.Second)
+	d = sendDue(t, s)[0]
+	if d.Status != WebhookPending || d.Attempts != 2 || d.StatusCode != http.StatusBadGateway {
+		t.Fatalf("after 2nd attempt: %+v", d)
+	}
+	// The delay is doubled, but capped by MaxRetryDelay.
+	if want := clk.Now().Add(90 * time.Second); !d.NextAttempt.Equal(want) {
+		t.Errorf("next attempt: want %s, got %s", want, d.NextAttempt)
+	}
+
+	clk.Advance(90 * time.Second)
+	d = sendDue(t, s)[0]
+	if d.Status != WebhookDelivered || d.Attempts != 3 {
+		t.Fatalf("after 3rd attempt: %+v", d)
+	}
+}
+
+func TestWebhookSenderGivesUpAfterMaxAttempts(t *testing.T) {
+	srv := newWebhookServer(t, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+	clk := clock.NewFake(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC))
+	s := newTestWebhookSender(t, filepath.Join(t.TempDir(), "webhooks.json"), clk,
+		config.Webhook{Name: "ci", URL: srv.URL})
+
+	if err := s.Enqueue(HookEventStart, testWebhookEntry(1, "Coding"), nil); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	var d WebhookDelivery
+	for i := 0; i < 3; i++ {
+		d = sendDue(t, s)[0]
+		clk.Advance(time.Hour)
+	}
+	if d.Status != WebhookFailed || d.Attempts != 3 {
+		t.Fatalf("want failed after 3 attempts, got %+v", d)
+	}
+	sendDue(t, s)
+	if n := len(srv.received()); n != 3 {
+		t.Errorf("want 3 requests, got %d", n)
+	}
+}
+
+func TestWebhookSenderOutboxSurvivesRestart(t *testing.T) {
+	srv := newWebhookServer(t)
+	path := filepath.Join(t.TempDir(), "webhooks.json")
+	clk := clock.NewFake(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC))
+	s := newTestWebhookSender(t, path, clk,
+		config.Webhook{Name: "ci", URL: "http://127.0.0.1:0/unreachable"})
+	if err := s.Enqueue(HookEventStart, testWebhookEntry(1, "Coding"), nil); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	// The webhook's URL is changed while the event is still in the outbox.
+	restarted := newTestWebhookSender(t, path, clk,
+		config.Webhook{Name: "ci", URL: srv.URL})
+	deliveries := sendDue(t, restarted)
+
+	if n := len(srv.received()); n != 1 {
+		t.Fatalf("want 1 request, got %d", n)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != WebhookDelivered {
+		t.Fatalf("want 1 delivered delivery, got %+v", deliveries)
+	}
+	if deliveries[0].URL != srv.URL {
+		t.Errorf("URL: want %q, got %q", srv.URL, deliveries[0].URL)
+	}
+}
+
+func TestWebhookSenderFailsRemovedWebhook(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "webhooks.json")
+	clk := clock.NewFake(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC))
+	s := newTestWebhookSender(t, path, clk, config.Webhook{Name: "ci", URL: "http://127.0.0.1:0/"})
+	if err := s.Enqueue(HookEventStart, testWebhookEntry(1, "Coding"), nil); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	restarted := newTestWebhookSender(t, path, clk, config.Webhook{Name: "other", URL: "http://127.0.0.1:0/"})
+	deliveries := sendDue(t, restarted)
+	if len(deliveries) != 1 || deliveries[0].Status != WebhookFailed {
+		t.Fatalf("want 1 failed delivery, got %+v", deliveries)
+	}
+}
+
+func TestWebhookSenderListenerQueuesSwitch(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC))
+	s := newTestWebhookSender(t, filepath.Join(t.TempDir(), "webhooks.json"), clk,
+		config.Webhook{Name: "ci", URL: "http://127.0.0.1:0/"})
+
+	stopped := testWebhookEntry(1, "Review")
+	s.EntryStarted(testWebhookEntry(2, "Coding"), &stopped)
+	s.EntryStarted(testWebhookEntry(3, "Lunch"), nil)
+
+	// The listener must not touch the outbox, which is done by Run.
+	deliveries, err := s.Deliveries()
+	if err != nil {
+		t.Fatalf("deliveries: %v", err)
+	}
+	if len(deliveries) != 0 {
+		t.Fatalf("want empty outbox before Run, got %d deliveries", len(deliveries))
+	}
+	if ev := <-s.queue; ev.event != HookEventSwitch || ev.previous == nil || ev.previous.ID != 1 {
+		t.Errorf("want switch event from entry 1, got %+v", ev)
+	}
+	if ev := <-s.queue; ev.event != HookEventStart || ev.previous != nil {
+		t.Errorf("want start event, got %+v", ev)
+	}
+}
+
+func TestWebhookSenderListenerDropsWhenFull(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC))
+	s := newTestWebhookSender(t, filepath.Join(t.TempDir(), "webhooks.json"), clk,
+		config.Webhook{Name: "ci", URL: "http://127.0.0.1:0/"})
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < webhookQueueSize+10; i++ {
+			s.EntryStopped(testWebhookEntry(uint(i), "Coding"))
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener blocked on a full queue")
+	}
+	if n := len(s.queue); n != webhookQueueSize {
+		t.Errorf("want %d queued events, got %d", webhookQueueSize, n)
+	}
+}
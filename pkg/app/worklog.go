@@ -0,0 +1,106 @@
+package app
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/worklog"
+	"github.com/dinkur/dinkur/pkg/dinkur"
+)
+
+// WorklogEntries converts the entries for pushing as worklogs. Active entries
+// are left without an end time, and are never pushed.
+func WorklogEntries(entries []Entry) []worklog.Entry {
+	result := make([]worklog.Entry, len(entries))
+	for i, entry := range entries {
+		result[i] = worklog.Entry{
+			ID:    entry.ID,
+			Name:  entry.Name,
+			Start: entry.Start,
+			Keys:  entry.Tickets,
+		}
+		if entry.End != nil {
+			result[i].End = *entry.End
+		}
+	}
+	return result
+}
+
+// WorklogPath returns the path of the file that records which entries have
+// been pushed as worklogs.
+func WorklogPath(dataDir string) string {
+	return filepath.Join(dataDir, "worklogs.json")
+}
+
+func (a *App) worklogSyncer() (*worklog.Syncer, error) {
+	return worklog.New(a.cfg, WorklogPath(a.cfg.DataDir), a.clock)
+}
+
+func (a *App) worklogEntries(start, end time.Time) ([]worklog.Entry, time.Time, time.Time, error) {
+	start, end = a.daySpan(start, end)
+	entries, err := a.listEntries(a.ctx, start, end)
+	if err != nil {
+		return nil, start, end, err
+	}
+	return WorklogEntries(entries), start, end, nil
+}
+
+// PlanWorklogs returns which entries with ticket keys, from the calendar day
+// that contains the start time up to and including the day that contains
+// the end time, would be pushed as worklogs, and which have already been
+// pushed. Entries edited or deleted after they were pushed are flagged.
+func (a *App) PlanWorklogs(start, end time.Time) (worklog.Plan, error) {
+	syncer, err := a.worklogSyncer()
+	if err != nil {
+		return worklog.Plan{}, err
+	}
+	entries, start, end, err := a.worklogEntries(start, end)
+	if err != nil {
+		return worklog.Plan{}, err
+	}
+	return syncer.Plan(entries, start, end)
+}
+
+// PushWorklogs pushes the entries that [App.PlanWorklogs] lists as new, and
+// returns the updated plan. Entries that have already been pushed are never
+// pushed again.
+func (a *App) PushWorklogs(start, end time.Time) (worklog.Plan, error) {
+	syncer, err := a.worklogSyncer()
+	if err != nil {
+		return worklog.Plan{}, err
+	}
+	entries, start, end, err := a.worklogEntries(start, end)
+	if err != nil {
+		return worklog.Plan{}, err
+	}
+	plan, err := syncer.Push(a.ctx, entries, start, end)
+	if err != nil {
+		log.Warn().WithError(err).Message("Failed to push some worklogs.")
+	}
+	log.Info().
+		WithInt("pushed", plan.Count(worklog.StatusPushed)).
+		WithInt("failed", plan.Count(worklog.StatusNew)).
+		WithInt("edited", plan.Count(worklog.StatusEdited)).
+		WithInt("deleted", plan.Count(worklog.StatusDeleted)).
+		Message("Pushed worklogs.")
+	return plan, err
+}
+
+// AcknowledgeWorklog clears the flag of an entry that was edited or deleted
+// after it was pushed, once its worklog has been corrected by hand.
+func (a *App) AcknowledgeWorklog(entryID uint) error {
+	syncer, err := a.worklogSyncer()
+	if err != nil {
+		return err
+	}
+	entry, err := a.dinkur.GetEntry(a.ctx, entryID)
+	if errors.Is(err, dinkur.ErrNotFound) {
+		return syncer.Acknowledge(entryID, nil)
+	}
+	if err != nil {
+		return err
+	}
+	converted := WorklogEntries([]Entry{a.toEntry(entry)})
+	return syncer.Acknowledge(entryID, &converted[0])
+}
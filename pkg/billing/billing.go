@@ -0,0 +1,386 @@
+// Package billing calculates billable amounts of entries from hourly rates,
+// with optional rounding of the billed durations.
+package billing
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/calendar"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Entry is an entry to bill for. The start and end times are clamped to the
+// report's time span, and active entries should have their end time set to
+// the current time.
+type Entry struct {
+	ID       uint
+	Name     string
+	Client   string
+	Project  string
+	Tags     []string
+	Start    time.Time
+	End      time.Time
+	Billable bool
+}
+
+// Rate is a compiled hourly rate from the billing config.
+type Rate struct {
+	Name    string
+	Tag     string
+	Project string
+	Match   *regexp.Regexp
+	Rate    float64
+}
+
+// Matches returns true if the entry matches all of the rate's conditions.
+func (r Rate) Matches(entry Entry) bool {
+	if r.Tag != "" && !containsFold(entry.Tags, r.Tag) {
+		return false
+	}
+	if r.Project != "" && !strings.EqualFold(entry.Project, r.Project) &&
+		!strings.EqualFold(entry.Client, r.Project) {
+		return false
+	}
+	if r.Match != nil && !r.Match.MatchString(entry.Name) {
+		return false
+	}
+	return true
+}
+
+// Calculator calculates billable amounts.
+type Calculator struct {
+	rates       []Rate
+	defaultRate float64
+	rounding    config.Rounding
+	unit        currency.Unit
+	scale       int
+	printer     *message.Printer
+	cal         *calendar.Calendar
+}
+
+// New creates a new calculator from the billing config. The config is
+// expected to already have been validated via [config.Config.Validate], but
+// any invalid values are still reported. Entries are grouped by the days of
+// the calendar.
+func New(cfg config.Billing, cal *calendar.Calendar) (*Calculator, error) {
+	unit, err := currency.ParseISO(cfg.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("billing.currency: %w", err)
+	}
+	tag, err := locale(cfg.Locale)
+	if err != nil {
+		return nil, fmt.Errorf("billing.locale: %w", err)
+	}
+	scale, _ := currency.Standard.Rounding(unit)
+	c := &Calculator{
+		defaultRate: cfg.DefaultRate,
+		rounding:    cfg.Rounding,
+		unit:        unit,
+		scale:       scale,
+		printer:     message.NewPrinter(tag),
+		cal:         cal,
+	}
+	for i, r := range cfg.Rates {
+		rate := Rate{
+			Name:    r.Name,
+			Tag:     r.Tag,
+			Project: r.Project,
+			Rate:    r.Rate,
+		}
+		if r.Match != "" {
+			re, err := regexp.Compile(r.Match)
+			if err != nil {
+				return nil, fmt.Errorf("billing.rates[%d].match: %w", i, err)
+			}
+			rate.Match = re
+		}
+		if rate.Name == "" {
+			rate.Name = fmt.Sprintf("Rate #%d", i+1)
+		}
+		c.rates = append(c.rates, rate)
+	}
+	return c, nil
+}
+
+// locale parses the BCP 47 language tag, or falls back to the locale of the
+// environment if empty.
+func locale(s string) (language.Tag, error) {
+	if s != "" {
+		return language.Parse(s)
+	}
+	for _, env := range []string{"LC_ALL", "LC_MONETARY", "LANG"} {
+		value := os.Getenv(env)
+		if value == "" {
+			continue
+		}
+		// POSIX locales look like "sv_SE.UTF-8@euro"
+		value, _, _ = strings.Cut(value, ".")
+		value, _, _ = strings.Cut(value, "@")
+		if value == "C" || value == "POSIX" {
+			break
+		}
+		if tag, err := language.Parse(strings.ReplaceAll(value, "_", "-")); err == nil {
+			return tag, nil
+		}
+	}
+	return language.AmericanEnglish, nil
+}
+
+// Calendar returns the calendar used to group entries by day.
+func (c *Calculator) Calendar() *calendar.Calendar {
+	return c.cal
+}
+
+// Currency returns the ISO 4217 currency code of all amounts.
+func (c *Calculator) Currency() string {
+	return c.unit.String()
+}
+
+// Format formats the amount using the currency and locale from the config,
+// e.g "€ 1 234,50" for the currency EUR in the locale sv-SE.
+func (c *Calculator) Format(amount float64) string {
+	return c.printer.Sprint(currency.Symbol(c.unit.Amount(amount)))
+}
+
+// Rate returns the name and hourly rate of the first rate that matches the
+// entry, or the default rate if none matches.
+func (c *Calculator) Rate(entry Entry) (string, float64) {
+	for _, r := range c.rates {
+		if r.Matches(entry) {
+			return r.Name, r.Rate
+		}
+	}
+	return "Default", c.defaultRate
+}
+
+// Round rounds the duration according to the rounding mode and increment
+// from the config.
+func (c *Calculator) Round(d time.Duration) time.Duration {
+	return c.rounding.Mode.Round(d, c.rounding.Increment)
+}
+
+// Amount returns the cost of the duration at the hourly rate, rounded to the
+// currency's number of decimals.
+func (c *Calculator) Amount(d time.Duration, rate float64) float64 {
+	return c.RoundAmount(d.Hours() * rate)
+}
+
+// Report is the billable totals within a time span, per client.
+type Report struct {
+	Start    time.Time       `json:"start"`
+	End      time.Time       `json:"end"`
+	Currency string          `json:"currency"`
+	Rounding config.Rounding `json:"rounding"`
+	// Duration is the tracked time of all billable entries.
+	Duration time.Duration `json:"duration"`
+	// Billed is the tracked time of all billable entries after rounding.
+	Billed          time.Duration `json:"billed"`
+	Amount          float64       `json:"amount"`
+	FormattedAmount string        `json:"formattedAmount"`
+	// NonBillable is the tracked time of all non-billable entries.
+	NonBillable time.Duration `json:"nonBillable"`
+	Clients     []ClientTotal `json:"clients"`
+}
+
+// ClientTotal is the billable total of a single client. The client is empty
+// for entries without a client or project.
+type ClientTotal struct {
+	Client          string        `json:"client"`
+	Duration        time.Duration `json:"duration"`
+	Billed          time.Duration `json:"billed"`
+	Amount          float64       `json:"amount"`
+	FormattedAmount string        `json:"formattedAmount"`
+	Days            []DayTotal    `json:"days"`
+}
+
+// DayTotal is the billable total of a single client on a single day.
+type DayTotal struct {
+	Date            time.Time     `json:"date"`
+	Duration        time.Duration `json:"duration"`
+	Billed          time.Duration `json:"billed"`
+	Amount          float64       `json:"amount"`
+	FormattedAmount string        `json:"formattedAmount"`
+	Lines           []Line        `json:"lines"`
+}
+
+// Line is a single billable entry. When rounding per day, the rounding
+// difference is spread over the day's lines, starting with the longest, so
+// that the lines always add up to the day's total.
+type Line struct {
+	EntryID         uint          `json:"entryId"`
+	Name            string        `json:"name"`
+	Start           time.Time     `json:"start"`
+	End             time.Time     `json:"end"`
+	RateName        string        `json:"rateName"`
+	Rate            float64       `json:"rate"`
+	Duration        time.Duration `json:"duration"`
+	Billed          time.Duration `json:"billed"`
+	Amount          float64       `json:"amount"`
+	FormattedAmount string        `json:"formattedAmount"`
+}
+
+// Report calculates the billable totals of the entries within the given
+// time span. Entries are counted on the calendar day they start.
+func (c *Calculator) Report(entries []Entry, start, end time.Time) Report {
+	report := Report{
+		Start:    start.In(c.cal.Location()),
+		End:      end.In(c.cal.Location()),
+		Currency: c.Currency(),
+		Rounding: c.rounding,
+	}
+	type dayKey struct {
+		client string
+		date   time.Time
+	}
+	days := map[dayKey][]Line{}
+	for _, entry := range entries {
+		entryStart, entryEnd := clamp(entry.Start, entry.End, start, end)
+		dur := entryEnd.Sub(entryStart)
+		if dur <= 0 {
+			continue
+		}
+		if !entry.Billable {
+			report.NonBillable += dur
+			continue
+		}
+		rateName, rate := c.Rate(entry)
+		key := dayKey{entry.Client, c.cal.Date(entryStart)}
+		days[key] = append(days[key], Line{
+			EntryID:  entry.ID,
+			Name:     entry.Name,
+			Start:    entryStart.In(c.cal.Location()),
+			End:      entryEnd.In(c.cal.Location()),
+			RateName: rateName,
+			Rate:     rate,
+			Duration: dur,
+			Billed:   dur,
+		})
+	}
+
+	clients := map[string]*ClientTotal{}
+	for key, lines := range days {
+		c.roundLines(lines)
+		day := DayTotal{Date: key.date, Lines: lines}
+		for i := range day.Lines {
+			line := &day.Lines[i]
+			line.Amount = c.Amount(line.Billed, line.Rate)
+			line.FormattedAmount = c.Format(line.Amount)
+			day.Duration += line.Duration
+			day.Billed += line.Billed
+			day.Amount += line.Amount
+		}
+		sort.Slice(day.Lines, func(i, j int) bool {
+			return day.Lines[i].Start.Before(day.Lines[j].Start)
+		})
+		client, ok := clients[key.client]
+		if !ok {
+			client = &ClientTotal{Client: key.client}
+			clients[key.client] = client
+		}
+		client.Duration += day.Duration
+		client.Billed += day.Billed
+		client.Amount += day.Amount
+		client.Days = append(client.Days, day)
+	}
+
+	report.Clients = make([]ClientTotal, 0, len(clients))
+	for _, client := range clients {
+		sort.Slice(client.Days, func(i, j int) bool {
+			return client.Days[i].Date.Before(client.Days[j].Date)
+		})
+		for i := range client.Days {
+			client.Days[i].Amount = c.RoundAmount(client.Days[i].Amount)
+			client.Days[i].FormattedAmount = c.Format(client.Days[i].Amount)
+		}
+		client.Amount = c.RoundAmount(client.Amount)
+		client.FormattedAmount = c.Format(client.Amount)
+		report.Duration += client.Duration
+		report.Billed += client.Billed
+		report.Amount += client.Amount
+		report.Clients = append(report.Clients, *client)
+	}
+	sort.Slice(report.Clients, func(i, j int) bool {
+		// Entries without a client go last
+		a, b := report.Clients[i].Client, report.Clients[j].Client
+		if a == "" || b == "" {
+			return b == ""
+		}
+		return strings.ToLower(a) < strings.ToLower(b)
+	})
+	report.Amount = c.RoundAmount(report.Amount)
+	report.FormattedAmount = c.Format(report.Amount)
+	return report
+}
+
+// roundLines rounds the billed durations of a single day's lines, either
+// per line or per rate, depending on the rounding scope.
+func (c *Calculator) roundLines(lines []Line) {
+	if c.rounding.Mode == config.RoundingModeNone {
+		return
+	}
+	if c.rounding.Scope != config.RoundingScopeDay {
+		for i := range lines {
+			lines[i].Billed = c.Round(lines[i].Duration)
+		}
+		return
+	}
+	byRate := map[float64][]*Line{}
+	for i := range lines {
+		byRate[lines[i].Rate] = append(byRate[lines[i].Rate], &lines[i])
+	}
+	for _, group := range byRate {
+		var total time.Duration
+		for _, line := range group {
+			total += line.Duration
+		}
+		diff := c.Round(total) - total
+		sort.SliceStable(group, func(i, j int) bool {
+			return group[i].Duration > group[j].Duration
+		})
+		for _, line := range group {
+			if diff == 0 {
+				break
+			}
+			adjust := diff
+			if line.Billed+adjust < 0 {
+				adjust = -line.Billed
+			}
+			line.Billed += adjust
+			diff -= adjust
+		}
+	}
+}
+
+// RoundAmount rounds the amount to the currency's number of decimals.
+func (c *Calculator) RoundAmount(amount float64) float64 {
+	pow := math.Pow10(c.scale)
+	return math.Round(amount*pow) / pow
+}
+
+func clamp(entryStart, entryEnd, start, end time.Time) (time.Time, time.Time) {
+	if entryStart.Before(start) {
+		entryStart = start
+	}
+	if entryEnd.After(end) {
+		entryEnd = end
+	}
+	return entryStart, entryEnd
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+package billing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/calendar"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+)
+
+func newTestCalculator(t *testing.T, cfg config.Billing) *Calculator {
+	t.Helper()
+	cal, err := calendar.New(config.Calendar{TimeZone: "UTC"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Currency = "EUR"
+	cfg.Locale = "en-US"
+	c, err := New(cfg, cal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestRound(t *testing.T) {
+	const m = time.Minute
+	tests := []struct {
+		mode      config.RoundingMode
+		increment time.Duration
+		d         time.Duration
+		want      time.Duration
+	}{
+		{config.RoundingModeNone, 15 * m, 7 * m, 7 * m},
+		{config.RoundingModeUp, 15 * m, 0, 0},
+		{config.RoundingModeUp, 15 * m, 1 * m, 15 * m},
+		{config.RoundingModeUp, 15 * m, 15 * m, 15 * m},
+		{config.RoundingModeUp, 15 * m, 52 * m, time.Hour},
+		{config.RoundingModeUp, 15 * m, 61 * m, 75 * m},
+		{config.RoundingModeUp, 6 * m, 62 * m, 66 * m},
+		{config.RoundingModeUp, 0, 7 * m, 7 * m},
+		{config.RoundingModeDown, 15 * m, 0, 0},
+		{config.RoundingModeDown, 15 * m, 14 * m, 0},
+		{config.RoundingModeDown, 15 * m, 74 * m, time.Hour},
+		{config.RoundingModeDown, 30 * m, 89 * m, time.Hour},
+		{config.RoundingModeNearest, 15 * m, 0, 0},
+		{config.RoundingModeNearest, 15 * m, 7 * m, 0},
+		{config.RoundingModeNearest, 15 * m, 7*m + 30*time.Second, 15 * m},
+		{config.RoundingModeNearest, 15 * m, 53 * m, time.Hour},
+		{config.RoundingModeNearest, 6 * m, 62 * m, 60 * m},
+	}
+	for _, tc := range tests {
+		t.Run(string(tc.mode)+"/"+tc.increment.String()+"/"+tc.d.String(), func(t *testing.T) {
+			c := newTestCalculator(t, config.Billing{
+				Rounding: config.Rounding{Mode: tc.mode, Increment: tc.increment},
+			})
+			if got := c.Round(tc.d); got != tc.want {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRate(t *testing.T) {
+	c := newTestCalculator(t, config.Billing{
+		DefaultRate: 100,
+		Rates: []config.Rate{
+			{Name: "Urgent Acme", Tag: "urgent", Project: "acme", Rate: 200},
+			{Name: "Urgent", Tag: "urgent", Rate: 150},
+			{Name: "Acme", Project: "acme", Rate: 120},
+			{Match: "^Support", Rate: 90},
+		},
+	})
+	tests := []struct {
+		name  string
+		entry Entry
+		want  string
+		rate  float64
+	}{
+		{"all conditions", Entry{Name: "Fix", Project: "Acme", Tags: []string{"urgent"}}, "Urgent Acme", 200},
+		{"first match", Entry{Name: "Support call", Tags: []string{"Urgent"}}, "Urgent", 150},
+		{"project", Entry{Name: "Support call", Project: "acme"}, "Acme", 120},
+		{"client", Entry{Name: "Fix", Client: "ACME"}, "Acme", 120},
+		{"unnamed match", Entry{Name: "Support call"}, "Rate #4", 90},
+		{"match is case sensitive", Entry{Name: "support call"}, "Default", 100},
+		{"default", Entry{Name: "Fix"}, "Default", 100},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			name, rate := c.Rate(tc.entry)
+			if name != tc.want || rate != tc.rate {
+				t.Errorf("want %s at %v, got %s at %v", tc.want, tc.rate, name, rate)
+			}
+		})
+	}
+}
+
+func TestReport(t *testing.T) {
+	day := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	at := func(days int, hours float64) time.Time {
+		return day.AddDate(0, 0, days).Add(time.Duration(hours * float64(time.Hour)))
+	}
+	entries := []Entry{
+		// Started the day before the report, so only the last hour counts.
+		{ID: 1, Name: "Deploy", Client: "Acme", Start: at(-1, 23), End: at(0, 1), Billable: true},
+		{ID: 2, Name: "Fix", Client: "Acme", Start: at(0, 9), End: at(0, 9+50.0/60), Billable: true},
+		{ID: 3, Name: "Fix", Client: "Acme", Start: at(1, 9), End: at(1, 9+5.0/60), Billable: true},
+		{ID: 4, Name: "Empty", Client: "Acme", Start: at(1, 10), End: at(1, 10), Billable: true},
+		{ID: 5, Name: "Review", Start: at(1, 11), End: at(1, 11.5), Billable: true},
+		{ID: 6, Name: "Lunch", Start: at(1, 12), End: at(1, 13)},
+	}
+	tests := []struct {
+		name   string
+		scope  config.RoundingScope
+		billed map[uint]time.Duration
+		amount float64
+	}{
+		{
+			name:  "per entry",
+			scope: config.RoundingScopeEntry,
+			billed: map[uint]time.Duration{
+				1: time.Hour, 2: time.Hour, 3: 15 * time.Minute, 5: 30 * time.Minute,
+			},
+			// 2h15m at 100, and 30m at 80.
+			amount: 225 + 40,
+		},
+		{
+			name:  "per day",
+			scope: config.RoundingScopeDay,
+			billed: map[uint]time.Duration{
+				// 1h50m on the first day is rounded up to 2h, with the
+				// difference added to the longest entry.
+				1: 70 * time.Minute, 2: 50 * time.Minute, 3: 15 * time.Minute, 5: 30 * time.Minute,
+			},
+			amount: 225 + 40,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestCalculator(t, config.Billing{
+				DefaultRate: 100,
+				Rates:       []config.Rate{{Name: "Review", Match: "Review", Rate: 80}},
+				Rounding: config.Rounding{
+					Mode:      config.RoundingModeUp,
+					Increment: 15 * time.Minute,
+					Scope:     tc.scope,
+				},
+			})
+			report := c.Report(entries, day, day.AddDate(0, 0, 2))
+
+			billed := map[uint]time.Duration{}
+			var sumBilled time.Duration
+			var sumAmount float64
+			for _, client := range report.Clients {
+				var clientBilled time.Duration
+				for _, d := range client.Days {
+					var dayBilled time.Duration
+					for _, line := range d.Lines {
+						billed[line.EntryID] = line.Billed
+						dayBilled += line.Billed
+					}
+					if dayBilled != d.Billed {
+						t.Errorf("%s %s: want lines to add up to %v, got %v", client.Client, d.Date.Format(time.DateOnly), d.Billed, dayBilled)
+					}
+					clientBilled += d.Billed
+				}
+				if clientBilled != client.Billed {
+					t.Errorf("%s: want days to add up to %v, got %v", client.Client, client.Billed, clientBilled)
+				}
+				sumBilled += client.Billed
+				sumAmount += client.Amount
+			}
+			if len(billed) != len(tc.billed) {
+				t.Errorf("want %d lines, got %v", len(tc.billed), billed)
+			}
+			for id, want := range tc.billed {
+				if billed[id] != want {
+					t.Errorf("entry %d: want billed %v, got %v", id, want, billed[id])
+				}
+			}
+			if sumBilled != report.Billed || sumAmount != report.Amount {
+				t.Errorf("want clients to add up to %v and %v, got %v and %v", report.Billed, report.Amount, sumBilled, sumAmount)
+			}
+			if report.Amount != tc.amount {
+				t.Errorf("want amount %v, got %v", tc.amount, report.Amount)
+			}
+			if want := 2*time.Hour + 25*time.Minute; report.Duration != want {
+				t.Errorf("want duration %v, got %v", want, report.Duration)
+			}
+			if report.NonBillable != time.Hour {
+				t.Errorf("want 1h non-billable, got %v", report.NonBillable)
+			}
+			if len(report.Clients) != 2 || report.Clients[0].Client != "Acme" || report.Clients[1].Client != "" {
+				t.Errorf("want Acme first and entries without a client last, got %+v", report.Clients)
+			}
+		})
+	}
+}
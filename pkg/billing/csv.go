@@ -0,0 +1,61 @@
+package billing
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// WriteCSV writes the report's totals per client as CSV. If detailed is
+// true, then each billable entry is written as a separate row instead.
+//
+// Amounts and hours are written as plain decimal numbers, without currency
+// symbols or locale-specific separators, so they can be imported into
+// spreadsheets and accounting software.
+func WriteCSV(w io.Writer, report Report, detailed bool) error {
+	cw := csv.NewWriter(w)
+	if detailed {
+		cw.Write([]string{"client", "date", "entry", "start", "end", "rate name", "rate", "hours", "billed hours", "amount", "currency"})
+		for _, client := range report.Clients {
+			for _, day := range client.Days {
+				for _, line := range day.Lines {
+					cw.Write([]string{
+						client.Client,
+						day.Date.Format(time.DateOnly),
+						line.Name,
+						line.Start.Format(time.RFC3339),
+						line.End.Format(time.RFC3339),
+						line.RateName,
+						formatFloat(line.Rate),
+						formatHours(line.Duration),
+						formatHours(line.Billed),
+						formatFloat(line.Amount),
+						report.Currency,
+					})
+				}
+			}
+		}
+	} else {
+		cw.Write([]string{"client", "hours", "billed hours", "amount", "currency"})
+		for _, client := range report.Clients {
+			cw.Write([]string{
+				client.Client,
+				formatHours(client.Duration),
+				formatHours(client.Billed),
+				formatFloat(client.Amount),
+				report.Currency,
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatHours(d time.Duration) string {
+	return strconv.FormatFloat(d.Hours(), 'f', 2, 64)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
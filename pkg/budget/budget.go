@@ -0,0 +1,186 @@
+// Package budget tracks the progress of time targets and caps, such as a
+// daily goal or contracted hours per client and week.
+package budget
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/calendar"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+)
+
+// Thresholds are the percentages of a budget where notifications are sent.
+var Thresholds = []float64{80, 100}
+
+// Entry is an entry to count towards budgets. Active entries should have
+// their end time set to the current time.
+type Entry struct {
+	Project string
+	Client  string
+	Tags    []string
+	Start   time.Time
+	End     time.Time
+	Active  bool
+}
+
+// Progress is how much of a budget has been used within its current period.
+type Progress struct {
+	// Index is the budget's index in the config.
+	Index   int                 `json:"index"`
+	Name    string              `json:"name"`
+	Tag     string              `json:"tag"`
+	Project string              `json:"project"`
+	Period  config.BudgetPeriod `json:"period"`
+	Kind    config.BudgetKind   `json:"kind"`
+	Start   time.Time           `json:"start"`
+	End     time.Time           `json:"end"`
+	Budget  time.Duration       `json:"budget"`
+	Used    time.Duration       `json:"used"`
+	// Remaining is the time left until the budget is reached, or zero if it
+	// has already been reached.
+	Remaining time.Duration `json:"remaining"`
+	Percent   float64       `json:"percent"`
+	// IncludesActive is true if the active entry counts towards the budget.
+	IncludesActive bool `json:"includesActive"`
+}
+
+// Reached returns true if the used time is at or above the budget.
+func (p Progress) Reached() bool {
+	return p.Used >= p.Budget
+}
+
+// Summary formats the progress as a short human readable text, such as
+// "Daily goal: 5h12m of 8h (65%)".
+func (p Progress) Summary() string {
+	return fmt.Sprintf("%s: %s of %s (%.0f%%)", p.Name,
+		FormatDuration(p.Used), FormatDuration(p.Budget), p.Percent)
+}
+
+// FormatDuration formats the duration in hours and minutes, such as "5h12m",
+// "8h", or "45m".
+func FormatDuration(d time.Duration) string {
+	d = d.Truncate(time.Minute)
+	hours, minutes := int(d/time.Hour), int(d%time.Hour/time.Minute)
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%dm", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%dh", hours)
+	default:
+		return fmt.Sprintf("%dh%02dm", hours, minutes)
+	}
+}
+
+// Matches returns true if the entry counts towards the budget.
+func Matches(b config.Budget, entry Entry) bool {
+	if b.Tag != "" && !containsFold(entry.Tags, b.Tag) {
+		return false
+	}
+	if b.Project != "" && !strings.EqualFold(entry.Project, b.Project) &&
+		!strings.EqualFold(entry.Client, b.Project) {
+		return false
+	}
+	return true
+}
+
+// PeriodBounds returns the start and end of the period that contains the
+// given time, using the calendar's day start and first day of the week.
+func PeriodBounds(cal *calendar.Calendar, period config.BudgetPeriod, t time.Time) (time.Time, time.Time) {
+	switch period {
+	case config.BudgetPeriodWeek:
+		return cal.Week(t)
+	case config.BudgetPeriodMonth:
+		return cal.Month(t)
+	default:
+		return cal.Day(t)
+	}
+}
+
+// EarliestStart returns the earliest start of the current periods of all
+// budgets, i.e how far back entries are needed to calculate their progress.
+func EarliestStart(cal *calendar.Calendar, budgets []config.Budget, now time.Time) time.Time {
+	earliest, _ := PeriodBounds(cal, config.BudgetPeriodDay, now)
+	for _, b := range budgets {
+		if start, _ := PeriodBounds(cal, b.Period, now); start.Before(earliest) {
+			earliest = start
+		}
+	}
+	return earliest
+}
+
+// Compute calculates the progress of each budget within its current period.
+func Compute(cal *calendar.Calendar, budgets []config.Budget, entries []Entry, now time.Time) []Progress {
+	result := make([]Progress, len(budgets))
+	for i, b := range budgets {
+		start, end := PeriodBounds(cal, b.Period, now)
+		p := Progress{
+			Index:   i,
+			Name:    name(b, i),
+			Tag:     b.Tag,
+			Project: b.Project,
+			Period:  b.Period,
+			Kind:    b.Kind,
+			Start:   start,
+			End:     end,
+			Budget:  b.Duration,
+		}
+		for _, entry := range entries {
+			if !Matches(b, entry) {
+				continue
+			}
+			entryStart, entryEnd := entry.Start, entry.End
+			if entryStart.Before(start) {
+				entryStart = start
+			}
+			if entryEnd.After(end) {
+				entryEnd = end
+			}
+			if entryEnd.After(entryStart) {
+				p.Used += entryEnd.Sub(entryStart)
+				if entry.Active {
+					p.IncludesActive = true
+				}
+			}
+		}
+		if p.Used < p.Budget {
+			p.Remaining = p.Budget - p.Used
+		}
+		if p.Budget > 0 {
+			p.Percent = float64(p.Used) / float64(p.Budget) * 100
+		}
+		result[i] = p
+	}
+	return result
+}
+
+// MainGoal returns the progress of the budget with the given name, or the
+// first daily target if the name is empty. Returns false if none is found.
+func MainGoal(progress []Progress, name string) (Progress, bool) {
+	for _, p := range progress {
+		if name != "" && strings.EqualFold(p.Name, name) {
+			return p, true
+		}
+		if name == "" && p.Period == config.BudgetPeriodDay && p.Kind == config.BudgetKindTarget {
+			return p, true
+		}
+	}
+	return Progress{}, false
+}
+
+func name(b config.Budget, index int) string {
+	if b.Name != "" {
+		return b.Name
+	}
+	return fmt.Sprintf("Budget #%d", index+1)
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
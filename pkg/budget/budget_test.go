@@ -0,0 +1,122 @@
+package budget
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/calendar"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+)
+
+func newTestCalendar(t *testing.T, weekStart time.Weekday, dayStartHour int) *calendar.Calendar {
+	t.Helper()
+	cal, err := calendar.New(config.Calendar{
+		TimeZone:     "UTC",
+		WeekStart:    config.Weekday(weekStart),
+		DayStartHour: dayStartHour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cal
+}
+
+func date(year int, month time.Month, day, hour int) time.Time {
+	return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+}
+
+func TestPeriodBounds(t *testing.T) {
+	// 2023-03-01 is a Wednesday.
+	tests := []struct {
+		name         string
+		weekStart    time.Weekday
+		dayStartHour int
+		period       config.BudgetPeriod
+		t            time.Time
+		wantStart    time.Time
+		wantEnd      time.Time
+	}{
+		{"day", time.Monday, 0, config.BudgetPeriodDay, date(2023, 3, 1, 12), date(2023, 3, 1, 0), date(2023, 3, 2, 0)},
+		{"day before day start", time.Monday, 4, config.BudgetPeriodDay, date(2023, 3, 1, 2), date(2023, 2, 28, 4), date(2023, 3, 1, 4)},
+		{"week from Monday", time.Monday, 0, config.BudgetPeriodWeek, date(2023, 3, 1, 12), date(2023, 2, 27, 0), date(2023, 3, 6, 0)},
+		{"week from Sunday", time.Sunday, 0, config.BudgetPeriodWeek, date(2023, 3, 1, 12), date(2023, 2, 26, 0), date(2023, 3, 5, 0)},
+		{"week on its first day", time.Sunday, 0, config.BudgetPeriodWeek, date(2023, 3, 5, 0), date(2023, 3, 5, 0), date(2023, 3, 12, 0)},
+		{"week on its last day", time.Sunday, 0, config.BudgetPeriodWeek, date(2023, 3, 4, 23), date(2023, 2, 26, 0), date(2023, 3, 5, 0)},
+		{"month", time.Monday, 0, config.BudgetPeriodMonth, date(2023, 2, 15, 12), date(2023, 2, 1, 0), date(2023, 3, 1, 0)},
+		{"month rollover", time.Monday, 0, config.BudgetPeriodMonth, date(2023, 3, 1, 0), date(2023, 3, 1, 0), date(2023, 4, 1, 0)},
+		{"year rollover", time.Monday, 0, config.BudgetPeriodMonth, date(2023, 12, 31, 23), date(2023, 12, 1, 0), date(2024, 1, 1, 0)},
+		{"month before day start", time.Monday, 4, config.BudgetPeriodMonth, date(2023, 3, 1, 2), date(2023, 2, 1, 4), date(2023, 3, 1, 4)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cal := newTestCalendar(t, tc.weekStart, tc.dayStartHour)
+			start, end := PeriodBounds(cal, tc.period, tc.t)
+			if !start.Equal(tc.wantStart) || !end.Equal(tc.wantEnd) {
+				t.Errorf("want %v - %v, got %v - %v", tc.wantStart, tc.wantEnd, start, end)
+			}
+		})
+	}
+}
+
+func TestEarliestStart(t *testing.T) {
+	cal := newTestCalendar(t, time.Monday, 0)
+	now := date(2023, 3, 1, 12)
+	tests := []struct {
+		name    string
+		budgets []config.Budget
+		want    time.Time
+	}{
+		{"no budgets", nil, date(2023, 3, 1, 0)},
+		{"week", []config.Budget{{Period: config.BudgetPeriodDay}, {Period: config.BudgetPeriodWeek}}, date(2023, 2, 27, 0)},
+		{"month", []config.Budget{{Period: config.BudgetPeriodWeek}, {Period: config.BudgetPeriodMonth}}, date(2023, 2, 27, 0)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := EarliestStart(cal, tc.budgets, now); !got.Equal(tc.want) {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCompute(t *testing.T) {
+	cal := newTestCalendar(t, time.Monday, 0)
+	now := date(2023, 3, 1, 12)
+	budgets := []config.Budget{
+		{Name: "Daily goal", Period: config.BudgetPeriodDay, Kind: config.BudgetKindTarget, Duration: 8 * time.Hour},
+		{Tag: "meeting", Period: config.BudgetPeriodWeek, Kind: config.BudgetKindCap, Duration: 4 * time.Hour},
+		{Project: "acme", Period: config.BudgetPeriodMonth, Kind: config.BudgetKindCap, Duration: 10 * time.Hour},
+	}
+	entries := []Entry{
+		// Spans the end of February, so counts partly towards the week and
+		// not at all towards the month.
+		{Tags: []string{"meeting"}, Start: date(2023, 2, 28, 22), End: date(2023, 3, 1, 2)},
+		{Project: "acme", Start: date(2023, 2, 27, 9), End: date(2023, 2, 27, 17)},
+		{Client: "ACME", Start: date(2023, 3, 1, 8), End: date(2023, 3, 1, 10)},
+		{Tags: []string{"Meeting"}, Start: date(2023, 3, 1, 11), End: now, Active: true},
+	}
+	progress := Compute(cal, budgets, entries, now)
+	tests := []struct {
+		used           time.Duration
+		remaining      time.Duration
+		percent        float64
+		includesActive bool
+	}{
+		{5 * time.Hour, 3 * time.Hour, 62.5, true},
+		{5 * time.Hour, 0, 125, true},
+		{2 * time.Hour, 8 * time.Hour, 20, false},
+	}
+	if len(progress) != len(tests) {
+		t.Fatalf("want %d progresses, got %d", len(tests), len(progress))
+	}
+	for i, want := range tests {
+		p := progress[i]
+		if p.Index != i || p.Used != want.used || p.Remaining != want.remaining ||
+			p.Percent != want.percent || p.IncludesActive != want.includesActive {
+			t.Errorf("budget %d: want %+v, got %+v", i, want, p)
+		}
+	}
+	if start, _ := cal.Month(now); !progress[2].Start.Equal(start) {
+		t.Errorf("want month budget to start at %v, got %v", start, progress[2].Start)
+	}
+}
@@ -0,0 +1,119 @@
+// Package calendar does date math in the configured time zone, where weeks
+// start on the configured weekday and days start at the configured hour,
+// which may be after midnight.
+package calendar
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+
+	// Embeds the time zone database, as it's missing on some systems, such
+	// as on Windows.
+	_ "time/tzdata"
+)
+
+// Calendar calculates the bounds of days, weeks, and months.
+type Calendar struct {
+	loc          *time.Location
+	weekStart    time.Weekday
+	dayStartHour int
+}
+
+// New creates a new calendar from the config.
+func New(cfg config.Calendar) (*Calendar, error) {
+	loc := time.Local
+	if cfg.TimeZone != "" {
+		l, err := time.LoadLocation(cfg.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("load time zone: %w", err)
+		}
+		loc = l
+	}
+	if cfg.DayStartHour < 0 || cfg.DayStartHour > 23 {
+		return nil, fmt.Errorf("day start hour must be between 0 and 23, got %d", cfg.DayStartHour)
+	}
+	return &Calendar{
+		loc:          loc,
+		weekStart:    time.Weekday(cfg.WeekStart),
+		dayStartHour: cfg.DayStartHour,
+	}, nil
+}
+
+// Location returns the time zone of the calendar.
+func (c *Calendar) Location() *time.Location {
+	return c.loc
+}
+
+// WeekStart returns the first day of the week.
+func (c *Calendar) WeekStart() time.Weekday {
+	return c.weekStart
+}
+
+// DayStartHour returns the hour, 0-23, when days start.
+func (c *Calendar) DayStartHour() int {
+	return c.dayStartHour
+}
+
+// Date returns the date of the day that contains the time, as midnight in
+// the calendar's time zone. Times before the day start hour belong to the
+// previous date.
+func (c *Calendar) Date(t time.Time) time.Time {
+	t = t.In(c.loc)
+	y, m, d := t.Date()
+	if t.Hour() < c.dayStartHour {
+		d--
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, c.loc)
+}
+
+// StartOfDate returns when the day with the given date starts. Only the
+// year, month, and day of the date is used, and not its time zone.
+func (c *Calendar) StartOfDate(date time.Time) time.Time {
+	y, m, d := date.Date()
+	return time.Date(y, m, d, c.dayStartHour, 0, 0, 0, c.loc)
+}
+
+// ParseDate parses a date such as "2023-05-21" in the calendar's time zone.
+func (c *Calendar) ParseDate(value string) (time.Time, error) {
+	return time.ParseInLocation(time.DateOnly, value, c.loc)
+}
+
+// Day returns the start and end of the day that contains the time. Days are
+// 23 or 25 hours long when crossing daylight saving time transitions.
+func (c *Calendar) Day(t time.Time) (time.Time, time.Time) {
+	date := c.Date(t)
+	return c.StartOfDate(date), c.StartOfDate(date.AddDate(0, 0, 1))
+}
+
+// Week returns the start and end of the week that contains the time.
+func (c *Calendar) Week(t time.Time) (time.Time, time.Time) {
+	date := c.Date(t)
+	offset := (int(date.Weekday()) - int(c.weekStart) + 7) % 7
+	date = date.AddDate(0, 0, -offset)
+	return c.StartOfDate(date), c.StartOfDate(date.AddDate(0, 0, 7))
+}
+
+// Month returns the start and end of the month that contains the time.
+func (c *Calendar) Month(t time.Time) (time.Time, time.Time) {
+	y, m, _ := c.Date(t).Date()
+	date := time.Date(y, m, 1, 0, 0, 0, 0, c.loc)
+	return c.StartOfDate(date), c.StartOfDate(date.AddDate(0, 1, 0))
+}
+
+// DaysBetween returns the number of days from the day that contains "from"
+// to the day that contains "to", which is negative if "to" is earlier.
+func (c *Calendar) DaysBetween(from, to time.Time) int {
+	fromDate, toDate := c.Date(from), c.Date(to)
+	// Dates are compared in UTC, where all days are 24 hours long.
+	fromUTC := time.Date(fromDate.Year(), fromDate.Month(), fromDate.Day(), 0, 0, 0, 0, time.UTC)
+	toUTC := time.Date(toDate.Year(), toDate.Month(), toDate.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toUTC.Sub(fromUTC) / (24 * time.Hour))
+}
+
+// AddDays returns the same wall clock time as t in the calendar's time zone,
+// the given number of days later.
+func (c *Calendar) AddDays(t time.Time, days int) time.Time {
+	return t.In(c.loc).AddDate(0, 0, days)
+}
@@ -0,0 +1,103 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+)
+
+// In Europe/Stockholm, clocks move forward from 02:00 to 03:00 on
+// 2023-03-26, and back from 03:00 to 02:00 on 2023-10-29.
+func newStockholm(t *testing.T, dayStartHour int) (*Calendar, *time.Location) {
+	t.Helper()
+	c, err := New(config.Calendar{
+		TimeZone:     "Europe/Stockholm",
+		WeekStart:    config.Weekday(time.Monday),
+		DayStartHour: dayStartHour,
+	})
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+	return c, c.Location()
+}
+
+func TestDayAcrossDST(t *testing.T) {
+	c, loc := newStockholm(t, 0)
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Duration
+	}{
+		{"normal day", time.Date(2023, 3, 25, 12, 0, 0, 0, loc), 24 * time.Hour},
+		{"spring forward", time.Date(2023, 3, 26, 12, 0, 0, 0, loc), 23 * time.Hour},
+		{"fall back", time.Date(2023, 10, 29, 12, 0, 0, 0, loc), 25 * time.Hour},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			start, end := c.Day(tc.t)
+			y, m, d := tc.t.Date()
+			if want := time.Date(y, m, d, 0, 0, 0, 0, loc); !start.Equal(want) {
+				t.Errorf("want start %v, got %v", want, start)
+			}
+			if got := end.Sub(start); got != tc.want {
+				t.Errorf("want day of %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDayStartHourAcrossDST(t *testing.T) {
+	c, loc := newStockholm(t, 4)
+	// 03:30 belongs to the previous day when days start at 04:00.
+	start, end := c.Day(time.Date(2023, 3, 26, 3, 30, 0, 0, loc))
+	if want := time.Date(2023, 3, 25, 4, 0, 0, 0, loc); !start.Equal(want) {
+		t.Errorf("want start %v, got %v", want, start)
+	}
+	if got := end.Sub(start); got != 23*time.Hour {
+		t.Errorf("want day of 23h, got %v", got)
+	}
+
+	// Days that start at 02:00 start at 03:00 on the day that skips 02:00.
+	c, loc = newStockholm(t, 2)
+	start, _ = c.Day(time.Date(2023, 3, 26, 12, 0, 0, 0, loc))
+	if want := time.Date(2023, 3, 26, 3, 0, 0, 0, loc); !start.Equal(want) {
+		t.Errorf("want start %v, got %v", want, start)
+	}
+}
+
+func TestWeekAndMonthAcrossDST(t *testing.T) {
+	c, loc := newStockholm(t, 0)
+	start, end := c.Week(time.Date(2023, 3, 26, 12, 0, 0, 0, loc))
+	if want := time.Date(2023, 3, 20, 0, 0, 0, 0, loc); !start.Equal(want) {
+		t.Errorf("want week to start %v, got %v", want, start)
+	}
+	if got := end.Sub(start); got != 7*24*time.Hour-time.Hour {
+		t.Errorf("want week of 167h, got %v", got)
+	}
+
+	start, end = c.Month(time.Date(2023, 10, 15, 12, 0, 0, 0, loc))
+	if got := end.Sub(start); got != 31*24*time.Hour+time.Hour {
+		t.Errorf("want October of 745h, got %v", got)
+	}
+}
+
+func TestDaysBetweenAndAddDaysAcrossDST(t *testing.T) {
+	c, loc := newStockholm(t, 0)
+	from := time.Date(2023, 3, 25, 9, 0, 0, 0, loc)
+	to := time.Date(2023, 3, 26, 9, 0, 0, 0, loc)
+	if got := c.DaysBetween(from, to); got != 1 {
+		t.Errorf("want 1 day between, got %d", got)
+	}
+	if got := c.DaysBetween(to, from); got != -1 {
+		t.Errorf("want -1 day between, got %d", got)
+	}
+	// The same wall clock time, even though only 23 hours have passed.
+	if got := c.AddDays(from, 1); !got.Equal(to) {
+		t.Errorf("want %v, got %v", to, got)
+	}
+	// Times are moved into the calendar's time zone first.
+	if got := c.AddDays(from.UTC(), 1); !got.Equal(to) {
+		t.Errorf("want %v from UTC time, got %v", to, got)
+	}
+}
@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2023 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for
+// more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"encoding"
+	"fmt"
+
+	"github.com/invopop/jsonschema"
+	"github.com/spf13/pflag"
+)
+
+type BudgetKind string
+
+const (
+	// BudgetKindTarget is an amount of time to reach, such as a daily goal.
+	BudgetKindTarget BudgetKind = "target"
+	// BudgetKindCap is an amount of time not to exceed, such as contracted
+	// hours.
+	BudgetKindCap BudgetKind = "cap"
+)
+
+func _() {
+	// Ensure the type implements the interfaces
+	f := BudgetKindTarget
+	var _ pflag.Value = &f
+	var _ encoding.TextUnmarshaler = &f
+	var _ jsonSchemaInterface = f
+}
+
+func (f BudgetKind) String() string {
+	return string(f)
+}
+
+func (f *BudgetKind) Set(value string) error {
+	switch BudgetKind(value) {
+	case BudgetKindTarget:
+		*f = BudgetKindTarget
+	case BudgetKindCap:
+		*f = BudgetKindCap
+	default:
+		return fmt.Errorf("unknown budget kind: %q, must be one of: target, cap", value)
+	}
+	return nil
+}
+
+func (f *BudgetKind) Type() string {
+	return "kind"
+}
+
+func (f *BudgetKind) UnmarshalText(text []byte) error {
+	return f.Set(string(text))
+}
+
+// JSONSchema returns the JSON schema struct for this struct.
+func (BudgetKind) JSONSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:  "string",
+		Title: "Budget kind",
+		Enum: []any{
+			BudgetKindTarget,
+			BudgetKindCap,
+		},
+		Default: BudgetKindTarget,
+	}
+}
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2023 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for
+// more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"encoding"
+	"fmt"
+
+	"github.com/invopop/jsonschema"
+	"github.com/spf13/pflag"
+)
+
+type BudgetPeriod string
+
+const (
+	// BudgetPeriodDay is a budget that resets every day.
+	BudgetPeriodDay BudgetPeriod = "day"
+	// BudgetPeriodWeek is a budget that resets every week.
+	BudgetPeriodWeek BudgetPeriod = "week"
+	// BudgetPeriodMonth is a budget that resets every month.
+	BudgetPeriodMonth BudgetPeriod = "month"
+)
+
+func _() {
+	// Ensure the type implements the interfaces
+	f := BudgetPeriodDay
+	var _ pflag.Value = &f
+	var _ encoding.TextUnmarshaler = &f
+	var _ jsonSchemaInterface = f
+}
+
+func (f BudgetPeriod) String() string {
+	return string(f)
+}
+
+func (f *BudgetPeriod) Set(value string) error {
+	switch BudgetPeriod(value) {
+	case BudgetPeriodDay:
+		*f = BudgetPeriodDay
+	case BudgetPeriodWeek:
+		*f = BudgetPeriodWeek
+	case BudgetPeriodMonth:
+		*f = BudgetPeriodMonth
+	default:
+		return fmt.Errorf("unknown budget period: %q, must be one of: day, week, month", value)
+	}
+	return nil
+}
+
+func (f *BudgetPeriod) Type() string {
+	return "period"
+}
+
+func (f *BudgetPeriod) UnmarshalText(text []byte) error {
+	return f.Set(string(text))
+}
+
+// JSONSchema returns the JSON schema struct for this struct.
+func (BudgetPeriod) JSONSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:  "string",
+		Title: "Budget period",
+		Enum: []any{
+			BudgetPeriodDay,
+			BudgetPeriodWeek,
+			BudgetPeriodMonth,
+		},
+		Default: BudgetPeriodDay,
+	}
+}
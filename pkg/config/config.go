@@ -25,6 +25,9 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"runtime"
+	"strings"
+	"time"
 
 	"github.com/dinkur/dinkur-desktop/internal/casing"
 	"github.com/dinkur/dinkur/pkg/config"
@@ -37,8 +40,20 @@ import (
 
 var log = logger.NewScoped("config")
 
+// ErrConfigNotLoaded is returned when saving a config that could not be
+// fully loaded from the config file, as saving it would overwrite the file.
+var ErrConfigNotLoaded = errors.New("config file was not fully loaded, not overwriting it")
+
 var Path string
 
+// APITokenPath is the file where the token for the local HTTP API is stored,
+// which is created when the API is first started.
+var APITokenPath string
+
+// DefaultDataDir is the directory where Dinkur desktop stores its own data,
+// such as recorded focus sessions, unless overridden by the config.
+var DefaultDataDir string
+
 var Header = `# This file is automatically managed by Dinkur desktop.
 # Recommended to only change settings via the application's settings panel.
 # Any settings in this file may get overridden.
@@ -66,6 +81,108 @@ var Default = Config{
 		Level:  LogLevel(logger.LevelDebug),
 		Color:  LogColorAuto,
 	},
+	Idle: Idle{
+		Enabled:      true,
+		Threshold:    10 * time.Minute,
+		PollInterval: 5 * time.Second,
+		SplitName:    "Away",
+	},
+	WorkingHours: WorkingHours{
+		Start: NewTimeOfDay(9, 0),
+		End:   NewTimeOfDay(17, 0),
+		Weekdays: []Weekday{
+			Weekday(time.Monday),
+			Weekday(time.Tuesday),
+			Weekday(time.Wednesday),
+			Weekday(time.Thursday),
+			Weekday(time.Friday),
+		},
+	},
+	Reminder: Reminder{
+		Enabled:       true,
+		CheckInterval: time.Minute,
+		Snooze:        15 * time.Minute,
+	},
+	LongRunning: LongRunning{
+		CheckInterval: time.Minute,
+		WarnAfter:     8 * time.Hour,
+		AutoStop: AutoStop{
+			At:                   TimeOfDayUnset,
+			MaxDuration:          0,
+			BackdateToLastActive: true,
+		},
+	},
+	Pomodoro: Pomodoro{
+		Interval:      25 * time.Minute,
+		Break:         5 * time.Minute,
+		Cycles:        4,
+		OnIntervalEnd: PomodoroEndPause,
+	},
+	Tags: Tags{
+		TagPrefix:      "#",
+		ClientPrefix:   "@",
+		TicketPatterns: []string{`\b[A-Z][A-Z0-9]+-[0-9]+\b`},
+	},
+	Categorization: Categorization{
+		DefaultBillable: true,
+	},
+	Billing: Billing{
+		Currency: "EUR",
+		Rounding: Rounding{
+			Mode:      RoundingModeNone,
+			Increment: 15 * time.Minute,
+			Scope:     RoundingScopeEntry,
+		},
+	},
+	Invoice: Invoice{
+		NumberFormat: "INV-%04d",
+		DueDays:      30,
+	},
+	Budgets: Budgets{
+		CheckInterval: time.Minute,
+	},
+	Analyzer: Analyzer{
+		GapThreshold: 5 * time.Minute,
+		FillName:     "Untracked",
+	},
+	Journal: Journal{
+		Size: 100,
+	},
+	Calendar: Calendar{
+		WeekStart:    Weekday(time.Monday),
+		DayStartHour: 0,
+	},
+	API: API{
+		Enabled: false,
+		Address: "localhost:59123",
+	},
+	DBus: DBus{
+		Enabled: true,
+	},
+	Webhooks: Webhooks{
+		MaxAttempts:   10,
+		RetryDelay:    30 * time.Second,
+		MaxRetryDelay: time.Hour,
+		Timeout:       10 * time.Second,
+		LogSize:       200,
+	},
+	Git: Git{
+		SessionGap: 2 * time.Hour,
+		LeadTime:   30 * time.Minute,
+	},
+	Meetings: Meetings{
+		Tag:             "meeting",
+		RefreshInterval: 15 * time.Minute,
+	},
+	IssueTrackers: IssueTrackers{
+		CacheTTL: 24 * time.Hour,
+	},
+	Worklogs: Worklogs{
+		Target: WorklogTargetJira,
+		Tempo: Tempo{
+			URL: "https://api.tempo.io",
+		},
+	},
 }
 
 func init() {
@@ -74,25 +191,114 @@ func init() {
 		panic(fmt.Errorf("resolve user config directory: %w", err))
 	}
 	Path = filepath.Join(cfgPath, "dinkur-desktop.yaml")
+	APITokenPath = filepath.Join(cfgPath, "dinkur-desktop-api-token")
+	DefaultDataDir = filepath.Join(userDataDir(cfgPath), "dinkur-desktop")
+	Default.DataDir = DefaultDataDir
+	SecretsPath = filepath.Join(cfgPath, "dinkur-desktop-secrets.json")
+	SecretsKeyPath = filepath.Join(secretsKeyDir(cfgPath), "secrets.key")
+	legacySecretsKeyPath = filepath.Join(DefaultDataDir, "secrets.key")
+}
+
+// secretsKeyDir returns the directory of [SecretsKeyPath], which is kept
+// apart from the config directory: $XDG_DATA_HOME on GNU/Linux,
+// %LocalAppData% on Windows, as it is never roamed to other machines unlike
+// the config directory in %AppData%, and the home directory elsewhere, as
+// the config directory on macOS is also where application data is kept.
+func secretsKeyDir(cfgPath string) string {
+	switch runtime.GOOS {
+	case "linux":
+		return filepath.Join(userDataDir(cfgPath), "dinkur-desktop", "keys")
+	case "windows":
+		if dir, err := os.UserCacheDir(); err == nil {
+			return filepath.Join(dir, "dinkur-desktop", "keys")
+		}
+	default:
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, ".dinkur-desktop", "keys")
+		}
+	}
+	return filepath.Join(cfgPath, "dinkur-desktop-keys")
+}
+
+// userDataDir returns $XDG_DATA_HOME on GNU/Linux, or the user config
+// directory on other OS'es.
+func userDataDir(cfgPath string) string {
+	if runtime.GOOS != "linux" {
+		return cfgPath
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".local", "share")
+	}
+	return cfgPath
 }
 
 type Config struct {
 	fileUsed string
+	// loadErr is why the config file could not be fully loaded. Such a
+	// config is never saved, as that would overwrite the user's file with
+	// default values.
+	loadErr error
+	// secrets are the values of the secret fields as last loaded from or
+	// saved to the secret store, by key.
+	secrets map[string]string
 
 	ExitOnWindowClose bool `yaml:"exitOnWindowClose"`
+	// DataDir is the directory where Dinkur desktop stores its own data, such
+	// as recorded focus sessions. The time-tracked entries are stored
+	// separately, as defined by the Sqlite config.
+	DataDir string `yaml:"dataDir"`
 
 	Client ClientType
 	Sqlite Sqlite
 	GRPC   GRPC
 	Daemon Daemon
 
-	Log Log
+	Log            Log
+	Idle           Idle
+	WorkingHours   WorkingHours `yaml:"workingHours"`
+	Reminder       Reminder
+	LongRunning    LongRunning `yaml:"longRunning"`
+	Pomodoro       Pomodoro
+	Tags           Tags
+	Categorization Categorization
+	Billing        Billing
+	Invoice        Invoice
+	Budgets        Budgets
+	Analyzer       Analyzer
+	Journal        Journal
+	Calendar       Calendar
+	API            API
+	DBus           DBus `yaml:"dbus"`
+	Hooks          Hooks
+	Webhooks       Webhooks
+	Git            Git
+	Meetings       Meetings
+	IssueTrackers  IssueTrackers `yaml:"issueTrackers"`
+	Worklogs       Worklogs
+	Templates      []EntryTemplate `yaml:",omitempty"`
 }
 
 func (c *Config) FileUsed() string {
 	return c.fileUsed
 }
 
+// LoadError returns why the config file could not be fully loaded, or nil if
+// it was.
+func (c *Config) LoadError() error {
+	return c.loadErr
+}
+
+// Fallback returns a copy of the [Default] config to use when the config file
+// could not be read. It is not saved by [Config.Save].
+func Fallback(err error) Config {
+	cfg := Default
+	cfg.loadErr = err
+	return cfg
+}
+
 type Sqlite struct {
 	// Path is the file path of where to store the sqlite database file, i.e
 	// the file containing all the time-tracked entries.
@@ -128,11 +334,462 @@ type Log struct {
 	Color LogColor
 }
 
+type Idle struct {
+	// Enabled turns on idle detection. When returning after being idle while
+	// an entry is active, Dinkur desktop asks what to do with the idle time.
+	Enabled bool
+	// Threshold is how long the user must be idle (no keyboard or mouse
+	// input) before being considered away.
+	Threshold time.Duration
+	// PollInterval is how often the idle time is checked.
+	PollInterval time.Duration `yaml:"pollInterval"`
+	// SplitName is the name of the entry created for the idle time when
+	// choosing to split it out of the active entry.
+	SplitName string `yaml:"splitName"`
+}
+
+type WorkingHours struct {
+	// Start is the time of day when the work day starts, e.g "09:00".
+	Start TimeOfDay
+	// End is the time of day when the work day ends, e.g "17:00". If it's
+	// before Start, then the working hours span past midnight.
+	End TimeOfDay
+	// Weekdays are the days of the week that are work days. The weekday of
+	// Start is what counts for working hours that span past midnight.
+	Weekdays []Weekday
+}
+
+// Contains returns true if the given time is within the working hours.
+func (w WorkingHours) Contains(t time.Time) bool {
+	tod := TimeOfDayOf(t)
+	day := t
+	switch {
+	case w.Start < w.End:
+		if tod < w.Start || tod >= w.End {
+			return false
+		}
+	case w.Start > w.End:
+		if tod >= w.End && tod < w.Start {
+			return false
+		}
+		if tod < w.End {
+			day = t.AddDate(0, 0, -1)
+		}
+	default:
+		return false
+	}
+	for _, wd := range w.Weekdays {
+		if time.Weekday(wd) == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+type Reminder struct {
+	// Enabled turns on desktop notifications that remind you to start
+	// tracking when there's no active entry during working hours.
+	Enabled bool
+	// CheckInterval is how often to check if there's an active entry.
+	CheckInterval time.Duration `yaml:"checkInterval"`
+	// Snooze is how long to wait after a reminder before reminding again.
+	Snooze time.Duration
+}
+
+type LongRunning struct {
+	// CheckInterval is how often to check the active entry's duration.
+	CheckInterval time.Duration `yaml:"checkInterval"`
+	// WarnAfter sends a desktop notification when the active entry has been
+	// running for this long. Set to zero to disable.
+	WarnAfter time.Duration `yaml:"warnAfter"`
+	// AutoStop defines when the active entry should be stopped automatically.
+	AutoStop AutoStop `yaml:"autoStop"`
+}
+
+type AutoStop struct {
+	// At stops the active entry at this time of day, e.g "23:00". Set to
+	// empty string to disable.
+	At TimeOfDay
+	// MaxDuration stops the active entry when it has been running for this
+	// long. Set to zero to disable.
+	MaxDuration time.Duration `yaml:"maxDuration"`
+	// BackdateToLastActive sets the end time of automatically stopped
+	// entries to the last time the user was active, instead of when the
+	// entry was stopped.
+	BackdateToLastActive bool `yaml:"backdateToLastActive"`
+}
+
+type Pomodoro struct {
+	// Interval is the length of each focus interval.
+	Interval time.Duration
+	// Break is the length of the break between focus intervals.
+	Break time.Duration
+	// Cycles is the number of focus intervals in a pomodoro.
+	Cycles int
+	// OnIntervalEnd defines what happens with the entry when a focus interval
+	// ends. Either "pause", to stop the entry and resume it after the break,
+	// or "stop", to stop the entry and end the pomodoro.
+	OnIntervalEnd PomodoroEnd `yaml:"onIntervalEnd"`
+}
+
+type Tags struct {
+	// TagPrefix is the prefix of tags in entry names, e.g "#" for
+	// "#support". Set to empty string to disable tags.
+	TagPrefix string `yaml:"tagPrefix"`
+	// ClientPrefix is the prefix of the client or project in entry names,
+	// e.g "@" for "@acme". Set to empty string to disable clients.
+	ClientPrefix string `yaml:"clientPrefix"`
+	// TicketPatterns are regular expressions matching ticket keys in entry
+	// names, e.g "INC-123".
+	TicketPatterns []string `yaml:"ticketPatterns"`
+}
+
+type Categorization struct {
+	// DefaultBillable is whether entries are billable when no rule says
+	// otherwise.
+	DefaultBillable bool `yaml:"defaultBillable"`
+	// Rules categorize entries based on their names. All matching rules are
+	// applied in order, so later rules override the project and billable
+	// flag set by earlier rules.
+	Rules []Rule
+}
+
+type Rule struct {
+	// Name is a human readable name of the rule, shown when previewing which
+	// rules matched an entry.
+	Name string
+	// Match is a regular expression matched against the entry name.
+	Match string
+	// Project sets the project of matching entries, if not empty.
+	Project string `yaml:",omitempty"`
+	// Tags are added to matching entries.
+	Tags []string `yaml:",omitempty"`
+	// Billable sets if matching entries are billable, if set.
+	Billable *bool `yaml:",omitempty"`
+}
+
+type Billing struct {
+	// Currency is the ISO 4217 currency code of all rates, e.g "EUR".
+	Currency string
+	// Locale is the BCP 47 language tag used when formatting amounts, e.g
+	// "sv-SE". Defaults to the locale from the environment variables
+	// LC_ALL, LC_MONETARY, or LANG if empty.
+	Locale string `yaml:",omitempty"`
+	// DefaultRate is the hourly rate of billable entries that don't match
+	// any of the rates.
+	DefaultRate float64 `yaml:"defaultRate"`
+	// Rates set the hourly rate of matching billable entries. The first
+	// matching rate is used.
+	Rates []Rate
+	// Rounding defines how billed durations are rounded.
+	Rounding Rounding
+}
+
+type Rate struct {
+	// Name is a human readable name of the rate, shown in reports.
+	Name string
+	// Tag matches entries with this tag, if not empty.
+	Tag string `yaml:",omitempty"`
+	// Project matches entries with this project or client, if not empty.
+	Project string `yaml:",omitempty"`
+	// Match is a regular expression matched against the entry name, if not
+	// empty.
+	Match string `yaml:",omitempty"`
+	// Rate is the hourly rate, in the currency of the billing config.
+	Rate float64
+}
+
+type Rounding struct {
+	// Mode is how to round billed durations, one of "none", "nearest",
+	// "up", or "down".
+	Mode RoundingMode
+	// Increment is what to round billed durations to, one of 6m, 15m,
+	// or 30m.
+	Increment time.Duration
+	// Scope is what to round, either "entry" to round each entry, or "day"
+	// to round the total per day.
+	Scope RoundingScope
+}
+
+type Invoice struct {
+	// Template is the path to a Go html/template file used when rendering
+	// invoices as HTML. The built-in template is used if empty. Run
+	// "dinkur-desktop invoice template" to print the built-in template, as a
+	// starting point for your own.
+	Template string `yaml:",omitempty"`
+	// NumberFormat is the format of invoice numbers, where %d is replaced by
+	// the sequential invoice number, e.g "INV-%04d".
+	NumberFormat string `yaml:"numberFormat"`
+	// From is the name and address of the sender, shown at the top of
+	// invoices. May span multiple lines.
+	From string `yaml:",omitempty"`
+	// DueDays is how many days after the invoice date that invoices are due.
+	DueDays int `yaml:"dueDays"`
+}
+
+type Budgets struct {
+	// CheckInterval is how often to check the progress of all budgets.
+	CheckInterval time.Duration `yaml:"checkInterval"`
+	// MainGoal is the name of the daily budget shown in the tray tooltip.
+	// Defaults to the first daily target if empty.
+	MainGoal string `yaml:"mainGoal,omitempty"`
+	// Items are the budgets to track. Desktop notifications are sent when a
+	// budget reaches 80% and 100%.
+	Items []Budget
+}
+
+type Budget struct {
+	// Name is a human readable name of the budget, shown in notifications.
+	Name string
+	// Tag only counts entries with this tag, if not empty.
+	Tag string `yaml:",omitempty"`
+	// Project only counts entries with this project or client, if not empty.
+	Project string `yaml:",omitempty"`
+	// Period is how often the budget resets, one of "day", "week", or
+	// "month".
+	Period BudgetPeriod
+	// Kind is either "target", for time to reach, or "cap", for time not to
+	// exceed.
+	Kind BudgetKind
+	// Duration is the amount of time of the budget, e.g "40h".
+	Duration time.Duration
+}
+
+type Analyzer struct {
+	// GapThreshold is the shortest gap between two entries that is reported
+	// when analyzing a day's entries.
+	GapThreshold time.Duration `yaml:"gapThreshold"`
+	// FillName is the name of new entries created to fill gaps between
+	// entries. Set to empty string to only fill gaps by extending the
+	// surrounding entries.
+	FillName string `yaml:"fillName"`
+}
+
+type EntryTemplate struct {
+	// Name is the name of entries started from the template, which may
+	// include tags and a client, e.g "Standup #meeting".
+	Name string
+	// At is the time of day when entries from the template start, e.g
+	// "09:15". Entries start at the current time if not set, or if the time
+	// of day has not yet passed.
+	At *TimeOfDay `yaml:",omitempty"`
+	// Duration is the default length of entries from the template. If set,
+	// and the entry's end has already passed, then the entry is added as a
+	// stopped entry instead of being started.
+	Duration time.Duration `yaml:",omitempty"`
+}
+
+type Journal struct {
+	// Size is the number of changes to entries that can be undone. Set to 0
+	// to disable undo and redo.
+	Size int
+}
+
+type Calendar struct {
+	// TimeZone is the IANA name of the time zone used for dates and times,
+	// e.g "Europe/Stockholm". Defaults to the system's time zone if empty.
+	TimeZone string `yaml:"timeZone,omitempty"`
+	// WeekStart is the first day of the week, used for weekly reports and
+	// budgets.
+	WeekStart Weekday `yaml:"weekStart"`
+	// DayStartHour is the hour, 0-23, when a new day starts. Entries before
+	// this hour count towards the previous day, e.g set to 4 to count late
+	// night work towards the day before.
+	DayStartHour int `yaml:"dayStartHour"`
+}
+
+type API struct {
+	// Enabled turns on the local HTTP JSON API, used by scripts and status
+	// bars. Requests must use the token from the file next to the config
+	// file, as in "Authorization: Bearer <token>".
+	Enabled bool
+	// Address is the hostname and port to serve the API on, which must be
+	// on the local machine.
+	Address string
+	// Socket is the path of a Unix socket to serve the API on instead of
+	// the address, if set.
+	Socket string `yaml:",omitempty"`
+	// Metrics turns on the /metrics endpoint of the API, which serves
+	// metrics in the Prometheus text format. Uses the same token as the
+	// rest of the API.
+	Metrics bool
+}
+
+type DBus struct {
+	// Enabled turns on the org.dinkur.Desktop D-Bus service on the session
+	// bus, used by desktop widgets to show and control tracking. Only
+	// available on GNU/Linux.
+	Enabled bool
+}
+
+type Hooks struct {
+	// OnStart are the commands to run when an entry is started.
+	OnStart []Hook `yaml:"onStart,omitempty"`
+	// OnStop are the commands to run when the active entry is stopped,
+	// including when it's stopped by starting another entry.
+	OnStop []Hook `yaml:"onStop,omitempty"`
+	// OnSwitch are the commands to run instead of the OnStart commands when
+	// starting an entry stops another entry.
+	OnSwitch []Hook `yaml:"onSwitch,omitempty"`
+}
+
+type Hook struct {
+	// Command is the program to run, e.g "/home/me/bin/slack-status". The
+	// command is not run through a shell.
+	Command string
+	Args    []string `yaml:",omitempty"`
+	// Timeout is how long the command may run before it's killed. Defaults
+	// to 30s if not set.
+	Timeout time.Duration `yaml:",omitempty"`
+	// Env are additional environment variables for the command. The entry
+	// is described by environment variables prefixed with "DINKUR_", such as
+	// DINKUR_ENTRY_NAME.
+	Env map[string]string `yaml:",omitempty"`
+}
+
+type Webhooks struct {
+	// MaxAttempts is how many times delivering an event is attempted before
+	// giving up.
+	MaxAttempts int `yaml:"maxAttempts"`
+	// RetryDelay is how long to wait before the first retry. The delay is
+	// doubled for each following retry, up to MaxRetryDelay.
+	RetryDelay    time.Duration `yaml:"retryDelay"`
+	MaxRetryDelay time.Duration `yaml:"maxRetryDelay"`
+	// Timeout is how long to wait for a response to each attempt.
+	Timeout time.Duration
+	// LogSize is the number of finished deliveries kept in the delivery log.
+	LogSize int `yaml:"logSize"`
+	// Items are the URLs that entry events are sent to. Events that can't be
+	// delivered, such as while offline, are kept in an outbox in the data
+	// directory and retried later.
+	Items []Webhook `yaml:",omitempty"`
+}
+
+type Webhook struct {
+	// Name identifies the webhook, e.g "ci". It's shown in the delivery log,
+	// and is the key of the webhook's secret in the secret store.
+	Name string
+	// URL is where entry events are sent, as JSON in POST requests.
+	URL string
+	// Secret is used to sign the request body with HMAC-SHA256. The
+	// signature is sent in the X-Dinkur-Signature header, as
+	// "sha256=<hex>". Requests are not signed if empty. Kept in the secret
+	// store, not in this file.
+	Secret string `yaml:",omitempty" secret:"true"`
+}
+
+type Git struct {
+	// Repositories are the paths of local Git repositories that are scanned
+	// for commits when suggesting entries for untracked time.
+	Repositories []string `yaml:",omitempty"`
+	// Authors are the e-mail addresses of the commits to look for. Defaults
+	// to the user.email of each repository's Git config if empty.
+	Authors []string `yaml:",omitempty"`
+	// SessionGap is the longest time between two commits that are suggested
+	// as the same entry.
+	SessionGap time.Duration `yaml:"sessionGap"`
+	// LeadTime is how long before the first commit of a suggested entry the
+	// work is assumed to have started.
+	LeadTime time.Duration `yaml:"leadTime"`
+}
+
+type Meetings struct {
+	// Calendars are the iCalendar (.ics) files to suggest entries from, as
+	// file paths or http(s) URLs, e.g the secret address of a Google
+	// Calendar. All-day events are skipped.
+	Calendars []string `yaml:",omitempty"`
+	// Tag is added to the names of entries from meetings, e.g "meeting" for
+	// "Standup #meeting". No tag is added if empty.
+	Tag string
+	// RefreshInterval is how often calendars from URLs are downloaded again.
+	RefreshInterval time.Duration `yaml:"refreshInterval"`
+	// AutoStart starts an entry when a meeting starts, unless its suggestion
+	// has been dismissed.
+	AutoStart bool `yaml:"autoStart"`
+}
+
+type IssueTrackers struct {
+	// CacheTTL is how long resolved issues are kept in the cache in the data
+	// directory before they're fetched again.
+	CacheTTL time.Duration `yaml:"cacheTTL"`
+	// Items are the issue trackers used to look up the ticket keys in entry
+	// names, as found by the ticket patterns of the tags config. Each key is
+	// looked up in the first issue tracker that matches it.
+	Items []IssueTracker `yaml:",omitempty"`
+}
+
+type IssueTracker struct {
+	// Name identifies the issue tracker, e.g "work-jira".
+	Name string
+	// Type is the kind of issue tracker: jira, github, or gitlab.
+	Type IssueTrackerType
+	// URL is the base URL of the issue tracker, e.g
+	// "https://example.atlassian.net". Defaults to the public GitHub and
+	// GitLab APIs, and must be set for Jira.
+	URL string `yaml:",omitempty"`
+	// Match is a regular expression of the keys to look up in this issue
+	// tracker. Defaults to keys such as "ABC-123" for Jira,
+	// "owner/repo#123" for GitHub, and "group/project#123" or
+	// "group/project!123" for GitLab.
+	Match string `yaml:",omitempty"`
+	// User is the username or e-mail used together with the token for
+	// basic authentication, as used by Jira Cloud. The token is sent as a
+	// bearer token if empty.
+	User string `yaml:",omitempty"`
+	// TokenEnv is the name of an environment variable that contains the
+	// token used to access the issue tracker.
+	TokenEnv string `yaml:"tokenEnv,omitempty"`
+	// TokenFile is the path of a file that contains the token, used if
+	// TokenEnv is not set.
+	TokenFile string `yaml:"tokenFile,omitempty"`
+	// Token is used if neither TokenEnv nor TokenFile is set. Kept in the
+	// secret store, not in this file.
+	Token string `yaml:",omitempty" secret:"true"`
+}
+
+type Worklogs struct {
+	// Tracker is the name of the Jira issue tracker from the issue trackers
+	// config. Entries with keys that it matches are pushed as worklogs on
+	// those issues. Worklogs are disabled if empty.
+	Tracker string `yaml:",omitempty"`
+	// Target is where worklogs are pushed: jira for the worklogs of the Jira
+	// issues, or tempo for Tempo timesheets.
+	Target WorklogTarget
+	Tempo  Tempo
+}
+
+type Tempo struct {
+	// URL is the base URL of the Tempo REST API.
+	URL string
+	// AccountID is the Jira account ID of the user that worklogs are logged
+	// for.
+	AccountID string `yaml:"accountId,omitempty"`
+	// TokenEnv is the name of an environment variable that contains the
+	// Tempo API token.
+	TokenEnv string `yaml:"tokenEnv,omitempty"`
+	// TokenFile is the path of a file that contains the Tempo API token,
+	// used if TokenEnv is not set.
+	TokenFile string `yaml:"tokenFile,omitempty"`
+	// Token is the Tempo API token, used if neither TokenEnv nor TokenFile
+	// is set. Kept in the secret store, not in this file.
+	Token string `yaml:",omitempty" secret:"true"`
+}
+
 type jsonSchemaInterface interface {
 	JSONSchema() *jsonschema.Schema
 }
 
+// Save writes the config to the config file. Secret fields are moved to the
+// secret store and left out of the file. Returns [ErrConfigNotLoaded] if the
+// config file could not be fully loaded.
 func (c *Config) Save() error {
+	if c.loadErr != nil {
+		return fmt.Errorf("%w: %s", ErrConfigNotLoaded, c.fileUsed)
+	}
+	if err := c.saveSecrets(Secrets); err != nil {
+		return fmt.Errorf("save secrets: %w", err)
+	}
+	redacted := c.Redacted()
 	file, err := os.Create(Path)
 	if err != nil {
 		return err
@@ -141,9 +798,14 @@ func (c *Config) Save() error {
 	fmt.Fprintln(file, Header)
 	enc := yaml.NewEncoder(file)
 	defer enc.Close()
-	return enc.Encode(c)
+	return enc.Encode(&redacted)
 }
 
+// ReadAuto reads the config from the default config file, if it exists.
+//
+// If the config has invalid values, then the config is returned together
+// with the validation errors, with the sections that have invalid values
+// reset to their defaults. Such a config is not saved by [Config.Save].
 func ReadAuto(v *viper.Viper) (*Config, error) {
 	if err := AddDefaults(v); err != nil {
 		return nil, err
@@ -151,13 +813,11 @@ func ReadAuto(v *viper.Viper) (*Config, error) {
 	if err := AddAuto(v); err != nil {
 		return nil, err
 	}
-	var cfg Config
-	if err := Unmarshal(v, &cfg); err != nil {
-		return nil, fmt.Errorf("decoding config file: %w", err)
-	}
-	return &cfg, nil
+	return readValidated(v)
 }
 
+// ReadFile reads the config from the file, which must exist. Invalid values
+// are handled as by [ReadAuto].
 func ReadFile(v *viper.Viper, file string) (*Config, error) {
 	if err := AddDefaults(v); err != nil {
 		return nil, err
@@ -165,13 +825,66 @@ func ReadFile(v *viper.Viper, file string) (*Config, error) {
 	if err := AddFile(v, file); err != nil {
 		return nil, err
 	}
+	return readValidated(v)
+}
+
+func readValidated(v *viper.Viper) (*Config, error) {
 	var cfg Config
 	if err := Unmarshal(v, &cfg); err != nil {
 		return nil, fmt.Errorf("decoding config file: %w", err)
 	}
+	if err := cfg.Validate(); err != nil {
+		cfg.loadErr = err
+		cfg.resetInvalid(err)
+		return &cfg, err
+	}
 	return &cfg, nil
 }
 
+// resetInvalid resets the top-level sections with invalid values to their
+// defaults, such as all categorization rules if one of them is invalid.
+// Unknown fields are left alone, as they're not read anyway. Sections that
+// become invalid by resetting another section, such as worklogs that refer
+// to an issue tracker, are reset in turn.
+func (c *Config) resetInvalid(err error) {
+	v := reflect.ValueOf(c).Elem()
+	def := reflect.ValueOf(Default)
+	for err != nil {
+		reset := false
+		for _, e := range unjoin(err) {
+			var valErr *ValidationError
+			if !errors.As(e, &valErr) || errors.Is(valErr.Err, ErrUnknownField) {
+				continue
+			}
+			name, _, _ := strings.Cut(strings.SplitN(valErr.Path, "[", 2)[0], ".")
+			field, ok := structFieldByYAMLName(v.Type(), name)
+			if !ok {
+				continue
+			}
+			value, defValue := v.FieldByIndex(field.Index), def.FieldByIndex(field.Index)
+			if reflect.DeepEqual(value.Interface(), defValue.Interface()) {
+				continue
+			}
+			log.Warn().WithString("section", name).
+				Message("Invalid config. Using the default values of the section.")
+			value.Set(defValue)
+			cloneSlices(value)
+			reset = true
+		}
+		if !reset {
+			return
+		}
+		err = c.Validate()
+	}
+}
+
+func unjoin(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
+
 func AddAuto(v *viper.Viper) error {
 	if err := AddFile(v, Path); err != nil {
 		if os.IsNotExist(err) || errors.As(err, &viper.ConfigFileNotFoundError{}) {
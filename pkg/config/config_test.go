@@ -0,0 +1,70 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+const invalidConfig = `journal:
+  size: 5
+categorization:
+  rules:
+    - match: "ab[c"
+      project: x
+unknownField: 1
+idle:
+  treshold: 5m
+`
+
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "dinkur-desktop.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadFileResetsInvalidSections(t *testing.T) {
+	path := writeTestConfig(t, invalidConfig)
+	cfg, err := ReadFile(viper.New(), path)
+	if err == nil || cfg == nil {
+		t.Fatalf("want config and validation errors, got %v", err)
+	}
+	if len(cfg.Categorization.Rules) != 0 {
+		t.Errorf("want the invalid rules to be reset, got %+v", cfg.Categorization.Rules)
+	}
+	if cfg.Journal.Size != 5 {
+		t.Errorf("want the valid sections to be kept, got journal size %d", cfg.Journal.Size)
+	}
+	if cfg.LoadError() == nil {
+		t.Error("want load error")
+	}
+}
+
+func TestSaveRefusesConfigNotLoaded(t *testing.T) {
+	path := writeTestConfig(t, invalidConfig)
+	oldPath := Path
+	Path = path
+	t.Cleanup(func() { Path = oldPath })
+
+	cfg, _ := ReadFile(viper.New(), path)
+	if err := cfg.Save(); !errors.Is(err, ErrConfigNotLoaded) {
+		t.Errorf("want ErrConfigNotLoaded, got %v", err)
+	}
+	fallback := Fallback(errors.New("broken"))
+	if err := fallback.Save(); !errors.Is(err, ErrConfigNotLoaded) {
+		t.Errorf("want ErrConfigNotLoaded for fallback config, got %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != invalidConfig {
+		t.Errorf("want config file to be unchanged, got:\n%s", b)
+	}
+}
@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2023 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for
+// more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"encoding"
+	"fmt"
+
+	"github.com/invopop/jsonschema"
+	"github.com/spf13/pflag"
+)
+
+type IssueTrackerType string
+
+const (
+	// IssueTrackerJira resolves keys such as "ABC-123" using the Jira REST
+	// API.
+	IssueTrackerJira IssueTrackerType = "jira"
+	// IssueTrackerGitHub resolves keys such as "owner/repo#123" using the
+	// GitHub REST API.
+	IssueTrackerGitHub IssueTrackerType = "github"
+	// IssueTrackerGitLab resolves keys such as "group/project#123" for
+	// issues and "group/project!123" for merge requests using the GitLab
+	// REST API.
+	IssueTrackerGitLab IssueTrackerType = "gitlab"
+)
+
+func _() {
+	// Ensure the type implements the interfaces
+	f := IssueTrackerJira
+	var _ pflag.Value = &f
+	var _ encoding.TextUnmarshaler = &f
+	var _ jsonSchemaInterface = f
+}
+
+func (f IssueTrackerType) String() string {
+	return string(f)
+}
+
+func (f *IssueTrackerType) Set(value string) error {
+	switch IssueTrackerType(value) {
+	case IssueTrackerJira:
+		*f = IssueTrackerJira
+	case IssueTrackerGitHub:
+		*f = IssueTrackerGitHub
+	case IssueTrackerGitLab:
+		*f = IssueTrackerGitLab
+	default:
+		return fmt.Errorf("unknown issue tracker type: %q, must be one of: jira, github, gitlab", value)
+	}
+	return nil
+}
+
+func (f *IssueTrackerType) Type() string {
+	return "type"
+}
+
+func (f *IssueTrackerType) UnmarshalText(text []byte) error {
+	return f.Set(string(text))
+}
+
+// JSONSchema returns the JSON schema struct for this struct.
+func (IssueTrackerType) JSONSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:  "string",
+		Title: "Issue tracker type",
+		Enum: []any{
+			IssueTrackerJira,
+			IssueTrackerGitHub,
+			IssueTrackerGitLab,
+		},
+	}
+}
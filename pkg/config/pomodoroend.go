@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2023 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for
+// more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"encoding"
+	"fmt"
+
+	"github.com/invopop/jsonschema"
+	"github.com/spf13/pflag"
+)
+
+type PomodoroEnd string
+
+const (
+	// PomodoroEndPause stops the entry during the break, and then starts it
+	// again for the next focus interval until all cycles are done.
+	PomodoroEndPause PomodoroEnd = "pause"
+	// PomodoroEndStop stops the entry and ends the pomodoro after the first
+	// focus interval.
+	PomodoroEndStop PomodoroEnd = "stop"
+)
+
+func _() {
+	// Ensure the type implements the interfaces
+	f := PomodoroEndPause
+	var _ pflag.Value = &f
+	var _ encoding.TextUnmarshaler = &f
+	var _ jsonSchemaInterface = f
+}
+
+func (f PomodoroEnd) String() string {
+	return string(f)
+}
+
+func (f *PomodoroEnd) Set(value string) error {
+	switch PomodoroEnd(value) {
+	case PomodoroEndPause:
+		*f = PomodoroEndPause
+	case PomodoroEndStop:
+		*f = PomodoroEndStop
+	default:
+		return fmt.Errorf("unknown pomodoro end action: %q, must be one of: pause, stop", value)
+	}
+	return nil
+}
+
+func (f *PomodoroEnd) Type() string {
+	return "action"
+}
+
+func (f *PomodoroEnd) UnmarshalText(text []byte) error {
+	return f.Set(string(text))
+}
+
+// JSONSchema returns the JSON schema struct for this struct.
+func (PomodoroEnd) JSONSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:  "string",
+		Title: "Pomodoro interval end action",
+		Enum: []any{
+			PomodoroEndPause,
+			PomodoroEndStop,
+		},
+		Default: PomodoroEndPause,
+	}
+}
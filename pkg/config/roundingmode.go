@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2023 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for
+// more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"time"
+
+	"github.com/invopop/jsonschema"
+	"github.com/spf13/pflag"
+)
+
+type RoundingMode string
+
+const (
+	// RoundingModeNone does not round billed durations.
+	RoundingModeNone RoundingMode = "none"
+	// RoundingModeNearest rounds billed durations to the nearest increment.
+	RoundingModeNearest RoundingMode = "nearest"
+	// RoundingModeUp rounds billed durations up to the next increment.
+	RoundingModeUp RoundingMode = "up"
+	// RoundingModeDown rounds billed durations down to the previous
+	// increment.
+	RoundingModeDown RoundingMode = "down"
+)
+
+func _() {
+	// Ensure the type implements the interfaces
+	f := RoundingModeNone
+	var _ pflag.Value = &f
+	var _ encoding.TextUnmarshaler = &f
+	var _ jsonSchemaInterface = f
+}
+
+// Round rounds the duration to the given increment. The duration is
+// returned as-is if the increment is zero or negative.
+func (f RoundingMode) Round(d, increment time.Duration) time.Duration {
+	if increment <= 0 {
+		return d
+	}
+	switch f {
+	case RoundingModeNearest:
+		return d.Round(increment)
+	case RoundingModeUp:
+		if rem := d % increment; rem > 0 {
+			return d - rem + increment
+		}
+		return d
+	case RoundingModeDown:
+		return d.Truncate(increment)
+	default:
+		return d
+	}
+}
+
+func (f RoundingMode) String() string {
+	return string(f)
+}
+
+func (f *RoundingMode) Set(value string) error {
+	switch RoundingMode(value) {
+	case RoundingModeNone:
+		*f = RoundingModeNone
+	case RoundingModeNearest:
+		*f = RoundingModeNearest
+	case RoundingModeUp:
+		*f = RoundingModeUp
+	case RoundingModeDown:
+		*f = RoundingModeDown
+	default:
+		return fmt.Errorf("unknown rounding mode: %q, must be one of: none, nearest, up, down", value)
+	}
+	return nil
+}
+
+func (f *RoundingMode) Type() string {
+	return "mode"
+}
+
+func (f *RoundingMode) UnmarshalText(text []byte) error {
+	return f.Set(string(text))
+}
+
+// JSONSchema returns the JSON schema struct for this struct.
+func (RoundingMode) JSONSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:  "string",
+		Title: "Rounding mode",
+		Enum: []any{
+			RoundingModeNone,
+			RoundingModeNearest,
+			RoundingModeUp,
+			RoundingModeDown,
+		},
+		Default: RoundingModeNone,
+	}
+}
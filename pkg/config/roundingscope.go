@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2023 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for
+// more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"encoding"
+	"fmt"
+
+	"github.com/invopop/jsonschema"
+	"github.com/spf13/pflag"
+)
+
+type RoundingScope string
+
+const (
+	// RoundingScopeEntry rounds the duration of each entry.
+	RoundingScopeEntry RoundingScope = "entry"
+	// RoundingScopeDay rounds the total duration per day, client, and rate.
+	RoundingScopeDay RoundingScope = "day"
+)
+
+func _() {
+	// Ensure the type implements the interfaces
+	f := RoundingScopeEntry
+	var _ pflag.Value = &f
+	var _ encoding.TextUnmarshaler = &f
+	var _ jsonSchemaInterface = f
+}
+
+func (f RoundingScope) String() string {
+	return string(f)
+}
+
+func (f *RoundingScope) Set(value string) error {
+	switch RoundingScope(value) {
+	case RoundingScopeEntry:
+		*f = RoundingScopeEntry
+	case RoundingScopeDay:
+		*f = RoundingScopeDay
+	default:
+		return fmt.Errorf("unknown rounding scope: %q, must be one of: entry, day", value)
+	}
+	return nil
+}
+
+func (f *RoundingScope) Type() string {
+	return "scope"
+}
+
+func (f *RoundingScope) UnmarshalText(text []byte) error {
+	return f.Set(string(text))
+}
+
+// JSONSchema returns the JSON schema struct for this struct.
+func (RoundingScope) JSONSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:  "string",
+		Title: "Rounding scope",
+		Enum: []any{
+			RoundingScopeEntry,
+			RoundingScopeDay,
+		},
+		Default: RoundingScopeEntry,
+	}
+}
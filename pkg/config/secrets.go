@@ -0,0 +1,536 @@
+// SPDX-FileCopyrightText: 2023 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for
+// more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dinkur/dinkur-desktop/internal/jsonfile"
+)
+
+// Errors specific to secrets.
+var (
+	ErrSecretNotFound    = errors.New("secret not found")
+	ErrUnknownSecret     = errors.New("unknown secret key")
+	ErrSecretUnsupported = errors.New("secret service is not supported on this OS")
+)
+
+// SecretsPath is the file where secrets are stored encrypted when the
+// freedesktop Secret Service is not available.
+var SecretsPath string
+
+// SecretsKeyPath is the file with the key that the file at [SecretsPath] is
+// encrypted with. It is kept in a directory of its own, only accessible by
+// the user, apart from the encrypted file in the config directory, so that a
+// copy of the config directory does not reveal any secrets on its own.
+var SecretsKeyPath string
+
+// legacySecretsKeyPath is where the key was kept before it got a directory
+// of its own, which on other OS'es than GNU/Linux was the config directory.
+var legacySecretsKeyPath string
+
+// SecretStore stores the values of config fields marked as secret, such as
+// tokens, so that they are never written to the config file.
+//
+// Fields are marked with the `secret:"true"` struct tag, and are stored by
+// their key, such as "webhooks.items.my-hook.secret". Items in lists are
+// keyed by their name if set, or else by their index.
+type SecretStore interface {
+	// Get returns the secret, or [ErrSecretNotFound] if it isn't stored.
+	Get(key string) (string, error)
+	// Set stores the secret, replacing any previous value.
+	Set(key, value string) error
+	// Delete removes the secret. Deleting a missing secret is not an error.
+	Delete(key string) error
+}
+
+var (
+	secretsOnce  sync.Once
+	secretsStore SecretStore
+)
+
+// Secrets returns the secret store used by [Config.LoadSecrets] and
+// [Config.Save]. It uses the freedesktop Secret Service over D-Bus if
+// available, or else an encrypted file.
+func Secrets() SecretStore {
+	secretsOnce.Do(func() {
+		store, err := NewSecretService()
+		if err != nil {
+			log.Debug().WithError(err).Message("Secret Service is not available. Storing secrets in an encrypted file.")
+			secretsStore = NewFileSecretStore(SecretsPath, moveSecretsKey(legacySecretsKeyPath, SecretsKeyPath))
+			return
+		}
+		secretsStore = store
+	})
+	return secretsStore
+}
+
+// FileSecretStore is a [SecretStore] that keeps the secrets in a file,
+// encrypted with AES-256-GCM using a random key from another file.
+//
+// The key file is only readable by the user, in a directory only accessible
+// by the user. This keeps the secrets safe when the encrypted file is copied
+// on its own, such as with a config directory that is synced between
+// machines, committed to a dotfiles repository, or attached to a bug report.
+// It does not keep the secrets safe from programs running as the user, or
+// from backups of the whole home directory, which the OS keychain would.
+type FileSecretStore struct {
+	path    string
+	keyPath string
+	mu      sync.Mutex
+}
+
+type encryptedSecrets struct {
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+// NewFileSecretStore creates a new secret store that keeps the secrets in
+// the file at the path. The key is created on the first write.
+func NewFileSecretStore(path, keyPath string) *FileSecretStore {
+	return &FileSecretStore{path: path, keyPath: keyPath}
+}
+
+// Get returns the secret, or [ErrSecretNotFound] if it isn't stored.
+func (s *FileSecretStore) Get(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secrets, err := s.read()
+	if err != nil {
+		return "", err
+	}
+	value, ok := secrets[key]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return value, nil
+}
+
+// Set stores the secret, replacing any previous value.
+func (s *FileSecretStore) Set(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secrets, err := s.read()
+	if err != nil {
+		return err
+	}
+	secrets[key] = value
+	return s.write(secrets)
+}
+
+// Delete removes the secret.
+func (s *FileSecretStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secrets, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[key]; !ok {
+		return nil
+	}
+	delete(secrets, key)
+	return s.write(secrets)
+}
+
+func (s *FileSecretStore) read() (map[string]string, error) {
+	var file encryptedSecrets
+	if err := jsonfile.Read(s.path, &file); err != nil {
+		return nil, fmt.Errorf("read secrets: %w", err)
+	}
+	secrets := map[string]string{}
+	if file.Data == nil {
+		return secrets, nil
+	}
+	gcm, err := s.cipher(false)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt secrets: %w", err)
+	}
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, fmt.Errorf("decode secrets: %w", err)
+	}
+	return secrets, nil
+}
+
+func (s *FileSecretStore) write(secrets map[string]string) error {
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	gcm, err := s.cipher(true)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	file := encryptedSecrets{Nonce: nonce, Data: gcm.Seal(nil, nonce, plain, nil)}
+	if err := jsonfile.Write(s.path, file); err != nil {
+		return fmt.Errorf("write secrets: %w", err)
+	}
+	return nil
+}
+
+// cipher reads the key, or creates a new key if create is true and there is
+// no key yet.
+func (s *FileSecretStore) cipher(create bool) (cipher.AEAD, error) {
+	key, err := os.ReadFile(s.keyPath)
+	if errors.Is(err, fs.ErrNotExist) && create {
+		key, err = s.createKey()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read secrets key: %w", err)
+	}
+	if err := restrictKeyPermissions(s.keyPath); err != nil {
+		log.Warn().WithError(err).WithString("path", s.keyPath).
+			Message("Failed to restrict permissions of secrets key.")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("secrets key %s: %w", s.keyPath, err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *FileSecretStore) createKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.keyPath), 0o700); err != nil {
+		return nil, err
+	}
+	if err := restrictKeyPermissions(s.keyPath); err != nil {
+		return nil, err
+	}
+	// O_EXCL, so that a key that another process just created is never
+	// overwritten.
+	f, err := os.OpenFile(s.keyPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(key); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return key, f.Close()
+}
+
+// restrictKeyPermissions makes the key file, if it exists, only readable by
+// the user, and its directory only accessible by the user. Permissions are
+// left as is on Windows, where the user's profile is already private.
+func restrictKeyPermissions(keyPath string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	for path, perm := range map[string]fs.FileMode{filepath.Dir(keyPath): 0o700, keyPath: 0o600} {
+		info, err := os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode().Perm() != perm {
+			if err := os.Chmod(path, perm); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// moveSecretsKey moves the key from where it was kept before, if it has not
+// been moved yet, and returns the path to use. The old path is used if the
+// key could not be moved.
+func moveSecretsKey(oldPath, newPath string) string {
+	if oldPath == "" || oldPath == newPath {
+		return newPath
+	}
+	if _, err := os.Stat(oldPath); err != nil {
+		return newPath
+	}
+	if _, err := os.Stat(newPath); !errors.Is(err, fs.ErrNotExist) {
+		return newPath
+	}
+	err := os.MkdirAll(filepath.Dir(newPath), 0o700)
+	if err == nil {
+		err = os.Rename(oldPath, newPath)
+	}
+	if err != nil {
+		log.Warn().WithError(err).
+			WithString("from", oldPath).
+			WithString("to", newPath).
+			Message("Failed to move secrets key. Using it from its old location.")
+		return oldPath
+	}
+	log.Info().WithString("from", oldPath).WithString("to", newPath).Message("Moved secrets key.")
+	return newPath
+}
+
+// secretField is a config field marked with the `secret:"true"` tag.
+type secretField struct {
+	key string
+	// legacyKey is the key with item names not escaped, as secrets were
+	// stored before, which is the same as key unless a name contains dots.
+	legacyKey string
+	value     reflect.Value
+}
+
+// secretFields returns the secret fields of the struct, slice, or array,
+// keyed by their YAML path.
+func secretFields(v reflect.Value, prefix string) []secretField {
+	return secretFieldsWithLegacy(v, prefix, prefix)
+}
+
+func secretFieldsWithLegacy(v reflect.Value, prefix, legacyPrefix string) []secretField {
+	var fields []secretField
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			key := joinSecretKey(prefix, yamlName(f))
+			legacyKey := joinSecretKey(legacyPrefix, yamlName(f))
+			if f.Tag.Get("secret") == "true" && f.Type.Kind() == reflect.String {
+				fields = append(fields, secretField{key: key, legacyKey: legacyKey, value: v.Field(i)})
+				continue
+			}
+			fields = append(fields, secretFieldsWithLegacy(v.Field(i), key, legacyKey)...)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			elem := v.Index(i)
+			name := strconv.Itoa(i)
+			if elem.Kind() == reflect.Struct {
+				if n := elem.FieldByName("Name"); n.IsValid() && n.Kind() == reflect.String && n.String() != "" {
+					name = n.String()
+				}
+			}
+			fields = append(fields, secretFieldsWithLegacy(elem,
+				joinSecretKey(prefix, escapeSecretKeyName(name)),
+				joinSecretKey(legacyPrefix, name))...)
+		}
+	}
+	return fields
+}
+
+var secretKeyNameEscaper = strings.NewReplacer(`\`, `\\`, `.`, `\.`)
+
+// escapeSecretKeyName escapes the dots in an item name, such as
+// "jira.example.com", so that the keys of different fields never collide.
+func escapeSecretKeyName(name string) string {
+	return secretKeyNameEscaper.Replace(name)
+}
+
+func yamlName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if name == "" {
+		// Same as the default of gopkg.in/yaml.v3.
+		return strings.ToLower(f.Name)
+	}
+	return name
+}
+
+func joinSecretKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// cloneSlices replaces the slices within the value with copies, so that the
+// value can be changed without changing the value it was copied from.
+func cloneSlices(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				cloneSlices(v.Field(i))
+			}
+		}
+	case reflect.Slice:
+		if v.IsNil() {
+			return
+		}
+		clone := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(clone, v)
+		v.Set(clone)
+		for i := 0; i < clone.Len(); i++ {
+			cloneSlices(clone.Index(i))
+		}
+	}
+}
+
+// SecretKeys returns the keys of the config's secret fields, sorted.
+func (c *Config) SecretKeys() []string {
+	var keys []string
+	for _, f := range secretFields(reflect.ValueOf(c).Elem(), "") {
+		keys = append(keys, f.key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// HasSecret reports if the secret field with the key has a value.
+func (c *Config) HasSecret(key string) bool {
+	for _, f := range secretFields(reflect.ValueOf(c).Elem(), "") {
+		if f.key == key {
+			return f.value.String() != ""
+		}
+	}
+	return false
+}
+
+// Redacted returns a copy of the config with all secret fields emptied.
+func (c *Config) Redacted() Config {
+	redacted := *c
+	v := reflect.ValueOf(&redacted).Elem()
+	cloneSlices(v)
+	for _, f := range secretFields(v, "") {
+		f.value.SetString("")
+	}
+	return redacted
+}
+
+// LoadSecrets sets the empty secret fields to their values in the secret
+// store. Secrets that are set in the config file are kept, and are moved
+// to the secret store on the next [Config.Save].
+func (c *Config) LoadSecrets(store SecretStore) error {
+	var errs []error
+	for _, f := range secretFields(reflect.ValueOf(c).Elem(), "") {
+		if f.value.String() != "" {
+			continue
+		}
+		value, err := store.Get(f.key)
+		if errors.Is(err, ErrSecretNotFound) && f.legacyKey != f.key {
+			if value, err := store.Get(f.legacyKey); err == nil {
+				// Remembered by its old key, so that the next save moves it.
+				f.value.SetString(value)
+				c.rememberSecret(f.legacyKey, value)
+				continue
+			}
+		}
+		if errors.Is(err, ErrSecretNotFound) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.key, err))
+			continue
+		}
+		f.value.SetString(value)
+		c.rememberSecret(f.key, value)
+	}
+	return errors.Join(errs...)
+}
+
+// SetSecret stores the value of the secret field with the key in the secret
+// store, or deletes it if the value is empty.
+func (c *Config) SetSecret(store SecretStore, key, value string) error {
+	for _, f := range secretFields(reflect.ValueOf(c).Elem(), "") {
+		if f.key != key {
+			continue
+		}
+		var err error
+		if value == "" {
+			err = store.Delete(key)
+		} else {
+			err = store.Set(key, value)
+		}
+		if err != nil {
+			return err
+		}
+		f.value.SetString(value)
+		c.rememberSecret(key, value)
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownSecret, key)
+}
+
+// saveSecrets moves the secrets that have changed since they were loaded
+// into the secret store, and deletes the secrets that have been emptied or
+// whose fields have been removed, such as by renaming a webhook. The store
+// is opened with the function, and not at all if nothing has changed.
+func (c *Config) saveSecrets(open func() SecretStore) error {
+	changed := map[string]string{}
+	fields := map[string]bool{}
+	for _, f := range secretFields(reflect.ValueOf(c).Elem(), "") {
+		fields[f.key] = true
+		if value := f.value.String(); c.secrets[f.key] != value {
+			changed[f.key] = value
+		}
+	}
+	for key, value := range c.secrets {
+		if !fields[key] && value != "" {
+			changed[key] = ""
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	store := open()
+	keys := make([]string, 0, len(changed))
+	for key := range changed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := changed[key]
+		var err error
+		if value == "" {
+			err = store.Delete(key)
+		} else {
+			err = store.Set(key, value)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		c.rememberSecret(key, value)
+	}
+	return nil
+}
+
+func (c *Config) rememberSecret(key, value string) {
+	if c.secrets == nil {
+		c.secrets = map[string]string{}
+	}
+	c.secrets[key] = value
+}
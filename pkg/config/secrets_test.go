@@ -0,0 +1,222 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+// memorySecretStore is a [SecretStore] that keeps the secrets in a map.
+type memorySecretStore map[string]string
+
+func (s memorySecretStore) Get(key string) (string, error) {
+	value, ok := s[key]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return value, nil
+}
+
+func (s memorySecretStore) Set(key, value string) error {
+	s[key] = value
+	return nil
+}
+
+func (s memorySecretStore) Delete(key string) error {
+	delete(s, key)
+	return nil
+}
+
+func TestSaveSecrets(t *testing.T) {
+	store := memorySecretStore{}
+	opened := 0
+	open := func() SecretStore {
+		opened++
+		return store
+	}
+
+	cfg := Default
+	cfg.Webhooks.Items = []Webhook{
+		{Name: "ci", URL: "https://example.com/ci", Secret: "s3cret"},
+		{Name: "chat", URL: "https://example.com/chat", Secret: "t0ken"},
+	}
+	if err := cfg.saveSecrets(open); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if store["webhooks.items.ci.secret"] != "s3cret" || store["webhooks.items.chat.secret"] != "t0ken" {
+		t.Fatalf("want secrets to be stored, got %v", store)
+	}
+
+	opened = 0
+	if err := cfg.saveSecrets(open); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if opened != 0 {
+		t.Error("want the store not to be opened when nothing has changed")
+	}
+
+	// Emptying a secret deletes it, and so does renaming its webhook.
+	cfg.Webhooks.Items[0].Secret = ""
+	cfg.Webhooks.Items[1].Name = "team-chat"
+	if err := cfg.saveSecrets(open); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	want := memorySecretStore{"webhooks.items.team-chat.secret": "t0ken"}
+	if len(store) != len(want) || store["webhooks.items.team-chat.secret"] != "t0ken" {
+		t.Errorf("want %v, got %v", want, store)
+	}
+}
+
+func TestFileSecretStore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFileSecretStore(filepath.Join(dir, "config", "secrets.json"), filepath.Join(dir, "data", "secrets.key"))
+	if _, err := store.Get("a"); err != ErrSecretNotFound {
+		t.Fatalf("want ErrSecretNotFound, got %v", err)
+	}
+	if err := store.Set("a", "1"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if value, err := store.Get("a"); err != nil || value != "1" {
+		t.Fatalf("want 1, got %q, %v", value, err)
+	}
+	if err := store.Delete("a"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := store.Get("a"); err != ErrSecretNotFound {
+		t.Errorf("want ErrSecretNotFound after delete, got %v", err)
+	}
+}
+
+func TestFileSecretStoreKeyPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are not restricted on Windows")
+	}
+	dir := t.TempDir()
+	keyDir := filepath.Join(dir, "keys")
+	keyPath := filepath.Join(keyDir, "secrets.key")
+	store := NewFileSecretStore(filepath.Join(dir, "secrets.json"), keyPath)
+	if err := os.MkdirAll(keyDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("a", "1"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	checkPerm := func(path string, want os.FileMode) {
+		t.Helper()
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("%s: want permissions %v, got %v", path, want, got)
+		}
+	}
+	checkPerm(keyDir, 0o700)
+	checkPerm(keyPath, 0o600)
+
+	// Loosened permissions are restricted again when the key is read.
+	if err := os.Chmod(keyPath, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(keyDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := store.Get("a"); err != nil || value != "1" {
+		t.Fatalf("want 1, got %q, %v", value, err)
+	}
+	checkPerm(keyDir, 0o700)
+	checkPerm(keyPath, 0o600)
+}
+
+func TestMoveSecretsKey(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "config", "dinkur-desktop", "secrets.key")
+	newPath := filepath.Join(dir, "keys", "secrets.key")
+	secretsPath := filepath.Join(dir, "config", "secrets.json")
+	if err := NewFileSecretStore(secretsPath, oldPath).Set("a", "1"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+
+	if got := moveSecretsKey(oldPath, newPath); got != newPath {
+		t.Fatalf("want key moved to %s, got %s", newPath, got)
+	}
+	if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+		t.Errorf("want no key left at old path, got %v", err)
+	}
+	if value, err := NewFileSecretStore(secretsPath, newPath).Get("a"); err != nil || value != "1" {
+		t.Errorf("want 1 with moved key, got %q, %v", value, err)
+	}
+
+	// A key that has already been moved is kept.
+	if err := os.WriteFile(oldPath, []byte("stale"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := moveSecretsKey(oldPath, newPath); got != newPath {
+		t.Errorf("want new key kept at %s, got %s", newPath, got)
+	}
+	if value, err := NewFileSecretStore(secretsPath, newPath).Get("a"); err != nil || value != "1" {
+		t.Errorf("want 1 with kept key, got %q, %v", value, err)
+	}
+}
+
+func TestSecretKeysDoNotCollide(t *testing.T) {
+	type nested struct {
+		C string `secret:"true"`
+	}
+	type item struct {
+		Name string
+		C    string `secret:"true"`
+		B    nested
+	}
+	type items struct {
+		Items []item
+	}
+	v := items{Items: []item{
+		{Name: "a.b", C: "1"},
+		{Name: "a", B: nested{C: "2"}},
+		{Name: `a\`, C: "3"},
+	}}
+	fields := secretFields(reflect.ValueOf(&v).Elem(), "")
+	keys := map[string]bool{}
+	for _, f := range fields {
+		if keys[f.key] {
+			t.Errorf("duplicate key %q", f.key)
+		}
+		keys[f.key] = true
+	}
+	for _, want := range []string{`items.a\.b.c`, `items.a.b.c`, `items.a\\.c`} {
+		if !keys[want] {
+			t.Errorf("want key %q, got %v", want, keys)
+		}
+	}
+}
+
+func TestLoadSecretsMovesUnescapedKeys(t *testing.T) {
+	store := memorySecretStore{
+		"webhooks.items.ci.example.com.secret": "s3cret",
+		"webhooks.items.chat.secret":           "t0ken",
+	}
+	cfg := Default
+	cfg.Webhooks.Items = []Webhook{
+		{Name: "ci.example.com", URL: "https://ci.example.com"},
+		{Name: "chat", URL: "https://example.com/chat"},
+	}
+	if err := cfg.LoadSecrets(store); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Webhooks.Items[0].Secret != "s3cret" || cfg.Webhooks.Items[1].Secret != "t0ken" {
+		t.Fatalf("want secrets loaded, got %+v", cfg.Webhooks.Items)
+	}
+	if err := cfg.saveSecrets(func() SecretStore { return store }); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	want := memorySecretStore{
+		`webhooks.items.ci\.example\.com.secret`: "s3cret",
+		"webhooks.items.chat.secret":             "t0ken",
+	}
+	if !reflect.DeepEqual(store, want) {
+		t.Errorf("want %v, got %v", want, store)
+	}
+}
@@ -0,0 +1,224 @@
+// SPDX-FileCopyrightText: 2023 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for
+// more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	secretServiceDest      = "org.freedesktop.secrets"
+	secretServicePath      = "/org/freedesktop/secrets"
+	secretServiceInterface = "org.freedesktop.Secret.Service"
+	secretCollectionPath   = "/org/freedesktop/secrets/aliases/default"
+	secretPromptTimeout    = 2 * time.Minute
+	secretApplication      = "dinkur-desktop"
+)
+
+// secretValue is the Secret struct of the Secret Service API.
+type secretValue struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// SecretService is a [SecretStore] that uses the freedesktop Secret Service
+// D-Bus API, as provided by GNOME Keyring and KWallet. Secrets are stored
+// in the default collection, i.e the login keyring.
+//
+// https://specifications.freedesktop.org/secret-service/latest/
+type SecretService struct {
+	conn    *dbus.Conn
+	service dbus.BusObject
+	session dbus.ObjectPath
+	mu      sync.Mutex
+}
+
+// NewSecretService connects to the session bus and opens a session with the
+// Secret Service. Secrets are sent unencrypted within the session, as the
+// session bus is only reachable by the current user.
+func NewSecretService() (*SecretService, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("connect to session bus: %w", err)
+	}
+	service := conn.Object(secretServiceDest, secretServicePath)
+	var output dbus.Variant
+	var session dbus.ObjectPath
+	if err := service.Call(secretServiceInterface+".OpenSession", 0, "plain", dbus.MakeVariant("")).Store(&output, &session); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("open secret service session: %w", err)
+	}
+	return &SecretService{conn: conn, service: service, session: session}, nil
+}
+
+// Get returns the secret, or [ErrSecretNotFound] if it isn't stored.
+func (s *SecretService) Get(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items, err := s.search(key)
+	if err != nil {
+		return "", err
+	}
+	if len(items) == 0 {
+		return "", ErrSecretNotFound
+	}
+	var secret secretValue
+	if err := s.conn.Object(secretServiceDest, items[0]).
+		Call("org.freedesktop.Secret.Item.GetSecret", 0, s.session).
+		Store(&secret); err != nil {
+		return "", fmt.Errorf("get secret: %w", err)
+	}
+	return string(secret.Value), nil
+}
+
+// Set stores the secret in the default collection, replacing any previous
+// value.
+func (s *SecretService) Set(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.unlock([]dbus.ObjectPath{secretCollectionPath}); err != nil {
+		return err
+	}
+	props := map[string]dbus.Variant{
+		"org.freedesktop.Secret.Item.Label":      dbus.MakeVariant("Dinkur desktop: " + key),
+		"org.freedesktop.Secret.Item.Attributes": dbus.MakeVariant(secretAttributes(key)),
+	}
+	secret := secretValue{
+		Session:     s.session,
+		Value:       []byte(value),
+		ContentType: "text/plain; charset=utf8",
+	}
+	var item, prompt dbus.ObjectPath
+	if err := s.conn.Object(secretServiceDest, secretCollectionPath).
+		Call("org.freedesktop.Secret.Collection.CreateItem", 0, props, secret, true).
+		Store(&item, &prompt); err != nil {
+		return fmt.Errorf("create secret: %w", err)
+	}
+	return s.prompt(prompt)
+}
+
+// Delete removes the secret.
+func (s *SecretService) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items, err := s.search(key)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		var prompt dbus.ObjectPath
+		if err := s.conn.Object(secretServiceDest, item).
+			Call("org.freedesktop.Secret.Item.Delete", 0).
+			Store(&prompt); err != nil {
+			return fmt.Errorf("delete secret: %w", err)
+		}
+		if err := s.prompt(prompt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the session and the connection to the session bus.
+func (s *SecretService) Close() error {
+	s.conn.Object(secretServiceDest, s.session).Call("org.freedesktop.Secret.Session.Close", 0)
+	return s.conn.Close()
+}
+
+// search returns the items of the key, after unlocking any locked items.
+func (s *SecretService) search(key string) ([]dbus.ObjectPath, error) {
+	var unlocked, locked []dbus.ObjectPath
+	if err := s.service.Call(secretServiceInterface+".SearchItems", 0, secretAttributes(key)).
+		Store(&unlocked, &locked); err != nil {
+		return nil, fmt.Errorf("search secrets: %w", err)
+	}
+	if len(locked) > 0 {
+		if err := s.unlock(locked); err != nil {
+			return nil, err
+		}
+		unlocked = append(unlocked, locked...)
+	}
+	return unlocked, nil
+}
+
+// unlock unlocks the items or collections, which may prompt the user for
+// their password.
+func (s *SecretService) unlock(objects []dbus.ObjectPath) error {
+	var unlocked []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	if err := s.service.Call(secretServiceInterface+".Unlock", 0, objects).
+		Store(&unlocked, &prompt); err != nil {
+		return fmt.Errorf("unlock secrets: %w", err)
+	}
+	return s.prompt(prompt)
+}
+
+// prompt shows the prompt, if any, and waits for the user to complete it.
+func (s *SecretService) prompt(prompt dbus.ObjectPath) error {
+	if prompt == "" || prompt == "/" {
+		return nil
+	}
+	matches := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(prompt),
+		dbus.WithMatchInterface("org.freedesktop.Secret.Prompt"),
+		dbus.WithMatchMember("Completed"),
+	}
+	if err := s.conn.AddMatchSignal(matches...); err != nil {
+		return fmt.Errorf("listen for secret prompt: %w", err)
+	}
+	defer s.conn.RemoveMatchSignal(matches...)
+	ch := make(chan *dbus.Signal, 1)
+	s.conn.Signal(ch)
+	defer s.conn.RemoveSignal(ch)
+
+	if err := s.conn.Object(secretServiceDest, prompt).
+		Call("org.freedesktop.Secret.Prompt.Prompt", 0, "").Err; err != nil {
+		return fmt.Errorf("show secret prompt: %w", err)
+	}
+	timeout := time.After(secretPromptTimeout)
+	for {
+		select {
+		case sig := <-ch:
+			if sig.Path != prompt || sig.Name != "org.freedesktop.Secret.Prompt.Completed" {
+				continue
+			}
+			if len(sig.Body) > 0 {
+				if dismissed, _ := sig.Body[0].(bool); dismissed {
+					return errors.New("secret prompt was dismissed")
+				}
+			}
+			return nil
+		case <-timeout:
+			return errors.New("timed out waiting for secret prompt")
+		}
+	}
+}
+
+func secretAttributes(key string) map[string]string {
+	return map[string]string{
+		"application": secretApplication,
+		"key":         key,
+	}
+}
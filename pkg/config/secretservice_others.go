@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: 2023 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for
+// more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+//go:build !linux
+
+package config
+
+// SecretService is a placeholder for the freedesktop Secret Service store,
+// which is only available on GNU/Linux.
+type SecretService struct {
+	FileSecretStore
+}
+
+// NewSecretService always fails, as the freedesktop Secret Service is only
+// available on GNU/Linux.
+func NewSecretService() (*SecretService, error) {
+	return nil, ErrSecretUnsupported
+}
+
+// Close does nothing.
+func (*SecretService) Close() error {
+	return nil
+}
@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2023 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for
+// more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"time"
+
+	"github.com/invopop/jsonschema"
+	"github.com/spf13/pflag"
+)
+
+// TimeOfDay is a wall clock time, such as "09:30", stored as the duration
+// since midnight.
+type TimeOfDay time.Duration
+
+// TimeOfDayUnset is used for optional times of day that are not set. It is
+// read from and written as an empty string.
+const TimeOfDayUnset TimeOfDay = -1
+
+func _() {
+	// Ensure the type implements the interfaces
+	t := TimeOfDay(0)
+	var _ pflag.Value = &t
+	var _ encoding.TextUnmarshaler = &t
+	var _ encoding.TextMarshaler = t
+	var _ jsonSchemaInterface = t
+}
+
+// NewTimeOfDay creates a new time of day from an hour and minute.
+func NewTimeOfDay(hour, minute int) TimeOfDay {
+	return TimeOfDay(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+}
+
+// IsSet returns false if the time of day is [TimeOfDayUnset].
+func (t TimeOfDay) IsSet() bool {
+	return t >= 0
+}
+
+// Clock returns the hour and minute of this time of day.
+func (t TimeOfDay) Clock() (hour, minute int) {
+	d := time.Duration(t)
+	return int(d / time.Hour), int(d % time.Hour / time.Minute)
+}
+
+// On returns the time of day on the same date as the given time, in the
+// given time's location.
+func (t TimeOfDay) On(day time.Time) time.Time {
+	y, m, d := day.Date()
+	hour, minute := t.Clock()
+	return time.Date(y, m, d, hour, minute, 0, 0, day.Location())
+}
+
+// TimeOfDayOf returns the time of day of the given time, truncated to
+// whole minutes.
+func TimeOfDayOf(t time.Time) TimeOfDay {
+	return NewTimeOfDay(t.Hour(), t.Minute())
+}
+
+func (t TimeOfDay) String() string {
+	if !t.IsSet() {
+		return ""
+	}
+	hour, minute := t.Clock()
+	return fmt.Sprintf("%02d:%02d", hour, minute)
+}
+
+func (t *TimeOfDay) Set(value string) error {
+	if value == "" {
+		*t = TimeOfDayUnset
+		return nil
+	}
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return fmt.Errorf("invalid time of day: %q, must be in the format HH:MM, or empty", value)
+	}
+	*t = NewTimeOfDay(parsed.Hour(), parsed.Minute())
+	return nil
+}
+
+func (t *TimeOfDay) Type() string {
+	return "time"
+}
+
+func (t *TimeOfDay) UnmarshalText(text []byte) error {
+	return t.Set(string(text))
+}
+
+func (t TimeOfDay) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// JSONSchema returns the JSON schema struct for this struct.
+func (TimeOfDay) JSONSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:    "string",
+		Title:   "Time of day",
+		Pattern: `^(([01][0-9]|2[0-3]):[0-5][0-9])?$`,
+	}
+}
@@ -0,0 +1,564 @@
+// SPDX-FileCopyrightText: 2023 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for
+// more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnknownField is the error of a [ValidationError] for a field in the
+// config file that is not in the config, such as a misspelled field. The
+// field is ignored.
+var ErrUnknownField = errors.New("unknown field")
+
+// ValidationError is an invalid value in the config, together with its
+// position in the config file, if known.
+type ValidationError struct {
+	// File is the config file the value was read from, or empty if unknown.
+	File string
+	// Path is the path to the value, e.g "categorization.rules[1].match".
+	Path string
+	// Line and Column is the position of the value in the config file, or
+	// zero if unknown.
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s: %s", e.File, e.Line, e.Column, e.Path, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Validate checks the config for invalid values, such as malformed regular
+// expressions. The returned error is a join of [ValidationError] errors,
+// which includes the line and column of each invalid value when the config
+// was read from a file.
+func (c *Config) Validate() error {
+	v := validator{file: c.fileUsed, root: parseYAMLNodes(c.fileUsed)}
+	if v.root != nil && len(v.root.Content) > 0 {
+		v.checkUnknownFields(v.root.Content[0], reflect.TypeOf(*c), nil)
+	}
+	for i, pattern := range c.Tags.TicketPatterns {
+		v.checkRegexp(pattern, "tags", "ticketPatterns", i)
+	}
+	for i, rule := range c.Categorization.Rules {
+		if rule.Match == "" {
+			v.add(errors.New("must not be empty"), "categorization", "rules", i, "match")
+		} else {
+			v.checkRegexp(rule.Match, "categorization", "rules", i, "match")
+		}
+		if rule.Project == "" && len(rule.Tags) == 0 && rule.Billable == nil {
+			v.add(errors.New("rule has no effect, must set at least one of: project, tags, billable"),
+				"categorization", "rules", i)
+		}
+	}
+	if c.Idle.Threshold <= 0 {
+		v.add(errors.New("must be positive"), "idle", "threshold")
+	}
+	if c.Idle.PollInterval <= 0 {
+		v.add(errors.New("must be positive"), "idle", "pollInterval")
+	}
+	if c.Reminder.CheckInterval <= 0 {
+		v.add(errors.New("must be positive"), "reminder", "checkInterval")
+	}
+	if c.LongRunning.CheckInterval <= 0 {
+		v.add(errors.New("must be positive"), "longRunning", "checkInterval")
+	}
+	if c.Pomodoro.Interval <= 0 {
+		v.add(errors.New("must be positive"), "pomodoro", "interval")
+	}
+	if c.Pomodoro.Cycles <= 0 {
+		v.add(errors.New("must be positive"), "pomodoro", "cycles")
+	}
+	v.checkBilling(c.Billing)
+	if formatted := fmt.Sprintf(c.Invoice.NumberFormat, 1); strings.Contains(formatted, "%!") {
+		v.add(fmt.Errorf("must contain a single %%d, got: %q", c.Invoice.NumberFormat), "invoice", "numberFormat")
+	}
+	if c.Invoice.DueDays < 0 {
+		v.add(errors.New("must not be negative"), "invoice", "dueDays")
+	}
+	v.checkBudgets(c.Budgets)
+	v.checkTemplates(c.Templates)
+	if c.Journal.Size < 0 {
+		v.add(errors.New("must not be negative"), "journal", "size")
+	}
+	if c.Calendar.TimeZone != "" {
+		if _, err := time.LoadLocation(c.Calendar.TimeZone); err != nil {
+			v.add(err, "calendar", "timeZone")
+		}
+	}
+	if c.Calendar.DayStartHour < 0 || c.Calendar.DayStartHour > 23 {
+		v.add(errors.New("must be between 0 and 23"), "calendar", "dayStartHour")
+	}
+	v.checkHooks(c.Hooks.OnStart, "hooks", "onStart")
+	v.checkHooks(c.Hooks.OnStop, "hooks", "onStop")
+	v.checkHooks(c.Hooks.OnSwitch, "hooks", "onSwitch")
+	v.checkWebhooks(c.Webhooks)
+	v.checkGit(c.Git)
+	v.checkMeetings(c.Meetings)
+	v.checkIssueTrackers(c.IssueTrackers)
+	v.checkWorklogs(c.Worklogs, c.IssueTrackers)
+	if c.API.Socket == "" {
+		v.checkLocalAddress(c.API.Address, "api", "address")
+	}
+	return errors.Join(v.errs...)
+}
+
+func (v *validator) checkTemplates(templates []EntryTemplate) {
+	names := map[string]bool{}
+	for i, t := range templates {
+		name := strings.ToLower(strings.TrimSpace(t.Name))
+		switch {
+		case name == "":
+			v.add(errors.New("must not be empty"), "templates", i, "name")
+		case names[name]:
+			v.add(fmt.Errorf("duplicate template name: %q", t.Name), "templates", i, "name")
+		}
+		names[name] = true
+		if t.At != nil && !t.At.IsSet() {
+			v.add(errors.New("must not be empty, remove it instead"), "templates", i, "at")
+		}
+		if t.Duration < 0 {
+			v.add(errors.New("must not be negative"), "templates", i, "duration")
+		}
+	}
+}
+
+func (v *validator) checkBudgets(b Budgets) {
+	if b.CheckInterval <= 0 {
+		v.add(errors.New("must be positive"), "budgets", "checkInterval")
+	}
+	foundMainGoal := false
+	for i, budget := range b.Items {
+		if budget.Duration <= 0 {
+			v.add(errors.New("must be positive"), "budgets", "items", i, "duration")
+		}
+		if err := new(BudgetPeriod).Set(string(budget.Period)); err != nil {
+			v.add(err, "budgets", "items", i, "period")
+		}
+		if err := new(BudgetKind).Set(string(budget.Kind)); err != nil {
+			v.add(err, "budgets", "items", i, "kind")
+		}
+		if b.MainGoal != "" && strings.EqualFold(budget.Name, b.MainGoal) {
+			if budget.Period != BudgetPeriodDay {
+				v.add(fmt.Errorf("main goal %q must be a daily budget", b.MainGoal), "budgets", "mainGoal")
+			}
+			foundMainGoal = true
+		}
+	}
+	if b.MainGoal != "" && !foundMainGoal {
+		v.add(fmt.Errorf("no budget named %q", b.MainGoal), "budgets", "mainGoal")
+	}
+}
+
+func (v *validator) checkBilling(b Billing) {
+	if _, err := currency.ParseISO(b.Currency); err != nil {
+		v.add(fmt.Errorf("invalid ISO 4217 currency code: %q", b.Currency), "billing", "currency")
+	}
+	if b.Locale != "" {
+		if _, err := language.Parse(b.Locale); err != nil {
+			v.add(fmt.Errorf("invalid BCP 47 language tag: %q", b.Locale), "billing", "locale")
+		}
+	}
+	if b.DefaultRate < 0 {
+		v.add(errors.New("must not be negative"), "billing", "defaultRate")
+	}
+	for i, rate := range b.Rates {
+		if rate.Tag == "" && rate.Project == "" && rate.Match == "" {
+			v.add(errors.New("rate matches nothing, must set at least one of: tag, project, match"),
+				"billing", "rates", i)
+		}
+		if rate.Match != "" {
+			v.checkRegexp(rate.Match, "billing", "rates", i, "match")
+		}
+		if rate.Rate < 0 {
+			v.add(errors.New("must not be negative"), "billing", "rates", i, "rate")
+		}
+	}
+	if b.Rounding.Mode != RoundingModeNone {
+		switch b.Rounding.Increment {
+		case 6 * time.Minute, 15 * time.Minute, 30 * time.Minute:
+		default:
+			v.add(fmt.Errorf("unsupported rounding increment: %s, must be one of: 6m, 15m, 30m", b.Rounding.Increment),
+				"billing", "rounding", "increment")
+		}
+	}
+}
+
+type validator struct {
+	file string
+	root *yaml.Node
+	errs []error
+}
+
+func (v *validator) add(err error, path ...any) *ValidationError {
+	valErr := &ValidationError{
+		Path: formatPath(path),
+		Err:  err,
+	}
+	if node := lookupYAMLNode(v.root, path); node != nil {
+		valErr.File = v.file
+		valErr.Line = node.Line
+		valErr.Column = node.Column
+	}
+	v.errs = append(v.errs, valErr)
+	return valErr
+}
+
+func (v *validator) checkHooks(hooks []Hook, path ...any) {
+	for i, hook := range hooks {
+		if strings.TrimSpace(hook.Command) == "" {
+			v.add(errors.New("must not be empty"), append(path, i, "command")...)
+		}
+		if hook.Timeout < 0 {
+			v.add(errors.New("must not be negative"), append(path, i, "timeout")...)
+		}
+	}
+}
+
+func (v *validator) checkWebhooks(w Webhooks) {
+	if w.MaxAttempts < 1 {
+		v.add(errors.New("must be at least 1"), "webhooks", "maxAttempts")
+	}
+	if w.RetryDelay < 0 {
+		v.add(errors.New("must not be negative"), "webhooks", "retryDelay")
+	}
+	if w.MaxRetryDelay < w.RetryDelay {
+		v.add(errors.New("must not be less than retryDelay"), "webhooks", "maxRetryDelay")
+	}
+	if w.Timeout <= 0 {
+		v.add(errors.New("must be positive"), "webhooks", "timeout")
+	}
+	if w.LogSize < 0 {
+		v.add(errors.New("must not be negative"), "webhooks", "logSize")
+	}
+	names := map[string]bool{}
+	urls := map[string]bool{}
+	for i, hook := range w.Items {
+		switch {
+		case hook.Name == "":
+			v.add(errors.New("must not be empty"), "webhooks", "items", i, "name")
+		case names[hook.Name]:
+			v.add(fmt.Errorf("duplicate webhook name: %q", hook.Name), "webhooks", "items", i, "name")
+		}
+		names[hook.Name] = true
+		u, err := url.Parse(hook.URL)
+		switch {
+		case hook.URL == "":
+			v.add(errors.New("must not be empty"), "webhooks", "items", i, "url")
+		case err != nil:
+			v.add(err, "webhooks", "items", i, "url")
+		case u.Scheme != "http" && u.Scheme != "https":
+			v.add(fmt.Errorf("must use http or https, got %q", u.Scheme), "webhooks", "items", i, "url")
+		case urls[hook.URL]:
+			v.add(fmt.Errorf("duplicate webhook URL: %q", hook.URL), "webhooks", "items", i, "url")
+		}
+		urls[hook.URL] = true
+	}
+}
+
+func (v *validator) checkGit(g Git) {
+	for i, repo := range g.Repositories {
+		if repo == "" {
+			v.add(errors.New("must not be empty"), "git", "repositories", i)
+		}
+	}
+	for i, author := range g.Authors {
+		if !strings.Contains(author, "@") {
+			v.add(fmt.Errorf("must be an e-mail address, got: %q", author), "git", "authors", i)
+		}
+	}
+	if g.SessionGap <= 0 {
+		v.add(errors.New("must be positive"), "git", "sessionGap")
+	}
+	if g.LeadTime < 0 {
+		v.add(errors.New("must not be negative"), "git", "leadTime")
+	}
+}
+
+func (v *validator) checkMeetings(m Meetings) {
+	for i, cal := range m.Calendars {
+		if cal == "" {
+			v.add(errors.New("must not be empty"), "meetings", "calendars", i)
+			continue
+		}
+		if u, err := url.Parse(cal); err == nil && u.Scheme != "" && len(u.Scheme) > 1 {
+			switch u.Scheme {
+			case "http", "https", "webcal":
+			default:
+				v.add(fmt.Errorf("must be a file path, or use http, https, or webcal, got %q", u.Scheme),
+					"meetings", "calendars", i)
+			}
+		}
+	}
+	if m.RefreshInterval <= 0 {
+		v.add(errors.New("must be positive"), "meetings", "refreshInterval")
+	}
+}
+
+func (v *validator) checkIssueTrackers(t IssueTrackers) {
+	if t.CacheTTL < 0 {
+		v.add(errors.New("must not be negative"), "issueTrackers", "cacheTTL")
+	}
+	names := map[string]bool{}
+	for i, tracker := range t.Items {
+		switch {
+		case tracker.Name == "":
+			v.add(errors.New("must not be empty"), "issueTrackers", "items", i, "name")
+		case names[tracker.Name]:
+			v.add(fmt.Errorf("duplicate issue tracker name: %q", tracker.Name), "issueTrackers", "items", i, "name")
+		}
+		names[tracker.Name] = true
+		if err := new(IssueTrackerType).Set(string(tracker.Type)); err != nil {
+			v.add(err, "issueTrackers", "items", i, "type")
+		}
+		if tracker.URL == "" {
+			if tracker.Type == IssueTrackerJira {
+				v.add(errors.New("must be set for Jira"), "issueTrackers", "items", i, "url")
+			}
+		} else if u, err := url.Parse(tracker.URL); err != nil {
+			v.add(err, "issueTrackers", "items", i, "url")
+		} else if u.Scheme != "http" && u.Scheme != "https" {
+			v.add(fmt.Errorf("must use http or https, got %q", u.Scheme), "issueTrackers", "items", i, "url")
+		}
+		if tracker.Match != "" {
+			v.checkRegexp(tracker.Match, "issueTrackers", "items", i, "match")
+		}
+	}
+}
+
+func (v *validator) checkWorklogs(w Worklogs, trackers IssueTrackers) {
+	if err := new(WorklogTarget).Set(string(w.Target)); err != nil {
+		v.add(err, "worklogs", "target")
+	}
+	if w.Tracker == "" {
+		return
+	}
+	found := false
+	for _, tracker := range trackers.Items {
+		if tracker.Name != w.Tracker {
+			continue
+		}
+		found = true
+		if tracker.Type != IssueTrackerJira {
+			v.add(fmt.Errorf("must be a Jira issue tracker, got %q", tracker.Type), "worklogs", "tracker")
+		}
+	}
+	if !found {
+		v.add(fmt.Errorf("no issue tracker named %q", w.Tracker), "worklogs", "tracker")
+	}
+	if w.Target != WorklogTargetTempo {
+		return
+	}
+	if w.Tempo.AccountID == "" {
+		v.add(errors.New("must be set when pushing to Tempo"), "worklogs", "tempo", "accountId")
+	}
+	if u, err := url.Parse(w.Tempo.URL); err != nil {
+		v.add(err, "worklogs", "tempo", "url")
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		v.add(fmt.Errorf("must use http or https, got %q", u.Scheme), "worklogs", "tempo", "url")
+	}
+}
+
+func (v *validator) checkLocalAddress(address string, path ...any) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		v.add(err, path...)
+		return
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		v.add(fmt.Errorf("must be on the local machine, such as localhost or 127.0.0.1, got %q", host), path...)
+	}
+}
+
+func (v *validator) checkRegexp(pattern string, path ...any) {
+	_, err := regexp.Compile(pattern)
+	if err == nil {
+		return
+	}
+	var synErr *syntax.Error
+	if !errors.As(err, &synErr) {
+		v.add(err, path...)
+		return
+	}
+	offset := strings.Index(pattern, synErr.Expr)
+	if offset < 0 {
+		v.add(fmt.Errorf("invalid regular expression: %s: `%s`", synErr.Code, synErr.Expr), path...)
+		return
+	}
+	valErr := v.add(fmt.Errorf("invalid regular expression at offset %d: %s: `%s`",
+		offset, synErr.Code, synErr.Expr), path...)
+	if valErr.Column > 0 {
+		// Point at the offending part of the pattern, skipping the opening
+		// quote if the value was quoted.
+		node := lookupYAMLNode(v.root, path)
+		if node.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) != 0 {
+			valErr.Column++
+		}
+		valErr.Column += offset
+	}
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// checkUnknownFields reports the keys in the YAML mapping that are not
+// fields of the struct, recursively.
+func (v *validator) checkUnknownFields(node *yaml.Node, t reflect.Type, path []any) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return
+	}
+	switch {
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			field, ok := structFieldByYAMLName(t, key.Value)
+			if !ok {
+				v.errs = append(v.errs, &ValidationError{
+					File:   v.file,
+					Path:   formatPath(append(path, key.Value)),
+					Line:   key.Line,
+					Column: key.Column,
+					Err:    ErrUnknownField,
+				})
+				continue
+			}
+			v.checkUnknownFields(node.Content[i+1], field.Type, append(path, yamlName(field)))
+		}
+	case t.Kind() == reflect.Map && node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v.checkUnknownFields(node.Content[i+1], t.Elem(), append(path, node.Content[i].Value))
+		}
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for i, elem := range node.Content {
+			v.checkUnknownFields(elem, t.Elem(), append(path, i))
+		}
+	}
+}
+
+// structFieldByYAMLName returns the exported field with the YAML name,
+// ignoring case, as Viper does.
+func structFieldByYAMLName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.IsExported() && (strings.EqualFold(yamlName(f), name) || strings.EqualFold(f.Name, name)) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func formatPath(path []any) string {
+	var sb strings.Builder
+	for _, seg := range path {
+		switch seg := seg.(type) {
+		case int:
+			sb.WriteByte('[')
+			sb.WriteString(strconv.Itoa(seg))
+			sb.WriteByte(']')
+		default:
+			if sb.Len() > 0 {
+				sb.WriteByte('.')
+			}
+			fmt.Fprint(&sb, seg)
+		}
+	}
+	return sb.String()
+}
+
+func parseYAMLNodes(file string) *yaml.Node {
+	if file == "" {
+		return nil
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(b, &root); err != nil {
+		return nil
+	}
+	return &root
+}
+
+// lookupYAMLNode returns the node at the given path. If the full path does
+// not exist, then the deepest node found along the path is returned.
+func lookupYAMLNode(root *yaml.Node, path []any) *yaml.Node {
+	if root == nil {
+		return nil
+	}
+	node := root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	for _, seg := range path {
+		next := childYAMLNode(node, seg)
+		if next == nil {
+			break
+		}
+		node = next
+	}
+	if node.Kind == yaml.DocumentNode {
+		return nil
+	}
+	return node
+}
+
+func childYAMLNode(node *yaml.Node, seg any) *yaml.Node {
+	switch seg := seg.(type) {
+	case int:
+		if node.Kind == yaml.SequenceNode && seg < len(node.Content) {
+			return node.Content[seg]
+		}
+	case string:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		// Viper treats keys as case-insensitive
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if strings.EqualFold(node.Content[i].Value, seg) {
+				return node.Content[i+1]
+			}
+		}
+	}
+	return nil
+}
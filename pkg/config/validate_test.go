@@ -0,0 +1,120 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestValidateIntervals(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(c *Config)
+		path string
+	}{
+		{"idle threshold", func(c *Config) { c.Idle.Threshold = 0 }, "idle.threshold"},
+		{"idle poll interval", func(c *Config) { c.Idle.PollInterval = -time.Second }, "idle.pollInterval"},
+		{"reminder check interval", func(c *Config) { c.Reminder.CheckInterval = 0 }, "reminder.checkInterval"},
+		{"long-running check interval", func(c *Config) { c.LongRunning.CheckInterval = 0 }, "longRunning.checkInterval"},
+		{"pomodoro interval", func(c *Config) { c.Pomodoro.Interval = 0 }, "pomodoro.interval"},
+		{"pomodoro cycles", func(c *Config) { c.Pomodoro.Cycles = 0 }, "pomodoro.cycles"},
+		{"budgets check interval", func(c *Config) { c.Budgets.CheckInterval = 0 }, "budgets.checkInterval"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Default
+			tc.edit(&cfg)
+			err := cfg.Validate()
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("want validation error, got %v", err)
+			}
+			if verr.Path != tc.path {
+				t.Errorf("want error for %s, got: %v", tc.path, err)
+			}
+		})
+	}
+}
+
+func TestValidateDefault(t *testing.T) {
+	cfg := Default
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("want default config to be valid, got: %v", err)
+	}
+}
+
+func TestValidateWebhookNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []Webhook
+	}{
+		{"missing name", []Webhook{{URL: "https://example.com/hook"}}},
+		{"duplicate name", []Webhook{
+			{Name: "ci", URL: "https://example.com/a"},
+			{Name: "ci", URL: "https://example.com/b"},
+		}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Default
+			cfg.Webhooks.Items = tc.items
+			err := cfg.Validate()
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("want validation error, got %v", err)
+			}
+			if !strings.HasSuffix(verr.Path, ".name") {
+				t.Errorf("want error for the name, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidatePositions(t *testing.T) {
+	path := writeTestConfig(t, invalidConfig)
+	cfg, err := ReadFile(viper.New(), path)
+	if err == nil {
+		t.Fatal("want validation errors")
+	}
+	if cfg == nil {
+		t.Fatal("want the config to be returned together with the errors")
+	}
+	errs := validationErrors(err)
+	tests := []struct {
+		path         string
+		line, column int
+	}{
+		// The column points at "[c" inside the quoted pattern.
+		{"categorization.rules[0].match", 5, 17},
+		{"unknownField", 7, 1},
+		{"idle.treshold", 9, 3},
+	}
+	for _, tc := range tests {
+		valErr, ok := errs[tc.path]
+		if !ok {
+			t.Errorf("%s: want error, got: %v", tc.path, err)
+			continue
+		}
+		if valErr.File != path || valErr.Line != tc.line || valErr.Column != tc.column {
+			t.Errorf("%s: want %s:%d:%d, got %s:%d:%d", tc.path,
+				path, tc.line, tc.column, valErr.File, valErr.Line, valErr.Column)
+		}
+	}
+	if !errors.Is(errs["unknownField"].Err, ErrUnknownField) {
+		t.Errorf("want ErrUnknownField, got %v", errs["unknownField"].Err)
+	}
+}
+
+func validationErrors(err error) map[string]*ValidationError {
+	result := map[string]*ValidationError{}
+	for _, e := range unjoin(err) {
+		var valErr *ValidationError
+		if errors.As(e, &valErr) {
+			result[valErr.Path] = valErr
+		}
+	}
+	return result
+}
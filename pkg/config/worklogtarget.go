@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2023 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for
+// more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"encoding"
+	"fmt"
+
+	"github.com/invopop/jsonschema"
+	"github.com/spf13/pflag"
+)
+
+type WorklogTarget string
+
+const (
+	// WorklogTargetJira pushes worklogs to the issues in Jira.
+	WorklogTargetJira WorklogTarget = "jira"
+	// WorklogTargetTempo pushes worklogs to Tempo timesheets.
+	WorklogTargetTempo WorklogTarget = "tempo"
+)
+
+func _() {
+	// Ensure the type implements the interfaces
+	f := WorklogTargetJira
+	var _ pflag.Value = &f
+	var _ encoding.TextUnmarshaler = &f
+	var _ jsonSchemaInterface = f
+}
+
+func (f WorklogTarget) String() string {
+	return string(f)
+}
+
+func (f *WorklogTarget) Set(value string) error {
+	switch WorklogTarget(value) {
+	case WorklogTargetJira:
+		*f = WorklogTargetJira
+	case WorklogTargetTempo:
+		*f = WorklogTargetTempo
+	default:
+		return fmt.Errorf("unknown worklog target: %q, must be one of: jira, tempo", value)
+	}
+	return nil
+}
+
+func (f *WorklogTarget) Type() string {
+	return "target"
+}
+
+func (f *WorklogTarget) UnmarshalText(text []byte) error {
+	return f.Set(string(text))
+}
+
+// JSONSchema returns the JSON schema struct for this struct.
+func (WorklogTarget) JSONSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:  "string",
+		Title: "Worklog target",
+		Enum: []any{
+			WorklogTargetJira,
+			WorklogTargetTempo,
+		},
+	}
+}
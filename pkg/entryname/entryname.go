@@ -0,0 +1,119 @@
+// Package entryname parses tags, clients, and ticket keys out of entry names,
+// such as "INC-123 review #support @acme".
+package entryname
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Options defines which patterns in an entry name are recognized.
+type Options struct {
+	// TagPrefix is the prefix of tags, e.g "#" for "#support". Tags are
+	// disabled if empty.
+	TagPrefix string
+	// ClientPrefix is the prefix of the client or project, e.g "@" for
+	// "@acme". Clients are disabled if empty.
+	ClientPrefix string
+	// TicketPatterns are regular expressions that match ticket keys, such as
+	// `\b[A-Z][A-Z0-9]+-[0-9]+\b` for "INC-123".
+	TicketPatterns []string
+}
+
+// Parsed is the metadata parsed from an entry name.
+type Parsed struct {
+	Tags    []string `json:"tags"`
+	Client  string   `json:"client"`
+	Tickets []string `json:"tickets"`
+}
+
+// Parser parses entry names.
+type Parser struct {
+	tagPrefix    string
+	clientPrefix string
+	tickets      []*regexp.Regexp
+}
+
+// New creates a new entry name parser. An error is returned if any of the
+// ticket patterns is not a valid regular expression.
+func New(opts Options) (*Parser, error) {
+	p := &Parser{
+		tagPrefix:    opts.TagPrefix,
+		clientPrefix: opts.ClientPrefix,
+	}
+	for i, pattern := range opts.TicketPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("ticket pattern #%d: %w", i+1, err)
+		}
+		p.tickets = append(p.tickets, re)
+	}
+	return p, nil
+}
+
+// Parse extracts tags, client, and ticket keys from an entry name. Only the
+// first client in the name is used.
+func (p *Parser) Parse(name string) Parsed {
+	var parsed Parsed
+	for _, word := range strings.Fields(name) {
+		word = strings.TrimRightFunc(word, unicode.IsPunct)
+		if tag, ok := cutPrefix(word, p.tagPrefix); ok {
+			parsed.Tags = appendUnique(parsed.Tags, tag)
+		} else if client, ok := cutPrefix(word, p.clientPrefix); ok && parsed.Client == "" {
+			parsed.Client = client
+		}
+	}
+	for _, re := range p.tickets {
+		for _, ticket := range re.FindAllString(name, -1) {
+			parsed.Tickets = appendUnique(parsed.Tickets, ticket)
+		}
+	}
+	return parsed
+}
+
+// FormatTag returns the tag with the tag prefix, as it would be written in an
+// entry name. Returns empty string if tags are disabled, or if the tag would
+// not be parsed back as the same tag, such as when it contains spaces.
+func (p *Parser) FormatTag(tag string) string {
+	if p.tagPrefix == "" {
+		return ""
+	}
+	token := p.tagPrefix + tag
+	if parsed := p.Parse(token); len(parsed.Tags) != 1 || parsed.Tags[0] != tag {
+		return ""
+	}
+	return token
+}
+
+// FormatClient returns the client with the client prefix, as it would be
+// written in an entry name. Returns empty string if clients are disabled, or
+// if the client would not be parsed back as the same client, such as when it
+// contains spaces.
+func (p *Parser) FormatClient(client string) string {
+	if p.clientPrefix == "" || client == "" {
+		return ""
+	}
+	token := p.clientPrefix + client
+	if p.Parse(token).Client != client {
+		return ""
+	}
+	return token
+}
+
+func cutPrefix(word, prefix string) (string, bool) {
+	if prefix == "" || len(word) <= len(prefix) {
+		return "", false
+	}
+	return strings.CutPrefix(word, prefix)
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,101 @@
+package entryname
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newTestParser(t *testing.T) *Parser {
+	t.Helper()
+	p, err := New(Options{
+		TagPrefix:      "#",
+		ClientPrefix:   "@",
+		TicketPatterns: []string{`\b[A-Z][A-Z0-9]+-[0-9]+\b`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want Parsed
+	}{
+		{"empty", "", Parsed{}},
+		{"plain name", "Write docs", Parsed{}},
+		{"all at start", "#support @acme ABC-123 call", Parsed{Tags: []string{"support"}, Client: "acme", Tickets: []string{"ABC-123"}}},
+		{"all at end", "call #support @acme ABC-123", Parsed{Tags: []string{"support"}, Client: "acme", Tickets: []string{"ABC-123"}}},
+		{"only tag", "#support", Parsed{Tags: []string{"support"}}},
+		{"only client", "@acme", Parsed{Client: "acme"}},
+		{"only ticket", "ABC-123", Parsed{Tickets: []string{"ABC-123"}}},
+		{"trailing punctuation", "Call @acme, about ABC-123. #support!", Parsed{Tags: []string{"support"}, Client: "acme", Tickets: []string{"ABC-123"}}},
+		{"trailing dots", "Review #support... @acme...", Parsed{Tags: []string{"support"}, Client: "acme"}},
+		{"inner punctuation kept", "#front-end @acme.com", Parsed{Tags: []string{"front-end"}, Client: "acme.com"}},
+		{"prefix only", "# @ call", Parsed{}},
+		{"prefix with punctuation only", "#! @, call", Parsed{}},
+		{"duplicate tags", "#support #Support #support", Parsed{Tags: []string{"support"}}},
+		{"first client", "@acme @globex", Parsed{Client: "acme"}},
+		{"prefix inside word", "mail@acme.com issue#12", Parsed{}},
+		{"ticket inside word", "xABC-123 ABC-123x", Parsed{}},
+		{"lowercase ticket", "abc-123", Parsed{}},
+		{"duplicate tickets", "ABC-123 and ABC-123, DEF-4", Parsed{Tickets: []string{"ABC-123", "DEF-4"}}},
+	}
+	p := newTestParser(t)
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := p.Parse(tc.text); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParseDisabledPrefixes(t *testing.T) {
+	p, err := New(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Parse("#support @acme ABC-123"); !reflect.DeepEqual(got, Parsed{}) {
+		t.Errorf("want nothing parsed, got %+v", got)
+	}
+	if tag := p.FormatTag("support"); tag != "" {
+		t.Errorf("want no tag, got %q", tag)
+	}
+	if client := p.FormatClient("acme"); client != "" {
+		t.Errorf("want no client, got %q", client)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		value      string
+		wantTag    string
+		wantClient string
+	}{
+		{"support", "#support", "@support"},
+		{"front-end", "#front-end", "@front-end"},
+		{"customer support", "", ""},
+		{"support.", "", ""},
+		{"", "", ""},
+	}
+	p := newTestParser(t)
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			if got := p.FormatTag(tc.value); got != tc.wantTag {
+				t.Errorf("want tag %q, got %q", tc.wantTag, got)
+			}
+			if got := p.FormatClient(tc.value); got != tc.wantClient {
+				t.Errorf("want client %q, got %q", tc.wantClient, got)
+			}
+		})
+	}
+}
+
+func TestNewInvalidTicketPattern(t *testing.T) {
+	if _, err := New(Options{TicketPatterns: []string{`[A-Z`}}); err == nil {
+		t.Error("want error for invalid ticket pattern")
+	}
+}
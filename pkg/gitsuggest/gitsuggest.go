@@ -0,0 +1,409 @@
+// Package gitsuggest proposes entries from the user's commits in local Git
+// repositories, to help reconstruct forgotten days. Repositories are read
+// directly from disk, without running git or accessing the network.
+package gitsuggest
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/entryname"
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// defaultBranches are the branch names that say nothing about what was
+// worked on, and so are not used to name suggestions. Commits that are
+// reachable from several branches are attributed to other branches first.
+var defaultBranches = map[string]bool{
+	"main":    true,
+	"master":  true,
+	"develop": true,
+	"trunk":   true,
+}
+
+// Options configures how repositories are scanned and commits grouped.
+type Options struct {
+	// Repositories are the paths of the repositories, or of any directory
+	// inside them.
+	Repositories []string
+	// Authors are the e-mail addresses of the commits to include. Defaults
+	// to the user.email of each repository's Git config if empty.
+	Authors []string
+	// SessionGap is the longest time between two commits in the same
+	// suggestion.
+	SessionGap time.Duration
+	// LeadTime is how long before its first commit a suggestion starts.
+	LeadTime time.Duration
+	// Names is used to find ticket keys in branch names and commit
+	// messages. Ticket keys are not used if nil.
+	Names *entryname.Parser
+}
+
+// Commit is a commit made by the user.
+type Commit struct {
+	// Repository is the name of the repository's directory.
+	Repository string `json:"repository"`
+	Hash       string `json:"hash"`
+	// Branch is the local branch the commit was found on, or empty if only
+	// found from a detached HEAD.
+	Branch string `json:"branch"`
+	// Message is the first line of the commit message.
+	Message string `json:"message"`
+	// Time is when the commit was authored.
+	Time time.Time `json:"time"`
+}
+
+// Suggestion is a proposed entry covering one or more commits in a row.
+type Suggestion struct {
+	// ID identifies the suggestion, based on its first commit.
+	ID string `json:"id"`
+	// Name is the ticket key, the branch name, or the repository name,
+	// whichever is found first.
+	Name       string    `json:"name"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Repository string    `json:"repository"`
+	Branch     string    `json:"branch"`
+	Ticket     string    `json:"ticket"`
+	Commits    []Commit  `json:"commits"`
+}
+
+// Suggest scans the repositories for commits authored between from and to,
+// and groups them into suggestions. Suggestions are returned from all
+// repositories that could be read, together with any errors from the
+// others.
+func Suggest(opts Options, from, to time.Time) ([]Suggestion, error) {
+	commits, err := Scan(opts, from, to)
+	return Group(commits, opts), err
+}
+
+// Scan returns the commits in the repositories authored between from and to
+// by any of the authors, oldest first.
+func Scan(opts Options, from, to time.Time) ([]Commit, error) {
+	var commits []Commit
+	var errs []error
+	for _, path := range opts.Repositories {
+		repoCommits, err := scanRepository(path, opts.Authors, from, to)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("scan repository %q: %w", path, err))
+			continue
+		}
+		commits = append(commits, repoCommits...)
+	}
+	sort.SliceStable(commits, func(i, j int) bool {
+		return commits[i].Time.Before(commits[j].Time)
+	})
+	return commits, errors.Join(errs...)
+}
+
+func scanRepository(path string, authors []string, from, to time.Time) ([]Commit, error) {
+	repo, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, err
+	}
+	emails, err := authorEmails(repo, authors)
+	if err != nil {
+		return nil, err
+	}
+	if len(emails) == 0 {
+		return nil, errors.New("no authors configured, and no user.email in the Git config")
+	}
+	heads, err := branchHeads(repo)
+	if err != nil {
+		return nil, err
+	}
+	name := repositoryName(repo, path)
+	seen := map[plumbing.Hash]bool{}
+	var commits []Commit
+	for _, head := range heads {
+		shared, err := sharedWithDefault(repo, head, heads, from)
+		if err != nil {
+			return nil, err
+		}
+		err = walk(repo, head.hash, from, func(c *object.Commit) {
+			if seen[c.Hash] || shared[c.Hash] {
+				return
+			}
+			seen[c.Hash] = true
+			when := c.Author.When
+			if when.Before(from) || !when.Before(to) || !emails[strings.ToLower(c.Author.Email)] {
+				return
+			}
+			commits = append(commits, Commit{
+				Repository: name,
+				Hash:       c.Hash.String(),
+				Branch:     head.branch,
+				Message:    firstLine(c.Message),
+				Time:       when,
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return commits, nil
+}
+
+// walk calls fn for each commit reachable from the hash, newest first, until
+// the commits are older than from.
+func walk(repo *git.Repository, hash plumbing.Hash, from time.Time, fn func(*object.Commit)) error {
+	iter, err := repo.Log(&git.LogOptions{From: hash, Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return fmt.Errorf("log %s: %w", hash, err)
+	}
+	defer iter.Close()
+	err = iter.ForEach(func(c *object.Commit) error {
+		// A commit is never committed before it's authored, so no older
+		// commits can be in range.
+		if c.Committer.When.Before(from) {
+			return storer.ErrStop
+		}
+		fn(c)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("log %s: %w", hash, err)
+	}
+	return nil
+}
+
+// sharedWithDefault returns the commits since from that a branch has in
+// common with the default branches, such as commits made before the branch
+// was created, so that they're attributed to the default branch instead.
+// Nothing is returned for branches that have been merged into a default
+// branch, as all their commits were then made on the branch.
+func sharedWithDefault(repo *git.Repository, head branchHead, heads []branchHead, from time.Time) (map[plumbing.Hash]bool, error) {
+	shared := map[plumbing.Hash]bool{}
+	if head.branch == "" || defaultBranches[head.branch] {
+		return shared, nil
+	}
+	commit, err := repo.CommitObject(head.hash)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range heads {
+		if !defaultBranches[other.branch] {
+			continue
+		}
+		otherCommit, err := repo.CommitObject(other.hash)
+		if err != nil {
+			return nil, err
+		}
+		bases, err := commit.MergeBase(otherCommit)
+		if err != nil {
+			return nil, fmt.Errorf("merge base of %s and %s: %w", head.branch, other.branch, err)
+		}
+		for _, base := range bases {
+			if base.Hash == head.hash {
+				return map[plumbing.Hash]bool{}, nil
+			}
+			if err := walk(repo, base.Hash, from, func(c *object.Commit) {
+				shared[c.Hash] = true
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return shared, nil
+}
+
+func authorEmails(repo *git.Repository, authors []string) (map[string]bool, error) {
+	emails := map[string]bool{}
+	for _, author := range authors {
+		emails[strings.ToLower(author)] = true
+	}
+	if len(emails) > 0 {
+		return emails, nil
+	}
+	cfg, err := repo.ConfigScoped(gitconfig.GlobalScope)
+	if err != nil {
+		return nil, fmt.Errorf("read Git config: %w", err)
+	}
+	if cfg.User.Email != "" {
+		emails[strings.ToLower(cfg.User.Email)] = true
+	}
+	return emails, nil
+}
+
+type branchHead struct {
+	branch string
+	hash   plumbing.Hash
+}
+
+// branchHeads returns the commits that local branches point to, with the
+// default branches last, followed by HEAD if it's detached.
+func branchHeads(repo *git.Repository) ([]branchHead, error) {
+	iter, err := repo.Branches()
+	if err != nil {
+		return nil, err
+	}
+	var heads []branchHead
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		heads = append(heads, branchHead{ref.Name().Short(), ref.Hash()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(heads, func(i, j int) bool {
+		return !defaultBranches[heads[i].branch] && defaultBranches[heads[j].branch]
+	})
+	// Branches that point to the same commit as a default branch, such as
+	// after being fast-forward merged, could have been created at any time.
+	defaultHashes := map[plumbing.Hash]bool{}
+	for _, head := range heads {
+		if defaultBranches[head.branch] {
+			defaultHashes[head.hash] = true
+		}
+	}
+	kept := heads[:0]
+	for _, head := range heads {
+		if defaultBranches[head.branch] || !defaultHashes[head.hash] {
+			kept = append(kept, head)
+		}
+	}
+	heads = kept
+	if head, err := repo.Head(); err == nil && !head.Name().IsBranch() {
+		heads = append(heads, branchHead{"", head.Hash()})
+	}
+	return heads, nil
+}
+
+func repositoryName(repo *git.Repository, path string) string {
+	if wt, err := repo.Worktree(); err == nil {
+		return filepath.Base(wt.Filesystem.Root())
+	}
+	return strings.TrimSuffix(filepath.Base(filepath.Clean(path)), ".git")
+}
+
+func firstLine(message string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return strings.TrimSpace(line)
+}
+
+// Group groups the commits, which must be sorted oldest first, into
+// suggestions. Commits in a row with the same name and repository, and no
+// more than the session gap apart, are grouped together. Suggestions start
+// the lead time before their first commit, but never before the previous
+// suggestion ends.
+func Group(commits []Commit, opts Options) []Suggestion {
+	var suggestions []Suggestion
+	var current *Suggestion
+	for _, c := range commits {
+		name, ticket := suggestionName(c, opts.Names)
+		if current != nil && current.Name == name && current.Repository == c.Repository &&
+			c.Time.Sub(current.End) <= opts.SessionGap {
+			current.End = c.Time
+			current.Commits = append(current.Commits, c)
+			continue
+		}
+		start := c.Time.Add(-opts.LeadTime)
+		if current != nil && start.Before(current.End) {
+			start = current.End
+		}
+		suggestions = append(suggestions, Suggestion{
+			ID:         "git-" + c.Hash[:12],
+			Name:       name,
+			Start:      start,
+			End:        c.Time,
+			Repository: c.Repository,
+			Branch:     c.Branch,
+			Ticket:     ticket,
+			Commits:    []Commit{c},
+		})
+		current = &suggestions[len(suggestions)-1]
+	}
+	return suggestions
+}
+
+// suggestionName returns the first ticket key in the branch name or commit
+// message, or else the branch name unless it's a default branch, or else the
+// repository name.
+func suggestionName(c Commit, names *entryname.Parser) (name, ticket string) {
+	if names != nil {
+		for _, s := range []string{c.Branch, c.Message} {
+			if tickets := names.Parse(s).Tickets; len(tickets) > 0 {
+				return tickets[0], tickets[0]
+			}
+		}
+	}
+	if c.Branch != "" && !defaultBranches[c.Branch] {
+		return c.Branch, ""
+	}
+	return c.Repository, ""
+}
+
+// Span is a span of time, such as an existing entry.
+type Span struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Fit shortens the suggestions to the untracked time between from and to,
+// i.e the time not covered by any of the tracked spans. Each suggestion is
+// fitted into the untracked span it overlaps the most, and suggestions that
+// don't overlap any untracked time are dropped. The IDs of the fitted
+// suggestions include their start time, so that a suggestion gets a new ID
+// when it's fitted differently after the tracked time changes.
+func Fit(suggestions []Suggestion, tracked []Span, from, to time.Time) []Suggestion {
+	free := untracked(tracked, from, to)
+	var fitted []Suggestion
+	for _, s := range suggestions {
+		var best Span
+		var bestOverlap time.Duration
+		for _, span := range free {
+			start, end := latest(s.Start, span.Start), earliest(s.End, span.End)
+			if overlap := end.Sub(start); overlap > bestOverlap {
+				best, bestOverlap = Span{start, end}, overlap
+			}
+		}
+		if bestOverlap <= 0 {
+			continue
+		}
+		s.ID = fmt.Sprintf("%s-%d", s.ID, best.Start.Unix())
+		s.Start, s.End = best.Start, best.End
+		fitted = append(fitted, s)
+	}
+	return fitted
+}
+
+func untracked(tracked []Span, from, to time.Time) []Span {
+	sorted := append([]Span(nil), tracked...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+	var free []Span
+	cursor := from
+	for _, span := range sorted {
+		if span.Start.After(cursor) {
+			free = append(free, Span{cursor, earliest(span.Start, to)})
+		}
+		cursor = latest(cursor, span.End)
+		if !cursor.Before(to) {
+			return free
+		}
+	}
+	return append(free, Span{cursor, to})
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,227 @@
+package gitsuggest
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/entryname"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const (
+	testEmail  = "me@example.com"
+	otherEmail = "other@example.com"
+)
+
+// testRepo is a Git repository in a temporary directory.
+type testRepo struct {
+	t    *testing.T
+	path string
+	repo *git.Repository
+	wt   *git.Worktree
+}
+
+func newTestRepo(t *testing.T, name string) *testRepo {
+	t.Helper()
+	// Keep the user's own Git config out of the tests.
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), name)
+	repo, err := git.PlainInit(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testRepo{t: t, path: path, repo: repo, wt: wt}
+}
+
+func (r *testRepo) setUserEmail(email string) {
+	r.t.Helper()
+	cfg, err := r.repo.Config()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	cfg.User.Email = email
+	if err := r.repo.SetConfig(cfg); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+// commit adds an empty commit on the current branch, authored and committed
+// at the given time.
+func (r *testRepo) commit(message, email string, when time.Time) {
+	r.t.Helper()
+	sig := &object.Signature{Name: "Test", Email: email, When: when}
+	if _, err := r.wt.Commit(message, &git.CommitOptions{
+		Author:            sig,
+		Committer:         sig,
+		AllowEmptyCommits: true,
+	}); err != nil {
+		r.t.Fatalf("commit %q: %v", message, err)
+	}
+}
+
+func (r *testRepo) checkout(branch string, create bool) {
+	r.t.Helper()
+	if err := r.wt.Checkout(&git.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName(branch),
+		Create: create,
+	}); err != nil {
+		r.t.Fatalf("checkout %q: %v", branch, err)
+	}
+}
+
+func at(day, hour, minute int) time.Time {
+	return time.Date(2023, 3, day, hour, minute, 0, 0, time.UTC)
+}
+
+// newWebappRepo creates a repository with commits on 2023-03-01 on the
+// master branch and on a feature branch, together with commits by someone
+// else and commits on the days before and after.
+func newWebappRepo(t *testing.T) *testRepo {
+	r := newTestRepo(t, "webapp")
+	r.commit("Initial commit", testEmail, at(1, 0, 0).Add(-time.Minute))
+	r.commit("Add readme", testEmail, at(1, 0, 0))
+	r.commit("Fix typo\n\nLonger description.", testEmail, at(1, 9, 0))
+	r.commit("Update docs", testEmail, at(1, 9, 20))
+	r.commit("Review feedback", otherEmail, at(1, 9, 40))
+	r.checkout("ABC-12-login", true)
+	r.commit("Add login form", testEmail, at(1, 13, 0))
+	r.commit("Validate password", testEmail, at(1, 13, 30))
+	r.checkout("master", false)
+	r.commit("Bump version", testEmail, at(2, 0, 0))
+	return r
+}
+
+func newTestNames(t *testing.T) *entryname.Parser {
+	t.Helper()
+	names, err := entryname.New(entryname.Options{TicketPatterns: []string{`\b[A-Z][A-Z0-9]+-[0-9]+\b`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+type wantSuggestion struct {
+	name     string
+	start    time.Time
+	end      time.Time
+	branch   string
+	ticket   string
+	messages []string
+}
+
+func checkSuggestions(t *testing.T, got []Suggestion, want []wantSuggestion) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("want %d suggestions, got %d: %+v", len(want), len(got), got)
+	}
+	for i, w := range want {
+		s := got[i]
+		if s.Name != w.name || !s.Start.Equal(w.start) || !s.End.Equal(w.end) ||
+			s.Branch != w.branch || s.Ticket != w.ticket || s.Repository != "webapp" {
+			t.Errorf("suggestion %d: want %s %v - %v on %q (ticket %q), got %s %v - %v on %q (ticket %q) in %q",
+				i, w.name, w.start, w.end, w.branch, w.ticket,
+				s.Name, s.Start, s.End, s.Branch, s.Ticket, s.Repository)
+		}
+		var messages []string
+		for _, c := range s.Commits {
+			messages = append(messages, c.Message)
+		}
+		if len(messages) != len(w.messages) {
+			t.Errorf("suggestion %d: want commits %q, got %q", i, w.messages, messages)
+			continue
+		}
+		for j := range messages {
+			if messages[j] != w.messages[j] {
+				t.Errorf("suggestion %d: want commits %q, got %q", i, w.messages, messages)
+				break
+			}
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	r := newWebappRepo(t)
+	opts := Options{
+		Repositories: []string{r.path},
+		Authors:      []string{testEmail},
+		SessionGap:   time.Hour,
+		LeadTime:     30 * time.Minute,
+		Names:        newTestNames(t),
+	}
+	suggestions, err := Suggest(opts, at(1, 0, 0), at(2, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSuggestions(t, suggestions, []wantSuggestion{
+		// The commit exactly at the start of the day is included, but not
+		// the one exactly at the end.
+		{"webapp", at(1, 0, 0).Add(-30 * time.Minute), at(1, 0, 0), "master", "", []string{"Add readme"}},
+		{"webapp", at(1, 8, 30), at(1, 9, 20), "master", "", []string{"Fix typo", "Update docs"}},
+		{"ABC-12", at(1, 12, 30), at(1, 13, 30), "ABC-12-login", "ABC-12", []string{"Add login form", "Validate password"}},
+	})
+
+	// Fitted into the day, around an entry from 09:00 to 10:00.
+	tracked := []Span{{at(1, 9, 0), at(1, 10, 0)}}
+	checkSuggestions(t, Fit(suggestions, tracked, at(1, 0, 0), at(1, 13, 15)), []wantSuggestion{
+		{"webapp", at(1, 8, 30), at(1, 9, 0), "master", "", []string{"Fix typo", "Update docs"}},
+		{"ABC-12", at(1, 12, 30), at(1, 13, 15), "ABC-12-login", "ABC-12", []string{"Add login form", "Validate password"}},
+	})
+}
+
+func TestSuggestAuthors(t *testing.T) {
+	r := newWebappRepo(t)
+	tests := []struct {
+		name      string
+		authors   []string
+		userEmail string
+		want      []string
+	}{
+		{"configured author", []string{"OTHER@example.com"}, testEmail, []string{"Review feedback"}},
+		{"several authors", []string{testEmail, otherEmail}, "", []string{
+			"Add readme", "Fix typo", "Update docs", "Review feedback", "Add login form", "Validate password",
+		}},
+		{"Git config user", nil, otherEmail, []string{"Review feedback"}},
+		{"unknown author", []string{"nobody@example.com"}, testEmail, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r.setUserEmail(tc.userEmail)
+			commits, err := Scan(Options{Repositories: []string{r.path}, Authors: tc.authors}, at(1, 0, 0), at(2, 0, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, c := range commits {
+				got = append(got, c.Message)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("want commits %q, got %q", tc.want, got)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("want commits %q, got %q", tc.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSuggestWithoutAuthors(t *testing.T) {
+	r := newWebappRepo(t)
+	missing := filepath.Join(t.TempDir(), "missing")
+	suggestions, err := Suggest(Options{Repositories: []string{r.path, missing}}, at(1, 0, 0), at(2, 0, 0))
+	if err == nil {
+		t.Error("want errors for repository without authors, and missing repository")
+	}
+	if len(suggestions) != 0 {
+		t.Errorf("want no suggestions, got %+v", suggestions)
+	}
+}
@@ -0,0 +1,466 @@
+// Package ics parses events from iCalendar (.ics) files and expands
+// recurring events into their occurrences.
+//
+// Only the parts of RFC 5545 needed to list meetings are supported. Time
+// zones are resolved by their IANA names, and VTIMEZONE definitions are
+// ignored.
+//
+// https://www.rfc-editor.org/rfc/rfc5545
+package ics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a VEVENT component.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	// AllDay is set if the event starts on a date rather than a time.
+	AllDay bool
+	// Cancelled is set if the event's status is CANCELLED.
+	Cancelled bool
+	// Transparent is set if the event does not block time, e.g a reminder.
+	Transparent bool
+	// Rule is how the event recurs, or nil if it doesn't.
+	Rule *Rule
+	// RDates are additional start times of a recurring event.
+	RDates []time.Time
+	// ExDates are start times excluded from a recurring event.
+	ExDates []time.Time
+	// RecurrenceID is set on events that replace a single occurrence of a
+	// recurring event with the same UID, and is the start time of the
+	// occurrence they replace.
+	RecurrenceID *time.Time
+
+	// duration is the DURATION of the event, which sets the end once the
+	// whole event has been read.
+	duration *duration
+}
+
+// Parse reads the events from an iCalendar file. Times without a time zone,
+// and times in time zones that are not known by their IANA name, are read
+// in the given location.
+//
+// Events that can't be read, such as ones with unsupported recurrence rules,
+// are skipped. They are returned as errors together with the other events.
+func Parse(r io.Reader, loc *time.Location) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		events []Event
+		errs   []error
+		event  *Event
+		// eventErr is the first error of the current event.
+		eventErr error
+		// depth is how deep inside other components of the event we are,
+		// such as VALARM.
+		depth int
+	)
+	for i, line := range lines {
+		prop, err := parseProperty(line)
+		if err != nil {
+			if event != nil && eventErr == nil {
+				eventErr = fmt.Errorf("line %d: %w", i+1, err)
+			}
+			continue
+		}
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT") && event == nil:
+			event, eventErr = &Event{}, nil
+		case event == nil:
+			continue
+		case prop.name == "BEGIN":
+			depth++
+		case prop.name == "END" && depth > 0:
+			depth--
+		case prop.name == "END" && strings.EqualFold(prop.value, "VEVENT"):
+			if eventErr == nil && event.Start.IsZero() {
+				eventErr = errors.New("missing DTSTART")
+			}
+			if eventErr != nil {
+				errs = append(errs, fmt.Errorf("skipped event %q: %w", event.Summary, eventErr))
+			} else {
+				if event.duration != nil {
+					event.End = event.duration.addTo(event.Start)
+				} else if event.End.IsZero() {
+					event.End = defaultEnd(*event)
+				}
+				events = append(events, *event)
+			}
+			event = nil
+		case depth > 0:
+			continue
+		default:
+			if err := event.set(prop, loc); err != nil && eventErr == nil {
+				eventErr = fmt.Errorf("line %d: %s: %w", i+1, prop.name, err)
+			}
+		}
+	}
+	return events, errors.Join(errs...)
+}
+
+// Occurrence is a single occurrence of an event, or the event itself if it
+// doesn't recur.
+type Occurrence struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	Transparent bool
+}
+
+// Expand returns the occurrences of the events that overlap the time between
+// from and to, sorted by their start time. Recurring events are expanded,
+// and cancelled events and occurrences are left out.
+func Expand(events []Event, from, to time.Time) []Occurrence {
+	// Occurrences that are replaced by other events, by UID and start time.
+	replaced := map[string]map[int64]bool{}
+	for _, e := range events {
+		if e.RecurrenceID == nil {
+			continue
+		}
+		if replaced[e.UID] == nil {
+			replaced[e.UID] = map[int64]bool{}
+		}
+		replaced[e.UID][e.RecurrenceID.Unix()] = true
+	}
+	var occurrences []Occurrence
+	add := func(e Event, start time.Time) {
+		end := e.End.Sub(e.Start)
+		o := Occurrence{
+			UID:         e.UID,
+			Summary:     e.Summary,
+			Description: e.Description,
+			Location:    e.Location,
+			Start:       start,
+			End:         start.Add(end),
+			AllDay:      e.AllDay,
+			Transparent: e.Transparent,
+		}
+		if e.AllDay {
+			// Keep whole days across daylight saving time changes.
+			o.End = start.AddDate(0, 0, int((end+12*time.Hour)/(24*time.Hour)))
+		}
+		if o.Start.Before(to) && o.End.After(from) {
+			occurrences = append(occurrences, o)
+		}
+	}
+	for _, e := range events {
+		if e.Cancelled {
+			continue
+		}
+		if e.RecurrenceID != nil || e.Rule == nil && len(e.RDates) == 0 {
+			add(e, e.Start)
+			continue
+		}
+		starts := []time.Time{e.Start}
+		if e.Rule != nil {
+			// Occurrences that start before from may still overlap it.
+			starts = e.Rule.starts(e.Start, from.Add(-e.End.Sub(e.Start)), to)
+		}
+		starts = append(starts, e.RDates...)
+		seen := map[int64]bool{}
+		for _, start := range starts {
+			key := start.Unix()
+			if seen[key] || replaced[e.UID][key] || containsTime(e.ExDates, start) {
+				continue
+			}
+			seen[key] = true
+			add(e, start)
+		}
+	}
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		return occurrences[i].Start.Before(occurrences[j].Start)
+	})
+	return occurrences
+}
+
+func containsTime(times []time.Time, t time.Time) bool {
+	for _, other := range times {
+		if other.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultEnd returns the end of an event without DTEND or DURATION, which
+// is the day after for all-day events, and the start time for others.
+func defaultEnd(e Event) time.Time {
+	if e.AllDay {
+		return e.Start.AddDate(0, 0, 1)
+	}
+	return e.Start
+}
+
+func (e *Event) set(prop property, loc *time.Location) error {
+	switch prop.name {
+	case "UID":
+		e.UID = prop.value
+	case "SUMMARY":
+		e.Summary = unescapeText(prop.value)
+	case "DESCRIPTION":
+		e.Description = unescapeText(prop.value)
+	case "LOCATION":
+		e.Location = unescapeText(prop.value)
+	case "STATUS":
+		e.Cancelled = strings.EqualFold(prop.value, "CANCELLED")
+	case "TRANSP":
+		e.Transparent = strings.EqualFold(prop.value, "TRANSPARENT")
+	case "DTSTART":
+		t, allDay, err := parseTime(prop, loc)
+		if err != nil {
+			return err
+		}
+		e.Start, e.AllDay = t, allDay
+	case "DTEND":
+		t, _, err := parseTime(prop, loc)
+		if err != nil {
+			return err
+		}
+		e.End = t
+	case "DURATION":
+		d, err := parseDuration(prop.value)
+		if err != nil {
+			return err
+		}
+		e.duration = &d
+	case "RRULE":
+		rule, err := ParseRule(prop.value, loc)
+		if err != nil {
+			return err
+		}
+		e.Rule = &rule
+	case "RDATE", "EXDATE":
+		times, err := parseTimeList(prop, loc)
+		if err != nil {
+			return err
+		}
+		if prop.name == "RDATE" {
+			e.RDates = append(e.RDates, times...)
+		} else {
+			e.ExDates = append(e.ExDates, times...)
+		}
+	case "RECURRENCE-ID":
+		t, _, err := parseTime(prop, loc)
+		if err != nil {
+			return err
+		}
+		e.RecurrenceID = &t
+	}
+	return nil
+}
+
+// unfold reads the content lines, joining lines that continue on the next
+// line, which then starts with a space or tab.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// parseProperty parses a content line, such as
+// "DTSTART;TZID=Europe/Stockholm:20230521T090000".
+func parseProperty(line string) (property, error) {
+	inQuotes := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon == -1 {
+		return property{}, fmt.Errorf("missing colon: %q", line)
+	}
+	parts := splitUnquoted(line[:colon], ';')
+	prop := property{
+		name:   strings.ToUpper(parts[0]),
+		params: map[string]string{},
+		value:  line[colon+1:],
+	}
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	return prop, nil
+}
+
+func splitUnquoted(s string, sep rune) []string {
+	var parts []string
+	inQuotes := false
+	start := 0
+	for i, r := range s {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == sep && !inQuotes {
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+var textUnescaper = strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+func unescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
+
+func parseTime(prop property, loc *time.Location) (time.Time, bool, error) {
+	if tzid := prop.params["TZID"]; tzid != "" {
+		loc = location(tzid, loc)
+	}
+	return parseTimeValue(prop.value, prop.params["VALUE"] == "DATE", loc)
+}
+
+func parseTimeList(prop property, loc *time.Location) ([]time.Time, error) {
+	if tzid := prop.params["TZID"]; tzid != "" {
+		loc = location(tzid, loc)
+	}
+	if prop.params["VALUE"] == "PERIOD" {
+		return nil, nil
+	}
+	var times []time.Time
+	for _, value := range strings.Split(prop.value, ",") {
+		t, _, err := parseTimeValue(value, prop.params["VALUE"] == "DATE", loc)
+		if err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	return times, nil
+}
+
+// parseTimeValue parses a DATE or DATE-TIME value, such as "20230521",
+// "20230521T090000", or "20230521T070000Z".
+func parseTimeValue(value string, isDate bool, loc *time.Location) (time.Time, bool, error) {
+	if isDate || len(value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// location returns the time zone with the IANA name, or the fallback if the
+// name is unknown, as with Windows time zone names used by some calendars.
+func location(tzid string, fallback *time.Location) *time.Location {
+	// Some calendars prefix the name, as in "/mozilla.org/20050126_1/Europe/Stockholm".
+	for name := strings.TrimPrefix(tzid, "/"); name != ""; {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+		_, rest, ok := strings.Cut(name, "/")
+		if !ok {
+			break
+		}
+		name = rest
+	}
+	return fallback
+}
+
+type duration struct {
+	days int
+	time time.Duration
+}
+
+// addTo adds the duration, where days are added as calendar days so that
+// they keep the time of day across daylight saving time changes.
+func (d duration) addTo(t time.Time) time.Time {
+	return t.AddDate(0, 0, d.days).Add(d.time)
+}
+
+// parseDuration parses a DURATION value, such as "PT1H30M" or "P1D".
+func parseDuration(value string) (duration, error) {
+	s := value
+	sign := 1
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		s, sign = rest, -1
+	} else {
+		s = strings.TrimPrefix(s, "+")
+	}
+	s, ok := strings.CutPrefix(s, "P")
+	if !ok || s == "" {
+		return duration{}, fmt.Errorf("invalid duration: %q", value)
+	}
+	var d duration
+	inTime := false
+	num := ""
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			num += string(r)
+			continue
+		case r == 'T':
+			inTime = true
+			continue
+		}
+		n, err := strconv.Atoi(num)
+		if err != nil {
+			return duration{}, fmt.Errorf("invalid duration: %q", value)
+		}
+		num = ""
+		switch {
+		case r == 'W' && !inTime:
+			d.days += 7 * n
+		case r == 'D' && !inTime:
+			d.days += n
+		case r == 'H' && inTime:
+			d.time += time.Duration(n) * time.Hour
+		case r == 'M' && inTime:
+			d.time += time.Duration(n) * time.Minute
+		case r == 'S' && inTime:
+			d.time += time.Duration(n) * time.Second
+		default:
+			return duration{}, fmt.Errorf("invalid duration: %q", value)
+		}
+	}
+	if num != "" {
+		return duration{}, fmt.Errorf("invalid duration: %q", value)
+	}
+	d.days *= sign
+	d.time *= time.Duration(sign)
+	return d, nil
+}
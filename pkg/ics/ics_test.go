@@ -0,0 +1,226 @@
+package ics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		name     string
+		events   string
+		from, to string
+		want     []string
+	}{
+		{
+			name: "weekly by day",
+			events: `DTSTART:20240101T090000Z
+DTEND:20240101T093000Z
+RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR`,
+			from: "2024-01-01T00:00Z",
+			to:   "2024-01-13T00:00Z",
+			want: []string{
+				"2024-01-01T09:00Z", "2024-01-03T09:00Z", "2024-01-05T09:00Z",
+				"2024-01-08T09:00Z", "2024-01-10T09:00Z", "2024-01-12T09:00Z",
+			},
+		},
+		{
+			name: "every other week",
+			events: `DTSTART:20240102T090000Z
+RRULE:FREQ=WEEKLY;INTERVAL=2`,
+			from: "2024-01-01T00:00Z",
+			to:   "2024-02-01T00:00Z",
+			want: []string{"2024-01-02T09:00Z", "2024-01-16T09:00Z", "2024-01-30T09:00Z"},
+		},
+		{
+			name: "monthly last friday",
+			events: `DTSTART:20240126T140000Z
+RRULE:FREQ=MONTHLY;BYDAY=-1FR`,
+			from: "2024-01-01T00:00Z",
+			to:   "2024-05-01T00:00Z",
+			want: []string{"2024-01-26T14:00Z", "2024-02-23T14:00Z", "2024-03-29T14:00Z", "2024-04-26T14:00Z"},
+		},
+		{
+			name: "monthly last weekday by set position",
+			events: `DTSTART:20240131T160000Z
+RRULE:FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1`,
+			from: "2024-01-01T00:00Z",
+			to:   "2024-07-01T00:00Z",
+			want: []string{
+				"2024-01-31T16:00Z", "2024-02-29T16:00Z", "2024-03-29T16:00Z",
+				"2024-04-30T16:00Z", "2024-05-31T16:00Z", "2024-06-28T16:00Z",
+			},
+		},
+		{
+			name: "yearly by month and day",
+			events: `DTSTART:20220301T100000Z
+RRULE:FREQ=YEARLY;BYMONTH=3;BYMONTHDAY=1`,
+			from: "2023-01-01T00:00Z",
+			to:   "2025-01-01T00:00Z",
+			want: []string{"2023-03-01T10:00Z", "2024-03-01T10:00Z"},
+		},
+		{
+			name: "until date includes the whole day",
+			events: `DTSTART:20240101T170000Z
+RRULE:FREQ=DAILY;UNTIL=20240103`,
+			from: "2024-01-01T00:00Z",
+			to:   "2024-01-10T00:00Z",
+			want: []string{"2024-01-01T17:00Z", "2024-01-02T17:00Z", "2024-01-03T17:00Z"},
+		},
+		{
+			name: "until time",
+			events: `DTSTART:20240101T090000Z
+RRULE:FREQ=DAILY;UNTIL=20240103T085959Z`,
+			from: "2024-01-01T00:00Z",
+			to:   "2024-01-10T00:00Z",
+			want: []string{"2024-01-01T09:00Z", "2024-01-02T09:00Z"},
+		},
+		{
+			name: "count",
+			events: `DTSTART:20240101T090000Z
+RRULE:FREQ=WEEKLY;COUNT=3`,
+			from: "2024-01-01T00:00Z",
+			to:   "2024-03-01T00:00Z",
+			want: []string{"2024-01-01T09:00Z", "2024-01-08T09:00Z", "2024-01-15T09:00Z"},
+		},
+		{
+			name: "count is from dtstart",
+			events: `DTSTART:20240101T090000Z
+RRULE:FREQ=DAILY;COUNT=5`,
+			from: "2024-01-04T00:00Z",
+			to:   "2024-01-10T00:00Z",
+			want: []string{"2024-01-04T09:00Z", "2024-01-05T09:00Z"},
+		},
+		{
+			name: "exdate and overrides",
+			events: `UID:standup
+DTSTART:20240101T090000Z
+DTEND:20240101T091500Z
+RRULE:FREQ=DAILY
+EXDATE:20240102T090000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:standup
+RECURRENCE-ID:20240103T090000Z
+DTSTART:20240103T140000Z
+DTEND:20240103T141500Z
+END:VEVENT
+BEGIN:VEVENT
+UID:standup
+RECURRENCE-ID:20240104T090000Z
+DTSTART:20240104T090000Z
+STATUS:CANCELLED`,
+			from: "2024-01-01T00:00Z",
+			to:   "2024-01-06T00:00Z",
+			want: []string{"2024-01-01T09:00Z", "2024-01-03T14:00Z", "2024-01-05T09:00Z"},
+		},
+		{
+			name: "rdate",
+			events: `DTSTART:20240101T090000Z
+RRULE:FREQ=WEEKLY;COUNT=2
+RDATE:20240103T120000Z`,
+			from: "2024-01-01T00:00Z",
+			to:   "2024-02-01T00:00Z",
+			want: []string{"2024-01-01T09:00Z", "2024-01-03T12:00Z", "2024-01-08T09:00Z"},
+		},
+		{
+			name: "keeps local time across daylight saving time",
+			events: `DTSTART;TZID=Europe/Stockholm:20240318T090000
+DTEND;TZID=Europe/Stockholm:20240318T100000
+RRULE:FREQ=WEEKLY`,
+			from: "2024-03-18T00:00+01:00",
+			to:   "2024-04-02T00:00+02:00",
+			want: []string{"2024-03-18T09:00+01:00", "2024-03-25T09:00+01:00", "2024-04-01T09:00+02:00"},
+		},
+		{
+			name: "long-running series",
+			events: `DTSTART:20000103T090000Z
+RRULE:FREQ=WEEKLY;BYDAY=MO,TH`,
+			from: "2024-05-06T00:00Z",
+			to:   "2024-05-14T00:00Z",
+			want: []string{"2024-05-06T09:00Z", "2024-05-09T09:00Z", "2024-05-13T09:00Z"},
+		},
+		{
+			name: "long-running series with interval",
+			events: `DTSTART:20200115T090000Z
+RRULE:FREQ=MONTHLY;INTERVAL=2`,
+			from: "2024-04-01T00:00Z",
+			to:   "2024-09-01T00:00Z",
+			want: []string{"2024-05-15T09:00Z", "2024-07-15T09:00Z"},
+		},
+		{
+			name: "occurrence that starts before from",
+			events: `DTSTART:20240101T230000Z
+DTEND:20240102T010000Z
+RRULE:FREQ=DAILY`,
+			from: "2024-01-05T00:00Z",
+			to:   "2024-01-05T12:00Z",
+			want: []string{"2024-01-04T23:00Z"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			events, err := Parse(strings.NewReader(calendar(tc.events)), time.UTC)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			var got []string
+			for _, o := range Expand(events, parseTestTime(t, tc.from), parseTestTime(t, tc.to)) {
+				got = append(got, o.Start.Format(testTimeLayout))
+			}
+			if strings.Join(got, " ") != strings.Join(tc.want, " ") {
+				t.Errorf("want:\n%v\ngot:\n%v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRuleStartsSkipsPastPeriods(t *testing.T) {
+	rule, err := ParseRule("FREQ=DAILY", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dtstart := time.Date(2000, 1, 1, 9, 0, 0, 0, time.UTC)
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	starts := rule.starts(dtstart, from, from.AddDate(0, 0, 2))
+	// DTSTART, a period of margin, and the two days.
+	if len(starts) > 4 {
+		t.Errorf("want the periods before from to be skipped, got %d start times", len(starts))
+	}
+	if last := starts[len(starts)-1]; !last.Equal(time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("want last start on 2024-05-02, got %s", last)
+	}
+}
+
+func TestParseRuleErrors(t *testing.T) {
+	for _, value := range []string{
+		"BYDAY=MO",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=DAILY;BYHOUR=9",
+	} {
+		if _, err := ParseRule(value, time.UTC); err == nil {
+			t.Errorf("%q: want error", value)
+		}
+	}
+}
+
+const testTimeLayout = "2006-01-02T15:04Z07:00"
+
+// calendar wraps the properties of one or more events in a calendar.
+func calendar(events string) string {
+	return "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\n" +
+		strings.ReplaceAll(events, "\n", "\r\n") +
+		"\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+}
+
+func parseTestTime(t *testing.T, s string) time.Time {
+	t.Helper()
+	v, err := time.Parse(testTimeLayout, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
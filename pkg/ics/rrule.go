@@ -0,0 +1,429 @@
+package ics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPeriods limits how many days, weeks, months, or years are stepped
+// through when expanding a recurring event, so that odd rules never loop
+// forever.
+const maxPeriods = 100_000
+
+// Frequency is how often a recurring event repeats.
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// WeekdayNum is a day of the week in a BYDAY rule part, such as "MO" for
+// every Monday, "2TU" for the second Tuesday, or "-1FR" for the last
+// Friday.
+type WeekdayNum struct {
+	// N is the week number within the month or year, counted from the end
+	// if negative, or 0 for every week.
+	N       int
+	Weekday time.Weekday
+}
+
+// Rule is a recurrence rule, i.e an RRULE property.
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	// Count is the number of occurrences, or 0 if not limited.
+	Count int
+	// Until is the last possible start of an occurrence, or zero if not
+	// limited.
+	Until      time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	// BySetPos picks among the occurrences of each period, counted from the
+	// end if negative.
+	BySetPos  []int
+	WeekStart time.Weekday
+}
+
+// ParseRule parses the value of an RRULE property, such as
+// "FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20231231T230000Z". A date-only UNTIL is
+// read in the given location.
+func ParseRule(value string, loc *time.Location) (Rule, error) {
+	rule := Rule{Interval: 1, WeekStart: time.Monday}
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = Frequency(strings.ToUpper(val))
+			switch rule.Freq {
+			case Daily, Weekly, Monthly, Yearly:
+			default:
+				err = fmt.Errorf("unsupported frequency: %q", val)
+			}
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(val)
+			if err == nil && rule.Interval < 1 {
+				err = fmt.Errorf("invalid interval: %d", rule.Interval)
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(val)
+		case "UNTIL":
+			var isDate bool
+			rule.Until, isDate, err = parseTimeValue(val, false, loc)
+			if isDate {
+				// The whole day is included.
+				rule.Until = rule.Until.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			}
+		case "BYDAY":
+			rule.ByDay, err = parseWeekdayNums(val)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseInts(val)
+		case "BYMONTH":
+			var months []int
+			months, err = parseInts(val)
+			for _, m := range months {
+				rule.ByMonth = append(rule.ByMonth, time.Month(m))
+			}
+		case "BYSETPOS":
+			rule.BySetPos, err = parseInts(val)
+		case "WKST":
+			day, ok := weekdays[strings.ToUpper(val)]
+			if !ok {
+				err = fmt.Errorf("invalid weekday: %q", val)
+			}
+			rule.WeekStart = day
+		default:
+			err = fmt.Errorf("unsupported rule part: %q", key)
+		}
+		if err != nil {
+			return Rule{}, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	if rule.Freq == "" {
+		return Rule{}, fmt.Errorf("missing FREQ: %q", value)
+	}
+	return rule, nil
+}
+
+func parseWeekdayNums(value string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, s := range strings.Split(value, ",") {
+		s = strings.ToUpper(strings.TrimSpace(s))
+		if len(s) < 2 {
+			return nil, fmt.Errorf("invalid weekday: %q", s)
+		}
+		day, ok := weekdays[s[len(s)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday: %q", s)
+		}
+		var n int
+		if num := s[:len(s)-2]; num != "" {
+			var err error
+			if n, err = strconv.Atoi(num); err != nil {
+				return nil, fmt.Errorf("invalid weekday: %q", s)
+			}
+		}
+		days = append(days, WeekdayNum{N: n, Weekday: day})
+	}
+	return days, nil
+}
+
+func parseInts(value string) ([]int, error) {
+	var ints []int
+	for _, s := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return nil, err
+		}
+		ints = append(ints, n)
+	}
+	return ints, nil
+}
+
+// starts returns the start times of the occurrences before the time to,
+// beginning with dtstart. The periods that end before the time from are
+// skipped, so that the start times of long-running series are not expanded
+// from dtstart each time, unless the rule has a COUNT. Some occurrences
+// before from may still be returned.
+func (r Rule) starts(dtstart, from, to time.Time) []time.Time {
+	var starts []time.Time
+	add := func(t time.Time) bool {
+		if !r.Until.IsZero() && t.After(r.Until) || !t.Before(to) {
+			return false
+		}
+		if r.Count > 0 && len(starts) >= r.Count {
+			return false
+		}
+		starts = append(starts, t)
+		return true
+	}
+	// DTSTART is always the first occurrence, even if it doesn't match the
+	// rule.
+	if !add(dtstart) {
+		return starts
+	}
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	// COUNT needs all occurrences since dtstart to be counted.
+	first := 0
+	if r.Count == 0 && from.After(dtstart) {
+		// Dates are compared without time zones, so start a period early.
+		first = r.periodsUntil(dtstart, from)/interval*interval - interval
+		if first < 0 {
+			first = 0
+		}
+	}
+	// Dates are compared without time zones, so leave a day of margin.
+	last := date(to.Date()).AddDate(0, 0, 1)
+	for i := 0; i < maxPeriods; i++ {
+		n := first + i*interval
+		if r.periodStart(dtstart, n).After(last) {
+			break
+		}
+		for _, t := range r.period(dtstart, n) {
+			if !t.After(dtstart) {
+				continue
+			}
+			if !add(t) {
+				return starts
+			}
+		}
+	}
+	return starts
+}
+
+// periodStart returns the first date of the n-th day, week, month, or year
+// after the one of dtstart.
+func (r Rule) periodStart(dtstart time.Time, n int) time.Time {
+	y, m, d := dtstart.Date()
+	switch r.Freq {
+	case Weekly:
+		offset := (int(dtstart.Weekday()) - int(r.WeekStart) + 7) % 7
+		return date(y, m, d-offset+7*n)
+	case Monthly:
+		return date(y, m+time.Month(n), 1)
+	case Yearly:
+		return date(y+n, time.January, 1)
+	default:
+		return date(y, m, d+n)
+	}
+}
+
+// periodsUntil returns the number of days, weeks, months, or years from the
+// one of dtstart to the one of t.
+func (r Rule) periodsUntil(dtstart, t time.Time) int {
+	y, m, _ := dtstart.Date()
+	ty, tm, td := t.Date()
+	switch r.Freq {
+	case Weekly:
+		days := date(ty, tm, td).Sub(r.periodStart(dtstart, 0)) / (24 * time.Hour)
+		return int(days) / 7
+	case Monthly:
+		return (ty-y)*12 + int(tm-m)
+	case Yearly:
+		return ty - y
+	default:
+		return int(date(ty, tm, td).Sub(date(dtstart.Date())) / (24 * time.Hour))
+	}
+}
+
+// period returns the start times in the n-th day, week, month, or year after
+// the one of dtstart, sorted.
+func (r Rule) period(dtstart time.Time, n int) []time.Time {
+	y, m, d := dtstart.Date()
+	var dates []time.Time
+	switch r.Freq {
+	case Daily:
+		dates = []time.Time{date(y, m, d+n)}
+	case Weekly:
+		weekStart := r.periodStart(dtstart, n)
+		for i := 0; i < 7; i++ {
+			day := weekStart.AddDate(0, 0, i)
+			if r.matchesWeekday(day, dtstart) {
+				dates = append(dates, day)
+			}
+		}
+	case Monthly:
+		first := date(y, m+time.Month(n), 1)
+		dates = r.monthDates(first.Year(), first.Month(), d)
+	case Yearly:
+		year := y + n
+		if len(r.ByDay) > 0 && len(r.ByMonth) == 0 && len(r.ByMonthDay) == 0 {
+			dates = nthWeekdays(date(year, time.January, 1), date(year+1, time.January, 1), r.ByDay)
+			break
+		}
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []time.Month{m}
+		}
+		for _, month := range months {
+			dates = append(dates, r.monthDates(year, month, d)...)
+		}
+	}
+	var filtered []time.Time
+	for _, day := range dates {
+		if r.matchesFilters(day) {
+			filtered = append(filtered, day)
+		}
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].Before(filtered[j])
+	})
+	filtered = r.setPos(filtered)
+
+	hour, min, sec := dtstart.Clock()
+	times := make([]time.Time, len(filtered))
+	for i, day := range filtered {
+		y, m, d := day.Date()
+		times[i] = time.Date(y, m, d, hour, min, sec, 0, dtstart.Location())
+	}
+	return times
+}
+
+// monthDates returns the dates in the month that match BYMONTHDAY and BYDAY,
+// or the day of the month of DTSTART if neither is set.
+func (r Rule) monthDates(year int, month time.Month, day int) []time.Time {
+	first := date(year, month, 1)
+	next := first.AddDate(0, 1, 0)
+	daysInMonth := next.AddDate(0, 0, -1).Day()
+	if len(r.ByMonthDay) == 0 {
+		if len(r.ByDay) > 0 {
+			return nthWeekdays(first, next, r.ByDay)
+		}
+		if day > daysInMonth {
+			return nil
+		}
+		return []time.Time{date(year, month, day)}
+	}
+	var dates []time.Time
+	for _, md := range r.ByMonthDay {
+		if md < 0 {
+			md = daysInMonth + md + 1
+		}
+		if md < 1 || md > daysInMonth {
+			continue
+		}
+		dates = append(dates, date(year, month, md))
+	}
+	return dates
+}
+
+// nthWeekdays returns the dates between from and to that match the
+// weekdays, where a non-zero N picks only the N-th such weekday.
+func nthWeekdays(from, to time.Time, days []WeekdayNum) []time.Time {
+	var dates []time.Time
+	for _, wd := range days {
+		var matching []time.Time
+		for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+			if day.Weekday() == wd.Weekday {
+				matching = append(matching, day)
+			}
+		}
+		switch {
+		case wd.N == 0:
+			dates = append(dates, matching...)
+		case wd.N > 0 && wd.N <= len(matching):
+			dates = append(dates, matching[wd.N-1])
+		case wd.N < 0 && -wd.N <= len(matching):
+			dates = append(dates, matching[len(matching)+wd.N])
+		}
+	}
+	return dates
+}
+
+func (r Rule) matchesWeekday(day, dtstart time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return day.Weekday() == dtstart.Weekday()
+	}
+	for _, wd := range r.ByDay {
+		if wd.Weekday == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesFilters reports if the date matches the rule parts that limit,
+// rather than expand, the dates of the period.
+func (r Rule) matchesFilters(day time.Time) bool {
+	if len(r.ByMonth) > 0 && !contains(r.ByMonth, day.Month()) {
+		return false
+	}
+	switch r.Freq {
+	case Daily:
+		if len(r.ByMonthDay) > 0 && !r.matchesMonthDay(day) {
+			return false
+		}
+		if len(r.ByDay) > 0 && !r.matchesWeekday(day, day) {
+			return false
+		}
+	case Monthly, Yearly:
+		// BYDAY limits the days from BYMONTHDAY.
+		if len(r.ByMonthDay) > 0 && len(r.ByDay) > 0 && !r.matchesWeekday(day, day) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r Rule) matchesMonthDay(day time.Time) bool {
+	daysInMonth := date(day.Year(), day.Month()+1, 0).Day()
+	for _, md := range r.ByMonthDay {
+		if md == day.Day() || md < 0 && daysInMonth+md+1 == day.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+// setPos picks the dates at the BYSETPOS positions, if set.
+func (r Rule) setPos(dates []time.Time) []time.Time {
+	if len(r.BySetPos) == 0 {
+		return dates
+	}
+	var picked []time.Time
+	for _, pos := range r.BySetPos {
+		switch {
+		case pos > 0 && pos <= len(dates):
+			picked = append(picked, dates[pos-1])
+		case pos < 0 && -pos <= len(dates):
+			picked = append(picked, dates[len(dates)+pos])
+		}
+	}
+	sort.Slice(picked, func(i, j int) bool {
+		return picked[i].Before(picked[j])
+	})
+	return picked
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func contains[T comparable](values []T, v T) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+// Package invoice creates invoice drafts from billing reports, and renders
+// them as HTML or PDF.
+package invoice
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/billing"
+)
+
+// ErrNoBillableEntries is returned when creating an invoice for a client
+// without any billable entries in the period.
+var ErrNoBillableEntries = errors.New("no billable entries for client in period")
+
+// Format is the file format of a rendered invoice.
+type Format string
+
+const (
+	// FormatHTML renders the invoice as HTML, using the user-editable
+	// template.
+	FormatHTML Format = "html"
+	// FormatPDF renders the invoice as PDF, using a fixed layout.
+	FormatPDF Format = "pdf"
+)
+
+// Write renders the invoice in the given format. The HTML template is read
+// from the template path, or the built-in template is used if empty.
+func Write(w io.Writer, inv Invoice, format Format, templatePath string) error {
+	switch format {
+	case FormatHTML:
+		tmpl, err := LoadTemplate(templatePath)
+		if err != nil {
+			return err
+		}
+		return WriteHTML(w, tmpl, inv)
+	case FormatPDF:
+		return WritePDF(w, inv)
+	default:
+		return fmt.Errorf("unknown invoice format: %q, must be one of: html, pdf", format)
+	}
+}
+
+// Options are the details of an invoice that don't come from the billing
+// report.
+type Options struct {
+	Number  string
+	Date    time.Time
+	DueDays int
+	From    string
+}
+
+// Invoice is an invoice draft for a single client and period.
+type Invoice struct {
+	Number  string    `json:"number"`
+	Date    time.Time `json:"date"`
+	DueDate time.Time `json:"dueDate"`
+	From    string    `json:"from"`
+	Client  string    `json:"client"`
+	// Start is the date of the first day of the period.
+	Start time.Time `json:"start"`
+	// End is the date of the last day of the period, which is the day before
+	// the end of the billing report.
+	End      time.Time `json:"end"`
+	Currency string    `json:"currency"`
+	Items    []Item    `json:"items"`
+	// Billed is the total billed time of all items.
+	Billed         time.Duration `json:"billed"`
+	Total          float64       `json:"total"`
+	FormattedTotal string        `json:"formattedTotal"`
+}
+
+// Item is a line item on an invoice, which is all billed time of a single
+// task at a single rate.
+type Item struct {
+	Task            string        `json:"task"`
+	Billed          time.Duration `json:"billed"`
+	Rate            float64       `json:"rate"`
+	FormattedRate   string        `json:"formattedRate"`
+	Amount          float64       `json:"amount"`
+	FormattedAmount string        `json:"formattedAmount"`
+}
+
+// New creates an invoice for the client from the billing report. The billed
+// time is grouped by task, where entries with the same name and rate are the
+// same task. Items are ordered by when each task was first worked on.
+func New(calc *billing.Calculator, report billing.Report, client string, opts Options) (Invoice, error) {
+	var total *billing.ClientTotal
+	for i := range report.Clients {
+		if strings.EqualFold(report.Clients[i].Client, client) {
+			total = &report.Clients[i]
+			break
+		}
+	}
+	if total == nil || total.Billed == 0 {
+		return Invoice{}, fmt.Errorf("%w: %q", ErrNoBillableEntries, client)
+	}
+	cal := calc.Calendar()
+	date := cal.Date(opts.Date)
+	inv := Invoice{
+		Number:   opts.Number,
+		Date:     date,
+		DueDate:  date.AddDate(0, 0, opts.DueDays),
+		From:     opts.From,
+		Client:   total.Client,
+		Start:    cal.Date(report.Start),
+		End:      cal.Date(report.End.Add(-time.Nanosecond)),
+		Currency: report.Currency,
+	}
+	type itemKey struct {
+		task string
+		rate float64
+	}
+	indexes := map[itemKey]int{}
+	for _, day := range total.Days {
+		for _, line := range day.Lines {
+			if line.Billed == 0 {
+				continue
+			}
+			key := itemKey{line.Name, line.Rate}
+			i, ok := indexes[key]
+			if !ok {
+				i = len(inv.Items)
+				indexes[key] = i
+				inv.Items = append(inv.Items, Item{
+					Task:          line.Name,
+					Rate:          line.Rate,
+					FormattedRate: calc.Format(line.Rate),
+				})
+			}
+			inv.Items[i].Billed += line.Billed
+		}
+	}
+	for i := range inv.Items {
+		item := &inv.Items[i]
+		item.Amount = calc.Amount(item.Billed, item.Rate)
+		item.FormattedAmount = calc.Format(item.Amount)
+		inv.Billed += item.Billed
+		inv.Total += item.Amount
+	}
+	inv.Total = calc.RoundAmount(inv.Total)
+	inv.FormattedTotal = calc.Format(inv.Total)
+	return inv, nil
+}
+
+// FormatHours formats the duration as decimal hours with two decimals, such
+// as "1.50" for 1h30m.
+func FormatHours(d time.Duration) string {
+	return fmt.Sprintf("%.2f", d.Hours())
+}
@@ -0,0 +1,100 @@
+package invoice
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/billing"
+	"github.com/dinkur/dinkur-desktop/pkg/calendar"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func testInvoice(t *testing.T) Invoice {
+	t.Helper()
+	cal, err := calendar.New(config.Calendar{TimeZone: "UTC"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	calc, err := billing.New(config.Billing{
+		Currency:    "EUR",
+		Locale:      "en-US",
+		DefaultRate: 100,
+		Rates:       []config.Rate{{Name: "Support", Match: "^Support", Rate: 80}},
+		Rounding: config.Rounding{
+			Mode:      config.RoundingModeUp,
+			Increment: 15 * time.Minute,
+			Scope:     config.RoundingScopeEntry,
+		},
+	}, cal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	at := func(days, hour, minute int) time.Time {
+		return time.Date(2023, 3, 1+days, hour, minute, 0, 0, time.UTC)
+	}
+	entries := []billing.Entry{
+		{ID: 1, Name: "API <v2> & docs", Client: "Acme", Start: at(0, 9, 0), End: at(0, 11, 10), Billable: true},
+		{ID: 2, Name: "Support call", Client: "Acme", Start: at(0, 13, 0), End: at(0, 13, 20), Billable: true},
+		{ID: 3, Name: "API <v2> & docs", Client: "Acme", Start: at(1, 9, 0), End: at(1, 10, 0), Billable: true},
+		{ID: 4, Name: "Other client", Client: "Globex", Start: at(1, 10, 0), End: at(1, 11, 0), Billable: true},
+	}
+	report := calc.Report(entries, day, day.AddDate(0, 0, 7))
+	inv, err := New(calc, report, "acme", Options{
+		Number:  FormatNumber("INV-%04d", 42),
+		Date:    at(7, 12, 0),
+		DueDays: 30,
+		From:    "Jane Doe Consulting\nMain Street 1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return inv
+}
+
+func TestNew(t *testing.T) {
+	inv := testInvoice(t)
+	if inv.Client != "Acme" || len(inv.Items) != 2 {
+		t.Fatalf("want 2 items for Acme, got %+v", inv)
+	}
+	// 2h10m and 1h of the same task, each rounded up to 15 minutes.
+	if inv.Items[0].Billed != 3*time.Hour+15*time.Minute || inv.Items[0].Amount != 325 {
+		t.Errorf("want 3h15m for 325, got %+v", inv.Items[0])
+	}
+	if inv.Items[1].Billed != 30*time.Minute || inv.Items[1].Amount != 40 {
+		t.Errorf("want 30m for 40, got %+v", inv.Items[1])
+	}
+	if inv.Total != 365 {
+		t.Errorf("want total 365, got %v", inv.Total)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	tmpl, err := LoadTemplate("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, tmpl, testInvoice(t)); err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "invoice.golden.html")
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("HTML differs from %s, which is updated with -update:\n%s", golden, buf.String())
+	}
+}
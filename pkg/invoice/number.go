@@ -0,0 +1,72 @@
+package invoice
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dinkur/dinkur-desktop/internal/jsonfile"
+)
+
+// ErrNumberUsed is returned when using an invoice number that has already
+// been used, such as by another invoice draft that was saved first.
+var ErrNumberUsed = errors.New("invoice number has already been used")
+
+// numbersMu guards the invoice number files, as each call may create its
+// own [NumberStore].
+var numbersMu sync.Mutex
+
+// NumberStore persists the last used invoice number in a JSON file, so that
+// invoice numbers keep increasing between runs.
+type NumberStore struct {
+	path string
+}
+
+type numberState struct {
+	Last int `json:"last"`
+}
+
+// NewNumberStore creates a new invoice number store backed by the JSON file
+// at the given path.
+func NewNumberStore(path string) *NumberStore {
+	return &NumberStore{path: path}
+}
+
+// Next returns the next invoice number, without reserving it. Call
+// [NumberStore.Use] before the invoice is saved.
+func (s *NumberStore) Next() (int, error) {
+	numbersMu.Lock()
+	defer numbersMu.Unlock()
+	var state numberState
+	if err := jsonfile.Read(s.path, &state); err != nil {
+		return 0, fmt.Errorf("read invoice number: %w", err)
+	}
+	return state.Last + 1, nil
+}
+
+// Use marks the invoice number as used, so that it is not returned by
+// [NumberStore.Next] again. Numbers may be skipped, but returns
+// [ErrNumberUsed] if the number is not higher than the last used number, so
+// that two drafts with the same number are never both saved.
+func (s *NumberStore) Use(number int) error {
+	numbersMu.Lock()
+	defer numbersMu.Unlock()
+	var state numberState
+	if err := jsonfile.Read(s.path, &state); err != nil {
+		return fmt.Errorf("read invoice number: %w", err)
+	}
+	if number <= state.Last {
+		return fmt.Errorf("%w: %d, the last used number is %d", ErrNumberUsed, number, state.Last)
+	}
+	state.Last = number
+	if err := jsonfile.Write(s.path, state); err != nil {
+		return fmt.Errorf("write invoice number: %w", err)
+	}
+	return nil
+}
+
+// FormatNumber formats the sequential invoice number using the format from
+// the config, e.g "INV-%04d".
+func FormatNumber(format string, number int) string {
+	return fmt.Sprintf(format, number)
+}
@@ -0,0 +1,108 @@
+package invoice
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestNumberStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invoice-number.json")
+	s := NewNumberStore(path)
+	next := func(s *NumberStore) int {
+		t.Helper()
+		n, err := s.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	if n := next(s); n != 1 {
+		t.Fatalf("want first number 1, got %d", n)
+	}
+	// Previewing doesn't use up the number.
+	if n := next(s); n != 1 {
+		t.Fatalf("want number 1 again, got %d", n)
+	}
+	if err := s.Use(1); err != nil {
+		t.Fatal(err)
+	}
+	if n := next(NewNumberStore(path)); n != 2 {
+		t.Errorf("want number 2 after restart, got %d", n)
+	}
+
+	// Numbers may be skipped, but never reused.
+	if err := s.Use(5); err != nil {
+		t.Fatal(err)
+	}
+	if n := next(s); n != 6 {
+		t.Errorf("want number 6 after skipping ahead, got %d", n)
+	}
+	if err := s.Use(3); !errors.Is(err, ErrNumberUsed) {
+		t.Errorf("want %v for number in gap, got %v", ErrNumberUsed, err)
+	}
+}
+
+func TestNumberStoreConcurrentDrafts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invoice-number.json")
+	first, second := NewNumberStore(path), NewNumberStore(path)
+	a, err := first.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := second.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b {
+		t.Fatalf("want both drafts to get the same number, got %d and %d", a, b)
+	}
+	if err := first.Use(a); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Use(b); !errors.Is(err, ErrNumberUsed) {
+		t.Errorf("want %v when saving the second draft, got %v", ErrNumberUsed, err)
+	}
+
+	// Drafts that retry with a new number all get numbers of their own.
+	const drafts = 10
+	var mu sync.Mutex
+	var used []int
+	var wg sync.WaitGroup
+	for i := 0; i < drafts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s := NewNumberStore(path)
+			for {
+				n, err := s.Next()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				err = s.Use(n)
+				if errors.Is(err, ErrNumberUsed) {
+					continue
+				}
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				used = append(used, n)
+				mu.Unlock()
+				return
+			}
+		}()
+	}
+	wg.Wait()
+	sort.Ints(used)
+	for i, n := range used {
+		if want := a + 1 + i; n != want {
+			t.Fatalf("want numbers %d to %d without duplicates, got %v", a+1, a+drafts, used)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package invoice
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/internal/pdf"
+)
+
+const (
+	pdfMargin   = 56
+	pdfFontSize = 10
+	pdfLineGap  = 15
+)
+
+// WritePDF renders the invoice as PDF. Unlike HTML, the PDF uses a fixed
+// layout that cannot be changed via templates.
+func WritePDF(w io.Writer, inv Invoice) error {
+	doc := &pdf.Document{Title: "Invoice " + inv.Number}
+	page := doc.AddPage()
+	y := pdf.A4Height - pdfMargin - 14
+	left := float64(pdfMargin)
+	right := pdf.A4Width - pdfMargin
+
+	page.Text(left, y, pdf.HelveticaBold, 20, "Invoice")
+	y -= 2 * pdfLineGap
+	if inv.From != "" {
+		for _, line := range strings.Split(strings.TrimSpace(inv.From), "\n") {
+			page.Text(left, y, pdf.Helvetica, pdfFontSize, line)
+			y -= pdfLineGap
+		}
+		y -= pdfLineGap
+	}
+	meta := [][2]string{
+		{"Invoice number", inv.Number},
+		{"Invoice date", inv.Date.Format(time.DateOnly)},
+		{"Due date", inv.DueDate.Format(time.DateOnly)},
+		{"Client", inv.Client},
+		{"Period", inv.Start.Format(time.DateOnly) + " – " + inv.End.Format(time.DateOnly)},
+	}
+	for _, row := range meta {
+		page.Text(left, y, pdf.Helvetica, pdfFontSize, row[0])
+		page.Text(left+110, y, pdf.Helvetica, pdfFontSize, row[1])
+		y -= pdfLineGap
+	}
+	y -= pdfLineGap
+
+	// Columns are right-aligned at these positions
+	hoursX := right - 200
+	rateX := right - 100
+	amountX := right
+	header := func() {
+		page.Text(left, y, pdf.HelveticaBold, pdfFontSize, "Task")
+		page.Text(hoursX-30, y, pdf.HelveticaBold, pdfFontSize, "Hours")
+		page.Text(rateX-25, y, pdf.HelveticaBold, pdfFontSize, "Rate")
+		page.Text(amountX-40, y, pdf.HelveticaBold, pdfFontSize, "Amount")
+		page.Line(left, y-4, right, y-4)
+		y -= pdfLineGap + 4
+	}
+	header()
+	for _, item := range inv.Items {
+		if y < pdfMargin+2*pdfLineGap {
+			page = doc.AddPage()
+			y = pdf.A4Height - pdfMargin
+			header()
+		}
+		page.Text(left, y, pdf.Helvetica, pdfFontSize, truncate(item.Task, 60))
+		page.TextRight(hoursX, y, pdfFontSize, FormatHours(item.Billed))
+		page.TextRight(rateX, y, pdfFontSize, item.FormattedRate)
+		page.TextRight(amountX, y, pdfFontSize, item.FormattedAmount)
+		y -= pdfLineGap
+	}
+	page.Line(left, y+pdfLineGap-4, right, y+pdfLineGap-4)
+	y -= 4
+	page.Text(left, y, pdf.HelveticaBold, pdfFontSize, "Total")
+	page.TextRight(hoursX, y, pdfFontSize, FormatHours(inv.Billed))
+	page.TextRight(amountX, y, pdfFontSize, inv.FormattedTotal)
+
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
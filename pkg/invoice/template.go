@@ -0,0 +1,55 @@
+package invoice
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultTemplate is the built-in HTML template for invoices, used when no
+// template is set in the config.
+//
+//go:embed default.html.tmpl
+var DefaultTemplate string
+
+// funcs are the extra functions available in invoice templates.
+var funcs = template.FuncMap{
+	"hours": FormatHours,
+	"date": func(t time.Time) string {
+		return t.Format(time.DateOnly)
+	},
+	"lines": func(s string) []string {
+		return strings.Split(strings.TrimSpace(s), "\n")
+	},
+}
+
+// LoadTemplate parses the HTML template file at the given path, or the
+// built-in template if the path is empty. The template is executed with an
+// [Invoice] as data.
+func LoadTemplate(path string) (*template.Template, error) {
+	if path == "" {
+		return template.New("invoice").Funcs(funcs).Parse(DefaultTemplate)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read invoice template: %w", err)
+	}
+	tmpl, err := template.New(filepath.Base(path)).Funcs(funcs).Parse(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("parse invoice template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// WriteHTML renders the invoice as HTML using the template.
+func WriteHTML(w io.Writer, tmpl *template.Template, inv Invoice) error {
+	if err := tmpl.Execute(w, inv); err != nil {
+		return fmt.Errorf("render invoice template: %w", err)
+	}
+	return nil
+}
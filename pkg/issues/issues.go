@@ -0,0 +1,140 @@
+// Package issues looks up the ticket keys in entry names in issue trackers,
+// such as Jira, GitHub, and GitLab, to show the titles of the issues.
+package issues
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+)
+
+// ErrNotFound is returned by providers when there's no issue with the key.
+var ErrNotFound = errors.New("issue not found")
+
+// Issue is an issue, or a pull or merge request, in an issue tracker.
+type Issue struct {
+	Key string `json:"key"`
+	// Tracker is the name of the issue tracker from the config.
+	Tracker string `json:"tracker"`
+	Title   string `json:"title"`
+	Status  string `json:"status"`
+	URL     string `json:"url"`
+}
+
+// Provider looks up issues in an issue tracker.
+type Provider interface {
+	// Issue fetches the issue with the key, and returns [ErrNotFound] if
+	// there's no such issue. The Tracker field is set by the caller.
+	Issue(ctx context.Context, key string) (Issue, error)
+}
+
+// Tracker is a provider together with the keys it looks up.
+type Tracker struct {
+	Name     string
+	Match    *regexp.Regexp
+	Provider Provider
+}
+
+var defaultMatch = map[config.IssueTrackerType]string{
+	config.IssueTrackerJira:   `^[A-Z][A-Z0-9]+-[0-9]+$`,
+	config.IssueTrackerGitHub: `^[\w.-]+/[\w.-]+#[0-9]+$`,
+	config.IssueTrackerGitLab: `^[\w.-]+(/[\w.-]+)+[#!][0-9]+$`,
+}
+
+// NewTrackers creates the issue trackers from the config. The tokens are
+// read from the environment variables or files given in the config, or
+// taken from the config's secret fields.
+func NewTrackers(cfg config.IssueTrackers) ([]Tracker, error) {
+	var trackers []Tracker
+	for _, c := range cfg.Items {
+		tracker, err := newTracker(c)
+		if err != nil {
+			return nil, fmt.Errorf("issue tracker %q: %w", c.Name, err)
+		}
+		trackers = append(trackers, tracker)
+	}
+	return trackers, nil
+}
+
+func newTracker(c config.IssueTracker) (Tracker, error) {
+	pattern := c.Match
+	if pattern == "" {
+		pattern = defaultMatch[c.Type]
+	}
+	match, err := regexp.Compile(pattern)
+	if err != nil {
+		return Tracker{}, fmt.Errorf("match: %w", err)
+	}
+	token, err := ReadToken(c.TokenEnv, c.TokenFile, c.Token)
+	if err != nil {
+		return Tracker{}, err
+	}
+	client := &http.Client{Timeout: requestTimeout}
+	var provider Provider
+	switch c.Type {
+	case config.IssueTrackerJira:
+		provider = &Jira{BaseURL: c.URL, User: c.User, Token: token, Client: client}
+	case config.IssueTrackerGitHub:
+		provider = &GitHub{BaseURL: c.URL, Token: token, Client: client}
+	case config.IssueTrackerGitLab:
+		provider = &GitLab{BaseURL: c.URL, Token: token, Client: client}
+	default:
+		return Tracker{}, fmt.Errorf("unknown type: %q", c.Type)
+	}
+	return Tracker{Name: c.Name, Match: match, Provider: provider}, nil
+}
+
+// ReadToken reads a token from the environment variable, or from the file if
+// the variable name is empty. Returns the stored token, as loaded from the
+// secret store, if both are empty.
+func ReadToken(env, file, stored string) (string, error) {
+	if env != "" {
+		return os.Getenv(env), nil
+	}
+	if file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("read token: %w", err)
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+	return stored, nil
+}
+
+// getJSON sends a GET request and decodes the JSON response into v.
+// Returns [ErrNotFound] on 404 Not Found.
+func getJSON(ctx context.Context, client *http.Client, url string, header http.Header, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "dinkur-desktop")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
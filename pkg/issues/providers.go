@@ -0,0 +1,159 @@
+package issues
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// requestTimeout is how long to wait for a response from an issue tracker.
+const requestTimeout = 15 * time.Second
+
+// Jira looks up issues using the Jira REST API.
+type Jira struct {
+	// BaseURL is the URL of the Jira site, e.g
+	// "https://example.atlassian.net".
+	BaseURL string
+	// User is used for basic authentication together with the token, as
+	// with Jira Cloud API tokens. The token is sent as a bearer token if
+	// empty, as with Jira Data Center personal access tokens.
+	User   string
+	Token  string
+	Client *http.Client
+}
+
+// Issue fetches a Jira issue by its key, such as "ABC-123".
+func (j *Jira) Issue(ctx context.Context, key string) (Issue, error) {
+	base := strings.TrimSuffix(j.BaseURL, "/")
+	var body struct {
+		Fields struct {
+			Summary string `json:"summary"`
+			Status  struct {
+				Name string `json:"name"`
+			} `json:"status"`
+		} `json:"fields"`
+	}
+	apiURL := fmt.Sprintf("%s/rest/api/2/issue/%s?fields=summary,status", base, url.PathEscape(key))
+	if err := getJSON(ctx, j.Client, apiURL, j.Header(), &body); err != nil {
+		return Issue{}, err
+	}
+	return Issue{
+		Key:    key,
+		Title:  body.Fields.Summary,
+		Status: body.Fields.Status.Name,
+		URL:    base + "/browse/" + url.PathEscape(key),
+	}, nil
+}
+
+// Header returns the authentication header of requests to Jira.
+func (j *Jira) Header() http.Header {
+	header := http.Header{}
+	switch {
+	case j.User != "":
+		auth := base64.StdEncoding.EncodeToString([]byte(j.User + ":" + j.Token))
+		header.Set("Authorization", "Basic "+auth)
+	case j.Token != "":
+		header.Set("Authorization", "Bearer "+j.Token)
+	}
+	return header
+}
+
+// GitHub looks up issues and pull requests using the GitHub REST API.
+type GitHub struct {
+	// BaseURL is the URL of the API. Defaults to "https://api.github.com".
+	BaseURL string
+	Token   string
+	Client  *http.Client
+}
+
+// Issue fetches a GitHub issue or pull request by its key, such as
+// "owner/repo#123".
+func (g *GitHub) Issue(ctx context.Context, key string) (Issue, error) {
+	repo, number, ok := strings.Cut(key, "#")
+	owner, name, _ := strings.Cut(repo, "/")
+	if !ok || !validSegment(owner) || !validSegment(name) || strings.Contains(name, "/") || !isNumber(number) {
+		return Issue{}, fmt.Errorf("invalid GitHub key %q, must be in the format owner/repo#123", key)
+	}
+	base := strings.TrimSuffix(g.BaseURL, "/")
+	if base == "" {
+		base = "https://api.github.com"
+	}
+	header := http.Header{}
+	header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if g.Token != "" {
+		header.Set("Authorization", "Bearer "+g.Token)
+	}
+	var body struct {
+		Title   string `json:"title"`
+		State   string `json:"state"`
+		HTMLURL string `json:"html_url"`
+	}
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/issues/%s", base, url.PathEscape(owner), url.PathEscape(name), number)
+	if err := getJSON(ctx, g.Client, apiURL, header, &body); err != nil {
+		return Issue{}, err
+	}
+	return Issue{Key: key, Title: body.Title, Status: body.State, URL: body.HTMLURL}, nil
+}
+
+// GitLab looks up issues and merge requests using the GitLab REST API.
+type GitLab struct {
+	// BaseURL is the URL of the GitLab instance. Defaults to
+	// "https://gitlab.com".
+	BaseURL string
+	Token   string
+	Client  *http.Client
+}
+
+// Issue fetches a GitLab issue by its key, such as "group/project#123", or
+// a merge request, such as "group/project!123".
+func (g *GitLab) Issue(ctx context.Context, key string) (Issue, error) {
+	i := strings.LastIndexAny(key, "#!")
+	if i <= 0 || !validSegment(key[:i]) || !isNumber(key[i+1:]) {
+		return Issue{}, fmt.Errorf("invalid GitLab key %q, must be in the format group/project#123", key)
+	}
+	project, number := key[:i], key[i+1:]
+	kind := "issues"
+	if key[i] == '!' {
+		kind = "merge_requests"
+	}
+	base := strings.TrimSuffix(g.BaseURL, "/")
+	if base == "" {
+		base = "https://gitlab.com"
+	}
+	header := http.Header{}
+	if g.Token != "" {
+		header.Set("PRIVATE-TOKEN", g.Token)
+	}
+	var body struct {
+		Title  string `json:"title"`
+		State  string `json:"state"`
+		WebURL string `json:"web_url"`
+	}
+	apiURL := fmt.Sprintf("%s/api/v4/projects/%s/%s/%s", base, url.PathEscape(project), kind, number)
+	if err := getJSON(ctx, g.Client, apiURL, header, &body); err != nil {
+		return Issue{}, err
+	}
+	return Issue{Key: key, Title: body.Title, Status: body.State, URL: body.WebURL}, nil
+}
+
+// validSegment reports if the value can be used as a segment of an API path,
+// once escaped. Escaping leaves "." and "..", which would change the path.
+func validSegment(s string) bool {
+	return s != "" && s != "." && s != ".."
+}
+
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,179 @@
+package issues
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type trackerRequest struct {
+	path   string
+	header http.Header
+}
+
+// lastRequest is the last request received by a test server.
+type lastRequest struct {
+	mu  sync.Mutex
+	req trackerRequest
+}
+
+func (l *lastRequest) get() trackerRequest {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.req
+}
+
+// newTrackerServer responds with the body to requests for the path, with
+// 404 Not Found to other paths, and records the last request.
+func newTrackerServer(t *testing.T, path, body string) (*httptest.Server, *lastRequest) {
+	var last lastRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last.mu.Lock()
+		last.req = trackerRequest{path: r.URL.EscapedPath(), header: r.Header.Clone()}
+		last.mu.Unlock()
+		if r.URL.EscapedPath() != path {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &last
+}
+
+func TestJira(t *testing.T) {
+	srv, last := newTrackerServer(t, "/rest/api/2/issue/ABC-123",
+		`{"fields":{"summary":"Fix login","status":{"name":"In Progress"}}}`)
+
+	j := &Jira{BaseURL: srv.URL + "/", User: "me@example.com", Token: "t0ken", Client: srv.Client()}
+	issue, err := j.Issue(context.Background(), "ABC-123")
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	want := Issue{Key: "ABC-123", Title: "Fix login", Status: "In Progress", URL: srv.URL + "/browse/ABC-123"}
+	if issue != want {
+		t.Errorf("want %+v, got %+v", want, issue)
+	}
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("me@example.com:t0ken"))
+	if got := last.get().header.Get("Authorization"); got != basic {
+		t.Errorf("authorization: want %q, got %q", basic, got)
+	}
+
+	j.User = ""
+	if _, err := j.Issue(context.Background(), "ABC-123"); err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if got := last.get().header.Get("Authorization"); got != "Bearer t0ken" {
+		t.Errorf("authorization: want bearer token, got %q", got)
+	}
+
+	if _, err := j.Issue(context.Background(), "ABC-999"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound, got %v", err)
+	}
+}
+
+func TestGitHub(t *testing.T) {
+	srv, last := newTrackerServer(t, "/repos/dinkur/dinkur-desktop/issues/42",
+		`{"title":"Add webhooks","state":"open","html_url":"https://github.com/dinkur/dinkur-desktop/pull/42"}`)
+
+	g := &GitHub{BaseURL: srv.URL, Token: "t0ken", Client: srv.Client()}
+	issue, err := g.Issue(context.Background(), "dinkur/dinkur-desktop#42")
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	want := Issue{Key: "dinkur/dinkur-desktop#42", Title: "Add webhooks", Status: "open", URL: "https://github.com/dinkur/dinkur-desktop/pull/42"}
+	if issue != want {
+		t.Errorf("want %+v, got %+v", want, issue)
+	}
+	if got := last.get().header.Get("Authorization"); got != "Bearer t0ken" {
+		t.Errorf("authorization: want bearer token, got %q", got)
+	}
+	if got := last.get().header.Get("X-GitHub-Api-Version"); got == "" {
+		t.Error("want API version header")
+	}
+}
+
+func TestGitHubInvalidKeys(t *testing.T) {
+	srv, last := newTrackerServer(t, "/", `{}`)
+	g := &GitHub{BaseURL: srv.URL, Client: srv.Client()}
+	for _, key := range []string{
+		"dinkur#1",
+		"dinkur/#1",
+		"/dinkur#1",
+		"dinkur/dinkur/x#1",
+		"../../user#1",
+		"dinkur/..#1",
+		"dinkur/dinkur#",
+		"dinkur/dinkur#1/comments",
+		"dinkur/dinkur#1?x=y",
+	} {
+		if _, err := g.Issue(context.Background(), key); err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("%q: want invalid key error, got %v", key, err)
+		}
+	}
+	if last.get().path != "" {
+		t.Errorf("want no requests, got request to %q", last.get().path)
+	}
+}
+
+func TestGitHubEscapesRepo(t *testing.T) {
+	srv, last := newTrackerServer(t, "/", `{}`)
+	g := &GitHub{BaseURL: srv.URL, Client: srv.Client()}
+	g.Issue(context.Background(), "owner/re%2Fpo#1")
+	if want := "/repos/owner/re%252Fpo/issues/1"; last.get().path != want {
+		t.Errorf("want request to %q, got %q", want, last.get().path)
+	}
+}
+
+func TestGitLab(t *testing.T) {
+	tests := []struct {
+		key  string
+		path string
+	}{
+		{"group/sub/project#7", "/api/v4/projects/group%2Fsub%2Fproject/issues/7"},
+		{"group/project!12", "/api/v4/projects/group%2Fproject/merge_requests/12"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.key, func(t *testing.T) {
+			srv, last := newTrackerServer(t, tc.path,
+				`{"title":"Speed up builds","state":"opened","web_url":"https://gitlab.example.com/x"}`)
+			g := &GitLab{BaseURL: srv.URL, Token: "t0ken", Client: srv.Client()}
+			issue, err := g.Issue(context.Background(), tc.key)
+			if err != nil {
+				t.Fatalf("issue: %v", err)
+			}
+			want := Issue{Key: tc.key, Title: "Speed up builds", Status: "opened", URL: "https://gitlab.example.com/x"}
+			if issue != want {
+				t.Errorf("want %+v, got %+v", want, issue)
+			}
+			if got := last.get().header.Get("PRIVATE-TOKEN"); got != "t0ken" {
+				t.Errorf("token: want %q, got %q", "t0ken", got)
+			}
+		})
+	}
+
+	srv, _ := newTrackerServer(t, "/", `{}`)
+	g := &GitLab{BaseURL: srv.URL, Client: srv.Client()}
+	for _, key := range []string{"#1", "..#1", "group/project#x", "group/project!"} {
+		if _, err := g.Issue(context.Background(), key); err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("%q: want invalid key error, got %v", key, err)
+		}
+	}
+}
+
+func TestUnexpectedStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusForbidden)
+	}))
+	defer srv.Close()
+	g := &GitHub{BaseURL: srv.URL, Client: srv.Client()}
+	_, err := g.Issue(context.Background(), "dinkur/dinkur#1")
+	if err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("want status error, got %v", err)
+	}
+}
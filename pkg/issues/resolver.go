@@ -0,0 +1,246 @@
+package issues
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/internal/clock"
+	"github.com/dinkur/dinkur-desktop/internal/jsonfile"
+)
+
+// retryDelay is how long to wait before looking up a key again after the
+// issue tracker could not be reached.
+const retryDelay = 5 * time.Minute
+
+type cachedIssue struct {
+	// Issue is nil if the issue tracker had no issue with the key.
+	Issue   *Issue    `json:"issue"`
+	Fetched time.Time `json:"fetched"`
+}
+
+type cacheFile struct {
+	// Trackers are the cached issues by tracker name and key.
+	Trackers map[string]map[string]cachedIssue `json:"trackers"`
+}
+
+// Resolver looks up ticket keys in the issue tracker that matches them, and
+// caches the results in a file.
+type Resolver struct {
+	// OnResolve is called by Run after fetching issues in the background,
+	// with the issues that were found and the errors of the keys that could
+	// not be looked up.
+	OnResolve func(issues []Issue, err error)
+
+	trackers []Tracker
+	path     string
+	ttl      time.Duration
+	clock    clock.Clock
+
+	mu      sync.Mutex
+	cache   *cacheFile
+	pending map[string]bool
+	failed  map[string]time.Time
+	wake    chan struct{}
+}
+
+// NewResolver creates a new resolver that keeps its cache in the file at the
+// path. Cached issues are fetched again once they're older than the TTL.
+func NewResolver(trackers []Tracker, path string, ttl time.Duration, clk clock.Clock) *Resolver {
+	return &Resolver{
+		trackers: trackers,
+		path:     path,
+		ttl:      ttl,
+		clock:    clk,
+		pending:  map[string]bool{},
+		failed:   map[string]time.Time{},
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Tracker returns the first issue tracker that matches the key.
+func (r *Resolver) Tracker(key string) (Tracker, bool) {
+	for _, t := range r.trackers {
+		if t.Match.MatchString(key) {
+			return t, true
+		}
+	}
+	return Tracker{}, false
+}
+
+// Lookup returns the cached issues of the keys without waiting for any
+// issue tracker. Keys that are not cached, or whose cached issue is older
+// than the TTL, are fetched by Run in the background.
+func (r *Resolver) Lookup(keys []string) []Issue {
+	if len(r.trackers) == 0 || len(keys) == 0 {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.load()
+	now := r.clock.Now()
+	var found []Issue
+	queued := false
+	for _, key := range keys {
+		tracker, ok := r.Tracker(key)
+		if !ok {
+			continue
+		}
+		cached, ok := r.cache.Trackers[tracker.Name][key]
+		if ok && cached.Issue != nil {
+			found = append(found, *cached.Issue)
+		}
+		if ok && now.Sub(cached.Fetched) < r.ttl {
+			continue
+		}
+		if failed, ok := r.failed[key]; ok && now.Sub(failed) < retryDelay {
+			continue
+		}
+		if !r.pending[key] {
+			r.pending[key] = true
+			queued = true
+		}
+	}
+	if queued {
+		select {
+		case r.wake <- struct{}{}:
+		default:
+		}
+	}
+	return found
+}
+
+// Resolve fetches the issues of the keys, except for those cached within
+// the TTL, and returns the issues that were found. Keys that no issue
+// tracker matches are skipped.
+func (r *Resolver) Resolve(ctx context.Context, keys []string) ([]Issue, error) {
+	r.mu.Lock()
+	r.load()
+	now := r.clock.Now()
+	var found []Issue
+	var fetch []string
+	for _, key := range keys {
+		tracker, ok := r.Tracker(key)
+		if !ok {
+			continue
+		}
+		cached, ok := r.cache.Trackers[tracker.Name][key]
+		if ok && now.Sub(cached.Fetched) < r.ttl {
+			if cached.Issue != nil {
+				found = append(found, *cached.Issue)
+			}
+			continue
+		}
+		fetch = append(fetch, key)
+	}
+	r.mu.Unlock()
+
+	var errs []error
+	fetched := map[string]map[string]cachedIssue{}
+	for _, key := range fetch {
+		tracker, _ := r.Tracker(key)
+		issue, err := tracker.Provider.Issue(ctx, key)
+		if errors.Is(err, ErrNotFound) {
+			if fetched[tracker.Name] == nil {
+				fetched[tracker.Name] = map[string]cachedIssue{}
+			}
+			fetched[tracker.Name][key] = cachedIssue{Fetched: r.clock.Now()}
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: look up %s: %w", tracker.Name, key, err))
+			r.mu.Lock()
+			r.failed[key] = r.clock.Now()
+			r.mu.Unlock()
+			continue
+		}
+		issue.Key = key
+		issue.Tracker = tracker.Name
+		if fetched[tracker.Name] == nil {
+			fetched[tracker.Name] = map[string]cachedIssue{}
+		}
+		fetched[tracker.Name][key] = cachedIssue{Issue: &issue, Fetched: r.clock.Now()}
+		found = append(found, issue)
+	}
+	if len(fetched) > 0 {
+		if err := r.store(fetched); err != nil {
+			errs = append(errs, fmt.Errorf("write issue cache: %w", err))
+		}
+	}
+	return found, errors.Join(errs...)
+}
+
+// Run fetches the keys queued by Lookup, until the context is cancelled.
+func (r *Resolver) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.wake:
+		}
+		r.mu.Lock()
+		keys := make([]string, 0, len(r.pending))
+		for key := range r.pending {
+			keys = append(keys, key)
+		}
+		r.mu.Unlock()
+
+		issues, err := r.Resolve(ctx, keys)
+
+		r.mu.Lock()
+		for _, key := range keys {
+			delete(r.pending, key)
+		}
+		r.mu.Unlock()
+		if ctx.Err() != nil {
+			return
+		}
+		if r.OnResolve != nil {
+			r.OnResolve(issues, err)
+		}
+	}
+}
+
+// load reads the cache file, unless it has already been read. Must be called
+// with the mutex held. An unreadable cache is treated as empty.
+func (r *Resolver) load() {
+	if r.cache != nil {
+		return
+	}
+	var cache cacheFile
+	if err := jsonfile.Read(r.path, &cache); err != nil {
+		cache = cacheFile{}
+	}
+	if cache.Trackers == nil {
+		cache.Trackers = map[string]map[string]cachedIssue{}
+	}
+	r.cache = &cache
+}
+
+// store adds the fetched issues to the cache, drops the issues of trackers
+// that are no longer configured, and writes the cache file.
+func (r *Resolver) store(fetched map[string]map[string]cachedIssue) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, issues := range fetched {
+		if r.cache.Trackers[name] == nil {
+			r.cache.Trackers[name] = map[string]cachedIssue{}
+		}
+		for key, cached := range issues {
+			r.cache.Trackers[name][key] = cached
+			delete(r.failed, key)
+		}
+	}
+	configured := map[string]bool{}
+	for _, t := range r.trackers {
+		configured[t.Name] = true
+	}
+	for name := range r.cache.Trackers {
+		if !configured[name] {
+			delete(r.cache.Trackers, name)
+		}
+	}
+	return jsonfile.Write(r.path, r.cache)
+}
@@ -0,0 +1,146 @@
+package issues
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/internal/clock"
+)
+
+// fakeProvider returns the issues by key, ErrNotFound for other keys, or err
+// if set, and counts the lookups of each key.
+type fakeProvider struct {
+	mu      sync.Mutex
+	issues  map[string]Issue
+	err     error
+	lookups map[string]int
+}
+
+func (p *fakeProvider) Issue(ctx context.Context, key string) (Issue, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.lookups == nil {
+		p.lookups = map[string]int{}
+	}
+	p.lookups[key]++
+	if p.err != nil {
+		return Issue{}, p.err
+	}
+	issue, ok := p.issues[key]
+	if !ok {
+		return Issue{}, ErrNotFound
+	}
+	return issue, nil
+}
+
+func (p *fakeProvider) count(key string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lookups[key]
+}
+
+func newTestResolver(t *testing.T, path string, provider Provider, clk clock.Clock) *Resolver {
+	trackers := []Tracker{{
+		Name:     "jira",
+		Match:    regexp.MustCompile(`^[A-Z]+-[0-9]+$`),
+		Provider: provider,
+	}}
+	return NewResolver(trackers, path, time.Hour, clk)
+}
+
+func TestResolverCaches(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "issues.json")
+	clk := clock.NewFake(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC))
+	provider := &fakeProvider{issues: map[string]Issue{"ABC-1": {Title: "Fix login"}}}
+	r := newTestResolver(t, path, provider, clk)
+
+	resolve := func(r *Resolver) []Issue {
+		t.Helper()
+		issues, err := r.Resolve(context.Background(), []string{"ABC-1", "ABC-2", "not a key"})
+		if err != nil {
+			t.Fatalf("resolve: %v", err)
+		}
+		return issues
+	}
+
+	issues := resolve(r)
+	if len(issues) != 1 || issues[0].Key != "ABC-1" || issues[0].Tracker != "jira" || issues[0].Title != "Fix login" {
+		t.Fatalf("unexpected issues: %+v", issues)
+	}
+	if n := provider.count("not a key"); n != 0 {
+		t.Errorf("want keys without tracker to be skipped, got %d lookups", n)
+	}
+
+	// Found and missing issues are both cached within the TTL, also by
+	// resolvers that read the same cache file.
+	clk.Advance(59 * time.Minute)
+	resolve(r)
+	resolve(newTestResolver(t, path, provider, clk))
+	if a, b := provider.count("ABC-1"), provider.count("ABC-2"); a != 1 || b != 1 {
+		t.Errorf("want 1 lookup of each key within the TTL, got %d and %d", a, b)
+	}
+
+	clk.Advance(time.Minute)
+	if issues := resolve(r); len(issues) != 1 {
+		t.Errorf("want 1 issue, got %+v", issues)
+	}
+	if a, b := provider.count("ABC-1"), provider.count("ABC-2"); a != 2 || b != 2 {
+		t.Errorf("want keys to be looked up again after the TTL, got %d and %d lookups", a, b)
+	}
+}
+
+func TestResolverLookup(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC))
+	provider := &fakeProvider{issues: map[string]Issue{"ABC-1": {Title: "Fix login"}}}
+	r := newTestResolver(t, filepath.Join(t.TempDir(), "issues.json"), provider, clk)
+
+	resolved := make(chan []Issue, 1)
+	r.OnResolve = func(issues []Issue, err error) {
+		if err != nil {
+			t.Errorf("resolve: %v", err)
+		}
+		resolved <- issues
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Run(ctx)
+
+	if issues := r.Lookup([]string{"ABC-1"}); len(issues) != 0 {
+		t.Fatalf("want no cached issues, got %+v", issues)
+	}
+	select {
+	case issues := <-resolved:
+		if len(issues) != 1 || issues[0].Key != "ABC-1" {
+			t.Fatalf("unexpected issues: %+v", issues)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the issue to be resolved")
+	}
+	if issues := r.Lookup([]string{"ABC-1"}); len(issues) != 1 || issues[0].Title != "Fix login" {
+		t.Errorf("want the cached issue, got %+v", issues)
+	}
+}
+
+func TestResolverRetriesFailedLookups(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC))
+	provider := &fakeProvider{err: errors.New("connection refused")}
+	r := newTestResolver(t, filepath.Join(t.TempDir(), "issues.json"), provider, clk)
+
+	if _, err := r.Resolve(context.Background(), []string{"ABC-1"}); err == nil {
+		t.Fatal("want error")
+	}
+	r.Lookup([]string{"ABC-1"})
+	if len(r.pending) != 0 {
+		t.Errorf("want failed key not to be queued within the retry delay")
+	}
+	clk.Advance(retryDelay)
+	r.Lookup([]string{"ABC-1"})
+	if !r.pending["ABC-1"] {
+		t.Errorf("want failed key to be queued after the retry delay")
+	}
+}
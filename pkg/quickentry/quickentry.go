@@ -0,0 +1,598 @@
+// Package quickentry parses manually typed entries written in natural
+// language, such as "yesterday 13:00-14:30 code review" or
+// "2h on INC-44 this morning", into a name, start, and end time.
+//
+// The text may contain, in any order:
+//
+//   - A day: "today", "yesterday", "tomorrow", a weekday such as "monday" or
+//     "last friday", "3 days ago", or a date such as "2023-02-14".
+//   - A part of the day: "this morning", "afternoon", "in the evening", or
+//     "tonight".
+//   - A time range: "13:00-14:30", "1-2:30pm", "9 to 5", or
+//     "from 9am until noon".
+//   - A start or end time: "at 13:00", "since 9", or "until 14:30".
+//   - A duration: "2h", "1h30m", "90 min", "1.5 hours", or "for 45m".
+//
+// Everything else is the entry's name. Text within double quotes is always
+// part of the name, e.g "\"2h review\" yesterday 10-12".
+package quickentry
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/calendar"
+)
+
+// Errors returned when parsing.
+var (
+	ErrEmptyName   = errors.New("missing entry name")
+	ErrMissingTime = errors.New("missing start time, end time, or duration")
+	ErrMissingEnd  = errors.New("missing end time or duration, only entries on the current day can be left active")
+	ErrZeroLength  = errors.New("entry ends at the same time as it starts")
+	ErrInFuture    = errors.New("entry is in the future")
+)
+
+// Entry is a parsed entry.
+type Entry struct {
+	Name  string    `json:"name"`
+	Start time.Time `json:"start"`
+	// End is nil if the entry should be started as the active entry.
+	End *time.Time `json:"end"`
+}
+
+// PartOfDay is a named time span of a day, such as the morning.
+type PartOfDay struct {
+	Name string
+	// Start and End are the hours when the part of day starts and ends.
+	Start int
+	End   int
+}
+
+// PartsOfDay are the recognized parts of a day. Times of day without "am"
+// or "pm" within the afternoon or evening are assumed to be in the
+// afternoon, e.g "this afternoon 2-3" is 14:00-15:00.
+var PartsOfDay = []PartOfDay{
+	{Name: "morning", Start: 8, End: 12},
+	{Name: "afternoon", Start: 13, End: 17},
+	{Name: "evening", Start: 17, End: 21},
+}
+
+// Parse parses the text into an entry, relative to the given reference time,
+// which is typically the current time. Days are the calendar's days, so
+// "today" may be the previous date before the calendar's day start hour,
+// and times are in the calendar's time zone.
+//
+// Entries with only a duration end at the reference time, and entries with
+// only a start time on the current day are left active.
+func Parse(text string, now time.Time, cal *calendar.Calendar) (Entry, error) {
+	now = now.In(cal.Location())
+	p := parser{now: now, today: cal.Date(now), cal: cal, tokens: tokenize(text)}
+	for p.pos < len(p.tokens) {
+		if p.parseQuoted() || p.parseDay() || p.parsePart() || p.parseRange() ||
+			p.parseMarkedTime() || p.parseTime() || p.parseDuration() {
+			continue
+		}
+		p.name = append(p.name, p.tokens[p.pos].text)
+		p.pos++
+	}
+	name := trimName(p.name)
+	if name == "" {
+		return Entry{}, ErrEmptyName
+	}
+	start, end, err := p.resolve()
+	if err != nil {
+		return Entry{}, err
+	}
+	if start.After(now) {
+		return Entry{}, fmt.Errorf("%w, starts at %s", ErrInFuture, start.Format("2006-01-02 15:04"))
+	}
+	if end != nil && end.After(now) {
+		return Entry{}, fmt.Errorf("%w, ends at %s", ErrInFuture, end.Format("2006-01-02 15:04"))
+	}
+	return Entry{Name: name, Start: start, End: end}, nil
+}
+
+type token struct {
+	text   string
+	lower  string
+	quoted bool
+}
+
+var spacedMeridiemRegex = regexp.MustCompile(`(?i)\b(\d{1,2}(?:[:.]\d{2})?)\s+(am|pm)\b`)
+
+var spacedDashRegex = regexp.MustCompile(`(?i)(\d|am|pm|noon|midnight)\s+-\s*(\d|noon|midnight)|(\d|am|pm|noon|midnight)\s*-\s+(\d|noon|midnight)`)
+
+// tokenize splits the text on whitespace, keeping quoted text together.
+// Spaces within times and around dashes in time ranges are removed, so that
+// "1 pm - 2 pm" is a single token.
+func tokenize(text string) []token {
+	text = strings.NewReplacer("–", "-", "—", "-").Replace(text)
+	text = spacedMeridiemRegex.ReplaceAllString(text, "$1$2")
+	text = spacedDashRegex.ReplaceAllString(text, "$1$3-$2$4")
+	var tokens []token
+	var sb strings.Builder
+	flush := func(quoted bool) {
+		if sb.Len() > 0 {
+			s := sb.String()
+			tokens = append(tokens, token{
+				text:   s,
+				lower:  strings.ToLower(strings.Trim(s, ",;")),
+				quoted: quoted,
+			})
+		}
+		sb.Reset()
+	}
+	inQuotes := false
+	for _, r := range text {
+		switch {
+		case r == '"':
+			flush(inQuotes)
+			inQuotes = !inQuotes
+		case !inQuotes && (r == ' ' || r == '\t' || r == '\n'):
+			flush(false)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	flush(inQuotes)
+	return tokens
+}
+
+// nameConnectors are words that are left out from the start of names, as
+// in "2h on INC-44" or "for 1h doing code review".
+var nameConnectors = map[string]bool{
+	"on": true, "for": true, "doing": true, "with": true, "-": true, ":": true,
+}
+
+func trimName(words []string) string {
+	for len(words) > 0 && nameConnectors[strings.ToLower(words[0])] {
+		words = words[1:]
+	}
+	for len(words) > 0 && (words[len(words)-1] == "-" || words[len(words)-1] == ":") {
+		words = words[:len(words)-1]
+	}
+	return strings.TrimSpace(strings.Join(words, " "))
+}
+
+type clockTime struct {
+	hour, minute int
+	// meridiem is "am", "pm", or empty for 24-hour clock times.
+	meridiem string
+	// bare is true for times written as only a number, such as "9", which
+	// are only recognized next to other times or after words like "at".
+	bare bool
+}
+
+var clockRegex = regexp.MustCompile(`^(\d{1,2})(?:[:.](\d{2}))?\s*(am|pm|a\.m\.|p\.m\.|a|p)?$`)
+
+// parseClock parses a time of day, such as "13:00", "9.30", "1pm", "noon",
+// or "9" if bare numbers are allowed.
+func parseClock(s string, allowBare bool) (clockTime, bool) {
+	switch s {
+	case "noon", "midday":
+		return clockTime{hour: 12}, true
+	case "midnight":
+		return clockTime{hour: 0}, true
+	}
+	m := clockRegex.FindStringSubmatch(s)
+	if m == nil {
+		return clockTime{}, false
+	}
+	hour, _ := strconv.Atoi(m[1])
+	minute := 0
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+	c := clockTime{hour: hour, minute: minute}
+	if m[3] != "" {
+		c.meridiem = string(m[3][0]) + "m"
+		if hour < 1 || hour > 12 {
+			return clockTime{}, false
+		}
+	}
+	c.bare = m[2] == "" && m[3] == ""
+	if c.bare && !allowBare {
+		return clockTime{}, false
+	}
+	if hour > 23 || minute > 59 {
+		return clockTime{}, false
+	}
+	return c, true
+}
+
+// hour24 returns the hour on a 24-hour clock. Times without "am" or "pm"
+// are moved to the afternoon if pm is true and they're before noon.
+func (c clockTime) hour24(pm bool) int {
+	switch {
+	case c.meridiem == "am" && c.hour == 12:
+		return 0
+	case c.meridiem == "am":
+		return c.hour
+	case c.meridiem == "pm" && c.hour == 12:
+		return 12
+	case c.meridiem == "pm":
+		return c.hour + 12
+	case pm && c.hour >= 1 && c.hour < 12:
+		return c.hour + 12
+	default:
+		return c.hour
+	}
+}
+
+func (c clockTime) on(day time.Time, pm bool) time.Time {
+	y, m, d := day.Date()
+	return time.Date(y, m, d, c.hour24(pm), c.minute, 0, 0, day.Location())
+}
+
+type parser struct {
+	now time.Time
+	// today is the date of the calendar day that contains now, as midnight.
+	today  time.Time
+	cal    *calendar.Calendar
+	tokens []token
+	pos    int
+	name   []string
+
+	day      *time.Time
+	part     *PartOfDay
+	start    *clockTime
+	end      *clockTime
+	duration time.Duration
+}
+
+func (p *parser) peek(offset int) string {
+	if p.pos+offset >= len(p.tokens) || p.tokens[p.pos+offset].quoted {
+		return ""
+	}
+	return p.tokens[p.pos+offset].lower
+}
+
+func (p *parser) parseQuoted() bool {
+	if !p.tokens[p.pos].quoted {
+		return false
+	}
+	p.name = append(p.name, p.tokens[p.pos].text)
+	p.pos++
+	return true
+}
+
+var daysAgoUnits = map[string]bool{"day": true, "days": true}
+
+func (p *parser) parseDay() bool {
+	if p.day != nil {
+		return false
+	}
+	offset := 0
+	if p.peek(0) == "on" {
+		offset = 1
+	}
+	today := p.today
+	word := p.peek(offset)
+	var day time.Time
+	consumed := offset + 1
+	switch word {
+	case "today":
+		day = today
+	case "yesterday":
+		day = today.AddDate(0, 0, -1)
+	case "tomorrow":
+		day = today.AddDate(0, 0, 1)
+	case "last":
+		weekday, ok := parseWeekday(p.peek(offset + 1))
+		if !ok {
+			return false
+		}
+		day = lastWeekday(today, weekday, false)
+		consumed++
+	default:
+		if weekday, ok := parseWeekday(word); ok {
+			day = lastWeekday(today, weekday, true)
+		} else if date, err := p.cal.ParseDate(word); err == nil {
+			day = date
+		} else if n, err := strconv.Atoi(word); err == nil && offset == 0 &&
+			daysAgoUnits[p.peek(1)] && p.peek(2) == "ago" {
+			day = today.AddDate(0, 0, -n)
+			consumed = 3
+		} else {
+			return false
+		}
+	}
+	p.day = &day
+	p.pos += consumed
+	return true
+}
+
+func parseWeekday(s string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if s == strings.ToLower(d.String()) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// lastWeekday returns the most recent day with the given weekday, which may
+// be today if includeToday is true.
+func lastWeekday(today time.Time, weekday time.Weekday, includeToday bool) time.Time {
+	diff := (int(today.Weekday()) - int(weekday) + 7) % 7
+	if diff == 0 && !includeToday {
+		diff = 7
+	}
+	return today.AddDate(0, 0, -diff)
+}
+
+func (p *parser) parsePart() bool {
+	if p.part != nil {
+		return false
+	}
+	offset := 0
+	switch {
+	case p.peek(0) == "this":
+		offset = 1
+	case p.peek(0) == "in" && p.peek(1) == "the":
+		offset = 2
+	}
+	word := p.peek(offset)
+	if word == "tonight" && offset == 0 {
+		word = "evening"
+	}
+	for i := range PartsOfDay {
+		if PartsOfDay[i].Name == word {
+			p.part = &PartsOfDay[i]
+			p.pos += offset + 1
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) parseRange() bool {
+	if p.start != nil || p.end != nil {
+		return false
+	}
+	from, to, ok := strings.Cut(p.peek(0), "-")
+	if !ok {
+		return false
+	}
+	start, ok := parseClock(from, true)
+	if !ok {
+		return false
+	}
+	end, ok := parseClock(to, true)
+	if !ok {
+		return false
+	}
+	consumed := 1
+	// "9-10 am"
+	if end.meridiem == "" {
+		if m := p.peek(1); m == "am" || m == "pm" {
+			end.meridiem = m
+			end.bare = false
+			consumed++
+		}
+	}
+	p.start, p.end = &start, &end
+	p.pos += consumed
+	return true
+}
+
+var (
+	startMarkers = map[string]bool{"at": true, "from": true, "since": true, "starting": true}
+	endMarkers   = map[string]bool{"to": true, "until": true, "till": true, "til": true, "-": true}
+)
+
+// clockAt parses the time of day at the token offset, including a following
+// "am" or "pm" token. Numbers followed by a unit, as in "1.25 hours", are
+// durations and not times of day.
+func (p *parser) clockAt(offset int, allowBare bool) (clockTime, int, bool) {
+	word := p.peek(offset)
+	if word == "" || durationUnitRegex.MatchString(p.peek(offset+1)) {
+		return clockTime{}, 0, false
+	}
+	if m := p.peek(offset + 1); m == "am" || m == "pm" {
+		if c, ok := parseClock(word+m, false); ok {
+			return c, 2, true
+		}
+	}
+	c, ok := parseClock(word, allowBare)
+	return c, 1, ok
+}
+
+func (p *parser) parseMarkedTime() bool {
+	word := p.peek(0)
+	switch {
+	case startMarkers[word] && p.start == nil:
+		c, n, ok := p.clockAt(1, true)
+		if !ok {
+			return false
+		}
+		p.start = &c
+		p.pos += 1 + n
+		return true
+	case endMarkers[word] && p.end == nil:
+		c, n, ok := p.clockAt(1, true)
+		if !ok {
+			return false
+		}
+		p.end = &c
+		p.pos += 1 + n
+		return true
+	}
+	return false
+}
+
+// parseTime parses a start time without a marker word, such as "13:00", or
+// "9" when followed by an end time, as in "9 to 10".
+func (p *parser) parseTime() bool {
+	if p.start != nil {
+		return false
+	}
+	c, n, ok := p.clockAt(0, false)
+	if !ok {
+		c, n, ok = p.clockAt(0, true)
+		if !ok || !endMarkers[p.peek(n)] {
+			return false
+		}
+		if _, _, ok := p.clockAt(n+1, true); !ok {
+			return false
+		}
+	}
+	p.start = &c
+	p.pos += n
+	return true
+}
+
+var (
+	durationRegex     = regexp.MustCompile(`(\d+(?:[.,]\d+)?)(hours?|hrs?|h|minutes?|mins?|m)`)
+	durationUnitRegex = regexp.MustCompile(`^(hours?|hrs?|h|minutes?|mins?|m)$`)
+)
+
+func (p *parser) parseDuration() bool {
+	offset := 0
+	if p.peek(0) == "for" {
+		offset = 1
+	}
+	d, n, ok := p.durationAt(offset)
+	if !ok {
+		return false
+	}
+	p.duration += d
+	p.pos += offset + n
+	return true
+}
+
+// durationAt parses a duration such as "1h30m", "2 hours", or "1.5h" at the
+// token offset.
+func (p *parser) durationAt(offset int) (time.Duration, int, bool) {
+	word := p.peek(offset)
+	if word == "" {
+		return 0, 0, false
+	}
+	if _, err := strconv.ParseFloat(strings.Replace(word, ",", ".", 1), 64); err == nil &&
+		durationUnitRegex.MatchString(p.peek(offset+1)) {
+		word += p.peek(offset + 1)
+		d, ok := parseDuration(word)
+		return d, 2, ok
+	}
+	d, ok := parseDuration(word)
+	return d, 1, ok
+}
+
+func parseDuration(s string) (time.Duration, bool) {
+	matches := durationRegex.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return 0, false
+	}
+	var total time.Duration
+	pos := 0
+	for _, m := range matches {
+		if m[0] != pos {
+			return 0, false
+		}
+		pos = m[1]
+		value, err := strconv.ParseFloat(strings.Replace(s[m[2]:m[3]], ",", ".", 1), 64)
+		if err != nil {
+			return 0, false
+		}
+		unit := time.Minute
+		if s[m[4]] == 'h' {
+			unit = time.Hour
+		}
+		total += time.Duration(value * float64(unit))
+	}
+	if pos != len(s) || total <= 0 {
+		return 0, false
+	}
+	return total.Round(time.Second), true
+}
+
+// resolve calculates the start and end times from the parsed parts.
+func (p *parser) resolve() (time.Time, *time.Time, error) {
+	today := p.today
+	day := today
+	if p.day != nil {
+		day = *p.day
+	}
+	isToday := day.Equal(today)
+	pm := p.part != nil && p.part.Start >= 12
+
+	switch {
+	case p.start != nil && p.end != nil:
+		start, end := p.resolveRange(day, pm)
+		if end.Equal(start) {
+			return time.Time{}, nil, ErrZeroLength
+		}
+		return start, &end, nil
+	case p.start != nil:
+		start := p.start.on(day, pm)
+		if p.duration > 0 {
+			end := start.Add(p.duration)
+			return start, &end, nil
+		}
+		if !isToday {
+			return time.Time{}, nil, ErrMissingEnd
+		}
+		return start, nil, nil
+	case p.end != nil:
+		if p.duration == 0 {
+			return time.Time{}, nil, ErrMissingTime
+		}
+		end := p.end.on(day, pm)
+		return end.Add(-p.duration), &end, nil
+	case p.part != nil:
+		y, m, d := day.Date()
+		start := time.Date(y, m, d, p.part.Start, 0, 0, 0, day.Location())
+		end := time.Date(y, m, d, p.part.End, 0, 0, 0, day.Location())
+		if p.duration > 0 {
+			end = start.Add(p.duration)
+		} else if end.After(p.now) {
+			end = p.now
+		}
+		return start, &end, nil
+	case p.duration > 0:
+		if !isToday {
+			return time.Time{}, nil, ErrMissingTime
+		}
+		end := p.now
+		return end.Add(-p.duration), &end, nil
+	default:
+		if !isToday {
+			return time.Time{}, nil, ErrMissingTime
+		}
+		return p.now, nil, nil
+	}
+}
+
+// resolveRange returns the start and end of a time range. A start time
+// without "am" or "pm" gets the end time's, as in "1-2:30pm", unless that
+// would make it start after the end, as in "11-1pm". Ranges of numbers that
+// would otherwise end before they start are assumed to end in the
+// afternoon, e.g "9-5" is 09:00-17:00, or else end on the next day, e.g
+// "22:00-01:00".
+func (p *parser) resolveRange(day time.Time, pm bool) (time.Time, time.Time) {
+	startClock, endClock := *p.start, *p.end
+	if startClock.meridiem == "" && endClock.meridiem != "" && startClock.hour <= 12 {
+		withMeridiem := startClock
+		withMeridiem.meridiem = endClock.meridiem
+		if withMeridiem.on(day, pm).Before(endClock.on(day, pm)) {
+			startClock = withMeridiem
+		}
+	}
+	start, end := startClock.on(day, pm), endClock.on(day, pm)
+	if !end.After(start) && endClock.bare && endClock.hour < 12 {
+		afternoonClock := endClock
+		afternoonClock.meridiem = "pm"
+		if afternoon := afternoonClock.on(day, pm); afternoon.After(start) {
+			end = afternoon
+		}
+	}
+	if end.Before(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end
+}
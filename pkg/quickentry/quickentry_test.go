@@ -0,0 +1,187 @@
+package quickentry
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/pkg/calendar"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+)
+
+func newTestCalendar(t *testing.T, timeZone string, dayStartHour int) *calendar.Calendar {
+	t.Helper()
+	cal, err := calendar.New(config.Calendar{TimeZone: timeZone, DayStartHour: dayStartHour})
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+	return cal
+}
+
+func TestParse(t *testing.T) {
+	// Wednesday afternoon.
+	now := time.Date(2023, 3, 15, 16, 0, 0, 0, time.UTC)
+	utc := newTestCalendar(t, "UTC", 0)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2023, 3, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		text      string
+		wantName  string
+		wantStart time.Time
+		// wantEnd is the zero time for active entries.
+		wantEnd time.Time
+	}{
+		// Durations
+		{"2h on INC-44", "INC-44", at(15, 14, 0), at(15, 16, 0)},
+		{"code review 1h30m", "code review", at(15, 14, 30), at(15, 16, 0)},
+		{"90 min planning", "planning", at(15, 14, 30), at(15, 16, 0)},
+		{"1.25 hours code review", "code review", at(15, 14, 45), at(15, 16, 0)},
+		{"for 45m doing email", "email", at(15, 15, 15), at(15, 16, 0)},
+		{"1,5h docs", "docs", at(15, 14, 30), at(15, 16, 0)},
+
+		// Ranges
+		{"13:00-14:30 meeting", "meeting", at(15, 13, 0), at(15, 14, 30)},
+		{"1-2:30pm lunch", "lunch", at(15, 13, 0), at(15, 14, 30)},
+		{"yesterday 9-5 workshop", "workshop", at(14, 9, 0), at(14, 17, 0)},
+		{"yesterday 9 to 5 workshop", "workshop", at(14, 9, 0), at(14, 17, 0)},
+		{"from 9am until noon support", "support", at(15, 9, 0), at(15, 12, 0)},
+		{"1 pm - 2 pm sync", "sync", at(15, 13, 0), at(15, 14, 0)},
+		{"yesterday 22:00-01:00 deploy", "deploy", at(14, 22, 0), at(15, 1, 0)},
+		{"this afternoon 2-3 review", "review", at(15, 14, 0), at(15, 15, 0)},
+
+		// Relative and absolute days
+		{"yesterday 13:00-14:30 code review", "code review", at(14, 13, 0), at(14, 14, 30)},
+		{"last wednesday 10-11 retro", "retro", at(8, 10, 0), at(8, 11, 0)},
+		{"monday 10-11 retro", "retro", at(13, 10, 0), at(13, 11, 0)},
+		{"3 days ago 10-11 retro", "retro", at(12, 10, 0), at(12, 11, 0)},
+		{"2023-02-14 10:00-11:00 valentine", "valentine", time.Date(2023, 2, 14, 10, 0, 0, 0, time.UTC), time.Date(2023, 2, 14, 11, 0, 0, 0, time.UTC)},
+		{"yesterday morning admin", "admin", at(14, 8, 0), at(14, 12, 0)},
+		{"this afternoon admin", "admin", at(15, 13, 0), at(15, 16, 0)},
+
+		// Start and end times
+		{"at 13:00 coding", "coding", at(15, 13, 0), time.Time{}},
+		{"since 9 coding", "coding", at(15, 9, 0), time.Time{}},
+		{"until 14:30 for 2h coding", "coding", at(15, 12, 30), at(15, 14, 30)},
+		{"yesterday at 10 for 1h call", "call", at(14, 10, 0), at(14, 11, 0)},
+
+		// Names with digits
+		{"fix bug 42 in parser 2h", "fix bug 42 in parser", at(15, 14, 0), at(15, 16, 0)},
+		{"review PR 7 1h", "review PR 7", at(15, 15, 0), at(15, 16, 0)},
+		{`"2h review" yesterday 10-12`, "2h review", at(14, 10, 0), at(14, 12, 0)},
+		{"release v2.1 30m", "release v2.1", at(15, 15, 30), at(15, 16, 0)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.text, func(t *testing.T) {
+			checkParse(t, tc.text, now, utc, tc.wantName, tc.wantStart, tc.wantEnd)
+		})
+	}
+
+	// In Europe/Stockholm, clocks move forward from 02:00 to 03:00 on
+	// 2023-03-26, and back from 03:00 to 02:00 on 2023-10-29.
+	stockholmCal := newTestCalendar(t, "Europe/Stockholm", 0)
+	stockholm := stockholmCal.Location()
+	dstTests := []struct {
+		text      string
+		now       time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{
+			"yesterday morning admin",
+			time.Date(2023, 3, 27, 16, 0, 0, 0, stockholm),
+			time.Date(2023, 3, 26, 8, 0, 0, 0, stockholm),
+			time.Date(2023, 3, 26, 12, 0, 0, 0, stockholm),
+		},
+		{
+			"yesterday afternoon admin",
+			time.Date(2023, 10, 30, 16, 0, 0, 0, stockholm),
+			time.Date(2023, 10, 29, 13, 0, 0, 0, stockholm),
+			time.Date(2023, 10, 29, 17, 0, 0, 0, stockholm),
+		},
+		{
+			"yesterday 9-5 admin",
+			time.Date(2023, 10, 30, 16, 0, 0, 0, stockholm),
+			time.Date(2023, 10, 29, 9, 0, 0, 0, stockholm),
+			time.Date(2023, 10, 29, 17, 0, 0, 0, stockholm),
+		},
+	}
+	for _, tc := range dstTests {
+		t.Run(tc.text+" across DST", func(t *testing.T) {
+			checkParse(t, tc.text, tc.now, stockholmCal, "admin", tc.wantStart, tc.wantEnd)
+		})
+	}
+}
+
+func checkParse(t *testing.T, text string, now time.Time, cal *calendar.Calendar, wantName string, wantStart, wantEnd time.Time) {
+	t.Helper()
+	got, err := Parse(text, now, cal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != wantName {
+		t.Errorf("want name %q, got %q", wantName, got.Name)
+	}
+	if !got.Start.Equal(wantStart) {
+		t.Errorf("want start %v, got %v", wantStart, got.Start)
+	}
+	switch {
+	case wantEnd.IsZero() && got.End != nil:
+		t.Errorf("want active entry, got end %v", *got.End)
+	case !wantEnd.IsZero() && got.End == nil:
+		t.Errorf("want end %v, got active entry", wantEnd)
+	case !wantEnd.IsZero() && !got.End.Equal(wantEnd):
+		t.Errorf("want end %v, got %v", wantEnd, *got.End)
+	}
+}
+
+func TestParseDayStartHour(t *testing.T) {
+	// Before the day starts at 04:00, so still on Tuesday the 14th.
+	now := time.Date(2023, 3, 15, 2, 0, 0, 0, time.UTC)
+	cal := newTestCalendar(t, "UTC", 4)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2023, 3, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		text      string
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{"yesterday 10-11 retro", at(13, 10, 0), at(13, 11, 0)},
+		{"2023-03-14 morning retro", at(14, 8, 0), at(14, 12, 0)},
+		{"1h retro", at(15, 1, 0), at(15, 2, 0)},
+		{"at 23:00 retro", at(14, 23, 0), time.Time{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.text, func(t *testing.T) {
+			checkParse(t, tc.text, now, cal, "retro", tc.wantStart, tc.wantEnd)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	now := time.Date(2023, 3, 15, 16, 0, 0, 0, time.UTC)
+	cal := newTestCalendar(t, "UTC", 0)
+	tests := []struct {
+		text string
+		want error
+	}{
+		{"2h", ErrEmptyName},
+		{"yesterday coding", ErrMissingTime},
+		{"yesterday at 10 coding", ErrMissingEnd},
+		{"until 14:00 coding", ErrMissingTime},
+		{"13:00-13:00 coding", ErrZeroLength},
+		{"tomorrow 10-11 coding", ErrInFuture},
+		{"at 17:00 coding", ErrInFuture},
+		{"fix bug 10-20 in parser", ErrInFuture},
+		{"at 15:00 for 2h coding", ErrInFuture},
+	}
+	for _, tc := range tests {
+		t.Run(tc.text, func(t *testing.T) {
+			_, err := Parse(tc.text, now, cal)
+			if !errors.Is(err, tc.want) {
+				t.Errorf("want %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
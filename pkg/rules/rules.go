@@ -0,0 +1,172 @@
+// Package rules categorizes entries into projects, tags, and billable or
+// non-billable time, based on user-defined rules matched against entry names.
+package rules
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+	"github.com/dinkur/dinkur-desktop/pkg/entryname"
+	"github.com/dinkur/dinkur/pkg/dinkur"
+)
+
+// Rule is a compiled categorization rule.
+type Rule struct {
+	Index    int
+	Name     string
+	Match    *regexp.Regexp
+	Project  string
+	Tags     []string
+	Billable *bool
+}
+
+// Engine evaluates categorization rules.
+type Engine struct {
+	rules           []Rule
+	defaultBillable bool
+}
+
+// New compiles the rules from the config. The config is expected to already
+// have been validated via [config.Config.Validate], but any invalid regular
+// expressions are still reported.
+func New(cfg config.Categorization) (*Engine, error) {
+	e := &Engine{defaultBillable: cfg.DefaultBillable}
+	for i, r := range cfg.Rules {
+		re, err := regexp.Compile(r.Match)
+		if err != nil {
+			return nil, fmt.Errorf("categorization.rules[%d].match: %w", i, err)
+		}
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("Rule #%d", i+1)
+		}
+		e.rules = append(e.rules, Rule{
+			Index:    i,
+			Name:     name,
+			Match:    re,
+			Project:  r.Project,
+			Tags:     r.Tags,
+			Billable: r.Billable,
+		})
+	}
+	return e, nil
+}
+
+// Match is a rule that matched an entry name.
+type Match struct {
+	// Index is the rule's index in the config.
+	Index int    `json:"index"`
+	Name  string `json:"name"`
+	// Text is the part of the entry name that the rule matched.
+	Text string `json:"text"`
+}
+
+// Result is the categorization of an entry.
+type Result struct {
+	Project  string   `json:"project"`
+	Tags     []string `json:"tags"`
+	Billable bool     `json:"billable"`
+	Matches  []Match  `json:"matches"`
+}
+
+// Evaluate applies all matching rules to the entry name, in order.
+func (e *Engine) Evaluate(name string) Result {
+	result := Result{Billable: e.defaultBillable}
+	for _, r := range e.rules {
+		loc := r.Match.FindStringIndex(name)
+		if loc == nil {
+			continue
+		}
+		result.Matches = append(result.Matches, Match{
+			Index: r.Index,
+			Name:  r.Name,
+			Text:  name[loc[0]:loc[1]],
+		})
+		if r.Project != "" {
+			result.Project = r.Project
+		}
+		for _, tag := range r.Tags {
+			result.Tags = appendUnique(result.Tags, tag)
+		}
+		if r.Billable != nil {
+			result.Billable = *r.Billable
+		}
+	}
+	return result
+}
+
+// Change is an entry name that was changed when reapplying rules.
+type Change struct {
+	Entry   dinkur.Entry `json:"entry"`
+	NewName string       `json:"newName"`
+}
+
+// Rename returns the entry name with the tags and project from the rules
+// added to it, using the parser's tag and client prefixes, so that the
+// categorization becomes part of the stored entry name. The billable flag
+// cannot be stored in the name, and is therefore always evaluated anew.
+func (e *Engine) Rename(parser *entryname.Parser, name string) string {
+	parsed := parser.Parse(name)
+	result := e.Evaluate(name)
+	var suffix []string
+	for _, tag := range result.Tags {
+		if containsFold(parsed.Tags, tag) {
+			continue
+		}
+		if token := parser.FormatTag(tag); token != "" {
+			suffix = append(suffix, token)
+		}
+	}
+	if result.Project != "" && parsed.Client == "" {
+		if token := parser.FormatClient(result.Project); token != "" {
+			suffix = append(suffix, token)
+		}
+	}
+	if len(suffix) == 0 {
+		return name
+	}
+	return name + " " + strings.Join(suffix, " ")
+}
+
+// Reapply applies the rules to the names of the given entries, and saves the
+// renamed entries through the client. If dryRun is true, then the changes
+// are only returned and not saved.
+func (e *Engine) Reapply(ctx context.Context, client dinkur.Entries, parser *entryname.Parser, entries []dinkur.Entry, dryRun bool) ([]Change, error) {
+	var changes []Change
+	for _, entry := range entries {
+		newName := e.Rename(parser, entry.Name)
+		if newName == entry.Name {
+			continue
+		}
+		changes = append(changes, Change{Entry: entry, NewName: newName})
+		if dryRun {
+			continue
+		}
+		if _, err := client.UpdateEntry(ctx, dinkur.EditEntry{
+			IDOrZero: entry.ID,
+			Name:     &newName,
+		}); err != nil {
+			return changes, fmt.Errorf("rename entry %d: %w", entry.ID, err)
+		}
+	}
+	return changes, nil
+}
+
+func appendUnique(values []string, value string) []string {
+	if containsFold(values, value) {
+		return values
+	}
+	return append(values, value)
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
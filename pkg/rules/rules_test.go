@@ -0,0 +1,110 @@
+package rules
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+	"github.com/dinkur/dinkur-desktop/pkg/entryname"
+	"github.com/dinkur/dinkur/pkg/dinkur"
+)
+
+// renamingClient stores the entry names it is asked to update.
+type renamingClient struct {
+	dinkur.Entries
+	names map[uint]string
+}
+
+func (c *renamingClient) UpdateEntry(_ context.Context, edit dinkur.EditEntry) (dinkur.UpdatedEntry, error) {
+	c.names[edit.IDOrZero] = *edit.Name
+	return dinkur.UpdatedEntry{}, nil
+}
+
+func TestReapplyIsIdempotent(t *testing.T) {
+	billable := true
+	engine, err := New(config.Categorization{Rules: []config.Rule{
+		{Match: `(?i)support`, Tags: []string{"support"}},
+		{Match: `(?i)review`, Tags: []string{"review", "code review"}, Project: "acme"},
+		{Match: `(?i)standup`, Project: "Acme Corp", Billable: &billable},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser, err := entryname.New(entryname.Options{TagPrefix: "#", ClientPrefix: "@"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{
+		"Support call",
+		"Review PR #Support",
+		"Review PR @globex",
+		"Standup",
+		"Lunch",
+	}
+	want := []string{
+		"Support call #support",
+		"Review PR #Support #review @acme",
+		"Review PR @globex #review",
+		"Standup",
+		"Lunch",
+	}
+	entries := make([]dinkur.Entry, len(names))
+	for i, name := range names {
+		entries[i].ID = uint(i + 1)
+		entries[i].Name = name
+	}
+	client := &renamingClient{names: map[uint]string{}}
+
+	changes, err := engine.Reapply(context.Background(), client, parser, entries, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 3 {
+		t.Errorf("want 3 changes, got %+v", changes)
+	}
+	for i := range entries {
+		if name, ok := client.names[entries[i].ID]; ok {
+			entries[i].Name = name
+		}
+		if entries[i].Name != want[i] {
+			t.Errorf("entry %d: want %q, got %q", entries[i].ID, want[i], entries[i].Name)
+		}
+	}
+
+	client.names = map[uint]string{}
+	changes, err = engine.Reapply(context.Background(), client, parser, entries, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 || len(client.names) != 0 {
+		t.Errorf("want no changes when reapplying, got %+v", changes)
+	}
+}
+
+func TestReapplyDryRun(t *testing.T) {
+	engine, err := New(config.Categorization{Rules: []config.Rule{
+		{Match: `(?i)support`, Tags: []string{"support"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser, err := entryname.New(entryname.Options{TagPrefix: "#"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry dinkur.Entry
+	entry.ID = 1
+	entry.Name = "Support call"
+	client := &renamingClient{names: map[uint]string{}}
+
+	changes, err := engine.Reapply(context.Background(), client, parser, []dinkur.Entry{entry}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].NewName != "Support call #support" {
+		t.Errorf("want one change, got %+v", changes)
+	}
+	if len(client.names) != 0 {
+		t.Errorf("want nothing saved in a dry run, got %v", client.names)
+	}
+}
@@ -0,0 +1,142 @@
+package worklog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dinkur/dinkur-desktop/pkg/issues"
+)
+
+// Jira pushes worklogs to the issues in Jira.
+type Jira struct {
+	*issues.Jira
+}
+
+// Push adds a worklog to the Jira issue, and returns the ID of the worklog.
+func (j Jira) Push(ctx context.Context, w Worklog) (string, error) {
+	apiURL := fmt.Sprintf("%s/rest/api/2/issue/%s/worklog", strings.TrimSuffix(j.BaseURL, "/"), url.PathEscape(w.Key))
+	req := struct {
+		Started          string `json:"started"`
+		TimeSpentSeconds int    `json:"timeSpentSeconds"`
+		Comment          string `json:"comment"`
+	}{
+		Started:          w.Start.Format("2006-01-02T15:04:05.000-0700"),
+		TimeSpentSeconds: w.Seconds(),
+		Comment:          w.Comment,
+	}
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if err := doJSON(ctx, j.Client, http.MethodPost, apiURL, j.Header(), req, &resp); err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
+
+// Tempo pushes worklogs to Tempo timesheets, using the Tempo REST API v4.
+type Tempo struct {
+	// BaseURL is the URL of the API, e.g "https://api.tempo.io".
+	BaseURL string
+	Token   string
+	// AccountID is the Jira account ID of the user the worklogs are logged
+	// for.
+	AccountID string
+	// Jira is used to look up the IDs of issues by their keys, as Tempo only
+	// accepts issue IDs.
+	Jira   *issues.Jira
+	Client *http.Client
+}
+
+// Push adds a worklog to the Tempo timesheet, and returns the ID of the
+// worklog.
+func (t Tempo) Push(ctx context.Context, w Worklog) (string, error) {
+	issueID, err := t.issueID(ctx, w.Key)
+	if err != nil {
+		return "", err
+	}
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+t.Token)
+	req := struct {
+		IssueID          int    `json:"issueId"`
+		AuthorAccountID  string `json:"authorAccountId"`
+		StartDate        string `json:"startDate"`
+		StartTime        string `json:"startTime"`
+		TimeSpentSeconds int    `json:"timeSpentSeconds"`
+		Description      string `json:"description"`
+	}{
+		IssueID:          issueID,
+		AuthorAccountID:  t.AccountID,
+		StartDate:        w.Start.Format("2006-01-02"),
+		StartTime:        w.Start.Format("15:04:05"),
+		TimeSpentSeconds: w.Seconds(),
+		Description:      w.Comment,
+	}
+	var resp struct {
+		TempoWorklogID int `json:"tempoWorklogId"`
+	}
+	apiURL := strings.TrimSuffix(t.BaseURL, "/") + "/4/worklogs"
+	if err := doJSON(ctx, t.Client, http.MethodPost, apiURL, header, req, &resp); err != nil {
+		return "", err
+	}
+	return strconv.Itoa(resp.TempoWorklogID), nil
+}
+
+func (t Tempo) issueID(ctx context.Context, key string) (int, error) {
+	apiURL := fmt.Sprintf("%s/rest/api/2/issue/%s?fields=id", strings.TrimSuffix(t.Jira.BaseURL, "/"), url.PathEscape(key))
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if err := doJSON(ctx, t.Jira.Client, http.MethodGet, apiURL, t.Jira.Header(), nil, &resp); err != nil {
+		return 0, fmt.Errorf("look up issue ID: %w", err)
+	}
+	id, err := strconv.Atoi(resp.ID)
+	if err != nil {
+		return 0, fmt.Errorf("look up issue ID: invalid ID %q", resp.ID)
+	}
+	return id, nil
+}
+
+// doJSON sends a request with the body encoded as JSON, unless nil, and
+// decodes the JSON response into v.
+func doJSON(ctx context.Context, client *http.Client, method, url string, header http.Header, body, v any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "dinkur-desktop")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
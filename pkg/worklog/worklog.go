@@ -0,0 +1,431 @@
+// Package worklog pushes the time of entries with ticket keys to Jira or
+// Tempo as worklogs, and keeps track of what has been pushed so that no
+// entry is logged twice.
+package worklog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/internal/clock"
+	"github.com/dinkur/dinkur-desktop/internal/jsonfile"
+	"github.com/dinkur/dinkur-desktop/pkg/config"
+	"github.com/dinkur/dinkur-desktop/pkg/issues"
+)
+
+// Errors specific to worklogs.
+var (
+	ErrNotConfigured = errors.New("worklogs are not configured, set worklogs.tracker in the config")
+	ErrNotPushed     = errors.New("entry has not been pushed as a worklog")
+	ErrLocked        = errors.New("worklogs are being pushed by another process, such as the app or the CLI")
+)
+
+const (
+	// minDuration is the shortest entry that is pushed, as Jira rejects
+	// worklogs of less than a minute.
+	minDuration = time.Minute
+	// staleLock is how old a lock file must be to be removed, as it's then
+	// assumed to be left behind by a process that crashed while pushing.
+	staleLock = time.Hour
+)
+
+// Status is whether an entry has been pushed as a worklog.
+type Status string
+
+const (
+	// StatusNew is an entry that has not been pushed yet.
+	StatusNew Status = "new"
+	// StatusPushed is an entry that has been pushed, and not changed since.
+	StatusPushed Status = "pushed"
+	// StatusEdited is an entry whose name or times have changed since it was
+	// pushed. It is not pushed again, and the worklog must be corrected by
+	// hand.
+	StatusEdited Status = "edited"
+	// StatusDeleted is an entry that has been removed since it was pushed.
+	StatusDeleted Status = "deleted"
+)
+
+// Entry is an entry to push as a worklog.
+type Entry struct {
+	ID    uint
+	Name  string
+	Start time.Time
+	End   time.Time
+	// Keys are the ticket keys in the entry's name. The first key that the
+	// issue tracker matches is the issue the worklog is added to.
+	Keys []string
+}
+
+// Worklog is the time spent on an issue, as pushed to Jira or Tempo.
+type Worklog struct {
+	Key     string    `json:"key"`
+	Comment string    `json:"comment"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+}
+
+// Seconds returns the duration of the worklog in whole seconds.
+func (w Worklog) Seconds() int {
+	return int(w.End.Sub(w.Start) / time.Second)
+}
+
+// Pushed is an entry that has been pushed as a worklog.
+type Pushed struct {
+	Worklog
+	EntryID uint `json:"entryId"`
+	// WorklogID is the ID of the worklog in Jira or Tempo.
+	WorklogID string    `json:"worklogId"`
+	PushedAt  time.Time `json:"pushedAt"`
+}
+
+// Item is an entry in a plan of what to push.
+type Item struct {
+	Worklog
+	EntryID uint   `json:"entryId"`
+	Status  Status `json:"status"`
+	// Pushed is what was pushed for the entry, unless the status is new.
+	Pushed *Pushed `json:"pushed"`
+}
+
+// Plan lists the entries of a period and whether they've been pushed.
+type Plan struct {
+	Items []Item `json:"items"`
+}
+
+// Count returns the number of items with the status.
+func (p Plan) Count(status Status) int {
+	var n int
+	for _, item := range p.Items {
+		if item.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Pusher adds worklogs to an issue tracker.
+type Pusher interface {
+	// Push adds the worklog and returns its ID.
+	Push(ctx context.Context, w Worklog) (string, error)
+}
+
+type pushedFile struct {
+	// Entries are what has been pushed, by entry ID.
+	Entries map[uint]Pushed `json:"entries"`
+}
+
+// Syncer pushes entries as worklogs, and records what has been pushed in a
+// file. The file is locked while pushing, as both the app and the CLI may
+// push at the same time.
+type Syncer struct {
+	pusher Pusher
+	match  *regexp.Regexp
+	path   string
+	clock  clock.Clock
+	mu     sync.Mutex
+}
+
+// NewSyncer creates a new syncer that pushes the entries with keys that
+// match the regular expression, and records what has been pushed in the
+// file at the path.
+func NewSyncer(pusher Pusher, match *regexp.Regexp, path string, clk clock.Clock) *Syncer {
+	return &Syncer{pusher: pusher, match: match, path: path, clock: clk}
+}
+
+// New creates a new syncer from the config. Returns [ErrNotConfigured] if
+// no issue tracker is set in the worklogs config.
+func New(cfg *config.Config, path string, clk clock.Clock) (*Syncer, error) {
+	if cfg.Worklogs.Tracker == "" {
+		return nil, ErrNotConfigured
+	}
+	var trackerCfg config.IssueTrackers
+	for _, c := range cfg.IssueTrackers.Items {
+		if c.Name == cfg.Worklogs.Tracker {
+			trackerCfg.Items = append(trackerCfg.Items, c)
+		}
+	}
+	trackers, err := issues.NewTrackers(trackerCfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(trackers) == 0 {
+		return nil, fmt.Errorf("no issue tracker named %q", cfg.Worklogs.Tracker)
+	}
+	jira, ok := trackers[0].Provider.(*issues.Jira)
+	if !ok {
+		return nil, fmt.Errorf("issue tracker %q is not a Jira issue tracker", cfg.Worklogs.Tracker)
+	}
+	var pusher Pusher
+	switch cfg.Worklogs.Target {
+	case config.WorklogTargetTempo:
+		tempo := cfg.Worklogs.Tempo
+		token, err := issues.ReadToken(tempo.TokenEnv, tempo.TokenFile, tempo.Token)
+		if err != nil {
+			return nil, fmt.Errorf("tempo: %w", err)
+		}
+		pusher = Tempo{
+			BaseURL:   tempo.URL,
+			Token:     token,
+			AccountID: tempo.AccountID,
+			Jira:      jira,
+			Client:    jira.Client,
+		}
+	default:
+		pusher = Jira{Jira: jira}
+	}
+	return NewSyncer(pusher, trackers[0].Match, path, clk), nil
+}
+
+// Plan compares the entries within the period with what has been pushed.
+// Entries that are still active, shorter than a minute, or without any
+// matching key are left out, unless they have been pushed before.
+func (s *Syncer) Plan(entries []Entry, from, to time.Time) (Plan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, err := s.read()
+	if err != nil {
+		return Plan{}, err
+	}
+	plan, _ := s.plan(state, entries, from, to)
+	return plan, nil
+}
+
+// plan compares the entries with what has been pushed. Pushed records of
+// entries that have been recreated with a new ID are moved to the new ID in
+// the state, and relinked is set if any were moved.
+func (s *Syncer) plan(state pushedFile, entries []Entry, from, to time.Time) (plan Plan, relinked bool) {
+	seen := map[uint]bool{}
+	for _, entry := range entries {
+		seen[entry.ID] = true
+	}
+	orphans := map[Worklog]uint{}
+	for id, pushed := range state.Entries {
+		if !seen[id] {
+			orphans[orphanKey(pushed.Worklog)] = id
+		}
+	}
+	for _, entry := range entries {
+		if entry.End.IsZero() {
+			continue
+		}
+		w := s.worklog(entry)
+		if _, ok := state.Entries[entry.ID]; !ok && w.Key != "" {
+			// Entries get a new ID when recreated, such as when adding a
+			// past entry while another entry is active.
+			if id, ok := orphans[orphanKey(w)]; ok {
+				delete(orphans, orphanKey(w))
+				pushed := state.Entries[id]
+				pushed.EntryID = entry.ID
+				delete(state.Entries, id)
+				state.Entries[entry.ID] = pushed
+				relinked = true
+			}
+		}
+		if pushed, ok := state.Entries[entry.ID]; ok {
+			status := StatusPushed
+			if !sameWorklog(w, pushed.Worklog) {
+				status = StatusEdited
+			}
+			plan.Items = append(plan.Items, Item{Worklog: w, EntryID: entry.ID, Status: status, Pushed: &pushed})
+			continue
+		}
+		if w.Key == "" || w.End.Sub(w.Start) < minDuration {
+			continue
+		}
+		plan.Items = append(plan.Items, Item{Worklog: w, EntryID: entry.ID, Status: StatusNew})
+	}
+	for id, pushed := range state.Entries {
+		if seen[id] || pushed.Start.Before(from) || !pushed.Start.Before(to) {
+			continue
+		}
+		pushed := pushed
+		plan.Items = append(plan.Items, Item{EntryID: id, Status: StatusDeleted, Pushed: &pushed})
+	}
+	sort.SliceStable(plan.Items, func(i, j int) bool {
+		return itemStart(plan.Items[i]).Before(itemStart(plan.Items[j]))
+	})
+	return plan, relinked
+}
+
+// Push pushes the new entries of the plan of the period, and returns the
+// updated plan. Each pushed entry is recorded right away, so that a failed
+// push can be retried without logging any entry twice.
+func (s *Syncer) Push(ctx context.Context, entries []Entry, from, to time.Time) (Plan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lock()
+	if err != nil {
+		return Plan{}, err
+	}
+	defer unlock()
+	state, err := s.read()
+	if err != nil {
+		return Plan{}, err
+	}
+	plan, relinked := s.plan(state, entries, from, to)
+	var errs []error
+	if relinked {
+		if err := jsonfile.Write(s.path, state); err != nil {
+			return plan, fmt.Errorf("record pushed worklogs of recreated entries: %w", err)
+		}
+	}
+	for i, item := range plan.Items {
+		if item.Status != StatusNew {
+			continue
+		}
+		id, err := s.pusher.Push(ctx, item.Worklog)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("push entry #%d to %s: %w", item.EntryID, item.Key, err))
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		pushed := Pushed{
+			Worklog:   item.Worklog,
+			EntryID:   item.EntryID,
+			WorklogID: id,
+			PushedAt:  s.clock.Now(),
+		}
+		state.Entries[item.EntryID] = pushed
+		if err := jsonfile.Write(s.path, state); err != nil {
+			// Stop, as further pushes could not be recorded either.
+			errs = append(errs, fmt.Errorf("record pushed worklog %s of entry #%d: %w", id, item.EntryID, err))
+			break
+		}
+		plan.Items[i].Status = StatusPushed
+		plan.Items[i].Pushed = &pushed
+	}
+	return plan, errors.Join(errs...)
+}
+
+// Acknowledge clears the flag of an edited or deleted entry, after its
+// worklog has been corrected by hand. The entry's current state is recorded
+// as what has been pushed, or it is forgotten if the entry has been deleted.
+func (s *Syncer) Acknowledge(entryID uint, entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	state, err := s.read()
+	if err != nil {
+		return err
+	}
+	pushed, ok := state.Entries[entryID]
+	if !ok {
+		return ErrNotPushed
+	}
+	if entry == nil {
+		delete(state.Entries, entryID)
+	} else {
+		pushed.Worklog = s.worklog(*entry)
+		state.Entries[entryID] = pushed
+	}
+	return jsonfile.Write(s.path, state)
+}
+
+// Remap moves what has been pushed for an entry over to its new ID, after
+// the entry has been deleted and created again. Does nothing if the entry
+// has not been pushed.
+func (s *Syncer) Remap(oldID, newID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	state, err := s.read()
+	if err != nil {
+		return err
+	}
+	pushed, ok := state.Entries[oldID]
+	if !ok {
+		return nil
+	}
+	delete(state.Entries, oldID)
+	pushed.EntryID = newID
+	state.Entries[newID] = pushed
+	return jsonfile.Write(s.path, state)
+}
+
+func (s *Syncer) worklog(entry Entry) Worklog {
+	w := Worklog{
+		Comment: entry.Name,
+		// Jira only keeps whole seconds.
+		Start: entry.Start.Truncate(time.Second),
+		End:   entry.End.Truncate(time.Second),
+	}
+	for _, key := range entry.Keys {
+		if s.match.MatchString(key) {
+			w.Key = key
+			break
+		}
+	}
+	return w
+}
+
+// lock creates a lock file next to the file of pushed worklogs, and returns
+// a function that removes it. Returns [ErrLocked] if another process holds
+// the lock.
+func (s *Syncer) lock() (func(), error) {
+	path := s.path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	const flags = os.O_CREATE | os.O_EXCL | os.O_WRONLY
+	file, err := os.OpenFile(path, flags, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		// The file's time is from the system clock, not the syncer's.
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) >= staleLock {
+			os.Remove(path)
+			file, err = os.OpenFile(path, flags, 0o644)
+		}
+	}
+	if errors.Is(err, fs.ErrExist) {
+		return nil, ErrLocked
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lock pushed worklogs: %w", err)
+	}
+	file.Close()
+	return func() { os.Remove(path) }, nil
+}
+
+func (s *Syncer) read() (pushedFile, error) {
+	var state pushedFile
+	if err := jsonfile.Read(s.path, &state); err != nil {
+		return pushedFile{}, fmt.Errorf("read pushed worklogs: %w", err)
+	}
+	if state.Entries == nil {
+		state.Entries = map[uint]Pushed{}
+	}
+	return state, nil
+}
+
+// orphanKey returns the parts of the worklog that a recreated entry keeps.
+func orphanKey(w Worklog) Worklog {
+	return Worklog{Key: w.Key, Start: w.Start.UTC(), End: w.End.UTC()}
+}
+
+func sameWorklog(a, b Worklog) bool {
+	return a.Key == b.Key && a.Comment == b.Comment && a.Start.Equal(b.Start) && a.End.Equal(b.End)
+}
+
+func itemStart(item Item) time.Time {
+	if item.Status == StatusDeleted {
+		return item.Pushed.Start
+	}
+	return item.Start
+}
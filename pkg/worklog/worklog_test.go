@@ -0,0 +1,173 @@
+package worklog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/dinkur/dinkur-desktop/internal/clock"
+)
+
+type fakePusher struct {
+	pushed []Worklog
+}
+
+func (p *fakePusher) Push(ctx context.Context, w Worklog) (string, error) {
+	p.pushed = append(p.pushed, w)
+	return fmt.Sprint(len(p.pushed)), nil
+}
+
+func newTestSyncer(t *testing.T) (*Syncer, *fakePusher) {
+	pusher := &fakePusher{}
+	clk := clock.NewFake(time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC))
+	path := filepath.Join(t.TempDir(), "worklogs.json")
+	return NewSyncer(pusher, regexp.MustCompile(`^[A-Z]+-[0-9]+$`), path, clk), pusher
+}
+
+var (
+	testFrom = time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	testTo   = testFrom.AddDate(0, 0, 1)
+)
+
+func testEntry(id uint, key string, startHour, endHour int) Entry {
+	return Entry{
+		ID:    id,
+		Name:  key + " work",
+		Start: testFrom.Add(time.Duration(startHour) * time.Hour),
+		End:   testFrom.Add(time.Duration(endHour) * time.Hour),
+		Keys:  []string{key},
+	}
+}
+
+func statuses(plan Plan) map[uint]Status {
+	result := map[uint]Status{}
+	for _, item := range plan.Items {
+		result[item.EntryID] = item.Status
+	}
+	return result
+}
+
+func TestPushRecreatedEntry(t *testing.T) {
+	s, pusher := newTestSyncer(t)
+	ctx := context.Background()
+
+	entries := []Entry{testEntry(1, "ABC-1", 9, 10), testEntry(2, "ABC-2", 10, 12)}
+	if _, err := s.Push(ctx, entries, testFrom, testTo); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+
+	// Entry 1 is recreated as entry 3, and entry 2 is deleted.
+	entries = []Entry{testEntry(3, "ABC-1", 9, 10)}
+	plan, err := s.Plan(entries, testFrom, testTo)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	want := map[uint]Status{3: StatusPushed, 2: StatusDeleted}
+	if got := statuses(plan); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("plan: want %v, got %v", want, got)
+	}
+
+	if _, err := s.Push(ctx, entries, testFrom, testTo); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if len(pusher.pushed) != 2 {
+		t.Errorf("want recreated entry not to be pushed again, got %d pushes", len(pusher.pushed))
+	}
+	state, err := s.read()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if pushed, ok := state.Entries[3]; !ok || pushed.EntryID != 3 || pushed.WorklogID != "1" {
+		t.Errorf("want the pushed worklog to be recorded for the new entry ID, got %+v", state.Entries)
+	}
+	if _, ok := state.Entries[1]; ok {
+		t.Error("want the old entry ID to be forgotten")
+	}
+}
+
+func TestPushDifferentEntryWithSameKey(t *testing.T) {
+	s, pusher := newTestSyncer(t)
+	ctx := context.Background()
+
+	if _, err := s.Push(ctx, []Entry{testEntry(1, "ABC-1", 9, 10)}, testFrom, testTo); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	plan, err := s.Push(ctx, []Entry{testEntry(2, "ABC-1", 13, 14)}, testFrom, testTo)
+	if err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	want := map[uint]Status{1: StatusDeleted, 2: StatusPushed}
+	if got := statuses(plan); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("plan: want %v, got %v", want, got)
+	}
+	if len(pusher.pushed) != 2 {
+		t.Errorf("want 2 pushes, got %d", len(pusher.pushed))
+	}
+}
+
+func TestPushLocked(t *testing.T) {
+	s, pusher := newTestSyncer(t)
+	ctx := context.Background()
+	entries := []Entry{testEntry(1, "ABC-1", 9, 10)}
+
+	lockPath := s.path + ".lock"
+	if err := os.WriteFile(lockPath, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Push(ctx, entries, testFrom, testTo); !errors.Is(err, ErrLocked) {
+		t.Fatalf("want ErrLocked, got %v", err)
+	}
+	if len(pusher.pushed) != 0 {
+		t.Fatalf("want no pushes while locked, got %d", len(pusher.pushed))
+	}
+
+	// A lock left behind by a crashed process is removed eventually.
+	old := time.Now().Add(-staleLock)
+	if err := os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Push(ctx, entries, testFrom, testTo); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if len(pusher.pushed) != 1 {
+		t.Errorf("want 1 push, got %d", len(pusher.pushed))
+	}
+	if _, err := os.Stat(lockPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("want lock file to be removed after pushing, got %v", err)
+	}
+}
+
+func TestRemap(t *testing.T) {
+	s, pusher := newTestSyncer(t)
+	ctx := context.Background()
+
+	if _, err := s.Push(ctx, []Entry{testEntry(1, "ABC-1", 9, 10)}, testFrom, testTo); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if err := s.Remap(1, 5); err != nil {
+		t.Fatalf("remap: %v", err)
+	}
+	if err := s.Remap(2, 6); err != nil {
+		t.Fatalf("remap entry that was never pushed: %v", err)
+	}
+
+	// The recreated entry has also been edited, so it would not be matched
+	// as an orphan.
+	edited := testEntry(5, "ABC-1", 9, 11)
+	plan, err := s.Push(ctx, []Entry{edited}, testFrom, testTo)
+	if err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	want := map[uint]Status{5: StatusEdited}
+	if got := statuses(plan); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("plan: want %v, got %v", want, got)
+	}
+	if len(pusher.pushed) != 1 {
+		t.Errorf("want remapped entry not to be pushed again, got %d pushes", len(pusher.pushed))
+	}
+}